@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	OutcomeAllowed = "allowed"
+	OutcomeDenied  = "denied"
+)
+
+type Record struct {
+	Time       time.Time `json:"time"`
+	RemoteAddr string    `json:"remote_addr"`
+	User       string    `json:"user,omitempty"`
+	Auth       string    `json:"auth,omitempty"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Outcome    string    `json:"outcome"`
+}
+
+// Logger writes audit records as JSON lines.
+type Logger struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	now     func() time.Time
+}
+
+func NewLogger(w io.Writer) *Logger {
+	return &Logger{
+		encoder: json.NewEncoder(w),
+		now:     time.Now,
+	}
+}
+
+func (l *Logger) Log(record Record) error {
+	if record.Time.IsZero() {
+		record.Time = l.now().UTC()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.encoder.Encode(record)
+}
@@ -0,0 +1,52 @@
+package audit_test
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/bosh-prometheus/shield_exporter/audit"
+)
+
+var _ = Describe("Logger", func() {
+	var (
+		buffer *bytes.Buffer
+		logger *Logger
+	)
+
+	BeforeEach(func() {
+		buffer = &bytes.Buffer{}
+		logger = NewLogger(buffer)
+	})
+
+	It("writes one JSON record per line", func() {
+		Expect(logger.Log(Record{
+			Time:       time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC),
+			RemoteAddr: "10.0.0.1:1234",
+			User:       "prometheus",
+			Auth:       "basic",
+			Method:     "GET",
+			Path:       "/metrics",
+			Outcome:    OutcomeAllowed,
+		})).To(Succeed())
+		Expect(logger.Log(Record{
+			Time:       time.Date(2017, 1, 2, 3, 4, 6, 0, time.UTC),
+			RemoteAddr: "10.0.0.2:1234",
+			Method:     "GET",
+			Path:       "/metrics",
+			Outcome:    OutcomeDenied,
+		})).To(Succeed())
+
+		Expect(buffer.String()).To(Equal(
+			`{"time":"2017-01-02T03:04:05Z","remote_addr":"10.0.0.1:1234","user":"prometheus","auth":"basic","method":"GET","path":"/metrics","outcome":"allowed"}` + "\n" +
+				`{"time":"2017-01-02T03:04:06Z","remote_addr":"10.0.0.2:1234","method":"GET","path":"/metrics","outcome":"denied"}` + "\n",
+		))
+	})
+
+	It("sets the time of the record when missing", func() {
+		Expect(logger.Log(Record{Outcome: OutcomeDenied})).To(Succeed())
+		Expect(buffer.String()).ToNot(ContainSubstring(`"time":"0001-01-01T00:00:00Z"`))
+	})
+})
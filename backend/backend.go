@@ -0,0 +1,242 @@
+package backend
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/log"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+)
+
+// BackendCollector is the name of the registry holding the metrics about the
+// Shield backend itself, gathered along with any collector.
+const BackendCollector = "Backend"
+
+// UnresolvedBackendName is the placeholder backend name labeling the metrics
+// gathered while the name of a Shield backend is not resolved.
+const UnresolvedBackendName = "unresolved"
+
+// Registries holds a gatherer per collector name, so that collectors can be
+// gathered on their own.
+type Registries map[string]prometheus.Gatherer
+
+func (r Registries) Gather() ([]*dto.MetricFamily, error) {
+	return r.GatherCollectors(context.Background(), func(collectorName string) bool { return true })
+}
+
+// GatherCollectors gathers within ctx the registries of the collectors for
+// which enabled reports true, along with the registry of the BackendCollector.
+func (r Registries) GatherCollectors(ctx context.Context, enabled func(collectorName string) bool) ([]*dto.MetricFamily, error) {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		if name == BackendCollector || enabled(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	gatherers := make(prometheus.Gatherers, 0, len(names))
+	for _, name := range names {
+		gatherers = append(gatherers, WithContext(ctx, r[name]))
+	}
+
+	return gatherers.Gather()
+}
+
+// Checker checks the Shield backends behind a CollectorsGatherer.
+type Checker interface {
+	// Resolve resolves the names of the backends, and reports whether at
+	// least one of them is resolved.
+	Resolve() bool
+
+	// Ping requests the Shield Status of the backends.
+	Ping() error
+}
+
+// RegistryFactory builds the registries holding the collectors of a Shield
+// backend, labeled with the given backend name.
+type RegistryFactory func(backendName string, shieldClient *client.Client) Registries
+
+const (
+	// DefaultResolveInitialBackoff is the delay before resolving again a backend
+	// name that could not be resolved, doubled after every failure.
+	DefaultResolveInitialBackoff = 5 * time.Second
+
+	// DefaultResolveMaxBackoff caps the delay between resolutions of a backend
+	// name that could not be resolved.
+	DefaultResolveMaxBackoff = 5 * time.Minute
+)
+
+// Backend gathers the metrics of a Shield backend. The backend name is
+// resolved lazily from the Shield Status API and refreshed periodically;
+// collectors are rebuilt whenever the name changes. Only the unresolved
+// registries, if any, are gathered until the name is resolved, and failed
+// resolutions are retried with an exponential backoff. The Shield Status is
+// requested by a single gathering at a time, without blocking the concurrent
+// ones.
+type Backend struct {
+	shieldClient    *client.Client
+	newRegistry     RegistryFactory
+	refreshInterval time.Duration
+	initialBackoff  time.Duration
+	maxBackoff      time.Duration
+
+	mu         sync.Mutex
+	name       string
+	resolved   bool
+	resolving  bool
+	resolvedAt time.Time
+	retryAt    time.Time
+	backoff    time.Duration
+	registries Registries
+}
+
+func NewBackend(
+	shieldClient *client.Client,
+	newRegistry RegistryFactory,
+	refreshInterval time.Duration,
+	name string,
+) *Backend {
+	backend := &Backend{
+		shieldClient:    shieldClient,
+		newRegistry:     newRegistry,
+		refreshInterval: refreshInterval,
+		initialBackoff:  DefaultResolveInitialBackoff,
+		maxBackoff:      DefaultResolveMaxBackoff,
+		name:            name,
+		resolved:        name != "",
+		resolvedAt:      time.Now(),
+		registries:      Registries{},
+	}
+	if backend.resolved {
+		backend.registries = newRegistry(name, shieldClient)
+	}
+
+	return backend
+}
+
+// SetResolveBackoff sets the initial and maximum delays between resolutions
+// of a backend name that could not be resolved.
+func (b *Backend) SetResolveBackoff(initialBackoff time.Duration, maxBackoff time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.initialBackoff = initialBackoff
+	b.maxBackoff = maxBackoff
+}
+
+// SetUnresolvedRegistries sets the registries gathered instead of the ones
+// of the backend collectors until the backend name is resolved, ie reporting
+// the scrapes as failed.
+func (b *Backend) SetUnresolvedRegistries(registries Registries) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.resolved {
+		b.registries = registries
+	}
+}
+
+func (b *Backend) Name() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.name
+}
+
+// Resolved reports whether the backend name has been resolved, that is
+// whether the metrics of the backend are gathered.
+func (b *Backend) Resolved() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.resolved
+}
+
+// Resolve resolves the backend name, unless it is already resolved or a
+// failed resolution is being backed off, and reports whether it is resolved.
+func (b *Backend) Resolve() bool {
+	b.currentRegistries()
+	return b.Resolved()
+}
+
+// Ping requests the Shield Status through the client shared with the
+// collectors, so it waits for the same concurrency and rate limits.
+func (b *Backend) Ping() error {
+	_, err := b.shieldClient.GetStatus()
+	return err
+}
+
+// Stats returns the counters about the requests sent to the Shield backend.
+func (b *Backend) Stats() client.Stats {
+	return b.shieldClient.Stats()
+}
+
+func (b *Backend) Gather() ([]*dto.MetricFamily, error) {
+	return b.currentRegistries().Gather()
+}
+
+func (b *Backend) GatherCollectors(ctx context.Context, enabled CollectorEnabled) ([]*dto.MetricFamily, error) {
+	backendURL := b.shieldClient.BackendURL()
+	return b.currentRegistries().GatherCollectors(ctx, func(collectorName string) bool {
+		return enabled(backendURL, collectorName)
+	})
+}
+
+func (b *Backend) currentRegistries() Registries {
+	b.mu.Lock()
+	now := time.Now()
+	if b.resolving ||
+		(b.resolved && (b.refreshInterval <= 0 || now.Sub(b.resolvedAt) < b.refreshInterval)) ||
+		(!b.resolved && now.Before(b.retryAt)) {
+		registries := b.registries
+		b.mu.Unlock()
+		return registries
+	}
+	b.resolving = true
+	name, resolved := b.name, b.resolved
+	b.mu.Unlock()
+
+	status, err := b.shieldClient.GetStatus()
+
+	var registries Registries
+	if err == nil && (!resolved || status.Name != name) {
+		log.Infof("Collecting data from Shield `%s' version %s", status.Name, status.Version)
+		registries = b.newRegistry(status.Name, b.shieldClient)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.resolving = false
+	if err != nil {
+		if b.resolved {
+			log.Errorf("Error while refreshing Shield backend name: %v", err)
+			b.resolvedAt = time.Now()
+			return b.registries
+		}
+		if b.backoff == 0 {
+			b.backoff = b.initialBackoff
+		} else if b.backoff *= 2; b.backoff > b.maxBackoff {
+			b.backoff = b.maxBackoff
+		}
+		b.retryAt = time.Now().Add(b.backoff)
+		log.Errorf("Error while resolving Shield backend name, retrying in %s: %v", b.backoff, err)
+		return b.registries
+	}
+
+	if registries != nil {
+		b.name = status.Name
+		b.registries = registries
+	}
+	b.resolved = true
+	b.resolvedAt = time.Now()
+	b.backoff = 0
+
+	return b.registries
+}
@@ -0,0 +1,416 @@
+package backend_test
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+	"github.com/starkandwayne/shield/api"
+
+	. "github.com/bosh-prometheus/shield_exporter/backend"
+	"github.com/bosh-prometheus/shield_exporter/client"
+)
+
+func init() {
+	log.Base().SetLevel("fatal")
+}
+
+var _ = Describe("Backend", func() {
+	var (
+		err    error
+		server *ghttp.Server
+
+		shieldClient *client.Client
+
+		initialName     string
+		refreshInterval time.Duration
+		registryNames   []string
+
+		newRegistry RegistryFactory
+		backend     *Backend
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+
+		shieldClient, err = client.NewClient(client.Config{
+			BackendURL: server.URL(),
+			Username:   "fake_username",
+			Password:   "fake_password",
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		initialName = ""
+		refreshInterval = 0
+		registryNames = []string{}
+
+		newRegistry = func(backendName string, shieldClient *client.Client) Registries {
+			registryNames = append(registryNames, backendName)
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+				Name:        "fake_metric",
+				Help:        "Fake metric.",
+				ConstLabels: prometheus.Labels{"backend_name": backendName},
+			}))
+			otherRegistry := prometheus.NewRegistry()
+			otherRegistry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+				Name: "other_metric",
+				Help: "Other metric.",
+			}))
+			return Registries{"Fake": registry, "Other": otherRegistry}
+		}
+	})
+
+	JustBeforeEach(func() {
+		backend = NewBackend(shieldClient, newRegistry, refreshInterval, initialName)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("when the backend name is not known yet", func() {
+		Context("and the Shield Status is available", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v1/status"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, api.Status{Name: "fake_backend"}),
+					),
+				)
+			})
+
+			It("resolves the backend name on the first gather", func() {
+				Expect(backend.Resolved()).To(BeFalse())
+				mfs, err := backend.Gather()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(backend.Name()).To(Equal("fake_backend"))
+				Expect(backend.Resolved()).To(BeTrue())
+				Expect(registryNames).To(Equal([]string{"fake_backend"}))
+				Expect(mfs).To(HaveLen(2))
+				Expect(mfs[0].GetMetric()[0].GetLabel()[0].GetValue()).To(Equal("fake_backend"))
+			})
+
+			It("does not resolve the backend name again", func() {
+				_, err := backend.Gather()
+				Expect(err).ToNot(HaveOccurred())
+				_, err = backend.Gather()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
+		})
+
+		Context("and the Shield Status is not available", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v1/status"),
+						ghttp.RespondWith(http.StatusInternalServerError, nil),
+					),
+				)
+			})
+
+			It("does not gather any metric until the backend name is resolved", func() {
+				mfs, err := backend.Gather()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(mfs).To(BeEmpty())
+				Expect(backend.Name()).To(Equal(""))
+				Expect(backend.Resolved()).To(BeFalse())
+				Expect(registryNames).To(BeEmpty())
+			})
+
+			Context("and unresolved registries are set", func() {
+				JustBeforeEach(func() {
+					registry := prometheus.NewRegistry()
+					registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+						Name:        "unresolved_metric",
+						Help:        "Unresolved metric.",
+						ConstLabels: prometheus.Labels{"backend_name": UnresolvedBackendName},
+					}))
+					backend.SetUnresolvedRegistries(Registries{"Fake": registry})
+				})
+
+				It("gathers the unresolved registries until the backend name is resolved", func() {
+					mfs, err := backend.Gather()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(mfs).To(HaveLen(1))
+					Expect(mfs[0].GetName()).To(Equal("unresolved_metric"))
+					Expect(mfs[0].GetMetric()[0].GetLabel()[0].GetValue()).To(Equal(UnresolvedBackendName))
+					Expect(backend.Resolved()).To(BeFalse())
+				})
+
+				It("only gathers the unresolved registries of the enabled collectors", func() {
+					mfs, err := CollectorGatherer(backend, "Other").Gather()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(mfs).To(BeEmpty())
+				})
+
+				Context("and the backoff elapsed", func() {
+					JustBeforeEach(func() {
+						backend.SetResolveBackoff(time.Nanosecond, time.Nanosecond)
+						server.AppendHandlers(
+							ghttp.CombineHandlers(
+								ghttp.VerifyRequest("GET", "/v1/status"),
+								ghttp.RespondWithJSONEncoded(http.StatusOK, api.Status{Name: "fake_backend"}),
+							),
+						)
+					})
+
+					It("gathers the registries of the resolved backend", func() {
+						_, err := backend.Gather()
+						Expect(err).ToNot(HaveOccurred())
+						time.Sleep(time.Millisecond)
+						mfs, err := backend.Gather()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(mfs).To(HaveLen(2))
+						Expect(mfs[0].GetName()).To(Equal("fake_metric"))
+					})
+				})
+			})
+
+			It("does not resolve the backend name again before the backoff elapsed", func() {
+				_, err := backend.Gather()
+				Expect(err).ToNot(HaveOccurred())
+				_, err = CollectorGatherer(backend, "Fake").Gather()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
+
+			Context("and the backoff elapsed", func() {
+				JustBeforeEach(func() {
+					backend.SetResolveBackoff(time.Nanosecond, time.Nanosecond)
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/v1/status"),
+							ghttp.RespondWithJSONEncoded(http.StatusOK, api.Status{Name: "fake_backend"}),
+						),
+					)
+				})
+
+				It("resolves the backend name again", func() {
+					_, err := backend.Gather()
+					Expect(err).ToNot(HaveOccurred())
+					time.Sleep(time.Millisecond)
+					mfs, err := backend.Gather()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(mfs).To(HaveLen(2))
+					Expect(backend.Name()).To(Equal("fake_backend"))
+					Expect(registryNames).To(Equal([]string{"fake_backend"}))
+				})
+			})
+		})
+
+		Context("and the Shield Status is slow to answer", func() {
+			var release chan struct{}
+
+			BeforeEach(func() {
+				release = make(chan struct{})
+				release := release
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v1/status"),
+						func(w http.ResponseWriter, r *http.Request) { <-release },
+						ghttp.RespondWithJSONEncoded(http.StatusOK, api.Status{Name: "fake_backend"}),
+					),
+				)
+			})
+
+			It("does not block the concurrent gatherings", func() {
+				resolved := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					backend.Gather()
+					close(resolved)
+				}()
+				Eventually(server.ReceivedRequests).Should(HaveLen(1))
+
+				mfs, err := backend.Gather()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(mfs).To(BeEmpty())
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+				close(release)
+				Eventually(resolved).Should(BeClosed())
+				Expect(backend.Name()).To(Equal("fake_backend"))
+			})
+		})
+	})
+
+	Context("when the backend name is known", func() {
+		BeforeEach(func() {
+			initialName = "fake_backend"
+		})
+
+		It("does not resolve the backend name", func() {
+			_, err := backend.Gather()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(BeEmpty())
+		})
+
+		Context("and the refresh interval has elapsed", func() {
+			BeforeEach(func() {
+				refreshInterval = time.Nanosecond
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v1/status"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, api.Status{Name: "renamed_backend"}),
+					),
+				)
+			})
+
+			It("picks up the new backend name", func() {
+				_, err := backend.Gather()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(backend.Name()).To(Equal("renamed_backend"))
+				Expect(registryNames).To(Equal([]string{"fake_backend", "renamed_backend"}))
+			})
+		})
+
+		Context("and the Shield Status is not available anymore", func() {
+			BeforeEach(func() {
+				refreshInterval = 50 * time.Millisecond
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v1/status"),
+						ghttp.RespondWith(http.StatusInternalServerError, nil),
+					),
+				)
+			})
+
+			It("keeps gathering with the last backend name until the next refresh", func() {
+				time.Sleep(refreshInterval)
+				_, err := backend.Gather()
+				Expect(err).ToNot(HaveOccurred())
+				mfs, err := backend.Gather()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(mfs).To(HaveLen(2))
+				Expect(backend.Name()).To(Equal("fake_backend"))
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
+		})
+	})
+
+	Describe("Resolve", func() {
+		Context("when the Shield Status is available", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v1/status"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, api.Status{Name: "fake_backend"}),
+					),
+				)
+			})
+
+			It("resolves the backend name", func() {
+				Expect(backend.Resolve()).To(BeTrue())
+				Expect(backend.Name()).To(Equal("fake_backend"))
+			})
+		})
+
+		Context("when the Shield Status is not available", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v1/status"),
+						ghttp.RespondWith(http.StatusInternalServerError, nil),
+					),
+				)
+			})
+
+			It("does not resolve the backend name", func() {
+				Expect(backend.Resolve()).To(BeFalse())
+				Expect(backend.Resolve()).To(BeFalse())
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
+		})
+	})
+
+	Describe("Ping", func() {
+		BeforeEach(func() {
+			initialName = "fake_backend"
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/status"),
+					ghttp.RespondWith(http.StatusInternalServerError, nil),
+				),
+			)
+		})
+
+		It("requests the Shield Status", func() {
+			Expect(backend.Ping()).To(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+
+	Describe("GatherCollectors", func() {
+		BeforeEach(func() {
+			initialName = "fake_backend"
+		})
+
+		It("only gathers the given collector", func() {
+			mfs, err := CollectorGatherer(backend, "Other").Gather()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mfs).To(HaveLen(1))
+			Expect(mfs[0].GetName()).To(Equal("other_metric"))
+		})
+
+		It("only gathers the enabled collectors", func() {
+			enabledCollectors := map[string]bool{"Other": true}
+			gatherer := EnabledCollectorsGatherer(backend, func(backendURL string, collectorName string) bool {
+				return enabledCollectors[collectorName]
+			})
+
+			mfs, err := gatherer.Gather()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mfs).To(HaveLen(1))
+			Expect(mfs[0].GetName()).To(Equal("other_metric"))
+
+			enabledCollectors = map[string]bool{}
+			mfs, err = gatherer.Gather()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mfs).To(BeEmpty())
+		})
+
+		It("does not gather anything for an unknown collector", func() {
+			mfs, err := CollectorGatherer(backend, "Unknown").Gather()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mfs).To(BeEmpty())
+		})
+
+		Context("when the backend collector is registered", func() {
+			BeforeEach(func() {
+				collectorsRegistry := newRegistry
+				newRegistry = func(backendName string, shieldClient *client.Client) Registries {
+					registries := collectorsRegistry(backendName, shieldClient)
+					backendRegistry := prometheus.NewRegistry()
+					backendRegistry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+						Name: "backend_metric",
+						Help: "Backend metric.",
+					}))
+					registries[BackendCollector] = backendRegistry
+					return registries
+				}
+			})
+
+			It("gathers the backend collector along with the given collector", func() {
+				mfs, err := CollectorGatherer(backend, "Other").Gather()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(mfs).To(HaveLen(2))
+				Expect(mfs[0].GetName()).To(Equal("backend_metric"))
+				Expect(mfs[1].GetName()).To(Equal("other_metric"))
+			})
+
+			It("gathers the backend collector when no collector is enabled", func() {
+				mfs, err := EnabledCollectorsGatherer(backend, func(backendURL string, collectorName string) bool { return false }).Gather()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(mfs).To(HaveLen(1))
+				Expect(mfs[0].GetName()).To(Equal("backend_metric"))
+			})
+		})
+	})
+})
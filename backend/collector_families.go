@@ -0,0 +1,116 @@
+package backend
+
+import (
+	"context"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// CollectorFamilies records the names of the metric families gathered from
+// the registries of each collector, so that the metrics gathered from all
+// collectors, ie served from the scrape.interval cache, can be filtered by
+// collector afterwards.
+type CollectorFamilies struct {
+	mu       sync.RWMutex
+	families map[string]map[string]bool
+}
+
+func NewCollectorFamilies() *CollectorFamilies {
+	return &CollectorFamilies{
+		families: make(map[string]map[string]bool),
+	}
+}
+
+// Gatherer returns a ContextGatherer recording the metric families gathered
+// by gatherer as the ones of collectorName, or gatherer itself when f is nil.
+func (f *CollectorFamilies) Gatherer(collectorName string, gatherer prometheus.Gatherer) prometheus.Gatherer {
+	if f == nil {
+		return gatherer
+	}
+
+	return ContextGathererFunc(func(ctx context.Context) ([]*dto.MetricFamily, error) {
+		mfs, err := WithContext(ctx, gatherer).Gather()
+		f.record(collectorName, mfs)
+		return mfs, err
+	})
+}
+
+func (f *CollectorFamilies) record(collectorName string, mfs []*dto.MetricFamily) {
+	f.mu.RLock()
+	known := true
+	for _, mf := range mfs {
+		if !f.families[collectorName][mf.GetName()] {
+			known = false
+			break
+		}
+	}
+	f.mu.RUnlock()
+	if known {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	families, ok := f.families[collectorName]
+	if !ok {
+		families = make(map[string]bool)
+		f.families[collectorName] = families
+	}
+	for _, mf := range mfs {
+		families[mf.GetName()] = true
+	}
+}
+
+// FilterGatherer returns a ContextGatherer only keeping, out of the metric
+// families gathered by gatherer, the ones of collectorName and of the
+// BackendCollector. Within the families shared by several collectors, ie
+// `exporter_last_scrape_error`, the metrics labeled with another collector
+// are dropped.
+func (f *CollectorFamilies) FilterGatherer(gatherer prometheus.Gatherer, collectorName string) ContextGatherer {
+	return ContextGathererFunc(func(ctx context.Context) ([]*dto.MetricFamily, error) {
+		mfs, err := WithContext(ctx, gatherer).Gather()
+
+		f.mu.RLock()
+		defer f.mu.RUnlock()
+
+		filteredMfs := make([]*dto.MetricFamily, 0, len(mfs))
+		for _, mf := range mfs {
+			if !f.families[collectorName][mf.GetName()] && !f.families[BackendCollector][mf.GetName()] {
+				continue
+			}
+
+			metrics := make([]*dto.Metric, 0, len(mf.GetMetric()))
+			for _, metric := range mf.GetMetric() {
+				if labeledCollector(metric, collectorName) {
+					metrics = append(metrics, metric)
+				}
+			}
+			if len(metrics) == 0 {
+				continue
+			}
+
+			filteredMfs = append(filteredMfs, &dto.MetricFamily{
+				Name:   mf.Name,
+				Help:   mf.Help,
+				Type:   mf.Type,
+				Metric: metrics,
+			})
+		}
+
+		return filteredMfs, err
+	})
+}
+
+// labeledCollector reports whether metric has no `collector` label, or one
+// set to collectorName.
+func labeledCollector(metric *dto.Metric, collectorName string) bool {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == "collector" {
+			return label.GetValue() == collectorName
+		}
+	}
+	return true
+}
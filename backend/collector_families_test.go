@@ -0,0 +1,111 @@
+package backend_test
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/bosh-prometheus/shield_exporter/backend"
+)
+
+var _ = Describe("CollectorFamilies", func() {
+	var (
+		collectorFamilies *CollectorFamilies
+		gatherer          prometheus.Gatherer
+	)
+
+	newGauge := func(registry *prometheus.Registry, name string, labels prometheus.Labels) {
+		registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        name,
+			Help:        "Fake gauge",
+			ConstLabels: labels,
+		}))
+	}
+
+	BeforeEach(func() {
+		collectorFamilies = NewCollectorFamilies()
+
+		backendRegistry := prometheus.NewRegistry()
+		newGauge(backendRegistry, "test_backend_maintenance", nil)
+
+		jobsRegistry := prometheus.NewRegistry()
+		newGauge(jobsRegistry, "test_jobs_total", nil)
+		newGauge(jobsRegistry, "test_collector_available", prometheus.Labels{"collector": "Jobs"})
+
+		tasksRegistry := prometheus.NewRegistry()
+		newGauge(tasksRegistry, "test_tasks_total", nil)
+		newGauge(tasksRegistry, "test_collector_available", prometheus.Labels{"collector": "Tasks"})
+
+		gatherer = Gatherers{
+			collectorFamilies.Gatherer(BackendCollector, backendRegistry),
+			collectorFamilies.Gatherer("Jobs", jobsRegistry),
+			collectorFamilies.Gatherer("Tasks", tasksRegistry),
+		}
+	})
+
+	filtered := func(collectorName string) []string {
+		mfs, err := collectorFamilies.FilterGatherer(gatherer, collectorName).Gather()
+		Expect(err).ToNot(HaveOccurred())
+
+		metrics := []string{}
+		for _, mf := range mfs {
+			for _, metric := range mf.GetMetric() {
+				metrics = append(metrics, mf.GetName()+labels(metric))
+			}
+		}
+		return metrics
+	}
+
+	It("only keeps the metrics of the collector and of the Shield backend", func() {
+		Expect(filtered("Jobs")).To(Equal([]string{
+			"test_backend_maintenance",
+			"test_collector_available{collector=Jobs}",
+			"test_jobs_total",
+		}))
+		Expect(filtered("Tasks")).To(Equal([]string{
+			"test_backend_maintenance",
+			"test_collector_available{collector=Tasks}",
+			"test_tasks_total",
+		}))
+	})
+
+	It("keeps the metrics of the Shield backend for a collector never gathered", func() {
+		Expect(filtered("Stores")).To(Equal([]string{"test_backend_maintenance"}))
+	})
+
+	It("filters the metrics gathered by a wrapping gatherer", func() {
+		cached, err := gatherer.Gather()
+		Expect(err).ToNot(HaveOccurred())
+
+		mfs, err := collectorFamilies.FilterGatherer(prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+			return cached, nil
+		}), "Jobs").Gather()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mfs).To(HaveLen(3))
+	})
+
+	Context("without collector families", func() {
+		It("returns the gatherer unchanged", func() {
+			var noCollectorFamilies *CollectorFamilies
+			registry := prometheus.NewRegistry()
+			Expect(noCollectorFamilies.Gatherer("Jobs", registry)).To(BeIdenticalTo(registry))
+		})
+	})
+})
+
+func labels(metric *dto.Metric) string {
+	if len(metric.GetLabel()) == 0 {
+		return ""
+	}
+
+	s := "{"
+	for i, label := range metric.GetLabel() {
+		if i > 0 {
+			s += ","
+		}
+		s += label.GetName() + "=" + label.GetValue()
+	}
+	return s + "}"
+}
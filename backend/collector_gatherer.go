@@ -0,0 +1,35 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// CollectorEnabled reports whether a collector is enabled for the Shield
+// backend at backendURL.
+type CollectorEnabled func(backendURL string, collectorName string) bool
+
+// CollectorsGatherer gathers the metrics of all collectors, or of the ones
+// enabled for each Shield backend within a context, along with the metrics
+// of the BackendCollector.
+type CollectorsGatherer interface {
+	prometheus.Gatherer
+	GatherCollectors(ctx context.Context, enabled CollectorEnabled) ([]*dto.MetricFamily, error)
+}
+
+// CollectorGatherer returns a Gatherer only gathering the given collector.
+func CollectorGatherer(gatherer CollectorsGatherer, collectorName string) ContextGatherer {
+	return EnabledCollectorsGatherer(gatherer, func(backendURL string, name string) bool {
+		return name == collectorName
+	})
+}
+
+// EnabledCollectorsGatherer returns a Gatherer only gathering the collectors
+// enabled for each Shield backend at the time of the gathering.
+func EnabledCollectorsGatherer(gatherer CollectorsGatherer, enabled CollectorEnabled) ContextGatherer {
+	return ContextGathererFunc(func(ctx context.Context) ([]*dto.MetricFamily, error) {
+		return gatherer.GatherCollectors(ctx, enabled)
+	})
+}
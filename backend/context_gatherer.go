@@ -0,0 +1,97 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/bosh-prometheus/shield_exporter/collectors"
+)
+
+// ContextGatherer is a Gatherer whose gatherings can be bound to a context,
+// within which the collectors send their Shield API calls.
+type ContextGatherer interface {
+	prometheus.Gatherer
+	GatherContext(ctx context.Context) ([]*dto.MetricFamily, error)
+}
+
+// ContextGathererFunc turns a function into a ContextGatherer, gathering
+// within the background context when no context is given.
+type ContextGathererFunc func(ctx context.Context) ([]*dto.MetricFamily, error)
+
+func (f ContextGathererFunc) Gather() ([]*dto.MetricFamily, error) {
+	return f(context.Background())
+}
+
+func (f ContextGathererFunc) GatherContext(ctx context.Context) ([]*dto.MetricFamily, error) {
+	return f(ctx)
+}
+
+// WithContext returns a Gatherer gathering gatherer within ctx, when it is a
+// ContextGatherer, or gatherer itself otherwise.
+func WithContext(ctx context.Context, gatherer prometheus.Gatherer) prometheus.Gatherer {
+	contextGatherer, ok := gatherer.(ContextGatherer)
+	if !ok {
+		return gatherer
+	}
+
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return contextGatherer.GatherContext(ctx)
+	})
+}
+
+// NewCollectorRegistry returns a ContextGatherer gathering collector from a
+// registry of its own. Gatherings within a context register the collector
+// bound to that context in a new registry, so that concurrent gatherings
+// never share their context.
+func NewCollectorRegistry(collector prometheus.Collector) ContextGatherer {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	return &collectorRegistry{registry: registry, collector: collector}
+}
+
+type collectorRegistry struct {
+	registry  *prometheus.Registry
+	collector prometheus.Collector
+}
+
+func (r *collectorRegistry) Gather() ([]*dto.MetricFamily, error) {
+	return r.registry.Gather()
+}
+
+func (r *collectorRegistry) GatherContext(ctx context.Context) ([]*dto.MetricFamily, error) {
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(collectors.WithContext(ctx, r.collector)); err != nil {
+		return nil, err
+	}
+
+	return registry.Gather()
+}
+
+// WrapGatherer returns a ContextGatherer applying wrap, ie a Gatherer adding
+// labels, to gatherer bound to the context of every gathering, so that the
+// wrapped gatherer is still gathered within that context.
+func WrapGatherer(gatherer prometheus.Gatherer, wrap func(gatherer prometheus.Gatherer) prometheus.Gatherer) ContextGatherer {
+	return ContextGathererFunc(func(ctx context.Context) ([]*dto.MetricFamily, error) {
+		return wrap(WithContext(ctx, gatherer)).Gather()
+	})
+}
+
+// Gatherers is a ContextGatherer merging the metric families of several
+// gatherers, all gathered within the same context.
+type Gatherers []prometheus.Gatherer
+
+func (gs Gatherers) Gather() ([]*dto.MetricFamily, error) {
+	return gs.GatherContext(context.Background())
+}
+
+func (gs Gatherers) GatherContext(ctx context.Context) ([]*dto.MetricFamily, error) {
+	gatherers := make(prometheus.Gatherers, len(gs))
+	for i, gatherer := range gs {
+		gatherers[i] = WithContext(ctx, gatherer)
+	}
+
+	return gatherers.Gather()
+}
@@ -0,0 +1,127 @@
+package backend_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	. "github.com/bosh-prometheus/shield_exporter/backend"
+	"github.com/bosh-prometheus/shield_exporter/bosh"
+)
+
+type contextKey struct{}
+
+// contextCollector exports the value of contextKey in the context it is
+// collected within as the label of its metric.
+type contextCollector struct {
+	desc *prometheus.Desc
+}
+
+func newContextCollector() *contextCollector {
+	return &contextCollector{
+		desc: prometheus.NewDesc("fake_context_metric", "Fake context metric.", []string{"context"}, nil),
+	}
+}
+
+func (c *contextCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *contextCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	value, _ := ctx.Value(contextKey{}).(string)
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, 1, value)
+}
+
+func (c *contextCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+var _ = Describe("NewCollectorRegistry", func() {
+	var registry ContextGatherer
+
+	BeforeEach(func() {
+		registry = NewCollectorRegistry(newContextCollector())
+	})
+
+	contextLabel := func(gatherer prometheus.Gatherer) string {
+		mfs, err := gatherer.Gather()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mfs).To(HaveLen(1))
+		Expect(mfs[0].GetMetric()).To(HaveLen(1))
+		return mfs[0].GetMetric()[0].GetLabel()[0].GetValue()
+	}
+
+	It("collects the collector within the background context", func() {
+		Expect(contextLabel(registry)).To(Equal(""))
+	})
+
+	It("collects the collector within the context of the gathering", func() {
+		ctx := context.WithValue(context.Background(), contextKey{}, "fake_context")
+		Expect(contextLabel(WithContext(ctx, registry))).To(Equal("fake_context"))
+	})
+
+	It("does not share the context between concurrent gatherings", func() {
+		labels := make(chan string)
+		for _, value := range []string{"first", "second", "third"} {
+			go func(value string) {
+				defer GinkgoRecover()
+				ctx := context.WithValue(context.Background(), contextKey{}, value)
+				labels <- value + "=" + contextLabel(WithContext(ctx, registry))
+			}(value)
+		}
+
+		Expect([]string{<-labels, <-labels, <-labels}).To(ConsistOf("first=first", "second=second", "third=third"))
+	})
+
+	Context("when gathered through Registries", func() {
+		It("collects the collector within the context of the gathering", func() {
+			ctx := context.WithValue(context.Background(), contextKey{}, "fake_context")
+			gatherer := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+				return Registries{"Fake": registry}.GatherCollectors(ctx, func(collectorName string) bool { return true })
+			})
+			Expect(contextLabel(gatherer)).To(Equal("fake_context"))
+		})
+	})
+
+	Describe("WrapGatherer", func() {
+		It("gathers the wrapped gatherer within the context of the gathering", func() {
+			ctx := context.WithValue(context.Background(), contextKey{}, "fake_context")
+			wrapped := false
+			gatherer := WrapGatherer(registry, func(gatherer prometheus.Gatherer) prometheus.Gatherer {
+				wrapped = true
+				return gatherer
+			})
+
+			Expect(contextLabel(WithContext(ctx, gatherer))).To(Equal("fake_context"))
+			Expect(wrapped).To(BeTrue())
+		})
+	})
+
+	Describe("Gatherers", func() {
+		It("gathers every gatherer within the context of the gathering", func() {
+			ctx := context.WithValue(context.Background(), contextKey{}, "fake_context")
+			tenantGatherer := func(registry prometheus.Gatherer, tenant string) ContextGatherer {
+				return WrapGatherer(registry, func(gatherer prometheus.Gatherer) prometheus.Gatherer {
+					return bosh.NewLabelsGatherer(gatherer, map[string]string{"tenant": tenant})
+				})
+			}
+			gatherer := Gatherers{
+				tenantGatherer(registry, "first"),
+				tenantGatherer(NewCollectorRegistry(newContextCollector()), "second"),
+			}
+
+			mfs, err := WithContext(ctx, gatherer).Gather()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mfs).To(HaveLen(1))
+			Expect(mfs[0].GetMetric()).To(HaveLen(2))
+			for _, metric := range mfs[0].GetMetric() {
+				Expect(metric.GetLabel()[0].GetName()).To(Equal("context"))
+				Expect(metric.GetLabel()[0].GetValue()).To(Equal("fake_context"))
+			}
+		})
+	})
+})
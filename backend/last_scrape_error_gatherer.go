@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// LastScrapeErrorGatherer adds to the metrics of a collector its
+// `last_<collector>_scrape_error` metric as an `exporter_last_scrape_error`
+// one labeled by collector, so a single expression alerts on every collector.
+type LastScrapeErrorGatherer struct {
+	gatherer      prometheus.Gatherer
+	namespace     string
+	collectorName string
+}
+
+func NewLastScrapeErrorGatherer(gatherer prometheus.Gatherer, namespace string, collectorName string) *LastScrapeErrorGatherer {
+	return &LastScrapeErrorGatherer{
+		gatherer:      gatherer,
+		namespace:     namespace,
+		collectorName: collectorName,
+	}
+}
+
+func (g *LastScrapeErrorGatherer) Gather() ([]*dto.MetricFamily, error) {
+	return g.GatherContext(context.Background())
+}
+
+func (g *LastScrapeErrorGatherer) GatherContext(ctx context.Context) ([]*dto.MetricFamily, error) {
+	mfs, err := WithContext(ctx, g.gatherer).Gather()
+
+	prefix := g.namespace + "_last_"
+	suffix := "_scrape_error"
+	for _, mf := range mfs {
+		name := mf.GetName()
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+			continue
+		}
+
+		lastScrapeError := &dto.MetricFamily{
+			Name: proto.String(g.namespace + "_exporter_last_scrape_error"),
+			Help: proto.String("Whether the last scrape of a collector's metrics from Shield resulted in an error (1 for error, 0 for success)."),
+			Type: dto.MetricType_GAUGE.Enum(),
+		}
+		for _, metric := range mf.GetMetric() {
+			labels := []*dto.LabelPair{{Name: proto.String("collector"), Value: proto.String(g.collectorName)}}
+			labels = append(labels, metric.GetLabel()...)
+			sort.Slice(labels, func(i, j int) bool { return labels[i].GetName() < labels[j].GetName() })
+
+			lastScrapeError.Metric = append(lastScrapeError.Metric, &dto.Metric{
+				Label: labels,
+				Gauge: &dto.Gauge{Value: proto.Float64(metric.GetGauge().GetValue())},
+			})
+		}
+
+		mfs = append(mfs, lastScrapeError)
+		sort.Slice(mfs, func(i, j int) bool { return mfs[i].GetName() < mfs[j].GetName() })
+		break
+	}
+
+	return mfs, err
+}
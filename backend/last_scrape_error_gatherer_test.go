@@ -0,0 +1,94 @@
+package backend_test
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/bosh-prometheus/shield_exporter/backend"
+)
+
+var _ = Describe("LastScrapeErrorGatherer", func() {
+	var (
+		jobsRegistry  *prometheus.Registry
+		tasksRegistry *prometheus.Registry
+		gatherer      prometheus.Gatherer
+	)
+
+	newLastScrapeError := func(registry *prometheus.Registry, name string, value float64) {
+		lastScrapeError := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        name,
+			Help:        "Fake last scrape error",
+			ConstLabels: prometheus.Labels{"environment": "prod", "backend_name": "shield-1"},
+		})
+		lastScrapeError.Set(value)
+		registry.MustRegister(lastScrapeError)
+	}
+
+	BeforeEach(func() {
+		jobsRegistry = prometheus.NewRegistry()
+		newLastScrapeError(jobsRegistry, "test_last_jobs_scrape_error", 1)
+		jobsRegistry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "test_jobs_total",
+			Help: "Fake jobs total",
+		}))
+
+		tasksRegistry = prometheus.NewRegistry()
+		newLastScrapeError(tasksRegistry, "test_last_tasks_scrape_error", 0)
+
+		gatherer = prometheus.Gatherers{
+			NewLastScrapeErrorGatherer(jobsRegistry, "test", "Jobs"),
+			NewLastScrapeErrorGatherer(tasksRegistry, "test", "Tasks"),
+		}
+	})
+
+	lastScrapeErrors := func() map[string]float64 {
+		mfs, err := gatherer.Gather()
+		Expect(err).ToNot(HaveOccurred())
+
+		values := map[string]float64{}
+		for _, mf := range mfs {
+			if mf.GetName() != "test_exporter_last_scrape_error" {
+				continue
+			}
+			Expect(mf.GetType()).To(Equal(dto.MetricType_GAUGE))
+			for _, metric := range mf.GetMetric() {
+				key := ""
+				for _, label := range metric.GetLabel() {
+					key += label.GetName() + "=" + label.GetValue() + ","
+				}
+				values[key] = metric.GetGauge().GetValue()
+			}
+		}
+		return values
+	}
+
+	It("keeps the gathered metrics", func() {
+		mfs, err := gatherer.Gather()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mfs).To(HaveLen(4))
+		Expect(mfs[0].GetName()).To(Equal("test_exporter_last_scrape_error"))
+		Expect(mfs[1].GetName()).To(Equal("test_jobs_total"))
+		Expect(mfs[2].GetName()).To(Equal("test_last_jobs_scrape_error"))
+		Expect(mfs[3].GetName()).To(Equal("test_last_tasks_scrape_error"))
+	})
+
+	It("returns the last scrape error of every collector labeled by collector", func() {
+		Expect(lastScrapeErrors()).To(Equal(map[string]float64{
+			"backend_name=shield-1,collector=Jobs,environment=prod,":  1,
+			"backend_name=shield-1,collector=Tasks,environment=prod,": 0,
+		}))
+	})
+
+	Context("when the collector has no last scrape error metric", func() {
+		BeforeEach(func() {
+			gatherer = NewLastScrapeErrorGatherer(prometheus.NewRegistry(), "test", "Jobs")
+		})
+
+		It("does not return a last scrape error", func() {
+			Expect(lastScrapeErrors()).To(BeEmpty())
+		})
+	})
+})
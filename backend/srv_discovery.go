@@ -0,0 +1,169 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/log"
+)
+
+type LookupSRVFunc func(service, proto, name string) (string, []*net.SRV, error)
+
+// BackendFactory builds the Backend of a discovered Shield backend URL.
+type BackendFactory func(backendURL string) (*Backend, error)
+
+// SRVDiscovery resolves DNS SRV records into the set of Shield backends to
+// scrape, re-resolving them periodically.
+type SRVDiscovery struct {
+	name       string
+	scheme     string
+	lookupSRV  LookupSRVFunc
+	newBackend BackendFactory
+
+	mu       sync.Mutex
+	backends map[string]*Backend
+}
+
+func NewSRVDiscovery(name string, scheme string, lookupSRV LookupSRVFunc, newBackend BackendFactory) *SRVDiscovery {
+	return &SRVDiscovery{
+		name:       name,
+		scheme:     scheme,
+		lookupSRV:  lookupSRV,
+		newBackend: newBackend,
+		backends:   map[string]*Backend{},
+	}
+}
+
+func (d *SRVDiscovery) Start(refreshInterval time.Duration) {
+	d.Refresh()
+
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			d.Refresh()
+		}
+	}()
+}
+
+func (d *SRVDiscovery) Refresh() error {
+	_, records, err := d.lookupSRV("", "", d.name)
+	if err != nil {
+		log.Errorf("Error while resolving Shield backends from `%s`: %v", d.name, err)
+		return err
+	}
+
+	backendURLs := map[string]bool{}
+	for _, record := range records {
+		backendURL := fmt.Sprintf("%s://%s:%d", d.scheme, strings.TrimSuffix(record.Target, "."), record.Port)
+		backendURLs[backendURL] = true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for backendURL := range backendURLs {
+		if _, ok := d.backends[backendURL]; ok {
+			continue
+		}
+
+		backend, err := d.newBackend(backendURL)
+		if err != nil {
+			log.Errorf("Error while adding Shield backend `%s`: %v", backendURL, err)
+			continue
+		}
+		log.Infof("Discovered Shield backend `%s`", backendURL)
+		d.backends[backendURL] = backend
+	}
+
+	for backendURL := range d.backends {
+		if !backendURLs[backendURL] {
+			log.Infof("Shield backend `%s` is no longer discovered", backendURL)
+			delete(d.backends, backendURL)
+		}
+	}
+
+	return nil
+}
+
+func (d *SRVDiscovery) BackendURLs() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	backendURLs := make([]string, 0, len(d.backends))
+	for backendURL := range d.backends {
+		backendURLs = append(backendURLs, backendURL)
+	}
+
+	return backendURLs
+}
+
+// Backends returns the discovered backends by URL.
+func (d *SRVDiscovery) Backends() map[string]*Backend {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	backends := make(map[string]*Backend, len(d.backends))
+	for backendURL, backend := range d.backends {
+		backends[backendURL] = backend
+	}
+
+	return backends
+}
+
+// Resolve resolves the names of the discovered backends, and reports whether
+// at least one of them is resolved.
+func (d *SRVDiscovery) Resolve() bool {
+	resolved := false
+	for _, backend := range d.Backends() {
+		if backend.Resolve() {
+			resolved = true
+		}
+	}
+
+	return resolved
+}
+
+// Ping requests the Shield Status of the discovered backends, returning the
+// first error.
+func (d *SRVDiscovery) Ping() error {
+	for _, backend := range d.Backends() {
+		if err := backend.Ping(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *SRVDiscovery) Gather() ([]*dto.MetricFamily, error) {
+	return d.gatherers(func(backend *Backend) prometheus.Gatherer {
+		return backend
+	}).Gather()
+}
+
+func (d *SRVDiscovery) GatherCollectors(ctx context.Context, enabled CollectorEnabled) ([]*dto.MetricFamily, error) {
+	return d.gatherers(func(backend *Backend) prometheus.Gatherer {
+		return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+			return backend.GatherCollectors(ctx, enabled)
+		})
+	}).Gather()
+}
+
+func (d *SRVDiscovery) gatherers(gathererFor func(backend *Backend) prometheus.Gatherer) prometheus.Gatherers {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	gatherers := make(prometheus.Gatherers, 0, len(d.backends))
+	for _, backend := range d.backends {
+		gatherers = append(gatherers, gathererFor(backend))
+	}
+
+	return gatherers
+}
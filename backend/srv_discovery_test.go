@@ -0,0 +1,171 @@
+package backend_test
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	. "github.com/bosh-prometheus/shield_exporter/backend"
+	"github.com/bosh-prometheus/shield_exporter/client"
+)
+
+var _ = Describe("SRVDiscovery", func() {
+	var (
+		err error
+
+		records   []*net.SRV
+		lookupErr error
+		lookupSRV LookupSRVFunc
+
+		newBackend BackendFactory
+		discovery  *SRVDiscovery
+	)
+
+	BeforeEach(func() {
+		records = []*net.SRV{
+			&net.SRV{Target: "shield-1.prod.internal.", Port: 443},
+			&net.SRV{Target: "shield-2.prod.internal.", Port: 8443},
+		}
+		lookupErr = nil
+		lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+			Expect(name).To(Equal("_shield._tcp.prod.internal"))
+			return "", records, lookupErr
+		}
+
+		newBackend = func(backendURL string) (*Backend, error) {
+			shieldClient, err := client.NewClient(client.Config{BackendURL: backendURL})
+			if err != nil {
+				return nil, err
+			}
+			newRegistry := func(backendName string, shieldClient *client.Client) Registries {
+				registry := prometheus.NewRegistry()
+				registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+					Name:        "fake_metric",
+					Help:        "Fake metric.",
+					ConstLabels: prometheus.Labels{"backend_name": backendName},
+				}))
+				return Registries{"Fake": registry}
+			}
+			return NewBackend(shieldClient, newRegistry, 0, backendURL), nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		discovery = NewSRVDiscovery("_shield._tcp.prod.internal", "https", lookupSRV, newBackend)
+		err = discovery.Refresh()
+	})
+
+	It("adds a backend per SRV record", func() {
+		Expect(err).ToNot(HaveOccurred())
+		backendURLs := discovery.BackendURLs()
+		sort.Strings(backendURLs)
+		Expect(backendURLs).To(Equal([]string{
+			"https://shield-1.prod.internal:443",
+			"https://shield-2.prod.internal:8443",
+		}))
+	})
+
+	It("reports the backends as resolved", func() {
+		Expect(discovery.Resolve()).To(BeTrue())
+	})
+
+	Context("when no backend is discovered", func() {
+		BeforeEach(func() {
+			records = []*net.SRV{}
+		})
+
+		It("does not report any backend as resolved", func() {
+			Expect(discovery.Resolve()).To(BeFalse())
+		})
+	})
+
+	It("gathers the metrics of every backend", func() {
+		mfs, err := discovery.Gather()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mfs).To(HaveLen(1))
+		Expect(mfs[0].GetMetric()).To(HaveLen(2))
+	})
+
+	It("gathers a single collector of every backend", func() {
+		mfs, err := CollectorGatherer(discovery, "Fake").Gather()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mfs).To(HaveLen(1))
+		Expect(mfs[0].GetMetric()).To(HaveLen(2))
+
+		mfs, err = CollectorGatherer(discovery, "Unknown").Gather()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mfs).To(BeEmpty())
+	})
+
+	Context("when the backends enable different collectors", func() {
+		BeforeEach(func() {
+			newBackend = func(backendURL string) (*Backend, error) {
+				shieldClient, err := client.NewClient(client.Config{BackendURL: backendURL})
+				if err != nil {
+					return nil, err
+				}
+				newRegistry := func(backendName string, shieldClient *client.Client) Registries {
+					registries := Registries{}
+					for _, collectorName := range []string{"Fake", "Other"} {
+						registry := prometheus.NewRegistry()
+						registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+							Name:        strings.ToLower(collectorName) + "_metric",
+							Help:        collectorName + " metric.",
+							ConstLabels: prometheus.Labels{"backend_name": backendName},
+						}))
+						registries[collectorName] = registry
+					}
+					return registries
+				}
+				return NewBackend(shieldClient, newRegistry, 0, backendURL), nil
+			}
+		})
+
+		It("gathers the collectors enabled for each backend", func() {
+			enabledCollectors := map[string]string{
+				"https://shield-1.prod.internal:443":  "Fake",
+				"https://shield-2.prod.internal:8443": "Other",
+			}
+			mfs, err := EnabledCollectorsGatherer(discovery, func(backendURL string, collectorName string) bool {
+				return enabledCollectors[backendURL] == collectorName
+			}).Gather()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mfs).To(HaveLen(2))
+			Expect(mfs[0].GetName()).To(Equal("fake_metric"))
+			Expect(mfs[0].GetMetric()).To(HaveLen(1))
+			Expect(mfs[0].GetMetric()[0].GetLabel()[0].GetValue()).To(Equal("https://shield-1.prod.internal:443"))
+			Expect(mfs[1].GetName()).To(Equal("other_metric"))
+			Expect(mfs[1].GetMetric()).To(HaveLen(1))
+			Expect(mfs[1].GetMetric()[0].GetLabel()[0].GetValue()).To(Equal("https://shield-2.prod.internal:8443"))
+		})
+	})
+
+	Context("when a SRV record disappears", func() {
+		JustBeforeEach(func() {
+			records = records[:1]
+			err = discovery.Refresh()
+		})
+
+		It("removes the backend", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(discovery.BackendURLs()).To(Equal([]string{"https://shield-1.prod.internal:443"}))
+		})
+	})
+
+	Context("when the SRV records cannot be resolved", func() {
+		BeforeEach(func() {
+			lookupErr = errors.New("fake lookup error")
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(discovery.BackendURLs()).To(BeEmpty())
+		})
+	})
+})
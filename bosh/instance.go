@@ -0,0 +1,56 @@
+package bosh
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const DefaultInstanceDir = "/var/vcap/instance"
+
+// Instance is the metadata BOSH writes into the instance directory of the VMs
+// it deploys.
+type Instance struct {
+	Deployment string
+	AZ         string
+	Name       string
+	ID         string
+}
+
+// ReadInstance reads the instance metadata from dir. Missing files are left
+// empty, as older BOSH directors do not write all of them.
+func ReadInstance(dir string) (Instance, error) {
+	var instance Instance
+
+	if _, err := os.Stat(dir); err != nil {
+		return instance, err
+	}
+
+	for file, value := range map[string]*string{
+		"deployment": &instance.Deployment,
+		"az":         &instance.AZ,
+		"name":       &instance.Name,
+		"id":         &instance.ID,
+	} {
+		content, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return instance, err
+		}
+		*value = strings.TrimSpace(string(content))
+	}
+
+	return instance, nil
+}
+
+func (i Instance) Labels() map[string]string {
+	return map[string]string{
+		"bosh_deployment": i.Deployment,
+		"bosh_job_az":     i.AZ,
+		"bosh_job_name":   i.Name,
+		"bosh_job_id":     i.ID,
+	}
+}
@@ -0,0 +1,61 @@
+package bosh_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/bosh-prometheus/shield_exporter/bosh"
+)
+
+var _ = Describe("ReadInstance", func() {
+	var (
+		err      error
+		dir      string
+		instance Instance
+	)
+
+	BeforeEach(func() {
+		dir, err = ioutil.TempDir("", "shield_exporter_bosh")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(ioutil.WriteFile(filepath.Join(dir, "deployment"), []byte("fake_deployment\n"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "az"), []byte("z1"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "name"), []byte("shield"), 0644)).To(Succeed())
+	})
+
+	JustBeforeEach(func() {
+		instance, err = ReadInstance(dir)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("reads the instance metadata and ignores missing files", func() {
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance).To(Equal(Instance{Deployment: "fake_deployment", AZ: "z1", Name: "shield"}))
+	})
+
+	It("returns the instance labels", func() {
+		Expect(instance.Labels()).To(Equal(map[string]string{
+			"bosh_deployment": "fake_deployment",
+			"bosh_job_az":     "z1",
+			"bosh_job_name":   "shield",
+			"bosh_job_id":     "",
+		}))
+	})
+
+	Context("when the instance directory does not exist", func() {
+		BeforeEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,56 @@
+package bosh
+
+import (
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// LabelsGatherer attaches a fixed set of labels to every metric gathered by
+// the wrapped Gatherer.
+type LabelsGatherer struct {
+	gatherer prometheus.Gatherer
+	labels   []*dto.LabelPair
+}
+
+func NewLabelsGatherer(gatherer prometheus.Gatherer, labels map[string]string) *LabelsGatherer {
+	labelPairs := []*dto.LabelPair{}
+	for name, value := range labels {
+		labelPairs = append(labelPairs, &dto.LabelPair{
+			Name:  proto.String(name),
+			Value: proto.String(value),
+		})
+	}
+
+	return &LabelsGatherer{
+		gatherer: gatherer,
+		labels:   labelPairs,
+	}
+}
+
+func (g *LabelsGatherer) Gather() ([]*dto.MetricFamily, error) {
+	mfs, err := g.gatherer.Gather()
+
+	labeledMfs := make([]*dto.MetricFamily, 0, len(mfs))
+	for _, mf := range mfs {
+		labeledMf := *mf
+		labeledMf.Metric = make([]*dto.Metric, 0, len(mf.Metric))
+		for _, metric := range mf.Metric {
+			labeledMetric := *metric
+			labeledMetric.Label = append(append([]*dto.LabelPair{}, metric.Label...), g.labels...)
+			sort.Sort(labelPairsByName(labeledMetric.Label))
+			labeledMf.Metric = append(labeledMf.Metric, &labeledMetric)
+		}
+		labeledMfs = append(labeledMfs, &labeledMf)
+	}
+
+	return labeledMfs, err
+}
+
+type labelPairsByName []*dto.LabelPair
+
+func (s labelPairsByName) Len() int           { return len(s) }
+func (s labelPairsByName) Less(i, j int) bool { return s[i].GetName() < s[j].GetName() }
+func (s labelPairsByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,55 @@
+package bosh_test
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/bosh-prometheus/shield_exporter/bosh"
+)
+
+var _ = Describe("LabelsGatherer", func() {
+	var (
+		registry *prometheus.Registry
+		gatherer *LabelsGatherer
+	)
+
+	BeforeEach(func() {
+		registry = prometheus.NewRegistry()
+		gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "fake_metric",
+			Help:        "Fake metric",
+			ConstLabels: prometheus.Labels{"environment": "test"},
+		})
+		registry.MustRegister(gauge)
+
+		gatherer = NewLabelsGatherer(registry, map[string]string{"bosh_deployment": "fake_deployment"})
+	})
+
+	labelsOf := func(mfs []*dto.MetricFamily) map[string]string {
+		labels := map[string]string{}
+		for _, label := range mfs[0].Metric[0].Label {
+			labels[label.GetName()] = label.GetValue()
+		}
+		return labels
+	}
+
+	It("attaches the labels to the gathered metrics", func() {
+		mfs, err := gatherer.Gather()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mfs).To(HaveLen(1))
+		Expect(mfs[0].Metric[0].Label[0].GetName()).To(Equal("bosh_deployment"))
+		Expect(labelsOf(mfs)).To(Equal(map[string]string{"bosh_deployment": "fake_deployment", "environment": "test"}))
+	})
+
+	It("does not attach the labels twice on subsequent gathers", func() {
+		_, err := gatherer.Gather()
+		Expect(err).ToNot(HaveOccurred())
+
+		mfs, err := gatherer.Gather()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mfs[0].Metric[0].Label).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/log"
+)
+
+// BackgroundGatherer gathers the wrapped Gatherer in the background at the
+// scrape interval, and serves the metrics it gathered last on every request.
+// Cached metrics older than the metrics TTL, when set, are dropped instead,
+// flagged through the cache stale metric.
+type BackgroundGatherer struct {
+	gatherer         prometheus.Gatherer
+	interval         time.Duration
+	metricsTTL       time.Duration
+	cacheStaleMetric prometheus.Gauge
+
+	mu       sync.Mutex
+	cached   []*dto.MetricFamily
+	cachedAt time.Time
+}
+
+func NewBackgroundGatherer(gatherer prometheus.Gatherer, interval time.Duration, metricsTTL time.Duration, namespace string) *BackgroundGatherer {
+	cacheStaleMetric := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "exporter",
+			Name:      "cache_stale",
+			Help:      "Whether the cached Shield metrics have been dropped for being older than the metrics TTL (1 for dropped, 0 otherwise).",
+		},
+	)
+
+	return &BackgroundGatherer{
+		gatherer:         gatherer,
+		interval:         interval,
+		metricsTTL:       metricsTTL,
+		cacheStaleMetric: cacheStaleMetric,
+	}
+}
+
+// Start gathers the wrapped Gatherer right away, then at every interval.
+func (g *BackgroundGatherer) Start() {
+	go func() {
+		g.refresh()
+
+		ticker := time.NewTicker(g.interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			g.refresh()
+		}
+	}()
+}
+
+func (g *BackgroundGatherer) refresh() {
+	mfs, err := g.gatherer.Gather()
+	if err != nil {
+		log.Errorf("Error while gathering the Shield metrics in the background: %v", err)
+		if len(mfs) == 0 {
+			return
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.cached = mfs
+	g.cachedAt = time.Now()
+}
+
+func (g *BackgroundGatherer) Gather() ([]*dto.MetricFamily, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.metricsTTL > 0 && !g.cachedAt.IsZero() && time.Since(g.cachedAt) > g.metricsTTL {
+		g.cacheStaleMetric.Set(1)
+		return nil, nil
+	}
+
+	g.cacheStaleMetric.Set(0)
+	return g.cached, nil
+}
+
+func (g *BackgroundGatherer) Describe(ch chan<- *prometheus.Desc) {
+	g.cacheStaleMetric.Describe(ch)
+}
+
+func (g *BackgroundGatherer) Collect(ch chan<- prometheus.Metric) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.cacheStaleMetric.Collect(ch)
+}
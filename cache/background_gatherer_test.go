@@ -0,0 +1,170 @@
+package cache_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/log"
+
+	. "github.com/bosh-prometheus/shield_exporter/cache"
+	. "github.com/bosh-prometheus/shield_exporter/utils/test_matchers"
+)
+
+func init() {
+	log.Base().SetLevel("fatal")
+}
+
+// fakeGatherer returns a single gauge, set to the number of times it was
+// gathered, or fails once failing is set.
+type fakeGatherer struct {
+	mu       sync.Mutex
+	gathered int
+	failing  bool
+}
+
+func (g *fakeGatherer) Gather() ([]*dto.MetricFamily, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.failing {
+		return nil, errors.New("fake error")
+	}
+
+	g.gathered++
+	registry := prometheus.NewRegistry()
+	fakeMetric := prometheus.NewGauge(prometheus.GaugeOpts{Name: "fake_metric", Help: "Fake metric."})
+	fakeMetric.Set(float64(g.gathered))
+	registry.MustRegister(fakeMetric)
+
+	return registry.Gather()
+}
+
+func (g *fakeGatherer) setFailing(failing bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.failing = failing
+}
+
+var _ = Describe("BackgroundGatherer", func() {
+	var (
+		namespace = "test_namespace"
+
+		gatherer         *fakeGatherer
+		cacheStaleMetric prometheus.Gauge
+		interval         time.Duration
+		metricsTTL       time.Duration
+
+		backgroundGatherer *BackgroundGatherer
+	)
+
+	gatheredValue := func() float64 {
+		mfs, err := backgroundGatherer.Gather()
+		Expect(err).ToNot(HaveOccurred())
+		if len(mfs) == 0 {
+			return 0
+		}
+		return mfs[0].GetMetric()[0].GetGauge().GetValue()
+	}
+
+	BeforeEach(func() {
+		gatherer = &fakeGatherer{}
+
+		cacheStaleMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "exporter",
+				Name:      "cache_stale",
+				Help:      "Whether the cached Shield metrics have been dropped for being older than the metrics TTL (1 for dropped, 0 otherwise).",
+			},
+		)
+
+		interval = time.Hour
+		metricsTTL = 0
+	})
+
+	JustBeforeEach(func() {
+		backgroundGatherer = NewBackgroundGatherer(gatherer, interval, metricsTTL, namespace)
+	})
+
+	It("does not serve any metrics before the first gathering", func() {
+		mfs, err := backgroundGatherer.Gather()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mfs).To(BeEmpty())
+	})
+
+	Context("when started", func() {
+		JustBeforeEach(func() {
+			backgroundGatherer.Start()
+			Eventually(gatheredValue).Should(Equal(float64(1)))
+		})
+
+		It("serves the cached metrics without gathering again", func() {
+			Consistently(gatheredValue, 50*time.Millisecond).Should(Equal(float64(1)))
+		})
+
+		It("does not flag the cache as stale", func() {
+			metrics := make(chan prometheus.Metric, 1)
+			backgroundGatherer.Collect(metrics)
+
+			cacheStaleMetric.Set(0)
+			Eventually(metrics).Should(Receive(PrometheusMetric(cacheStaleMetric)))
+		})
+
+		Context("when the interval elapses", func() {
+			BeforeEach(func() {
+				interval = 10 * time.Millisecond
+			})
+
+			It("gathers the metrics again", func() {
+				Eventually(gatheredValue).Should(BeNumerically(">", 1))
+			})
+
+			Context("and the gathering fails", func() {
+				JustBeforeEach(func() {
+					gatherer.setFailing(true)
+					time.Sleep(30 * time.Millisecond)
+				})
+
+				It("keeps serving the last gathered metrics", func() {
+					value := gatheredValue()
+					Expect(value).To(BeNumerically(">", 0))
+					Consistently(gatheredValue, 50*time.Millisecond).Should(Equal(value))
+				})
+			})
+		})
+
+		Context("when the cached metrics are older than the metrics TTL", func() {
+			BeforeEach(func() {
+				metricsTTL = 200 * time.Millisecond
+			})
+
+			JustBeforeEach(func() {
+				time.Sleep(300 * time.Millisecond)
+			})
+
+			It("drops the cached metrics", func() {
+				mfs, err := backgroundGatherer.Gather()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(mfs).To(BeEmpty())
+			})
+
+			It("flags the cache as stale", func() {
+				_, err := backgroundGatherer.Gather()
+				Expect(err).ToNot(HaveOccurred())
+
+				metrics := make(chan prometheus.Metric, 1)
+				backgroundGatherer.Collect(metrics)
+
+				cacheStaleMetric.Set(1)
+				Eventually(metrics).Should(Receive(PrometheusMetric(cacheStaleMetric)))
+			})
+		})
+	})
+})
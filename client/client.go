@@ -0,0 +1,534 @@
+package client
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/log"
+	"github.com/starkandwayne/shield/api"
+	"golang.org/x/net/http/httpproxy"
+
+	"github.com/bosh-prometheus/shield_exporter/redact"
+)
+
+type Config struct {
+	BackendURL            string
+	Username              string
+	Password              string
+	SkipSSLValidation     bool
+	ProxyURL              string
+	Timeout               time.Duration
+	MaxRequestsPerSecond  float64
+	MaxIdleConns          int
+	IdleConnTimeout       time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	Semaphore             *Semaphore
+	Credentials           *Credentials
+}
+
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("Error %s", e.Status)
+}
+
+type Client struct {
+	backendURL  string
+	credentials *Credentials
+	httpClient  *http.Client
+	limiter     *rateLimiter
+	semaphore   *Semaphore
+	cache       *responseCache
+	stats       *statsRecorder
+	ctx         context.Context
+	tenant      *tenantScope
+}
+
+func NewClient(config Config) (*Client, error) {
+	if config.BackendURL == "" {
+		return nil, errors.New("Shield Backend URL is required")
+	}
+
+	if config.MaxRequestsPerSecond < 0 {
+		return nil, fmt.Errorf("Invalid maximum number of requests per second `%v`", config.MaxRequestsPerSecond)
+	}
+
+	if config.MaxIdleConns < 0 {
+		return nil, fmt.Errorf("Invalid maximum number of idle connections `%d`", config.MaxIdleConns)
+	}
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if config.ProxyURL != "" {
+		proxyURL, err := url.Parse(config.ProxyURL)
+		if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, fmt.Errorf("Invalid proxy URL `%s`", redact.String(config.ProxyURL))
+		}
+		proxyConfig := httpproxy.FromEnvironment()
+		proxyConfig.HTTPProxy = proxyURL.String()
+		proxyConfig.HTTPSProxy = proxyURL.String()
+		proxyFunc := proxyConfig.ProxyFunc()
+		proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	}
+
+	var limiter *rateLimiter
+	if config.MaxRequestsPerSecond > 0 {
+		limiter = newRateLimiter(config.MaxRequestsPerSecond)
+	}
+
+	credentials := config.Credentials
+	if credentials == nil {
+		credentials = NewCredentials(config.Username, config.Password)
+	}
+
+	return &Client{
+		backendURL:  strings.TrimSuffix(config.BackendURL, "/"),
+		credentials: credentials,
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: config.SkipSSLValidation,
+				},
+				Proxy:                 proxy,
+				MaxIdleConns:          config.MaxIdleConns,
+				MaxIdleConnsPerHost:   config.MaxIdleConns,
+				IdleConnTimeout:       config.IdleConnTimeout,
+				TLSHandshakeTimeout:   config.TLSHandshakeTimeout,
+				ResponseHeaderTimeout: config.ResponseHeaderTimeout,
+			},
+			Timeout: timeout,
+		},
+		limiter:   limiter,
+		semaphore: config.Semaphore,
+		cache:     newResponseCache(),
+		stats:     &statsRecorder{},
+	}, nil
+}
+
+func (c *Client) Get(path string, out interface{}) error {
+	endSpan := c.startSpan(path)
+	err := redact.Error(c.reauthenticating(func() error { return c.get(path, out) }))
+	c.stats.recordRequest(err)
+	endSpan(err)
+	return err
+}
+
+// BackendURL returns the URL of the Shield backend, without trailing slash.
+func (c *Client) BackendURL() string {
+	return c.backendURL
+}
+
+// Stats returns a snapshot of the counters about the requests sent so far.
+func (c *Client) Stats() Stats {
+	return c.stats.snapshot()
+}
+
+// reauthenticating sends the request again, once, after refreshing the
+// credentials when Shield rejected them.
+func (c *Client) reauthenticating(request func() error) error {
+	authToken := c.credentials.AuthToken()
+	err := request()
+	if statusErr, ok := err.(*StatusError); !ok || statusErr.StatusCode != http.StatusUnauthorized {
+		return err
+	}
+
+	retry, reauthErr := c.credentials.Reauthenticate(authToken)
+	if reauthErr != nil {
+		log.Errorf("Error while re-authenticating to Shield: %v", redact.Error(reauthErr))
+	}
+	if !retry {
+		return err
+	}
+
+	return request()
+}
+
+func (c *Client) get(path string, out interface{}) error {
+	req, err := c.newRequest(path)
+	if err != nil {
+		return err
+	}
+	if out != nil {
+		c.cache.addConditionalHeaders(path, req)
+	}
+
+	if c.semaphore != nil {
+		c.semaphore.Acquire()
+		defer c.semaphore.Release()
+	}
+
+	res, body, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified && out != nil && c.cache.load(path, out) {
+		c.stats.recordCacheHit()
+		return nil
+	}
+
+	if res.StatusCode == http.StatusServiceUnavailable && out != nil && c.cache.load(path, out) {
+		io.Copy(ioutil.Discard, body)
+		c.stats.recordCacheHit()
+		return nil
+	}
+
+	if res.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, body)
+		return &StatusError{StatusCode: res.StatusCode, Status: res.Status}
+	}
+
+	if out != nil {
+		countingBody := &countingReader{reader: body}
+		if err := json.NewDecoder(countingBody).Decode(out); err != nil {
+			return err
+		}
+		c.stats.recordResponseBytes(req.URL.Path, countingBody.bytes)
+		c.cache.store(path, res, out)
+	}
+
+	return nil
+}
+
+// stream decodes a JSON array response one element at a time, so the
+// memory used does not grow with the size of the listing.
+func (c *Client) stream(path string, decodeItem func(decoder *json.Decoder) error) error {
+	endSpan := c.startSpan(path)
+	err := redact.Error(c.reauthenticating(func() error { return c.decodeStream(path, decodeItem) }))
+	c.stats.recordRequest(err)
+	endSpan(err)
+	return err
+}
+
+func (c *Client) decodeStream(path string, decodeItem func(decoder *json.Decoder) error) error {
+	req, err := c.newRequest(path)
+	if err != nil {
+		return err
+	}
+
+	if c.semaphore != nil {
+		c.semaphore.Acquire()
+		defer c.semaphore.Release()
+	}
+
+	res, body, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, body)
+		return &StatusError{StatusCode: res.StatusCode, Status: res.Status}
+	}
+
+	countingBody := &countingReader{reader: body}
+	decoder := json.NewDecoder(countingBody)
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		c.stats.recordResponseBytes(req.URL.Path, countingBody.bytes)
+		return nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("Unexpected JSON token `%v` while decoding `%s`, expecting an array", token, path)
+	}
+
+	for decoder.More() {
+		if err := decodeItem(decoder); err != nil {
+			return err
+		}
+	}
+
+	if _, err = decoder.Token(); err != nil {
+		return err
+	}
+	c.stats.recordResponseBytes(req.URL.Path, countingBody.bytes)
+	return nil
+}
+
+// countingReader counts the bytes read from a response body, after its
+// decompression.
+type countingReader struct {
+	reader io.Reader
+	bytes  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.bytes += int64(n)
+	return n, err
+}
+
+func (c *Client) newRequest(path string) (*http.Request, error) {
+	if c.tenant != nil {
+		tenantPath, err := c.tenant.path(c, path)
+		if err != nil {
+			return nil, err
+		}
+		path = tenantPath
+	}
+
+	req, err := http.NewRequestWithContext(c.context(), "GET", c.backendURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", c.credentials.AuthToken())
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	return req, nil
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, io.Reader, error) {
+	if c.limiter != nil {
+		c.limiter.Wait()
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.stats.recordAPIRequest(req.URL.Path, res.StatusCode)
+
+	if res.TLS != nil && len(res.TLS.PeerCertificates) > 0 {
+		c.stats.recordTLSCertificate(res.TLS.PeerCertificates[0].NotAfter)
+	}
+
+	maintenance := res.StatusCode == http.StatusServiceUnavailable
+	if c.stats.recordMaintenance(maintenance) {
+		if maintenance {
+			log.Warnf("Shield backend `%s` is in maintenance mode, serving the last listings received", redact.String(c.backendURL))
+		} else {
+			log.Infof("Shield backend `%s` left maintenance mode", redact.String(c.backendURL))
+		}
+	}
+
+	if res.Header.Get("Content-Encoding") == "gzip" {
+		gzipReader, err := gzip.NewReader(res.Body)
+		if err != nil {
+			res.Body.Close()
+			return nil, nil, err
+		}
+		return res, gzipReader, nil
+	}
+
+	return res, res.Body, nil
+}
+
+func (c *Client) GetStatus() (api.Status, error) {
+	var status api.Status
+	return status, c.Get("/v1/status", &status)
+}
+
+// Archive is a Shield archive, with the cipher, compression and size of
+// Shield v8 cores, empty when Shield does not report them.
+type Archive struct {
+	api.Archive
+	EncryptionType string `json:"encryption_type,omitempty"`
+	Compression    string `json:"compression,omitempty"`
+	Size           int64  `json:"size,omitempty"`
+}
+
+func (c *Client) GetArchives() ([]Archive, error) {
+	var archives []Archive
+	return archives, c.Get("/v1/archives", &archives)
+}
+
+// AuthToken is a Shield v8 API token of the Shield user.
+type AuthToken struct {
+	UUID      string `json:"uuid"`
+	Name      string `json:"name"`
+	CreatedAt int64  `json:"created_at"`
+	LastSeen  int64  `json:"last_seen"`
+}
+
+func (c *Client) GetAuthTokens() ([]AuthToken, error) {
+	var authTokens []AuthToken
+	return authTokens, c.Get("/v2/auth/tokens", &authTokens)
+}
+
+// Agent is a Shield v8 agent, as registered with the core.
+type Agent struct {
+	UUID       string `json:"uuid"`
+	Name       string `json:"name"`
+	Address    string `json:"address"`
+	Version    string `json:"version"`
+	Status     string `json:"status"`
+	LastSeenAt int64  `json:"last_seen_at"`
+}
+
+func (c *Client) GetAgents() ([]Agent, error) {
+	var agents struct {
+		Agents []Agent `json:"agents"`
+	}
+	return agents.Agents, c.Get("/v2/agents", &agents)
+}
+
+// User is a Shield v8 local user.
+type User struct {
+	UUID    string `json:"uuid"`
+	Name    string `json:"name"`
+	Account string `json:"account"`
+	SysRole string `json:"sysrole"`
+}
+
+func (c *Client) GetUsers() ([]User, error) {
+	var users []User
+	return users, c.Get("/v2/auth/local/users", &users)
+}
+
+func (c *Client) GetJobs() ([]api.Job, error) {
+	var jobs []api.Job
+	return jobs, c.Get("/v1/jobs", &jobs)
+}
+
+func (c *Client) GetJobsStatus() (api.JobsStatus, error) {
+	var jobsStatus api.JobsStatus
+	return jobsStatus, c.Get("/v1/status/jobs", &jobsStatus)
+}
+
+func (c *Client) GetRetentionPolicies() ([]api.RetentionPolicy, error) {
+	var retentionPolicies []api.RetentionPolicy
+	return retentionPolicies, c.Get("/v1/retention", &retentionPolicies)
+}
+
+func (c *Client) GetSchedules() ([]api.Schedule, error) {
+	var schedules []api.Schedule
+	return schedules, c.Get("/v1/schedules", &schedules)
+}
+
+// Store is a Shield store, with the result of its last test on Shield v8
+// cores, nil when Shield does not report it.
+type Store struct {
+	api.Store
+	Healthy *bool `json:"healthy,omitempty"`
+}
+
+func (c *Client) GetStores() ([]Store, error) {
+	var stores []Store
+	return stores, c.Get("/v1/stores", &stores)
+}
+
+func (c *Client) GetTargets() ([]api.Target, error) {
+	var targets []api.Target
+	return targets, c.Get("/v1/targets", &targets)
+}
+
+func (c *Client) GetTasks() ([]api.Task, error) {
+	var tasks []api.Task
+	return tasks, c.Get("/v1/tasks", &tasks)
+}
+
+func (c *Client) ForEachArchive(fn func(archive Archive)) error {
+	return c.stream("/v1/archives", func(decoder *json.Decoder) error {
+		var archive Archive
+		if err := decoder.Decode(&archive); err != nil {
+			return err
+		}
+		fn(archive)
+		return nil
+	})
+}
+
+func (c *Client) ForEachTask(fn func(task api.Task)) error {
+	return c.stream("/v1/tasks", func(decoder *json.Decoder) error {
+		var task api.Task
+		if err := decoder.Decode(&task); err != nil {
+			return err
+		}
+		fn(task)
+		return nil
+	})
+}
+
+// IsMaintenance returns whether err is the answer of a Shield backend in
+// maintenance mode.
+func IsMaintenance(err error) bool {
+	statusError, ok := err.(*StatusError)
+	return ok && statusError.StatusCode == http.StatusServiceUnavailable
+}
+
+func IsNotImplemented(err error) bool {
+	statusError, ok := err.(*StatusError)
+	return ok && statusError.StatusCode == http.StatusNotImplemented
+}
+
+func IsNotFound(err error) bool {
+	statusError, ok := err.(*StatusError)
+	return ok && statusError.StatusCode == http.StatusNotFound
+}
+
+const (
+	TimeoutErrorReason    = "timeout"
+	AuthErrorReason       = "auth"
+	ConnectionErrorReason = "connection"
+	HTTP5xxErrorReason    = "http_5xx"
+	DecodeErrorReason     = "decode"
+	OtherErrorReason      = "other"
+)
+
+var ErrorReasons = []string{
+	TimeoutErrorReason,
+	AuthErrorReason,
+	ConnectionErrorReason,
+	HTTP5xxErrorReason,
+	DecodeErrorReason,
+	OtherErrorReason,
+}
+
+// ErrorReason classifies an error returned by the client into one of the
+// ErrorReasons.
+func ErrorReason(err error) string {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden:
+			return AuthErrorReason
+		case statusErr.StatusCode >= 500:
+			return HTTP5xxErrorReason
+		}
+		return OtherErrorReason
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return TimeoutErrorReason
+	}
+
+	var syntaxErr *json.SyntaxError
+	var unmarshalTypeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &unmarshalTypeErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return DecodeErrorReason
+	}
+
+	if netErr != nil {
+		return ConnectionErrorReason
+	}
+
+	return OtherErrorReason
+}
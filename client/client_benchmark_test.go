@@ -0,0 +1,76 @@
+package client_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/starkandwayne/shield/api"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+)
+
+func newArchivesServer(b *testing.B, count int) *httptest.Server {
+	archives := make([]api.Archive, count)
+	for i := range archives {
+		archives[i] = api.Archive{
+			UUID:           "00000000-0000-0000-0000-000000000000",
+			Status:         "valid",
+			StorePlugin:    "s3",
+			TargetPlugin:   "postgres",
+			StoreEndpoint:  `{"bucket":"backups","prefix":"shield"}`,
+			TargetEndpoint: `{"pg_host":"10.0.0.1","pg_port":"5432"}`,
+			Notes:          "nightly backup",
+		}
+	}
+
+	body, err := json.Marshal(archives)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+}
+
+func newBenchmarkClient(b *testing.B, url string) *client.Client {
+	shieldClient, err := client.NewClient(client.Config{
+		BackendURL: url,
+		Username:   "fake_username",
+		Password:   "fake_password",
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return shieldClient
+}
+
+func BenchmarkGetArchives(b *testing.B) {
+	server := newArchivesServer(b, 20000)
+	defer server.Close()
+	shieldClient := newBenchmarkClient(b, server.URL)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := shieldClient.GetArchives(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkForEachArchive(b *testing.B) {
+	server := newArchivesServer(b, 20000)
+	defer server.Close()
+	shieldClient := newBenchmarkClient(b, server.URL)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := shieldClient.ForEachArchive(func(archive client.Archive) {}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
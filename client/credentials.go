@@ -0,0 +1,184 @@
+package client
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/log"
+	"github.com/starkandwayne/shield/api"
+)
+
+// Credentials holds the auth token sent to Shield. A single Credentials can be
+// shared by several clients so that rotated credentials apply to all of them.
+type Credentials struct {
+	mu        sync.RWMutex
+	authToken string
+
+	reauthMu          sync.Mutex
+	reauthenticate    func() error
+	reauthentications uint64
+}
+
+func NewCredentials(username string, password string) *Credentials {
+	return &Credentials{authToken: api.BasicAuthToken(username, password)}
+}
+
+// NewBearerCredentials returns Credentials sending an access token, ie
+// obtained from UAA, instead of a username and a password.
+func NewBearerCredentials(token string) *Credentials {
+	return &Credentials{authToken: "Bearer " + token}
+}
+
+func (c *Credentials) Set(username string, password string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.authToken = api.BasicAuthToken(username, password)
+}
+
+func (c *Credentials) SetBearer(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.authToken = "Bearer " + token
+}
+
+func (c *Credentials) AuthToken() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.authToken
+}
+
+// SetReauthenticate sets the function refreshing the credentials when Shield
+// rejects them, ie when a session or an access token expired.
+func (c *Credentials) SetReauthenticate(reauthenticate func() error) {
+	c.reauthMu.Lock()
+	defer c.reauthMu.Unlock()
+
+	c.reauthenticate = reauthenticate
+}
+
+// Reauthenticate refreshes the credentials after Shield rejected staleAuthToken,
+// and reports whether the rejected request should be retried. Concurrent
+// requests rejected with the same token only trigger a single refresh.
+func (c *Credentials) Reauthenticate(staleAuthToken string) (bool, error) {
+	c.reauthMu.Lock()
+	defer c.reauthMu.Unlock()
+
+	if c.reauthenticate == nil {
+		return false, nil
+	}
+
+	if c.AuthToken() != staleAuthToken {
+		return true, nil
+	}
+
+	c.reauthentications++
+	if err := c.reauthenticate(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Reauthentications returns the number of times the credentials were
+// refreshed after being rejected by Shield.
+func (c *Credentials) Reauthentications() uint64 {
+	c.reauthMu.Lock()
+	defer c.reauthMu.Unlock()
+
+	return c.reauthentications
+}
+
+// CredentialsFilesWatcher reloads Credentials from a username and a password
+// file whenever their contents change. Files are polled rather than watched
+// for events, as mounted Kubernetes secrets are swapped through symlinks, so
+// changes are picked up up to one polling interval later, unless Shield
+// rejects the previous credentials first and Reload is called right away.
+type CredentialsFilesWatcher struct {
+	usernameFile string
+	passwordFile string
+	credentials  *Credentials
+
+	mu       sync.RWMutex
+	username []byte
+	password []byte
+}
+
+func NewCredentialsFilesWatcher(usernameFile string, passwordFile string) (*CredentialsFilesWatcher, error) {
+	w := &CredentialsFilesWatcher{
+		usernameFile: usernameFile,
+		passwordFile: passwordFile,
+	}
+
+	username, password, err := w.read()
+	if err != nil {
+		return nil, err
+	}
+	w.username, w.password = username, password
+	w.credentials = NewCredentials(string(username), string(password))
+
+	return w, nil
+}
+
+func (w *CredentialsFilesWatcher) Credentials() *Credentials {
+	return w.credentials
+}
+
+func (w *CredentialsFilesWatcher) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := w.Reload(); err != nil {
+				log.Errorf("Error while reloading Shield credentials: %v", err)
+			}
+		}
+	}()
+}
+
+// Reload reads the credentials files again and reports whether they changed.
+// On error, the previous credentials are kept.
+func (w *CredentialsFilesWatcher) Reload() (bool, error) {
+	username, password, err := w.read()
+	if err != nil {
+		return false, err
+	}
+
+	w.mu.RLock()
+	unchanged := bytes.Equal(username, w.username) && bytes.Equal(password, w.password)
+	w.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if bytes.Equal(username, w.username) && bytes.Equal(password, w.password) {
+		return false, nil
+	}
+
+	w.username, w.password = username, password
+	w.credentials.Set(string(username), string(password))
+	log.Infoln("Reloaded Shield credentials")
+
+	return true, nil
+}
+
+func (w *CredentialsFilesWatcher) read() ([]byte, []byte, error) {
+	username, err := ioutil.ReadFile(w.usernameFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	password, err := ioutil.ReadFile(w.passwordFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return bytes.TrimSpace(username), bytes.TrimSpace(password), nil
+}
@@ -0,0 +1,157 @@
+package client_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/starkandwayne/shield/api"
+
+	. "github.com/bosh-prometheus/shield_exporter/client"
+)
+
+var _ = Describe("CredentialsFilesWatcher", func() {
+	var (
+		err          error
+		dir          string
+		usernameFile string
+		passwordFile string
+
+		watcher *CredentialsFilesWatcher
+	)
+
+	BeforeEach(func() {
+		dir, err = ioutil.TempDir("", "shield_exporter_credentials")
+		Expect(err).ToNot(HaveOccurred())
+
+		usernameFile = filepath.Join(dir, "username")
+		passwordFile = filepath.Join(dir, "password")
+		Expect(ioutil.WriteFile(usernameFile, []byte("fake_username\n"), 0600)).To(Succeed())
+		Expect(ioutil.WriteFile(passwordFile, []byte("fake_password\n"), 0600)).To(Succeed())
+	})
+
+	JustBeforeEach(func() {
+		watcher, err = NewCredentialsFilesWatcher(usernameFile, passwordFile)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("NewCredentialsFilesWatcher", func() {
+		It("reads the credentials without trailing whitespaces", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(watcher.Credentials().AuthToken()).To(Equal(api.BasicAuthToken("fake_username", "fake_password")))
+		})
+
+		Context("when a file does not exist", func() {
+			BeforeEach(func() {
+				passwordFile = filepath.Join(dir, "unknown")
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Reload", func() {
+		var changed bool
+
+		It("does not report a change when the files are unchanged", func() {
+			changed, err = watcher.Reload()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeFalse())
+		})
+
+		It("swaps the credentials when a file changes", func() {
+			Expect(ioutil.WriteFile(passwordFile, []byte("rotated_password"), 0600)).To(Succeed())
+
+			changed, err = watcher.Reload()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(watcher.Credentials().AuthToken()).To(Equal(api.BasicAuthToken("fake_username", "rotated_password")))
+		})
+
+		It("reports a change once when reloaded concurrently", func() {
+			Expect(ioutil.WriteFile(passwordFile, []byte("rotated_password"), 0600)).To(Succeed())
+
+			changes := make(chan bool, 2)
+			for i := 0; i < 2; i++ {
+				go func() {
+					defer GinkgoRecover()
+					changed, err := watcher.Reload()
+					Expect(err).ToNot(HaveOccurred())
+					changes <- changed
+				}()
+			}
+
+			Expect([]bool{<-changes, <-changes}).To(ConsistOf(true, false))
+			Expect(watcher.Credentials().AuthToken()).To(Equal(api.BasicAuthToken("fake_username", "rotated_password")))
+		})
+
+		It("keeps the previous credentials when a file can not be read", func() {
+			Expect(os.Remove(passwordFile)).To(Succeed())
+
+			changed, err = watcher.Reload()
+			Expect(err).To(HaveOccurred())
+			Expect(changed).To(BeFalse())
+			Expect(watcher.Credentials().AuthToken()).To(Equal(api.BasicAuthToken("fake_username", "fake_password")))
+		})
+	})
+
+	Describe("Client", func() {
+		var server *ghttp.Server
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("uses the rotated credentials", func() {
+			shieldClient, err := NewClient(Config{BackendURL: server.URL(), Credentials: watcher.Credentials()})
+			Expect(err).ToNot(HaveOccurred())
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyBasicAuth("fake_username", "fake_password"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, api.Status{}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyBasicAuth("fake_username", "rotated_password"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, api.Status{}),
+				),
+			)
+
+			_, err = shieldClient.GetStatus()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ioutil.WriteFile(passwordFile, []byte("rotated_password"), 0600)).To(Succeed())
+			_, err = watcher.Reload()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = shieldClient.GetStatus()
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Credentials", func() {
+	Describe("NewBearerCredentials", func() {
+		It("sends the access token as a bearer token", func() {
+			credentials := NewBearerCredentials("fake_token")
+			Expect(credentials.AuthToken()).To(Equal("Bearer fake_token"))
+
+			credentials.SetBearer("refreshed_token")
+			Expect(credentials.AuthToken()).To(Equal("Bearer refreshed_token"))
+		})
+	})
+})
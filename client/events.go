@@ -0,0 +1,276 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/bosh-prometheus/shield_exporter/redact"
+)
+
+// Event is a message of the events stream of Shield v8 cores.
+type Event struct {
+	Event string          `json:"event"`
+	Queue string          `json:"queue"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// EventsStream is a connection to the events stream of a Shield v8 core.
+type EventsStream struct {
+	conn      *websocket.Conn
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// Receive waits for the next event of the stream.
+func (s *EventsStream) Receive() (Event, error) {
+	var event Event
+	err := websocket.JSON.Receive(s.conn, &event)
+	return event, redact.Error(err)
+}
+
+func (s *EventsStream) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	return s.conn.Close()
+}
+
+// ping sends a ping frame every interval until the stream is closed, so that
+// the pongs answered by Shield keep extending the read deadline of an idle
+// stream.
+func (s *EventsStream) ping(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closed:
+			return
+		case <-ticker.C:
+			if _, err := s.conn.Write(nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// OpenEvents connects to the events stream of the Shield backend, through the
+// proxy of the Shield API requests if any, re-authenticating once when Shield
+// rejects the auth token. Unless pingInterval is 0, a ping is sent every
+// pingInterval and the stream fails when nothing is received from Shield,
+// not even a pong, for twice that long.
+func (c *Client) OpenEvents(pingInterval time.Duration) (*EventsStream, error) {
+	var stream *EventsStream
+	err := c.reauthenticating(func() error {
+		var err error
+		stream, err = c.openEvents(pingInterval)
+		return err
+	})
+	if err != nil {
+		return nil, redact.Error(err)
+	}
+
+	return stream, nil
+}
+
+func (c *Client) openEvents(pingInterval time.Duration) (*EventsStream, error) {
+	eventsURL := c.backendURL + "/v2/events"
+	if strings.HasPrefix(eventsURL, "https://") {
+		eventsURL = "wss://" + strings.TrimPrefix(eventsURL, "https://")
+	} else {
+		eventsURL = "ws://" + strings.TrimPrefix(eventsURL, "http://")
+	}
+
+	config, err := websocket.NewConfig(eventsURL, c.backendURL)
+	if err != nil {
+		return nil, err
+	}
+	config.Header.Set("Authorization", c.credentials.AuthToken())
+
+	transport, _ := c.httpClient.Transport.(*http.Transport)
+	if transport != nil {
+		config.TlsConfig = transport.TLSClientConfig
+	}
+
+	netConn, err := c.dialEvents(config, transport)
+	if err != nil {
+		return nil, err
+	}
+
+	eventsConn := &eventsConn{Conn: netConn, readTimeout: 2 * pingInterval}
+	conn, err := websocket.NewClient(config, eventsConn)
+	if err != nil {
+		netConn.Close()
+		if statusErr := eventsConn.statusError(); err == websocket.ErrBadStatus && statusErr != nil {
+			return nil, statusErr
+		}
+		return nil, err
+	}
+	conn.PayloadType = websocket.PingFrame
+
+	stream := &EventsStream{conn: conn, closed: make(chan struct{})}
+	if pingInterval > 0 {
+		go stream.ping(pingInterval)
+	}
+
+	return stream, nil
+}
+
+// dialEvents opens the connection to the events stream of the Shield backend,
+// tunneled with a CONNECT request when the Shield API requests go through a
+// proxy.
+func (c *Client) dialEvents(config *websocket.Config, transport *http.Transport) (net.Conn, error) {
+	address := hostPort(config.Location)
+	dialer := &net.Dialer{Timeout: c.httpClient.Timeout}
+
+	var proxyURL *url.URL
+	if transport != nil && transport.Proxy != nil {
+		backendURL, err := url.Parse(c.backendURL)
+		if err != nil {
+			return nil, err
+		}
+		if proxyURL, err = transport.Proxy(&http.Request{URL: backendURL}); err != nil {
+			return nil, err
+		}
+	}
+
+	if proxyURL == nil {
+		conn, err := dialer.Dial("tcp", address)
+		if err != nil {
+			return nil, err
+		}
+		return tlsEvents(conn, config)
+	}
+
+	conn, err := dialer.Dial("tcp", hostPort(proxyURL))
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+	}
+
+	connect := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		connect.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+
+	conn.SetDeadline(time.Now().Add(c.httpClient.Timeout))
+	if err := connect.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	res, err := http.ReadResponse(bufio.NewReader(conn), connect)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("Error while connecting through proxy `%s`: %s", redact.String(proxyURL.String()), res.Status)
+	}
+	conn.SetDeadline(time.Time{})
+
+	return tlsEvents(conn, config)
+}
+
+// tlsEvents wraps conn with TLS when the events stream is served over TLS.
+func tlsEvents(conn net.Conn, config *websocket.Config) (net.Conn, error) {
+	if config.Location.Scheme != "wss" {
+		return conn, nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if config.TlsConfig != nil {
+		tlsConfig = config.TlsConfig.Clone()
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = config.Location.Hostname()
+	}
+
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return tlsConn, nil
+}
+
+func hostPort(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+
+	switch u.Scheme {
+	case "https", "wss":
+		return net.JoinHostPort(u.Hostname(), "443")
+	default:
+		return net.JoinHostPort(u.Hostname(), "80")
+	}
+}
+
+// eventsConn is the connection of an events stream. Its read deadline is
+// extended before every read, so that a stream silently dropped by the
+// network fails instead of blocking forever. It also keeps the first bytes
+// read, as the websocket package does not report the status of a rejected
+// handshake.
+type eventsConn struct {
+	net.Conn
+	readTimeout time.Duration
+	head        []byte
+}
+
+func (c *eventsConn) Read(b []byte) (int, error) {
+	if c.readTimeout > 0 {
+		if err := c.Conn.SetReadDeadline(time.Now().Add(c.readTimeout)); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := c.Conn.Read(b)
+	if len(c.head) < 64 {
+		c.head = append(c.head, b[:n]...)
+	}
+	return n, err
+}
+
+// statusError returns the status of the handshake response as a StatusError,
+// or nil when it was not read.
+func (c *eventsConn) statusError() *StatusError {
+	line := c.head
+	if i := bytes.IndexByte(line, '\r'); i >= 0 {
+		line = line[:i]
+	}
+
+	fields := strings.SplitN(string(line), " ", 2)
+	if len(fields) != 2 || !strings.HasPrefix(fields[0], "HTTP/") {
+		return nil
+	}
+	statusCode, err := strconv.Atoi(strings.SplitN(fields[1], " ", 2)[0])
+	if err != nil {
+		return nil
+	}
+
+	return &StatusError{StatusCode: statusCode, Status: fields[1]}
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// responseCache keeps the last decoded response of every endpoint, so
+// unchanged listings answered with a `304 Not Modified` are neither
+// transferred nor parsed again, and listings can still be served while the
+// Shield backend is in maintenance mode.
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+}
+
+type cachedResponse struct {
+	etag         string
+	lastModified string
+	value        reflect.Value
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{entries: map[string]cachedResponse{}}
+}
+
+func (rc *responseCache) addConditionalHeaders(path string, req *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[path]
+	if !ok {
+		return
+	}
+
+	if entry.etag != "" {
+		req.Header.Set("If-None-Match", entry.etag)
+	}
+	if entry.lastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.lastModified)
+	}
+}
+
+func (rc *responseCache) load(path string, out interface{}) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[path]
+	if !ok {
+		return false
+	}
+
+	outValue := reflect.ValueOf(out)
+	if outValue.Kind() != reflect.Ptr || outValue.Elem().Type() != entry.value.Type() {
+		return false
+	}
+	outValue.Elem().Set(entry.value)
+
+	return true
+}
+
+func (rc *responseCache) store(path string, res *http.Response, out interface{}) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	etag := res.Header.Get("ETag")
+	lastModified := res.Header.Get("Last-Modified")
+	outValue := reflect.ValueOf(out)
+	if outValue.Kind() != reflect.Ptr {
+		delete(rc.entries, path)
+		return
+	}
+
+	value := reflect.New(outValue.Elem().Type()).Elem()
+	value.Set(outValue.Elem())
+
+	rc.entries[path] = cachedResponse{
+		etag:         etag,
+		lastModified: lastModified,
+		value:        value,
+	}
+}
@@ -0,0 +1,19 @@
+package client
+
+// Semaphore bounds the number of simultaneous requests sent to Shield. A
+// single Semaphore can be shared by several clients to enforce a global limit.
+type Semaphore struct {
+	slots chan struct{}
+}
+
+func NewSemaphore(size int) *Semaphore {
+	return &Semaphore{slots: make(chan struct{}, size)}
+}
+
+func (s *Semaphore) Acquire() {
+	s.slots <- struct{}{}
+}
+
+func (s *Semaphore) Release() {
+	<-s.slots
+}
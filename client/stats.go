@@ -0,0 +1,122 @@
+package client
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Stats holds counters about the requests sent by a Client to its Shield
+// backend.
+type Stats struct {
+	Requests    int64      `json:"requests"`
+	Errors      int64      `json:"errors"`
+	CacheHits   int64      `json:"cache_hits"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+
+	// Maintenance is whether the Shield backend answered the last request
+	// with a `503 Service Unavailable`, ie while it is in maintenance mode.
+	Maintenance bool `json:"maintenance"`
+
+	// TLSCertNotAfter is the expiry of the certificate last presented by
+	// the Shield backend, nil when it is not reached over TLS.
+	TLSCertNotAfter *time.Time `json:"tls_cert_not_after,omitempty"`
+
+	// ResponseBytes is the size of the last decoded response body of every
+	// endpoint, keyed by request path.
+	ResponseBytes map[string]int64 `json:"response_bytes,omitempty"`
+
+	// APIRequests is the number of responses received from every endpoint,
+	// keyed by request path and then by status code.
+	APIRequests map[string]map[string]int64 `json:"api_requests,omitempty"`
+}
+
+type statsRecorder struct {
+	mu    sync.Mutex
+	stats Stats
+}
+
+func (s *statsRecorder) recordRequest(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.Requests++
+	if err != nil {
+		now := time.Now()
+		s.stats.Errors++
+		s.stats.LastError = err.Error()
+		s.stats.LastErrorAt = &now
+	}
+}
+
+func (s *statsRecorder) recordCacheHit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.CacheHits++
+}
+
+// recordMaintenance records whether the Shield backend is in maintenance mode,
+// returning whether it entered or left it.
+func (s *statsRecorder) recordMaintenance(maintenance bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := s.stats.Maintenance != maintenance
+	s.stats.Maintenance = maintenance
+	return changed
+}
+
+func (s *statsRecorder) recordTLSCertificate(notAfter time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.TLSCertNotAfter = &notAfter
+}
+
+func (s *statsRecorder) recordResponseBytes(endpoint string, bytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stats.ResponseBytes == nil {
+		s.stats.ResponseBytes = make(map[string]int64)
+	}
+	s.stats.ResponseBytes[endpoint] = bytes
+}
+
+func (s *statsRecorder) recordAPIRequest(endpoint string, statusCode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stats.APIRequests == nil {
+		s.stats.APIRequests = make(map[string]map[string]int64)
+	}
+	if s.stats.APIRequests[endpoint] == nil {
+		s.stats.APIRequests[endpoint] = make(map[string]int64)
+	}
+	s.stats.APIRequests[endpoint][strconv.Itoa(statusCode)]++
+}
+
+func (s *statsRecorder) snapshot() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	if s.stats.ResponseBytes != nil {
+		stats.ResponseBytes = make(map[string]int64, len(s.stats.ResponseBytes))
+		for endpoint, bytes := range s.stats.ResponseBytes {
+			stats.ResponseBytes[endpoint] = bytes
+		}
+	}
+	if s.stats.APIRequests != nil {
+		stats.APIRequests = make(map[string]map[string]int64, len(s.stats.APIRequests))
+		for endpoint, codes := range s.stats.APIRequests {
+			stats.APIRequests[endpoint] = make(map[string]int64, len(codes))
+			for code, requests := range codes {
+				stats.APIRequests[endpoint][code] = requests
+			}
+		}
+	}
+	return stats
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Tenant is a Shield v8 tenant, with its storage accounting.
+type Tenant struct {
+	UUID          string `json:"uuid"`
+	Name          string `json:"name"`
+	StorageUsed   int64  `json:"storage_used"`
+	ArchiveCount  int64  `json:"archive_count"`
+	DailyIncrease int64  `json:"daily_increase"`
+
+	Members []TenantMember `json:"members,omitempty"`
+}
+
+// TenantMember is a Shield v8 user granted a role on a tenant.
+type TenantMember struct {
+	UUID    string `json:"uuid"`
+	Name    string `json:"name"`
+	Account string `json:"account"`
+	Backend string `json:"backend"`
+	Role    string `json:"role"`
+}
+
+// tenantPaths maps the v1 API listings to their tenant scoped v2 API
+// resource. Other paths are not scoped to a tenant.
+var tenantPaths = map[string]string{
+	"/v1/archives":  "archives",
+	"/v1/jobs":      "jobs",
+	"/v1/retention": "policies",
+	"/v1/stores":    "stores",
+	"/v1/targets":   "targets",
+	"/v1/tasks":     "tasks",
+}
+
+// tenantScope resolves, once, the UUID of a tenant given by name or UUID.
+type tenantScope struct {
+	tenant string
+
+	mu   sync.Mutex
+	uuid string
+}
+
+func (s *tenantScope) path(c *Client, path string) (string, error) {
+	resource, ok := tenantPaths[path]
+	if !ok {
+		return path, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.uuid == "" {
+		var tenants []Tenant
+		if err := c.get("/v2/tenants", &tenants); err != nil {
+			return "", fmt.Errorf("Error while resolving Shield tenant `%s`: %v", s.tenant, err)
+		}
+		for _, tenant := range tenants {
+			if tenant.UUID == s.tenant || tenant.Name == s.tenant {
+				s.uuid = tenant.UUID
+				break
+			}
+		}
+		if s.uuid == "" {
+			return "", fmt.Errorf("Shield tenant `%s` not found", s.tenant)
+		}
+	}
+
+	return strings.Join([]string{"/v2/tenants", s.uuid, resource}, "/"), nil
+}
+
+// GetTenants returns the tenants visible to the Shield user.
+func (c *Client) GetTenants() ([]Tenant, error) {
+	var tenants []Tenant
+	return tenants, c.Get("/v2/tenants", &tenants)
+}
+
+// GetTenant returns the tenant with the given UUID, along with its members,
+// which are not part of the tenants listing.
+func (c *Client) GetTenant(uuid string) (Tenant, error) {
+	var tenant Tenant
+	return tenant, c.Get("/v2/tenants/"+uuid, &tenant)
+}
+
+// WithTenant returns a Client sharing the connections, credentials and
+// stats of c, but whose archives, jobs, retention policies, stores, targets
+// and tasks listings are scoped to the given tenant name or UUID.
+func (c *Client) WithTenant(tenant string) *Client {
+	tenantClient := *c
+	tenantClient.cache = newResponseCache()
+	tenantClient.tenant = &tenantScope{tenant: tenant}
+
+	return &tenantClient
+}
+
+// Tenant returns the tenant name or UUID the listings of c are scoped to,
+// or an empty string if they are not scoped to a tenant.
+func (c *Client) Tenant() string {
+	if c.tenant == nil {
+		return ""
+	}
+	return c.tenant.tenant
+}
@@ -0,0 +1,139 @@
+package client_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/starkandwayne/shield/api"
+
+	. "github.com/bosh-prometheus/shield_exporter/client"
+)
+
+var _ = Describe("Tenants", func() {
+	var (
+		server       *ghttp.Server
+		shieldClient *Client
+		tenantClient *Client
+
+		tenants = []Tenant{
+			{UUID: "tenant-uuid-1", Name: "tenant-1"},
+			{UUID: "tenant-uuid-2", Name: "tenant-2"},
+		}
+	)
+
+	BeforeEach(func() {
+		var err error
+		server = ghttp.NewServer()
+		shieldClient, err = NewClient(Config{
+			BackendURL: server.URL(),
+			Username:   "fake_username",
+			Password:   "fake_password",
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("GetTenants", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/tenants"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, tenants),
+				),
+			)
+		})
+
+		It("returns the tenants", func() {
+			Expect(shieldClient.GetTenants()).To(Equal(tenants))
+		})
+	})
+
+	Describe("GetTenant", func() {
+		var tenant = Tenant{
+			UUID: "tenant-uuid-1",
+			Name: "tenant-1",
+			Members: []TenantMember{
+				{UUID: "user-uuid-1", Name: "user-1", Account: "user-1", Role: "admin"},
+			},
+		}
+
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/tenants/tenant-uuid-1"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, tenant),
+				),
+			)
+		})
+
+		It("returns the tenant with its members", func() {
+			Expect(shieldClient.GetTenant("tenant-uuid-1")).To(Equal(tenant))
+		})
+	})
+
+	Describe("WithTenant", func() {
+		Context("when the tenant is given by name", func() {
+			BeforeEach(func() {
+				tenantClient = shieldClient.WithTenant("tenant-2")
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v2/tenants"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, tenants),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v2/tenants/tenant-uuid-2/jobs"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, []api.Job{{Name: "fake_job"}}),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v2/tenants/tenant-uuid-2/policies"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, []api.RetentionPolicy{}),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v1/status"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, api.Status{Name: "fake_name"}),
+					),
+				)
+			})
+
+			It("scopes the listings to the tenant, resolving it once", func() {
+				jobs, err := tenantClient.GetJobs()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(jobs).To(HaveLen(1))
+
+				_, err = tenantClient.GetRetentionPolicies()
+				Expect(err).ToNot(HaveOccurred())
+
+				status, err := tenantClient.GetStatus()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(status.Name).To(Equal("fake_name"))
+			})
+
+			It("shares the stats with the client", func() {
+				_, err := tenantClient.GetJobs()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(shieldClient.Stats().Requests).To(Equal(int64(1)))
+			})
+		})
+
+		Context("when the tenant does not exist", func() {
+			BeforeEach(func() {
+				tenantClient = shieldClient.WithTenant("unknown")
+				server.AppendHandlers(
+					ghttp.RespondWithJSONEncoded(http.StatusOK, tenants),
+				)
+			})
+
+			It("returns an error", func() {
+				_, err := tenantClient.GetJobs()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Shield tenant `unknown` not found"))
+			})
+		})
+	})
+})
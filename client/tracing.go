@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/bosh-prometheus/shield_exporter/client"
+
+// WithContext returns a Client sharing the connections, credentials, cache
+// and stats of c, but sending its requests within ctx: they are canceled
+// with ctx, and traced as spans child of the span of ctx, if any.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	contextClient := *c
+	contextClient.ctx = ctx
+
+	return &contextClient
+}
+
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+
+	return c.ctx
+}
+
+// startSpan starts the span of a call to the Shield API path, and returns
+// the function ending it with the outcome of the call. Without a span in the
+// context of the client, the span is not recorded.
+func (c *Client) startSpan(path string) func(err error) {
+	ctx := c.context()
+	_, span := trace.SpanFromContext(ctx).TracerProvider().Tracer(instrumentationName).Start(
+		ctx,
+		"GET "+path,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", "GET"),
+			attribute.String("url.path", path),
+		),
+	)
+	if c.tenant != nil {
+		span.SetAttributes(attribute.String("shield.tenant", c.tenant.tenant))
+	}
+
+	return func(err error) {
+		if statusErr, ok := err.(*StatusError); ok {
+			span.SetAttributes(attribute.Int("http.response.status_code", statusErr.StatusCode))
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,165 @@
+package collectors
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+	"github.com/bosh-prometheus/shield_exporter/filters"
+)
+
+type AgentsCollector struct {
+	namespace                           string
+	environment                         string
+	backendName                         string
+	shieldClient                        *client.Client
+	agentInfoDesc                       *prometheus.Desc
+	agentLastSeenTimestampDesc          *prometheus.Desc
+	collectorAvailableDesc              *prometheus.Desc
+	agentsScrapesTotalMetric            prometheus.Counter
+	agentsScrapeErrorsTotalMetric       prometheus.Counter
+	scrapeErrorsByReasonTotalMetric     *prometheus.CounterVec
+	lastAgentsScrapeErrorDesc           *prometheus.Desc
+	lastAgentsScrapeTimestampDesc       *prometheus.Desc
+	lastAgentsScrapeDurationSecondsDesc *prometheus.Desc
+}
+
+func NewAgentsCollector(
+	namespace string,
+	environment string,
+	backendName string,
+	shieldClient *client.Client,
+) *AgentsCollector {
+	agentInfoDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "agent", "info"),
+		"Labeled Shield Agent information with a constant '1' value.",
+		[]string{"agent_name", "address", "version", "status"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	agentLastSeenTimestampDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "agent", "last_seen_timestamp"),
+		"Number of seconds since 1970 since a Shield Agent was last seen by the Shield core.",
+		[]string{"agent_name", "address"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	collectorAvailableDesc := newCollectorAvailableDesc(namespace, environment, backendName, filters.AgentsCollector)
+	scrapeErrorsByReasonTotalMetric := newScrapeErrorsByReasonTotalMetric(namespace, environment, backendName, filters.AgentsCollector)
+
+	agentsScrapesTotalMetric := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "agents",
+			Name:        "scrapes_total",
+			Help:        "Total number of scrapes for Shield Agents.",
+			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+		},
+	)
+
+	agentsScrapeErrorsTotalMetric := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "agents",
+			Name:        "scrape_errors_total",
+			Help:        "Total number of scrape errors of Shield Agents.",
+			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+		},
+	)
+
+	lastAgentsScrapeErrorDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_agents_scrape_error"),
+		"Whether the last scrape of Agent metrics from Shield resulted in an error (1 for error, 0 for success).",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	lastAgentsScrapeTimestampDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_agents_scrape_timestamp"),
+		"Number of seconds since 1970 since last scrape of Agent metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	lastAgentsScrapeDurationSecondsDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_agents_scrape_duration_seconds"),
+		"Duration of the last scrape of Agent metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	return &AgentsCollector{
+		namespace:                           namespace,
+		environment:                         environment,
+		backendName:                         backendName,
+		shieldClient:                        shieldClient,
+		agentInfoDesc:                       agentInfoDesc,
+		agentLastSeenTimestampDesc:          agentLastSeenTimestampDesc,
+		collectorAvailableDesc:              collectorAvailableDesc,
+		agentsScrapesTotalMetric:            agentsScrapesTotalMetric,
+		agentsScrapeErrorsTotalMetric:       agentsScrapeErrorsTotalMetric,
+		scrapeErrorsByReasonTotalMetric:     scrapeErrorsByReasonTotalMetric,
+		lastAgentsScrapeErrorDesc:           lastAgentsScrapeErrorDesc,
+		lastAgentsScrapeTimestampDesc:       lastAgentsScrapeTimestampDesc,
+		lastAgentsScrapeDurationSecondsDesc: lastAgentsScrapeDurationSecondsDesc,
+	}
+}
+
+func (c AgentsCollector) Collect(ch chan<- prometheus.Metric) {
+	var begun = time.Now()
+
+	errorMetric := float64(0)
+	err := c.reportAgentsMetrics(ch)
+	if isScrapeError(err) {
+		errorMetric = float64(1)
+		c.agentsScrapeErrorsTotalMetric.Inc()
+		c.scrapeErrorsByReasonTotalMetric.WithLabelValues(client.ErrorReason(err)).Inc()
+	}
+	c.agentsScrapeErrorsTotalMetric.Collect(ch)
+	c.scrapeErrorsByReasonTotalMetric.Collect(ch)
+
+	c.agentsScrapesTotalMetric.Inc()
+	c.agentsScrapesTotalMetric.Collect(ch)
+
+	ch <- prometheus.MustNewConstMetric(c.collectorAvailableDesc, prometheus.GaugeValue, availability(err))
+	ch <- prometheus.MustNewConstMetric(c.lastAgentsScrapeErrorDesc, prometheus.GaugeValue, errorMetric)
+	ch <- prometheus.MustNewConstMetric(c.lastAgentsScrapeTimestampDesc, prometheus.GaugeValue, float64(time.Now().Unix()))
+	ch <- prometheus.MustNewConstMetric(c.lastAgentsScrapeDurationSecondsDesc, prometheus.GaugeValue, time.Since(begun).Seconds())
+}
+
+func (c AgentsCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	c.shieldClient = c.shieldClient.WithContext(ctx)
+	c.Collect(ch)
+}
+
+func (c AgentsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.agentInfoDesc
+	ch <- c.agentLastSeenTimestampDesc
+	ch <- c.collectorAvailableDesc
+	c.agentsScrapesTotalMetric.Describe(ch)
+	c.agentsScrapeErrorsTotalMetric.Describe(ch)
+	c.scrapeErrorsByReasonTotalMetric.Describe(ch)
+	ch <- c.lastAgentsScrapeErrorDesc
+	ch <- c.lastAgentsScrapeTimestampDesc
+	ch <- c.lastAgentsScrapeDurationSecondsDesc
+}
+
+func (c AgentsCollector) reportAgentsMetrics(ch chan<- prometheus.Metric) error {
+	agents, err := c.shieldClient.GetAgents()
+	if err != nil {
+		logError(err, "Error while listing agents: %v", err)
+		return err
+	}
+
+	for _, agent := range agents {
+		ch <- prometheus.MustNewConstMetric(c.agentInfoDesc, prometheus.GaugeValue, 1, agent.Name, agent.Address, agent.Version, agent.Status)
+
+		if agent.LastSeenAt > 0 {
+			ch <- prometheus.MustNewConstMetric(c.agentLastSeenTimestampDesc, prometheus.GaugeValue, float64(agent.LastSeenAt), agent.Name, agent.Address)
+		}
+	}
+
+	return nil
+}
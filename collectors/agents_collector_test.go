@@ -0,0 +1,248 @@
+package collectors_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+	. "github.com/bosh-prometheus/shield_exporter/collectors"
+	. "github.com/bosh-prometheus/shield_exporter/utils/test_matchers"
+)
+
+var _ = Describe("AgentsCollector", func() {
+	var (
+		err    error
+		server *ghttp.Server
+
+		shieldClient *client.Client
+
+		namespace   = "test_namespace"
+		environment = "test_environment"
+		backendName = "test_backend"
+
+		username = "fake_username"
+		password = "fake_password"
+
+		agentName1    = "agent_1"
+		agentAddress1 = "10.0.0.1:5444"
+		agentName2    = "agent_2"
+		agentAddress2 = "10.0.0.2:5444"
+
+		agentInfoMetric                *prometheus.GaugeVec
+		agentLastSeenTimestampMetric   *prometheus.GaugeVec
+		agentsScrapesTotalMetric       prometheus.Counter
+		agentsScrapeErrorsTotalMetric  prometheus.Counter
+		lastAgentsScrapeErrorMetric    prometheus.Gauge
+		lastAgentsScrapeDurationMetric prometheus.Gauge
+
+		agentsCollector *AgentsCollector
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		shieldClient, err = client.NewClient(client.Config{
+			BackendURL: server.URL(),
+			Username:   username,
+			Password:   password,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		agentInfoMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "agent",
+				Name:        "info",
+				Help:        "Labeled Shield Agent information with a constant '1' value.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"agent_name", "address", "version", "status"},
+		)
+		agentInfoMetric.WithLabelValues(agentName1, agentAddress1, "8.7.2", "ok").Set(1)
+		agentInfoMetric.WithLabelValues(agentName2, agentAddress2, "8.1.0", "failing").Set(1)
+
+		agentLastSeenTimestampMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "agent",
+				Name:        "last_seen_timestamp",
+				Help:        "Number of seconds since 1970 since a Shield Agent was last seen by the Shield core.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"agent_name", "address"},
+		)
+		agentLastSeenTimestampMetric.WithLabelValues(agentName1, agentAddress1).Set(1500000000)
+
+		agentsScrapesTotalMetric = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace:   namespace,
+				Subsystem:   "agents",
+				Name:        "scrapes_total",
+				Help:        "Total number of scrapes for Shield Agents.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+		agentsScrapesTotalMetric.Inc()
+
+		agentsScrapeErrorsTotalMetric = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace:   namespace,
+				Subsystem:   "agents",
+				Name:        "scrape_errors_total",
+				Help:        "Total number of scrape errors of Shield Agents.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+
+		lastAgentsScrapeErrorMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "",
+				Name:        "last_agents_scrape_error",
+				Help:        "Whether the last scrape of Agent metrics from Shield resulted in an error (1 for error, 0 for success).",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+
+		lastAgentsScrapeDurationMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "",
+				Name:        "last_agents_scrape_duration_seconds",
+				Help:        "Duration of the last scrape of Agent metrics from Shield.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+	})
+
+	JustBeforeEach(func() {
+		agentsCollector = NewAgentsCollector(namespace, environment, backendName, shieldClient)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Describe", func() {
+		var (
+			descriptions chan *prometheus.Desc
+		)
+
+		BeforeEach(func() {
+			descriptions = make(chan *prometheus.Desc)
+		})
+
+		JustBeforeEach(func() {
+			go agentsCollector.Describe(descriptions)
+		})
+
+		It("returns a agent_info metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(agentInfoMetric.WithLabelValues(agentName1, agentAddress1, "8.7.2", "ok").Desc())))
+		})
+
+		It("returns a agent_last_seen_timestamp metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(agentLastSeenTimestampMetric.WithLabelValues(agentName1, agentAddress1).Desc())))
+		})
+
+		It("returns a agents_scrapes_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(agentsScrapesTotalMetric.Desc())))
+		})
+
+		It("returns a agents_scrape_errors_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(agentsScrapeErrorsTotalMetric.Desc())))
+		})
+
+		It("returns a last_agents_scrape_error metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(lastAgentsScrapeErrorMetric.Desc())))
+		})
+
+		It("returns a last_agents_scrape_duration_seconds metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(lastAgentsScrapeDurationMetric.Desc())))
+		})
+	})
+
+	Describe("Collect", func() {
+		var (
+			statusCode     int
+			agentsResponse map[string][]client.Agent
+			metrics        chan prometheus.Metric
+		)
+
+		BeforeEach(func() {
+			statusCode = http.StatusOK
+			agentsResponse = map[string][]client.Agent{
+				"agents": []client.Agent{
+					client.Agent{
+						Name:       agentName1,
+						Address:    agentAddress1,
+						Version:    "8.7.2",
+						Status:     "ok",
+						LastSeenAt: 1500000000,
+					},
+					client.Agent{
+						Name:    agentName2,
+						Address: agentAddress2,
+						Version: "8.1.0",
+						Status:  "failing",
+					},
+				},
+			}
+			metrics = make(chan prometheus.Metric)
+		})
+
+		JustBeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/agents"),
+					ghttp.VerifyBasicAuth(username, password),
+					ghttp.RespondWithJSONEncodedPtr(&statusCode, &agentsResponse),
+				),
+			)
+			go agentsCollector.Collect(metrics)
+		})
+
+		It("returns a agent_info metric for agent 1", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(agentInfoMetric.WithLabelValues(agentName1, agentAddress1, "8.7.2", "ok"))))
+		})
+
+		It("returns a agent_info metric for agent 2", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(agentInfoMetric.WithLabelValues(agentName2, agentAddress2, "8.1.0", "failing"))))
+		})
+
+		It("returns a agent_last_seen_timestamp metric for agent 1", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(agentLastSeenTimestampMetric.WithLabelValues(agentName1, agentAddress1))))
+		})
+
+		It("does not return a agent_last_seen_timestamp metric for an agent never seen", func() {
+			Consistently(metrics).ShouldNot(Receive(PrometheusMetric(agentLastSeenTimestampMetric.WithLabelValues(agentName2, agentAddress2))))
+		})
+
+		It("returns a agents_scrapes_total metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(agentsScrapesTotalMetric)))
+		})
+
+		It("returns a last_agents_scrape_error metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(lastAgentsScrapeErrorMetric)))
+		})
+
+		Context("when it fails to list the agents", func() {
+			BeforeEach(func() {
+				statusCode = http.StatusInternalServerError
+				agentsScrapeErrorsTotalMetric.Inc()
+				lastAgentsScrapeErrorMetric.Set(1)
+			})
+
+			It("returns a agents_scrape_errors_total metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(agentsScrapeErrorsTotalMetric)))
+			})
+
+			It("returns a last_agents_scrape_error metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(lastAgentsScrapeErrorMetric)))
+			})
+		})
+	})
+})
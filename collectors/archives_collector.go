@@ -1,41 +1,81 @@
 package collectors
 
 import (
+	"context"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/common/log"
-	"github.com/starkandwayne/shield/api"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+	"github.com/bosh-prometheus/shield_exporter/filters"
 )
 
+type archiveLabels struct {
+	status       string
+	storePlugin  string
+	targetPlugin string
+	encryption   string
+	compression  string
+}
+
 type ArchivesCollector struct {
-	namespace                               string
-	environment                             string
-	backendName                             string
-	archivesTotalMetric                     *prometheus.GaugeVec
-	archivesScrapesTotalMetric              prometheus.Counter
-	archivesScrapeErrorsTotalMetric         prometheus.Counter
-	lastArchivesScrapeErrorMetric           prometheus.Gauge
-	lastArchivesScrapeTimestampMetric       prometheus.Gauge
-	lastArchivesScrapeDurationSecondsMetric prometheus.Gauge
+	namespace                             string
+	environment                           string
+	backendName                           string
+	shieldClient                          *client.Client
+	archivesTotalDesc                     *prometheus.Desc
+	archivesExpiringTotalDesc             *prometheus.Desc
+	archivesOrphanedTotalDesc             *prometheus.Desc
+	targetLastArchiveTimestampDesc        *prometheus.Desc
+	expiringWindows                       map[string]time.Duration
+	collectorAvailableDesc                *prometheus.Desc
+	archivesScrapesTotalMetric            prometheus.Counter
+	archivesScrapeErrorsTotalMetric       prometheus.Counter
+	scrapeErrorsByReasonTotalMetric       *prometheus.CounterVec
+	lastArchivesScrapeErrorDesc           *prometheus.Desc
+	lastArchivesScrapeTimestampDesc       *prometheus.Desc
+	lastArchivesScrapeDurationSecondsDesc *prometheus.Desc
+	maintenanceMetrics                    *maintenanceMetrics
 }
 
 func NewArchivesCollector(
 	namespace string,
 	environment string,
 	backendName string,
+	shieldClient *client.Client,
+	expiringWindows map[string]time.Duration,
 ) *ArchivesCollector {
-	archivesTotalMetric := prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "archives",
-			Name:        "total",
-			Help:        "Labeled total number of Shield Archives.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
-		[]string{"archive_status", "store_plugin", "target_plugin"},
+	archivesTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "archives", "total"),
+		"Labeled total number of Shield Archives.",
+		[]string{"archive_status", "store_plugin", "target_plugin", "encryption", "compression"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	archivesExpiringTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "archives", "expiring_total"),
+		"Total number of valid Shield Archives expiring within the window.",
+		[]string{"window"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	archivesOrphanedTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "archives", "orphaned_total"),
+		"Total number of valid Shield Archives whose Shield Target or Shield Store no longer exists.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
+	targetLastArchiveTimestampDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "target", "last_archive_timestamp"),
+		"Number of seconds since 1970 since the last Shield Archive of a Shield Target was taken.",
+		[]string{"target_name"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	collectorAvailableDesc := newCollectorAvailableDesc(namespace, environment, backendName, filters.ArchivesCollector)
+	scrapeErrorsByReasonTotalMetric := newScrapeErrorsByReasonTotalMetric(namespace, environment, backendName, filters.ArchivesCollector)
+
 	archivesScrapesTotalMetric := prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Namespace:   namespace,
@@ -56,46 +96,45 @@ func NewArchivesCollector(
 		},
 	)
 
-	lastArchivesScrapeErrorMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_archives_scrape_error",
-			Help:        "Whether the last scrape of Archive metrics from Shield resulted in an error (1 for error, 0 for success).",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastArchivesScrapeErrorDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_archives_scrape_error"),
+		"Whether the last scrape of Archive metrics from Shield resulted in an error (1 for error, 0 for success).",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	lastArchivesScrapeTimestampMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_archives_scrape_timestamp",
-			Help:        "Number of seconds since 1970 since last scrape of Archive metrics from Shield.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastArchivesScrapeTimestampDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_archives_scrape_timestamp"),
+		"Number of seconds since 1970 since last scrape of Archive metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	lastArchivesScrapeDurationSecondsMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_archives_scrape_duration_seconds",
-			Help:        "Duration of the last scrape of Archive metrics from Shield.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastArchivesScrapeDurationSecondsDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_archives_scrape_duration_seconds"),
+		"Duration of the last scrape of Archive metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
 	return &ArchivesCollector{
-		namespace:                               namespace,
-		environment:                             environment,
-		backendName:                             backendName,
-		archivesTotalMetric:                     archivesTotalMetric,
-		archivesScrapesTotalMetric:              archivesScrapesTotalMetric,
-		archivesScrapeErrorsTotalMetric:         archivesScrapeErrorsTotalMetric,
-		lastArchivesScrapeErrorMetric:           lastArchivesScrapeErrorMetric,
-		lastArchivesScrapeTimestampMetric:       lastArchivesScrapeTimestampMetric,
-		lastArchivesScrapeDurationSecondsMetric: lastArchivesScrapeDurationSecondsMetric,
+		namespace:                             namespace,
+		environment:                           environment,
+		backendName:                           backendName,
+		shieldClient:                          shieldClient,
+		archivesTotalDesc:                     archivesTotalDesc,
+		archivesExpiringTotalDesc:             archivesExpiringTotalDesc,
+		archivesOrphanedTotalDesc:             archivesOrphanedTotalDesc,
+		targetLastArchiveTimestampDesc:        targetLastArchiveTimestampDesc,
+		expiringWindows:                       expiringWindows,
+		collectorAvailableDesc:                collectorAvailableDesc,
+		archivesScrapesTotalMetric:            archivesScrapesTotalMetric,
+		archivesScrapeErrorsTotalMetric:       archivesScrapeErrorsTotalMetric,
+		scrapeErrorsByReasonTotalMetric:       scrapeErrorsByReasonTotalMetric,
+		lastArchivesScrapeErrorDesc:           lastArchivesScrapeErrorDesc,
+		lastArchivesScrapeTimestampDesc:       lastArchivesScrapeTimestampDesc,
+		lastArchivesScrapeDurationSecondsDesc: lastArchivesScrapeDurationSecondsDesc,
+		maintenanceMetrics:                    &maintenanceMetrics{},
 	}
 }
 
@@ -103,48 +142,134 @@ func (c ArchivesCollector) Collect(ch chan<- prometheus.Metric) {
 	var begun = time.Now()
 
 	errorMetric := float64(0)
-	if err := c.reportTargetsMetrics(ch); err != nil {
+	err := c.reportTargetsMetrics(ch)
+	if isScrapeError(err) {
 		errorMetric = float64(1)
 		c.archivesScrapeErrorsTotalMetric.Inc()
+		c.scrapeErrorsByReasonTotalMetric.WithLabelValues(client.ErrorReason(err)).Inc()
 	}
 	c.archivesScrapeErrorsTotalMetric.Collect(ch)
+	c.scrapeErrorsByReasonTotalMetric.Collect(ch)
 
 	c.archivesScrapesTotalMetric.Inc()
 	c.archivesScrapesTotalMetric.Collect(ch)
 
-	c.lastArchivesScrapeErrorMetric.Set(errorMetric)
-	c.lastArchivesScrapeErrorMetric.Collect(ch)
-
-	c.lastArchivesScrapeTimestampMetric.Set(float64(time.Now().Unix()))
-	c.lastArchivesScrapeTimestampMetric.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(c.collectorAvailableDesc, prometheus.GaugeValue, availability(err))
+	ch <- prometheus.MustNewConstMetric(c.lastArchivesScrapeErrorDesc, prometheus.GaugeValue, errorMetric)
+	ch <- prometheus.MustNewConstMetric(c.lastArchivesScrapeTimestampDesc, prometheus.GaugeValue, float64(time.Now().Unix()))
+	ch <- prometheus.MustNewConstMetric(c.lastArchivesScrapeDurationSecondsDesc, prometheus.GaugeValue, time.Since(begun).Seconds())
+}
 
-	c.lastArchivesScrapeDurationSecondsMetric.Set(time.Since(begun).Seconds())
-	c.lastArchivesScrapeDurationSecondsMetric.Collect(ch)
+func (c ArchivesCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	c.shieldClient = c.shieldClient.WithContext(ctx)
+	c.Collect(ch)
 }
 
 func (c ArchivesCollector) Describe(ch chan<- *prometheus.Desc) {
-	c.archivesTotalMetric.Describe(ch)
+	ch <- c.archivesTotalDesc
+	ch <- c.archivesExpiringTotalDesc
+	ch <- c.archivesOrphanedTotalDesc
+	ch <- c.targetLastArchiveTimestampDesc
+	ch <- c.collectorAvailableDesc
 	c.archivesScrapesTotalMetric.Describe(ch)
 	c.archivesScrapeErrorsTotalMetric.Describe(ch)
-	c.lastArchivesScrapeErrorMetric.Describe(ch)
-	c.lastArchivesScrapeTimestampMetric.Describe(ch)
-	c.lastArchivesScrapeDurationSecondsMetric.Describe(ch)
+	c.scrapeErrorsByReasonTotalMetric.Describe(ch)
+	ch <- c.lastArchivesScrapeErrorDesc
+	ch <- c.lastArchivesScrapeTimestampDesc
+	ch <- c.lastArchivesScrapeDurationSecondsDesc
 }
 
 func (c ArchivesCollector) reportTargetsMetrics(ch chan<- prometheus.Metric) error {
-	c.archivesTotalMetric.Reset()
+	metrics, err := c.targetsMetrics()
+	c.maintenanceMetrics.report(ch, metrics, err)
+	return err
+}
+
+func (c ArchivesCollector) targetsMetrics() ([]prometheus.Metric, error) {
+	now := time.Now()
+	archivesExpiringTotal := make(map[string]float64)
+	for window := range c.expiringWindows {
+		archivesExpiringTotal[window] = 0
+	}
 
-	archives, err := api.GetArchives(api.ArchiveFilter{})
+	targets, err := c.shieldClient.GetTargets()
 	if err != nil {
-		log.Errorf("Error while listing archives: %v", err)
-		return err
+		logError(err, "Error while listing targets: %v", err)
+		return nil, err
+	}
+	targetNames := make(map[string]string)
+	for _, target := range targets {
+		targetNames[target.UUID] = target.Name
 	}
 
-	for _, archive := range archives {
-		c.archivesTotalMetric.WithLabelValues(archive.Status, archive.StorePlugin, archive.TargetPlugin).Inc()
+	stores, err := c.shieldClient.GetStores()
+	if err != nil {
+		logError(err, "Error while listing stores: %v", err)
+		return nil, err
+	}
+	storeUUIDs := make(map[string]bool)
+	for _, store := range stores {
+		storeUUIDs[store.UUID] = true
 	}
 
-	c.archivesTotalMetric.Collect(ch)
+	archivesOrphanedTotal := float64(0)
+	archivesTotal := make(map[archiveLabels]float64)
+	targetsLastArchive := make(map[string]int64)
+	err = c.shieldClient.ForEachArchive(func(archive client.Archive) {
+		targetName, targetExists := targetNames[archive.TargetUUID]
+		if archive.Status == ValidArchiveStatus && (!targetExists || !storeUUIDs[archive.StoreUUID]) {
+			archivesOrphanedTotal++
+		}
+
+		if targetExists && !archive.TakenAt.IsZero() && archive.TakenAt.Time().Unix() > targetsLastArchive[targetName] {
+			targetsLastArchive[targetName] = archive.TakenAt.Time().Unix()
+		}
+
+		if archive.Status == ValidArchiveStatus && !archive.ExpiresAt.IsZero() {
+			expiresIn := archive.ExpiresAt.Time().Sub(now)
+			for window, duration := range c.expiringWindows {
+				if expiresIn >= 0 && expiresIn <= duration {
+					archivesExpiringTotal[window]++
+				}
+			}
+		}
+
+		archivesTotal[archiveLabels{
+			archive.Status,
+			archive.StorePlugin,
+			archive.TargetPlugin,
+			archive.EncryptionType,
+			archive.Compression,
+		}]++
+	})
+	if err != nil {
+		logError(err, "Error while listing archives: %v", err)
+		return nil, err
+	}
+
+	var metrics []prometheus.Metric
+	for labels, total := range archivesTotal {
+		metrics = append(metrics, prometheus.MustNewConstMetric(
+			c.archivesTotalDesc,
+			prometheus.GaugeValue,
+			total,
+			labels.status,
+			labels.storePlugin,
+			labels.targetPlugin,
+			labels.encryption,
+			labels.compression,
+		))
+	}
+
+	for window, total := range archivesExpiringTotal {
+		metrics = append(metrics, prometheus.MustNewConstMetric(c.archivesExpiringTotalDesc, prometheus.GaugeValue, total, window))
+	}
+
+	metrics = append(metrics, prometheus.MustNewConstMetric(c.archivesOrphanedTotalDesc, prometheus.GaugeValue, archivesOrphanedTotal))
+
+	for targetName, takenAt := range targetsLastArchive {
+		metrics = append(metrics, prometheus.MustNewConstMetric(c.targetLastArchiveTimestampDesc, prometheus.GaugeValue, float64(takenAt), targetName))
+	}
 
-	return nil
+	return metrics, nil
 }
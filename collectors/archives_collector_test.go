@@ -2,6 +2,7 @@ package collectors_test
 
 import (
 	"net/http"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -9,8 +10,10 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/log"
+	"github.com/starkandwayne/goutils/timestamp"
 	"github.com/starkandwayne/shield/api"
 
+	"github.com/bosh-prometheus/shield_exporter/client"
 	. "github.com/bosh-prometheus/shield_exporter/collectors"
 	. "github.com/bosh-prometheus/shield_exporter/utils/test_matchers"
 )
@@ -24,6 +27,8 @@ var _ = Describe("ArchivesCollectors", func() {
 		err    error
 		server *ghttp.Server
 
+		shieldClient *client.Client
+
 		namespace   = "test_namespace"
 		environment = "test_environment"
 		backendName = "test_backend"
@@ -38,7 +43,12 @@ var _ = Describe("ArchivesCollectors", func() {
 		targetPlugin1  = "target_plugin_1"
 		targetPlugin2  = "target_plugin_2"
 
+		expiringWindows = map[string]time.Duration{"24h": 24 * time.Hour, "168h": 168 * time.Hour}
+
 		archivesTotalMetric                     *prometheus.GaugeVec
+		archivesExpiringTotalMetric             *prometheus.GaugeVec
+		archivesOrphanedTotalMetric             prometheus.Gauge
+		targetLastArchiveTimestampMetric        *prometheus.GaugeVec
 		archivesScrapesTotalMetric              prometheus.Counter
 		archivesScrapeErrorsTotalMetric         prometheus.Counter
 		lastArchivesScrapeErrorMetric           prometheus.Gauge
@@ -50,24 +60,11 @@ var _ = Describe("ArchivesCollectors", func() {
 
 	BeforeEach(func() {
 		server = ghttp.NewServer()
-		server.AppendHandlers(
-			ghttp.CombineHandlers(
-				ghttp.VerifyRequest("GET", "/v1/ping"),
-				ghttp.RespondWith(http.StatusOK, "{}"),
-			),
-		)
-
-		api.Cfg = &api.Config{
-			Backend:  "default",
-			Backends: map[string]string{},
-			Aliases:  map[string]string{},
-		}
-
-		err = api.Cfg.AddBackend(server.URL(), "default")
-		Expect(err).ToNot(HaveOccurred())
-
-		authToken := api.BasicAuthToken(username, password)
-		err = api.Cfg.UpdateBackend("default", authToken)
+		shieldClient, err = client.NewClient(client.Config{
+			BackendURL: server.URL(),
+			Username:   username,
+			Password:   password,
+		})
 		Expect(err).ToNot(HaveOccurred())
 
 		archivesTotalMetric = prometheus.NewGaugeVec(
@@ -78,12 +75,46 @@ var _ = Describe("ArchivesCollectors", func() {
 				Help:        "Labeled total number of Shield Archives.",
 				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
 			},
-			[]string{"archive_status", "store_plugin", "target_plugin"},
+			[]string{"archive_status", "store_plugin", "target_plugin", "encryption", "compression"},
+		)
+		archivesTotalMetric.WithLabelValues(archiveStatus1, storePlugin1, targetPlugin1, "", "").Set(1)
+		archivesTotalMetric.WithLabelValues(archiveStatus2, storePlugin1, targetPlugin2, "", "").Set(1)
+		archivesTotalMetric.WithLabelValues(archiveStatus1, storePlugin2, targetPlugin1, "", "").Set(1)
+		archivesTotalMetric.WithLabelValues(archiveStatus2, storePlugin2, targetPlugin2, "", "").Set(1)
+
+		archivesExpiringTotalMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "archives",
+				Name:        "expiring_total",
+				Help:        "Total number of valid Shield Archives expiring within the window.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"window"},
+		)
+		archivesExpiringTotalMetric.WithLabelValues("24h").Set(0)
+		archivesExpiringTotalMetric.WithLabelValues("168h").Set(0)
+
+		archivesOrphanedTotalMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "archives",
+				Name:        "orphaned_total",
+				Help:        "Total number of valid Shield Archives whose Shield Target or Shield Store no longer exists.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+
+		targetLastArchiveTimestampMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "target",
+				Name:        "last_archive_timestamp",
+				Help:        "Number of seconds since 1970 since the last Shield Archive of a Shield Target was taken.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"target_name"},
 		)
-		archivesTotalMetric.WithLabelValues(archiveStatus1, storePlugin1, targetPlugin1).Set(1)
-		archivesTotalMetric.WithLabelValues(archiveStatus2, storePlugin1, targetPlugin2).Set(1)
-		archivesTotalMetric.WithLabelValues(archiveStatus1, storePlugin2, targetPlugin1).Set(1)
-		archivesTotalMetric.WithLabelValues(archiveStatus2, storePlugin2, targetPlugin2).Set(1)
 
 		archivesScrapesTotalMetric = prometheus.NewCounter(
 			prometheus.CounterOpts{
@@ -138,7 +169,7 @@ var _ = Describe("ArchivesCollectors", func() {
 	})
 
 	JustBeforeEach(func() {
-		archivesCollector = NewArchivesCollector(namespace, environment, backendName)
+		archivesCollector = NewArchivesCollector(namespace, environment, backendName, shieldClient, expiringWindows)
 	})
 
 	AfterEach(func() {
@@ -159,7 +190,19 @@ var _ = Describe("ArchivesCollectors", func() {
 		})
 
 		It("returns a archives_total metric description", func() {
-			Eventually(descriptions).Should(Receive(Equal(archivesTotalMetric.WithLabelValues(archiveStatus1, storePlugin1, targetPlugin1).Desc())))
+			Eventually(descriptions).Should(Receive(Equal(archivesTotalMetric.WithLabelValues(archiveStatus1, storePlugin1, targetPlugin1, "", "").Desc())))
+		})
+
+		It("returns a archives_expiring_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(archivesExpiringTotalMetric.WithLabelValues("24h").Desc())))
+		})
+
+		It("returns a archives_orphaned_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(archivesOrphanedTotalMetric.Desc())))
+		})
+
+		It("returns a target_last_archive_timestamp metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(targetLastArchiveTimestampMetric.WithLabelValues("").Desc())))
 		})
 
 		It("returns a archives_scrapes_total metric description", func() {
@@ -186,39 +229,45 @@ var _ = Describe("ArchivesCollectors", func() {
 	Describe("Collect", func() {
 		var (
 			statusCode       int
-			archivesResponse []api.Archive
+			archivesResponse []client.Archive
+			targetsResponse  []api.Target
+			storesResponse   []client.Store
 			metrics          chan prometheus.Metric
 		)
 
 		BeforeEach(func() {
 			statusCode = http.StatusOK
-			archivesResponse = []api.Archive{
-				api.Archive{
-					Status:       archiveStatus1,
-					StorePlugin:  storePlugin1,
-					TargetPlugin: targetPlugin1,
+			archivesResponse = []client.Archive{
+				client.Archive{
+					Archive: api.Archive{Status: archiveStatus1, StorePlugin: storePlugin1, TargetPlugin: targetPlugin1},
 				},
-				api.Archive{
-					Status:       archiveStatus2,
-					StorePlugin:  storePlugin1,
-					TargetPlugin: targetPlugin2,
+				client.Archive{
+					Archive: api.Archive{Status: archiveStatus2, StorePlugin: storePlugin1, TargetPlugin: targetPlugin2},
 				},
-				api.Archive{
-					Status:       archiveStatus1,
-					StorePlugin:  storePlugin2,
-					TargetPlugin: targetPlugin1,
+				client.Archive{
+					Archive: api.Archive{Status: archiveStatus1, StorePlugin: storePlugin2, TargetPlugin: targetPlugin1},
 				},
-				api.Archive{
-					Status:       archiveStatus2,
-					StorePlugin:  storePlugin2,
-					TargetPlugin: targetPlugin2,
+				client.Archive{
+					Archive: api.Archive{Status: archiveStatus2, StorePlugin: storePlugin2, TargetPlugin: targetPlugin2},
 				},
 			}
+			targetsResponse = []api.Target{}
+			storesResponse = []client.Store{}
 			metrics = make(chan prometheus.Metric)
 		})
 
 		JustBeforeEach(func() {
 			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/targets"),
+					ghttp.VerifyBasicAuth(username, password),
+					ghttp.RespondWithJSONEncodedPtr(&statusCode, &targetsResponse),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/stores"),
+					ghttp.VerifyBasicAuth(username, password),
+					ghttp.RespondWithJSONEncodedPtr(&statusCode, &storesResponse),
+				),
 				ghttp.CombineHandlers(
 					ghttp.VerifyRequest("GET", "/v1/archives"),
 					ghttp.VerifyBasicAuth(username, password),
@@ -229,19 +278,114 @@ var _ = Describe("ArchivesCollectors", func() {
 		})
 
 		It("returns a archives_total metric for archive status 1, store plugin 1, target plugin 1", func() {
-			Eventually(metrics).Should(Receive(PrometheusMetric(archivesTotalMetric.WithLabelValues(archiveStatus1, storePlugin1, targetPlugin1))))
+			Eventually(metrics).Should(Receive(PrometheusMetric(archivesTotalMetric.WithLabelValues(archiveStatus1, storePlugin1, targetPlugin1, "", ""))))
 		})
 
 		It("returns a archives_total metric for archive status 1, store plugin 1, target plugin 2", func() {
-			Eventually(metrics).Should(Receive(PrometheusMetric(archivesTotalMetric.WithLabelValues(archiveStatus2, storePlugin1, targetPlugin2))))
+			Eventually(metrics).Should(Receive(PrometheusMetric(archivesTotalMetric.WithLabelValues(archiveStatus2, storePlugin1, targetPlugin2, "", ""))))
 		})
 
 		It("returns a archives_total metric for archive status 1, store plugin 2, target plugin 1", func() {
-			Eventually(metrics).Should(Receive(PrometheusMetric(archivesTotalMetric.WithLabelValues(archiveStatus1, storePlugin2, targetPlugin1))))
+			Eventually(metrics).Should(Receive(PrometheusMetric(archivesTotalMetric.WithLabelValues(archiveStatus1, storePlugin2, targetPlugin1, "", ""))))
 		})
 
 		It("returns a archives_total metric for archive status 1, store plugin 2, target plugin 2", func() {
-			Eventually(metrics).Should(Receive(PrometheusMetric(archivesTotalMetric.WithLabelValues(archiveStatus2, storePlugin2, targetPlugin2))))
+			Eventually(metrics).Should(Receive(PrometheusMetric(archivesTotalMetric.WithLabelValues(archiveStatus2, storePlugin2, targetPlugin2, "", ""))))
+		})
+
+		It("returns a archives_expiring_total metric for every window", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(archivesExpiringTotalMetric.WithLabelValues("24h"))))
+		})
+
+		It("returns a archives_orphaned_total metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(archivesOrphanedTotalMetric)))
+		})
+
+		Context("when the target or store of archives no longer exists", func() {
+			BeforeEach(func() {
+				targetsResponse = []api.Target{api.Target{UUID: "target_uuid"}}
+				storesResponse = []client.Store{client.Store{Store: api.Store{UUID: "store_uuid"}}}
+				archive := func(status string, targetUUID string, storeUUID string) client.Archive {
+					return client.Archive{
+						Archive: api.Archive{Status: status, TargetUUID: targetUUID, StoreUUID: storeUUID},
+					}
+				}
+				archivesResponse = []client.Archive{
+					archive(ValidArchiveStatus, "target_uuid", "store_uuid"),
+					archive(ValidArchiveStatus, "deleted_target_uuid", "store_uuid"),
+					archive(ValidArchiveStatus, "target_uuid", "deleted_store_uuid"),
+					archive("purged", "deleted_target_uuid", "store_uuid"),
+				}
+				archivesOrphanedTotalMetric.Set(2)
+			})
+
+			It("returns a archives_orphaned_total metric counting the valid archives", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(archivesOrphanedTotalMetric)))
+			})
+		})
+
+		Context("when archives of the targets were taken", func() {
+			BeforeEach(func() {
+				targetsResponse = []api.Target{
+					api.Target{UUID: "target_uuid_1", Name: "target_name_1"},
+					api.Target{UUID: "target_uuid_2", Name: "target_name_2"},
+				}
+				archive := func(status string, targetUUID string, takenAt int64) client.Archive {
+					return client.Archive{
+						Archive: api.Archive{Status: status, TargetUUID: targetUUID, TakenAt: timestamp.NewTimestamp(time.Unix(takenAt, 0))},
+					}
+				}
+				archivesResponse = []client.Archive{
+					archive(ValidArchiveStatus, "target_uuid_1", 100),
+					archive("purged", "target_uuid_1", 200),
+					archive(ValidArchiveStatus, "target_uuid_2", 50),
+					archive(ValidArchiveStatus, "deleted_target_uuid", 300),
+				}
+				targetLastArchiveTimestampMetric.WithLabelValues("target_name_1").Set(200)
+				targetLastArchiveTimestampMetric.WithLabelValues("target_name_2").Set(50)
+			})
+
+			It("returns a target_last_archive_timestamp metric for target 1", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(targetLastArchiveTimestampMetric.WithLabelValues("target_name_1"))))
+			})
+
+			It("returns a target_last_archive_timestamp metric for target 2", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(targetLastArchiveTimestampMetric.WithLabelValues("target_name_2"))))
+			})
+		})
+
+		Context("when archives are expiring", func() {
+			BeforeEach(func() {
+				now := time.Now()
+				archivesResponse[0].Status = ValidArchiveStatus
+				archivesResponse[0].ExpiresAt = timestamp.NewTimestamp(now.Add(time.Hour))
+				archivesResponse[1].Status = ValidArchiveStatus
+				archivesResponse[1].ExpiresAt = timestamp.NewTimestamp(now.Add(72 * time.Hour))
+				archivesResponse[2].Status = "expired"
+				archivesResponse[2].ExpiresAt = timestamp.NewTimestamp(now.Add(time.Hour))
+				archivesExpiringTotalMetric.WithLabelValues("24h").Set(1)
+				archivesExpiringTotalMetric.WithLabelValues("168h").Set(2)
+			})
+
+			It("returns a archives_expiring_total metric for the valid archives expiring within 24h", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(archivesExpiringTotalMetric.WithLabelValues("24h"))))
+			})
+
+			It("returns a archives_expiring_total metric for the valid archives expiring within 168h", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(archivesExpiringTotalMetric.WithLabelValues("168h"))))
+			})
+		})
+
+		Context("when Shield reports the encryption and compression of the archives", func() {
+			BeforeEach(func() {
+				archivesResponse[0].EncryptionType = "aes256-ctr"
+				archivesResponse[0].Compression = "bzip2"
+				archivesTotalMetric.WithLabelValues(archiveStatus1, storePlugin1, targetPlugin1, "aes256-ctr", "bzip2").Set(1)
+			})
+
+			It("returns a archives_total metric labeled with the encryption and compression", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(archivesTotalMetric.WithLabelValues(archiveStatus1, storePlugin1, targetPlugin1, "aes256-ctr", "bzip2"))))
+			})
 		})
 
 		It("returns a archives_scrapes_total metric", func() {
@@ -271,5 +415,33 @@ var _ = Describe("ArchivesCollectors", func() {
 				Eventually(metrics).Should(Receive(PrometheusMetric(lastArchivesScrapeErrorMetric)))
 			})
 		})
+
+		Context("when the Shield backend enters maintenance mode after a scrape", func() {
+			var maintenanceMetrics chan prometheus.Metric
+
+			BeforeEach(func() {
+				metrics = make(chan prometheus.Metric, 100)
+				maintenanceMetrics = make(chan prometheus.Metric)
+			})
+
+			JustBeforeEach(func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(lastArchivesScrapeErrorMetric)))
+
+				server.AppendHandlers(
+					ghttp.RespondWith(http.StatusServiceUnavailable, nil),
+					ghttp.RespondWith(http.StatusServiceUnavailable, nil),
+					ghttp.RespondWith(http.StatusServiceUnavailable, nil),
+				)
+				go archivesCollector.Collect(maintenanceMetrics)
+			})
+
+			It("returns the archives_total metrics of the previous scrape", func() {
+				Eventually(maintenanceMetrics).Should(Receive(PrometheusMetric(archivesTotalMetric.WithLabelValues(archiveStatus1, storePlugin1, targetPlugin1, "", ""))))
+			})
+
+			It("returns a last_archives_scrape_error metric", func() {
+				Eventually(maintenanceMetrics).Should(Receive(PrometheusMetric(lastArchivesScrapeErrorMetric)))
+			})
+		})
 	})
 })
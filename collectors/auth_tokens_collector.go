@@ -0,0 +1,171 @@
+package collectors
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+	"github.com/bosh-prometheus/shield_exporter/filters"
+)
+
+type AuthTokensCollector struct {
+	namespace                               string
+	environment                             string
+	backendName                             string
+	shieldClient                            *client.Client
+	authTokensTotalDesc                     *prometheus.Desc
+	authTokenOldestAgeSecondsDesc           *prometheus.Desc
+	collectorAvailableDesc                  *prometheus.Desc
+	authTokensScrapesTotalMetric            prometheus.Counter
+	authTokensScrapeErrorsTotalMetric       prometheus.Counter
+	scrapeErrorsByReasonTotalMetric         *prometheus.CounterVec
+	lastAuthTokensScrapeErrorDesc           *prometheus.Desc
+	lastAuthTokensScrapeTimestampDesc       *prometheus.Desc
+	lastAuthTokensScrapeDurationSecondsDesc *prometheus.Desc
+}
+
+func NewAuthTokensCollector(
+	namespace string,
+	environment string,
+	backendName string,
+	shieldClient *client.Client,
+) *AuthTokensCollector {
+	authTokensTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "auth_tokens", "total"),
+		"Total number of Shield API Auth Tokens.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	authTokenOldestAgeSecondsDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "auth_token", "oldest_age_seconds"),
+		"Number of seconds since the oldest Shield API Auth Token was created.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	collectorAvailableDesc := newCollectorAvailableDesc(namespace, environment, backendName, filters.AuthTokensCollector)
+	scrapeErrorsByReasonTotalMetric := newScrapeErrorsByReasonTotalMetric(namespace, environment, backendName, filters.AuthTokensCollector)
+
+	authTokensScrapesTotalMetric := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "auth_tokens",
+			Name:        "scrapes_total",
+			Help:        "Total number of scrapes for Shield Auth Tokens.",
+			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+		},
+	)
+
+	authTokensScrapeErrorsTotalMetric := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "auth_tokens",
+			Name:        "scrape_errors_total",
+			Help:        "Total number of scrape errors of Shield Auth Tokens.",
+			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+		},
+	)
+
+	lastAuthTokensScrapeErrorDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_auth_tokens_scrape_error"),
+		"Whether the last scrape of Auth Tokens metrics from Shield resulted in an error (1 for error, 0 for success).",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	lastAuthTokensScrapeTimestampDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_auth_tokens_scrape_timestamp"),
+		"Number of seconds since 1970 since last scrape of Auth Tokens metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	lastAuthTokensScrapeDurationSecondsDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_auth_tokens_scrape_duration_seconds"),
+		"Duration of the last scrape of Auth Tokens metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	return &AuthTokensCollector{
+		namespace:                               namespace,
+		environment:                             environment,
+		backendName:                             backendName,
+		shieldClient:                            shieldClient,
+		authTokensTotalDesc:                     authTokensTotalDesc,
+		authTokenOldestAgeSecondsDesc:           authTokenOldestAgeSecondsDesc,
+		collectorAvailableDesc:                  collectorAvailableDesc,
+		authTokensScrapesTotalMetric:            authTokensScrapesTotalMetric,
+		authTokensScrapeErrorsTotalMetric:       authTokensScrapeErrorsTotalMetric,
+		scrapeErrorsByReasonTotalMetric:         scrapeErrorsByReasonTotalMetric,
+		lastAuthTokensScrapeErrorDesc:           lastAuthTokensScrapeErrorDesc,
+		lastAuthTokensScrapeTimestampDesc:       lastAuthTokensScrapeTimestampDesc,
+		lastAuthTokensScrapeDurationSecondsDesc: lastAuthTokensScrapeDurationSecondsDesc,
+	}
+}
+
+func (c AuthTokensCollector) Collect(ch chan<- prometheus.Metric) {
+	var begun = time.Now()
+
+	errorMetric := float64(0)
+	err := c.reportAuthTokensMetrics(ch)
+	if isScrapeError(err) {
+		errorMetric = float64(1)
+		c.authTokensScrapeErrorsTotalMetric.Inc()
+		c.scrapeErrorsByReasonTotalMetric.WithLabelValues(client.ErrorReason(err)).Inc()
+	}
+	c.authTokensScrapeErrorsTotalMetric.Collect(ch)
+	c.scrapeErrorsByReasonTotalMetric.Collect(ch)
+
+	c.authTokensScrapesTotalMetric.Inc()
+	c.authTokensScrapesTotalMetric.Collect(ch)
+
+	ch <- prometheus.MustNewConstMetric(c.collectorAvailableDesc, prometheus.GaugeValue, availability(err))
+	ch <- prometheus.MustNewConstMetric(c.lastAuthTokensScrapeErrorDesc, prometheus.GaugeValue, errorMetric)
+	ch <- prometheus.MustNewConstMetric(c.lastAuthTokensScrapeTimestampDesc, prometheus.GaugeValue, float64(time.Now().Unix()))
+	ch <- prometheus.MustNewConstMetric(c.lastAuthTokensScrapeDurationSecondsDesc, prometheus.GaugeValue, time.Since(begun).Seconds())
+}
+
+func (c AuthTokensCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	c.shieldClient = c.shieldClient.WithContext(ctx)
+	c.Collect(ch)
+}
+
+func (c AuthTokensCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.authTokensTotalDesc
+	ch <- c.authTokenOldestAgeSecondsDesc
+	ch <- c.collectorAvailableDesc
+	c.authTokensScrapesTotalMetric.Describe(ch)
+	c.authTokensScrapeErrorsTotalMetric.Describe(ch)
+	c.scrapeErrorsByReasonTotalMetric.Describe(ch)
+	ch <- c.lastAuthTokensScrapeErrorDesc
+	ch <- c.lastAuthTokensScrapeTimestampDesc
+	ch <- c.lastAuthTokensScrapeDurationSecondsDesc
+}
+
+func (c AuthTokensCollector) reportAuthTokensMetrics(ch chan<- prometheus.Metric) error {
+	authTokens, err := c.shieldClient.GetAuthTokens()
+	if err != nil {
+		logError(err, "Error while listing auth tokens: %v", err)
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.authTokensTotalDesc, prometheus.GaugeValue, float64(len(authTokens)))
+
+	if len(authTokens) == 0 {
+		return nil
+	}
+
+	oldestCreatedAt := authTokens[0].CreatedAt
+	for _, authToken := range authTokens[1:] {
+		if authToken.CreatedAt < oldestCreatedAt {
+			oldestCreatedAt = authToken.CreatedAt
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(c.authTokenOldestAgeSecondsDesc, prometheus.GaugeValue, time.Since(time.Unix(oldestCreatedAt, 0)).Seconds())
+
+	return nil
+}
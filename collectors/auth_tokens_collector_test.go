@@ -0,0 +1,267 @@
+package collectors_test
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+	. "github.com/bosh-prometheus/shield_exporter/collectors"
+	. "github.com/bosh-prometheus/shield_exporter/utils/test_matchers"
+)
+
+var _ = Describe("AuthTokensCollector", func() {
+	var (
+		err    error
+		server *ghttp.Server
+
+		shieldClient *client.Client
+
+		namespace   = "test_namespace"
+		environment = "test_environment"
+		backendName = "test_backend"
+
+		username = "fake_username"
+		password = "fake_password"
+
+		authTokensTotalMetric                     prometheus.Gauge
+		authTokenOldestAgeSecondsMetric           prometheus.Gauge
+		authTokensScrapesTotalMetric              prometheus.Counter
+		authTokensScrapeErrorsTotalMetric         prometheus.Counter
+		lastAuthTokensScrapeErrorMetric           prometheus.Gauge
+		lastAuthTokensScrapeTimestampMetric       prometheus.Gauge
+		lastAuthTokensScrapeDurationSecondsMetric prometheus.Gauge
+
+		authTokensCollector *AuthTokensCollector
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		shieldClient, err = client.NewClient(client.Config{
+			BackendURL: server.URL(),
+			Username:   username,
+			Password:   password,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		authTokensTotalMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "auth_tokens",
+				Name:        "total",
+				Help:        "Total number of Shield API Auth Tokens.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+		authTokensTotalMetric.Set(2)
+
+		authTokenOldestAgeSecondsMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "auth_token",
+				Name:        "oldest_age_seconds",
+				Help:        "Number of seconds since the oldest Shield API Auth Token was created.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+
+		authTokensScrapesTotalMetric = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace:   namespace,
+				Subsystem:   "auth_tokens",
+				Name:        "scrapes_total",
+				Help:        "Total number of scrapes for Shield Auth Tokens.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+		authTokensScrapesTotalMetric.Inc()
+
+		authTokensScrapeErrorsTotalMetric = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace:   namespace,
+				Subsystem:   "auth_tokens",
+				Name:        "scrape_errors_total",
+				Help:        "Total number of scrape errors of Shield Auth Tokens.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+
+		lastAuthTokensScrapeErrorMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "",
+				Name:        "last_auth_tokens_scrape_error",
+				Help:        "Whether the last scrape of Auth Tokens metrics from Shield resulted in an error (1 for error, 0 for success).",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+
+		lastAuthTokensScrapeTimestampMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "",
+				Name:        "last_auth_tokens_scrape_timestamp",
+				Help:        "Number of seconds since 1970 since last scrape of Auth Tokens metrics from Shield.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+
+		lastAuthTokensScrapeDurationSecondsMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "",
+				Name:        "last_auth_tokens_scrape_duration_seconds",
+				Help:        "Duration of the last scrape of Auth Tokens metrics from Shield.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+	})
+
+	JustBeforeEach(func() {
+		authTokensCollector = NewAuthTokensCollector(namespace, environment, backendName, shieldClient)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Describe", func() {
+		var (
+			descriptions chan *prometheus.Desc
+		)
+
+		BeforeEach(func() {
+			descriptions = make(chan *prometheus.Desc)
+		})
+
+		JustBeforeEach(func() {
+			go authTokensCollector.Describe(descriptions)
+		})
+
+		It("returns a auth_tokens_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(authTokensTotalMetric.Desc())))
+		})
+
+		It("returns a auth_token_oldest_age_seconds metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(authTokenOldestAgeSecondsMetric.Desc())))
+		})
+
+		It("returns a auth_tokens_scrapes_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(authTokensScrapesTotalMetric.Desc())))
+		})
+
+		It("returns a auth_tokens_scrape_errors_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(authTokensScrapeErrorsTotalMetric.Desc())))
+		})
+
+		It("returns a last_auth_tokens_scrape_error metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(lastAuthTokensScrapeErrorMetric.Desc())))
+		})
+
+		It("returns a last_auth_tokens_scrape_timestamp metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(lastAuthTokensScrapeTimestampMetric.Desc())))
+		})
+
+		It("returns a last_auth_tokens_scrape_duration_seconds metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(lastAuthTokensScrapeDurationSecondsMetric.Desc())))
+		})
+	})
+
+	Describe("Collect", func() {
+		var (
+			statusCode         int
+			authTokensResponse []client.AuthToken
+			metrics            chan prometheus.Metric
+		)
+
+		BeforeEach(func() {
+			statusCode = http.StatusOK
+			authTokensResponse = []client.AuthToken{
+				client.AuthToken{
+					Name:      "fake_auth_token_1",
+					CreatedAt: time.Now().Add(-1 * time.Hour).Unix(),
+				},
+				client.AuthToken{
+					Name:      "fake_auth_token_2",
+					CreatedAt: time.Now().Add(-24 * time.Hour).Unix(),
+				},
+			}
+			metrics = make(chan prometheus.Metric)
+		})
+
+		JustBeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/auth/tokens"),
+					ghttp.VerifyBasicAuth(username, password),
+					ghttp.RespondWithJSONEncodedPtr(&statusCode, &authTokensResponse),
+				),
+			)
+			go authTokensCollector.Collect(metrics)
+		})
+
+		It("returns a auth_tokens_total metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(authTokensTotalMetric)))
+		})
+
+		It("returns a auth_token_oldest_age_seconds metric", func() {
+			var metric prometheus.Metric
+			Eventually(metrics).Should(Receive(&metric))
+			Eventually(metrics).Should(Receive(&metric))
+			Expect(metric.Desc()).To(Equal(authTokenOldestAgeSecondsMetric.Desc()))
+
+			written := &dto.Metric{}
+			Expect(metric.Write(written)).To(Succeed())
+			Expect(written.GetGauge().GetValue()).To(BeNumerically("~", (24 * time.Hour).Seconds(), 5))
+		})
+
+		It("returns a auth_tokens_scrapes_total metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(authTokensScrapesTotalMetric)))
+		})
+
+		It("returns a auth_tokens_scrape_errors_total metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(authTokensScrapeErrorsTotalMetric)))
+		})
+
+		It("returns a last_auth_tokens_scrape_error metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(lastAuthTokensScrapeErrorMetric)))
+		})
+
+		Context("when there are no auth tokens", func() {
+			BeforeEach(func() {
+				authTokensResponse = []client.AuthToken{}
+				authTokensTotalMetric.Set(0)
+			})
+
+			It("returns a auth_tokens_total metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(authTokensTotalMetric)))
+			})
+
+			It("does not return a auth_token_oldest_age_seconds metric", func() {
+				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(authTokenOldestAgeSecondsMetric)))
+			})
+		})
+
+		Context("when it fails to list the auth tokens", func() {
+			BeforeEach(func() {
+				statusCode = http.StatusInternalServerError
+				authTokensScrapeErrorsTotalMetric.Inc()
+				lastAuthTokensScrapeErrorMetric.Set(1)
+			})
+
+			It("returns a auth_tokens_scrape_errors_total metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(authTokensScrapeErrorsTotalMetric)))
+			})
+
+			It("returns a last_auth_tokens_scrape_error metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(lastAuthTokensScrapeErrorMetric)))
+			})
+		})
+	})
+})
@@ -0,0 +1,98 @@
+package collectors
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+)
+
+// BackendCollector exports the metrics about the Shield backend itself,
+// collected from the statistics of the Shield client, so that they are
+// exported whatever collectors are enabled.
+type BackendCollector struct {
+	namespace              string
+	environment            string
+	backendName            string
+	shieldClient           *client.Client
+	backendResolvedDesc    *prometheus.Desc
+	backendMaintenanceDesc *prometheus.Desc
+	apiResponseBytesDesc   *prometheus.Desc
+	apiRequestsTotalDesc   *prometheus.Desc
+}
+
+func NewBackendCollector(
+	namespace string,
+	environment string,
+	backendName string,
+	shieldClient *client.Client,
+) *BackendCollector {
+	backendMaintenanceDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "backend", "maintenance"),
+		"Whether the Shield backend is in maintenance mode (1 for maintenance, 0 for available).",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	apiResponseBytesDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "exporter", "api_response_bytes"),
+		"Size in bytes of the last response body received from a Shield API endpoint.",
+		[]string{"endpoint"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	apiRequestsTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "exporter", "api_requests_total"),
+		"Total number of requests sent to a Shield API endpoint by status code.",
+		[]string{"endpoint", "code"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	return &BackendCollector{
+		namespace:              namespace,
+		environment:            environment,
+		backendName:            backendName,
+		shieldClient:           shieldClient,
+		backendResolvedDesc:    newBackendResolvedDesc(namespace, environment, backendName),
+		backendMaintenanceDesc: backendMaintenanceDesc,
+		apiResponseBytesDesc:   apiResponseBytesDesc,
+		apiRequestsTotalDesc:   apiRequestsTotalDesc,
+	}
+}
+
+func (c BackendCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.shieldClient.Stats()
+
+	ch <- prometheus.MustNewConstMetric(c.backendResolvedDesc, prometheus.GaugeValue, 1)
+
+	maintenance := float64(0)
+	if stats.Maintenance {
+		maintenance = float64(1)
+	}
+	ch <- prometheus.MustNewConstMetric(c.backendMaintenanceDesc, prometheus.GaugeValue, maintenance)
+
+	for endpoint, bytes := range stats.ResponseBytes {
+		ch <- prometheus.MustNewConstMetric(c.apiResponseBytesDesc, prometheus.GaugeValue, float64(bytes), endpoint)
+	}
+
+	for endpoint, codes := range stats.APIRequests {
+		for code, requests := range codes {
+			ch <- prometheus.MustNewConstMetric(c.apiRequestsTotalDesc, prometheus.CounterValue, float64(requests), endpoint, code)
+		}
+	}
+}
+
+func (c BackendCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.backendResolvedDesc
+	ch <- c.backendMaintenanceDesc
+	ch <- c.apiResponseBytesDesc
+	ch <- c.apiRequestsTotalDesc
+}
+
+func newBackendResolvedDesc(namespace string, environment string, backendName string) *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "backend", "resolved"),
+		"Whether the name of the Shield backend is resolved from the Shield Status, so that its metrics are gathered (1 for resolved, 0 for unresolved).",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+}
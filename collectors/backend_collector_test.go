@@ -0,0 +1,197 @@
+package collectors_test
+
+import (
+	"encoding/json"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+	. "github.com/bosh-prometheus/shield_exporter/collectors"
+	. "github.com/bosh-prometheus/shield_exporter/utils/test_matchers"
+)
+
+func init() {
+	log.Base().SetLevel("fatal")
+}
+
+var _ = Describe("BackendCollector", func() {
+	var (
+		err    error
+		server *ghttp.Server
+
+		shieldClient *client.Client
+
+		namespace   = "test_namespace"
+		environment = "test_environment"
+		backendName = "test_backend"
+
+		username = "fake_username"
+		password = "fake_password"
+
+		backendResolvedMetric    prometheus.Gauge
+		backendMaintenanceMetric prometheus.Gauge
+		apiResponseBytesMetric   *prometheus.GaugeVec
+		apiRequestsTotalMetric   *prometheus.CounterVec
+
+		backendCollector *BackendCollector
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		shieldClient, err = client.NewClient(client.Config{
+			BackendURL: server.URL(),
+			Username:   username,
+			Password:   password,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		backendResolvedMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "backend",
+				Name:        "resolved",
+				Help:        "Whether the name of the Shield backend is resolved from the Shield Status, so that its metrics are gathered (1 for resolved, 0 for unresolved).",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+		backendResolvedMetric.Set(1)
+
+		backendMaintenanceMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "backend",
+				Name:        "maintenance",
+				Help:        "Whether the Shield backend is in maintenance mode (1 for maintenance, 0 for available).",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+
+		apiResponseBytesMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "exporter",
+				Name:        "api_response_bytes",
+				Help:        "Size in bytes of the last response body received from a Shield API endpoint.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"endpoint"},
+		)
+
+		apiRequestsTotalMetric = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   namespace,
+				Subsystem:   "exporter",
+				Name:        "api_requests_total",
+				Help:        "Total number of requests sent to a Shield API endpoint by status code.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"endpoint", "code"},
+		)
+	})
+
+	JustBeforeEach(func() {
+		backendCollector = NewBackendCollector(namespace, environment, backendName, shieldClient)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Describe", func() {
+		var (
+			descriptions chan *prometheus.Desc
+		)
+
+		BeforeEach(func() {
+			descriptions = make(chan *prometheus.Desc)
+		})
+
+		JustBeforeEach(func() {
+			go backendCollector.Describe(descriptions)
+		})
+
+		It("returns a backend_resolved metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(backendResolvedMetric.Desc())))
+		})
+
+		It("returns a backend_maintenance metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(backendMaintenanceMetric.Desc())))
+		})
+
+		It("returns an exporter_api_response_bytes metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(apiResponseBytesMetric.WithLabelValues("").Desc())))
+		})
+
+		It("returns an exporter_api_requests_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(apiRequestsTotalMetric.WithLabelValues("", "").Desc())))
+		})
+	})
+
+	Describe("Collect", func() {
+		var (
+			statusCode     int
+			statusResponse map[string]interface{}
+			metrics        chan prometheus.Metric
+		)
+
+		BeforeEach(func() {
+			statusCode = http.StatusOK
+			statusResponse = map[string]interface{}{"version": "8.0.0"}
+			metrics = make(chan prometheus.Metric)
+		})
+
+		JustBeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/status"),
+					ghttp.RespondWithJSONEncodedPtr(&statusCode, &statusResponse),
+				),
+			)
+			shieldClient.Get("/v1/status", &map[string]interface{}{})
+
+			go backendCollector.Collect(metrics)
+		})
+
+		It("returns a backend_resolved metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(backendResolvedMetric)))
+		})
+
+		It("returns a backend_maintenance metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(backendMaintenanceMetric)))
+		})
+
+		It("returns an exporter_api_response_bytes metric for the requested endpoint", func() {
+			body, err := json.Marshal(statusResponse)
+			Expect(err).ToNot(HaveOccurred())
+			apiResponseBytesMetric.WithLabelValues("/v1/status").Set(float64(len(body)))
+			Eventually(metrics).Should(Receive(PrometheusMetric(apiResponseBytesMetric.WithLabelValues("/v1/status"))))
+		})
+
+		It("returns an exporter_api_requests_total metric for the requested endpoint", func() {
+			apiRequestsTotalMetric.WithLabelValues("/v1/status", "200").Inc()
+			Eventually(metrics).Should(Receive(PrometheusMetric(apiRequestsTotalMetric.WithLabelValues("/v1/status", "200"))))
+		})
+
+		Context("when the Shield backend is in maintenance mode", func() {
+			BeforeEach(func() {
+				statusCode = http.StatusServiceUnavailable
+				backendMaintenanceMetric.Set(1)
+				apiRequestsTotalMetric.WithLabelValues("/v1/status", "503").Inc()
+			})
+
+			It("returns a backend_maintenance metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(backendMaintenanceMetric)))
+			})
+
+			It("returns an exporter_api_requests_total metric counting the failed requests", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(apiRequestsTotalMetric.WithLabelValues("/v1/status", "503"))))
+			})
+		})
+	})
+})
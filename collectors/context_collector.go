@@ -0,0 +1,38 @@
+package collectors
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ContextCollector is a Collector whose collections can be bound to a
+// context, within which the Shield API calls are sent.
+type ContextCollector interface {
+	prometheus.Collector
+	CollectContext(ctx context.Context, ch chan<- prometheus.Metric)
+}
+
+// WithContext returns a Collector collecting collector within ctx, when it
+// is a ContextCollector, or collector itself otherwise.
+func WithContext(ctx context.Context, collector prometheus.Collector) prometheus.Collector {
+	contextCollector, ok := collector.(ContextCollector)
+	if !ok {
+		return collector
+	}
+
+	return &boundCollector{collector: contextCollector, ctx: ctx}
+}
+
+type boundCollector struct {
+	collector ContextCollector
+	ctx       context.Context
+}
+
+func (c *boundCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collector.CollectContext(c.ctx, ch)
+}
+
+func (c *boundCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.collector.Describe(ch)
+}
@@ -0,0 +1,62 @@
+package collectors
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+)
+
+// isScrapeError returns whether err fails a scrape. The errors of a Shield
+// backend in maintenance mode, or not implementing an endpoint, do not, as
+// they are reported by the backend_maintenance and collector_available
+// metrics instead.
+func isScrapeError(err error) bool {
+	return err != nil && !client.IsMaintenance(err) && !client.IsNotImplemented(err)
+}
+
+// logError logs err, at the debug level only for the errors of a Shield
+// backend in maintenance mode, which the client logs once, or not
+// implementing an endpoint, which would be logged at every scrape.
+func logError(err error, format string, args ...interface{}) {
+	if client.IsMaintenance(err) || client.IsNotImplemented(err) {
+		log.Debugf(format, args...)
+		return
+	}
+	log.Errorf(format, args...)
+}
+
+func newCollectorAvailableDesc(namespace string, environment string, backendName string, collectorName string) *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "collector", "available"),
+		"Whether the Shield backend implements the API endpoints of a collector (1 for available, 0 for not implemented).",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName, "collector": collectorName},
+	)
+}
+
+// availability returns the value of the collector_available metric after a
+// scrape that returned err.
+func availability(err error) float64 {
+	if client.IsNotImplemented(err) {
+		return 0
+	}
+	return 1
+}
+
+func newScrapeErrorsByReasonTotalMetric(namespace string, environment string, backendName string, collectorName string) *prometheus.CounterVec {
+	scrapeErrorsByReasonTotalMetric := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "exporter",
+			Name:        "scrape_errors_total",
+			Help:        "Total number of scrape errors of a collector's metrics from Shield by reason.",
+			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName, "collector": collectorName},
+		},
+		[]string{"reason"},
+	)
+	for _, reason := range client.ErrorReasons {
+		scrapeErrorsByReasonTotalMetric.WithLabelValues(reason)
+	}
+	return scrapeErrorsByReasonTotalMetric
+}
@@ -1,6 +1,7 @@
 package collectors
 
 import (
+	"context"
 	"strconv"
 	"strings"
 	"time"
@@ -8,6 +9,9 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/log"
 	"github.com/starkandwayne/shield/api"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+	"github.com/bosh-prometheus/shield_exporter/filters"
 )
 
 const (
@@ -18,82 +22,127 @@ const (
 	DoneStatus     = "done"
 )
 
+const ValidArchiveStatus = "valid"
+
+type jobLabels struct {
+	paused       bool
+	storePlugin  string
+	targetPlugin string
+}
+
+// jobLabelNames are the labels of every per-job metric, legacyJobLabelNames
+// the ones they carried before, kept for compatibility.
+var (
+	jobLabelNames       = []string{"job_name", "job_uuid", "target_name", "store_name"}
+	legacyJobLabelNames = []string{"job_name"}
+)
+
 type JobsCollector struct {
-	namespace                           string
-	environment                         string
-	backendName                         string
-	jobLastRunMetric                    *prometheus.GaugeVec
-	jobNextRunMetric                    *prometheus.GaugeVec
-	jobStatusMetric                     *prometheus.GaugeVec
-	jobPausedMetric                     *prometheus.GaugeVec
-	jobsTotalMetric                     *prometheus.GaugeVec
-	jobsScrapesTotalMetric              prometheus.Counter
-	jobsScrapeErrorsTotalMetric         prometheus.Counter
-	lastJobsScrapeErrorMetric           prometheus.Gauge
-	lastJobsScrapeTimestampMetric       prometheus.Gauge
-	lastJobsScrapeDurationSecondsMetric prometheus.Gauge
+	namespace                         string
+	environment                       string
+	backendName                       string
+	shieldClient                      *client.Client
+	jobLastRunDesc                    *prometheus.Desc
+	jobNextRunDesc                    *prometheus.Desc
+	jobSecondsSinceLastRunDesc        *prometheus.Desc
+	jobSecondsUntilNextRunDesc        *prometheus.Desc
+	jobStatusDesc                     *prometheus.Desc
+	jobPausedDesc                     *prometheus.Desc
+	jobsTotalDesc                     *prometheus.Desc
+	jobsPausedTotalDesc               *prometheus.Desc
+	jobSLAMetDesc                     *prometheus.Desc
+	slaEnabled                        bool
+	slaMaxAge                         time.Duration
+	legacyJobLabels                   bool
+	collectorAvailableDesc            *prometheus.Desc
+	jobsScrapesTotalMetric            prometheus.Counter
+	jobsScrapeErrorsTotalMetric       prometheus.Counter
+	scrapeErrorsByReasonTotalMetric   *prometheus.CounterVec
+	lastJobsScrapeErrorDesc           *prometheus.Desc
+	lastJobsScrapeTimestampDesc       *prometheus.Desc
+	lastJobsScrapeDurationSecondsDesc *prometheus.Desc
 }
 
 func NewJobsCollector(
 	namespace string,
 	environment string,
 	backendName string,
+	shieldClient *client.Client,
+	slaEnabled bool,
+	slaMaxAge time.Duration,
+	legacyJobLabels bool,
 ) *JobsCollector {
-	jobLastRunMetric := prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "job",
-			Name:        "last_run",
-			Help:        "Number of seconds since 1970 since last run of a Shield Job.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
-		[]string{"job_name"},
+	labelNames := jobLabelNames
+	if legacyJobLabels {
+		labelNames = legacyJobLabelNames
+	}
+
+	jobLastRunDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "job", "last_run"),
+		"Number of seconds since 1970 since last run of a Shield Job.",
+		labelNames,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	jobNextRunMetric := prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "job",
-			Name:        "next_run",
-			Help:        "Number of seconds since 1970 until next run of a Shield Job.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
-		[]string{"job_name"},
+	jobNextRunDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "job", "next_run"),
+		"Number of seconds since 1970 until next run of a Shield Job.",
+		labelNames,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	jobStatusMetric := prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "job",
-			Name:        "status",
-			Help:        "Shield Job status (0 for unknow, 1 for pending, 2 for running, 3 for canceled, 4 for failed, 5 for done).",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
-		[]string{"job_name"},
+	jobSecondsSinceLastRunDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "job", "seconds_since_last_run"),
+		"Number of seconds since last run of a Shield Job.",
+		labelNames,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	jobPausedMetric := prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "job",
-			Name:        "paused",
-			Help:        "Shield Job pause status (1 for paused, 0 for unpaused).",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
-		[]string{"job_name"},
+	jobSecondsUntilNextRunDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "job", "seconds_until_next_run"),
+		"Number of seconds until next run of a Shield Job.",
+		labelNames,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	jobsTotalMetric := prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "jobs",
-			Name:        "total",
-			Help:        "Labeled total number of Shield Jobs.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	jobStatusDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "job", "status"),
+		"Shield Job status (0 for unknow, 1 for pending, 2 for running, 3 for canceled, 4 for failed, 5 for done).",
+		labelNames,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	jobPausedDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "job", "paused"),
+		"Shield Job pause status (1 for paused, 0 for unpaused).",
+		labelNames,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	jobsTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "jobs", "total"),
+		"Labeled total number of Shield Jobs.",
 		[]string{"job_paused", "store_plugin", "target_plugin"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
+	jobsPausedTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "jobs", "paused_total"),
+		"Total number of paused Shield Jobs.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	jobSLAMetDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "job", "sla_met"),
+		"Whether a Shield Job has a valid archive more recent than its SLA (1 for met, 0 for not met).",
+		labelNames,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	collectorAvailableDesc := newCollectorAvailableDesc(namespace, environment, backendName, filters.JobsCollector)
+	scrapeErrorsByReasonTotalMetric := newScrapeErrorsByReasonTotalMetric(namespace, environment, backendName, filters.JobsCollector)
+
 	jobsScrapesTotalMetric := prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Namespace:   namespace,
@@ -114,50 +163,51 @@ func NewJobsCollector(
 		},
 	)
 
-	lastJobsScrapeErrorMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_jobs_scrape_error",
-			Help:        "Whether the last scrape of Job metrics from Shield resulted in an error (1 for error, 0 for success).",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastJobsScrapeErrorDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_jobs_scrape_error"),
+		"Whether the last scrape of Job metrics from Shield resulted in an error (1 for error, 0 for success).",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	lastJobsScrapeTimestampMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_jobs_scrape_timestamp",
-			Help:        "Number of seconds since 1970 since last scrape of Job metrics from Shield.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastJobsScrapeTimestampDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_jobs_scrape_timestamp"),
+		"Number of seconds since 1970 since last scrape of Job metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	lastJobsScrapeDurationSecondsMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_jobs_scrape_duration_seconds",
-			Help:        "Duration of the last scrape of Job metrics from Shield.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastJobsScrapeDurationSecondsDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_jobs_scrape_duration_seconds"),
+		"Duration of the last scrape of Job metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
 	return &JobsCollector{
-		namespace:                           namespace,
-		environment:                         environment,
-		backendName:                         backendName,
-		jobLastRunMetric:                    jobLastRunMetric,
-		jobNextRunMetric:                    jobNextRunMetric,
-		jobStatusMetric:                     jobStatusMetric,
-		jobPausedMetric:                     jobPausedMetric,
-		jobsTotalMetric:                     jobsTotalMetric,
-		jobsScrapesTotalMetric:              jobsScrapesTotalMetric,
-		jobsScrapeErrorsTotalMetric:         jobsScrapeErrorsTotalMetric,
-		lastJobsScrapeErrorMetric:           lastJobsScrapeErrorMetric,
-		lastJobsScrapeTimestampMetric:       lastJobsScrapeTimestampMetric,
-		lastJobsScrapeDurationSecondsMetric: lastJobsScrapeDurationSecondsMetric,
+		namespace:                         namespace,
+		environment:                       environment,
+		backendName:                       backendName,
+		shieldClient:                      shieldClient,
+		jobLastRunDesc:                    jobLastRunDesc,
+		jobNextRunDesc:                    jobNextRunDesc,
+		jobSecondsSinceLastRunDesc:        jobSecondsSinceLastRunDesc,
+		jobSecondsUntilNextRunDesc:        jobSecondsUntilNextRunDesc,
+		jobStatusDesc:                     jobStatusDesc,
+		jobPausedDesc:                     jobPausedDesc,
+		jobsTotalDesc:                     jobsTotalDesc,
+		jobsPausedTotalDesc:               jobsPausedTotalDesc,
+		jobSLAMetDesc:                     jobSLAMetDesc,
+		slaEnabled:                        slaEnabled,
+		slaMaxAge:                         slaMaxAge,
+		legacyJobLabels:                   legacyJobLabels,
+		collectorAvailableDesc:            collectorAvailableDesc,
+		jobsScrapesTotalMetric:            jobsScrapesTotalMetric,
+		jobsScrapeErrorsTotalMetric:       jobsScrapeErrorsTotalMetric,
+		scrapeErrorsByReasonTotalMetric:   scrapeErrorsByReasonTotalMetric,
+		lastJobsScrapeErrorDesc:           lastJobsScrapeErrorDesc,
+		lastJobsScrapeTimestampDesc:       lastJobsScrapeTimestampDesc,
+		lastJobsScrapeDurationSecondsDesc: lastJobsScrapeDurationSecondsDesc,
 	}
 }
 
@@ -165,70 +215,116 @@ func (c JobsCollector) Collect(ch chan<- prometheus.Metric) {
 	var begun = time.Now()
 
 	errorMetric := float64(0)
-	if err := c.reportJobsMetrics(ch); err != nil {
+	err := c.reportJobsMetrics(ch)
+	if isScrapeError(err) {
 		errorMetric = float64(1)
 		c.jobsScrapeErrorsTotalMetric.Inc()
+		c.scrapeErrorsByReasonTotalMetric.WithLabelValues(client.ErrorReason(err)).Inc()
 	}
 	c.jobsScrapeErrorsTotalMetric.Collect(ch)
+	c.scrapeErrorsByReasonTotalMetric.Collect(ch)
 
 	c.jobsScrapesTotalMetric.Inc()
 	c.jobsScrapesTotalMetric.Collect(ch)
 
-	c.lastJobsScrapeErrorMetric.Set(errorMetric)
-	c.lastJobsScrapeErrorMetric.Collect(ch)
-
-	c.lastJobsScrapeTimestampMetric.Set(float64(time.Now().Unix()))
-	c.lastJobsScrapeTimestampMetric.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(c.collectorAvailableDesc, prometheus.GaugeValue, availability(err))
+	ch <- prometheus.MustNewConstMetric(c.lastJobsScrapeErrorDesc, prometheus.GaugeValue, errorMetric)
+	ch <- prometheus.MustNewConstMetric(c.lastJobsScrapeTimestampDesc, prometheus.GaugeValue, float64(time.Now().Unix()))
+	ch <- prometheus.MustNewConstMetric(c.lastJobsScrapeDurationSecondsDesc, prometheus.GaugeValue, time.Since(begun).Seconds())
+}
 
-	c.lastJobsScrapeDurationSecondsMetric.Set(time.Since(begun).Seconds())
-	c.lastJobsScrapeDurationSecondsMetric.Collect(ch)
+func (c JobsCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	c.shieldClient = c.shieldClient.WithContext(ctx)
+	c.Collect(ch)
 }
 
 func (c JobsCollector) Describe(ch chan<- *prometheus.Desc) {
-	c.jobLastRunMetric.Describe(ch)
-	c.jobNextRunMetric.Describe(ch)
-	c.jobStatusMetric.Describe(ch)
-	c.jobPausedMetric.Describe(ch)
-	c.jobsTotalMetric.Describe(ch)
+	ch <- c.jobLastRunDesc
+	ch <- c.jobNextRunDesc
+	ch <- c.jobSecondsSinceLastRunDesc
+	ch <- c.jobSecondsUntilNextRunDesc
+	ch <- c.jobStatusDesc
+	ch <- c.jobPausedDesc
+	ch <- c.jobsTotalDesc
+	ch <- c.jobsPausedTotalDesc
+	ch <- c.jobSLAMetDesc
+	ch <- c.collectorAvailableDesc
 	c.jobsScrapesTotalMetric.Describe(ch)
 	c.jobsScrapeErrorsTotalMetric.Describe(ch)
-	c.lastJobsScrapeErrorMetric.Describe(ch)
-	c.lastJobsScrapeTimestampMetric.Describe(ch)
-	c.lastJobsScrapeDurationSecondsMetric.Describe(ch)
+	c.scrapeErrorsByReasonTotalMetric.Describe(ch)
+	ch <- c.lastJobsScrapeErrorDesc
+	ch <- c.lastJobsScrapeTimestampDesc
+	ch <- c.lastJobsScrapeDurationSecondsDesc
 }
 
 func (c JobsCollector) reportJobsMetrics(ch chan<- prometheus.Metric) error {
-	c.jobLastRunMetric.Reset()
-	c.jobNextRunMetric.Reset()
-	c.jobStatusMetric.Reset()
-	c.jobPausedMetric.Reset()
-	c.jobsTotalMetric.Reset()
-
-	jobs, err := api.GetJobs(api.JobFilter{})
+	jobs, err := c.shieldClient.GetJobs()
 	if err != nil {
-		log.Errorf("Error while listing jobs: %v", err)
+		logError(err, "Error while listing jobs: %v", err)
 		return err
 	}
 
+	jobsPausedTotal := float64(0)
+	jobsTotal := make(map[jobLabels]float64)
 	for _, job := range jobs {
-		c.jobsTotalMetric.WithLabelValues(strconv.FormatBool(job.Paused), job.StorePlugin, job.TargetPlugin).Inc()
+		jobsTotal[jobLabels{job.Paused, job.StorePlugin, job.TargetPlugin}]++
+		if job.Paused {
+			jobsPausedTotal++
+		}
 	}
+	ch <- prometheus.MustNewConstMetric(c.jobsPausedTotalDesc, prometheus.GaugeValue, jobsPausedTotal)
 
-	c.jobsTotalMetric.Collect(ch)
+	for labels, total := range jobsTotal {
+		ch <- prometheus.MustNewConstMetric(
+			c.jobsTotalDesc,
+			prometheus.GaugeValue,
+			total,
+			strconv.FormatBool(labels.paused),
+			labels.storePlugin,
+			labels.targetPlugin,
+		)
+	}
 
-	jobsStatus, err := api.GetJobsStatus()
+	if c.slaEnabled {
+		if err := c.reportJobsSLAMetrics(ch, jobs); err != nil {
+			return err
+		}
+	}
+
+	jobsStatus, err := c.shieldClient.GetJobsStatus()
 	if err != nil {
-		if strings.Contains(err.Error(), "Error 501 Not Implemented") {
-			log.Debug("Shield backend does not implement `/v1/status/jobs` API")
+		if client.IsNotImplemented(err) {
+			log.Debug("Shield backend does not implement `/v1/status/jobs` API, predicting the next runs from the schedules")
+			c.reportJobsNextRunMetrics(ch, jobs, time.Now().UTC())
 			return nil
 		}
-		log.Errorf("Error while getting jobs status: %+v", err)
+		logError(err, "Error while getting jobs status: %+v", err)
 		return err
 	}
 
+	jobsByName := make(map[string]api.Job)
+	for _, job := range jobs {
+		jobsByName[job.Name] = job
+	}
+
+	now := time.Now()
 	for _, jobHealth := range jobsStatus {
-		c.jobLastRunMetric.WithLabelValues(jobHealth.Name).Set(float64(jobHealth.LastRun))
-		c.jobNextRunMetric.WithLabelValues(jobHealth.Name).Set(float64(jobHealth.NextRun))
+		job, ok := jobsByName[jobHealth.Name]
+		if !ok {
+			job = api.Job{Name: jobHealth.Name}
+		}
+		labelValues := jobLabelValues(job, c.legacyJobLabels)
+
+		ch <- prometheus.MustNewConstMetric(c.jobLastRunDesc, prometheus.GaugeValue, float64(jobHealth.LastRun), labelValues...)
+		ch <- prometheus.MustNewConstMetric(c.jobNextRunDesc, prometheus.GaugeValue, float64(jobHealth.NextRun), labelValues...)
+
+		if jobHealth.LastRun > 0 {
+			ch <- prometheus.MustNewConstMetric(c.jobSecondsSinceLastRunDesc, prometheus.GaugeValue, now.Sub(time.Unix(jobHealth.LastRun, 0)).Seconds(), labelValues...)
+		}
+		if jobHealth.NextRun > 0 {
+			ch <- prometheus.MustNewConstMetric(c.jobSecondsUntilNextRunDesc, prometheus.GaugeValue, time.Unix(jobHealth.NextRun, 0).Sub(now).Seconds(), labelValues...)
+		}
+
 		var jobStatus float64
 		switch jobHealth.Status {
 		case PendingStatus:
@@ -244,18 +340,342 @@ func (c JobsCollector) reportJobsMetrics(ch chan<- prometheus.Metric) error {
 		default:
 			jobStatus = 0
 		}
-		c.jobStatusMetric.WithLabelValues(jobHealth.Name).Set(jobStatus)
+		ch <- prometheus.MustNewConstMetric(c.jobStatusDesc, prometheus.GaugeValue, jobStatus, labelValues...)
+
 		jobPaused := 0
 		if jobHealth.Paused {
 			jobPaused = 1
 		}
-		c.jobPausedMetric.WithLabelValues(jobHealth.Name).Set(float64(jobPaused))
+		ch <- prometheus.MustNewConstMetric(c.jobPausedDesc, prometheus.GaugeValue, float64(jobPaused), labelValues...)
+	}
+
+	return nil
+}
+
+func jobLabelValues(job api.Job, legacyJobLabels bool) []string {
+	if legacyJobLabels {
+		return []string{job.Name}
 	}
+	return []string{job.Name, job.UUID, job.TargetName, job.StoreName}
+}
 
-	c.jobLastRunMetric.Collect(ch)
-	c.jobNextRunMetric.Collect(ch)
-	c.jobStatusMetric.Collect(ch)
-	c.jobPausedMetric.Collect(ch)
+// reportJobsNextRunMetrics reports the next run of every job predicted from
+// its schedule, for Shield backends not implementing the jobs status API.
+func (c JobsCollector) reportJobsNextRunMetrics(ch chan<- prometheus.Metric, jobs []api.Job, now time.Time) {
+	for _, job := range jobs {
+		if job.Paused {
+			continue
+		}
+
+		nextRun, ok := scheduleNextRun(job.ScheduleWhen, now)
+		if !ok {
+			log.Debugf("Unable to predict the next run of job `%s` from its schedule `%s`", job.Name, job.ScheduleWhen)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.jobNextRunDesc, prometheus.GaugeValue, float64(nextRun.Unix()), jobLabelValues(job, c.legacyJobLabels)...)
+		ch <- prometheus.MustNewConstMetric(c.jobSecondsUntilNextRunDesc, prometheus.GaugeValue, nextRun.Sub(now).Seconds(), jobLabelValues(job, c.legacyJobLabels)...)
+	}
+}
+
+// reportJobsSLAMetrics reports whether the latest valid archive of every job,
+// ie of its target and store, is more recent than the SLA of the job.
+func (c JobsCollector) reportJobsSLAMetrics(ch chan<- prometheus.Metric, jobs []api.Job) error {
+	type archiveKey struct {
+		targetUUID string
+		storeUUID  string
+	}
+
+	lastArchives := make(map[archiveKey]int64)
+	err := c.shieldClient.ForEachArchive(func(archive client.Archive) {
+		if archive.Status != ValidArchiveStatus || archive.TakenAt.IsZero() {
+			return
+		}
+		key := archiveKey{archive.TargetUUID, archive.StoreUUID}
+		if takenAt := archive.TakenAt.Time().Unix(); takenAt > lastArchives[key] {
+			lastArchives[key] = takenAt
+		}
+	})
+	if err != nil {
+		logError(err, "Error while listing archives: %v", err)
+		return err
+	}
+
+	now := time.Now()
+	for _, job := range jobs {
+		sla := c.slaMaxAge
+		if sla == 0 {
+			var ok bool
+			if sla, ok = scheduleInterval(job.ScheduleWhen); !ok {
+				log.Debugf("Unable to compute the SLA of job `%s` from its schedule `%s`", job.Name, job.ScheduleWhen)
+				continue
+			}
+		}
+
+		slaMet := float64(0)
+		if lastArchive, ok := lastArchives[archiveKey{job.TargetUUID, job.StoreUUID}]; ok && lastArchive >= now.Add(-sla).Unix() {
+			slaMet = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.jobSLAMetDesc, prometheus.GaugeValue, slaMet, jobLabelValues(job, c.legacyJobLabels)...)
+	}
 
 	return nil
 }
+
+// countJobsBy lists the jobs and counts them by the name of the resource
+// whose UUID is returned by uuid, ie their schedule or target, given the
+// names of the resources by UUID. Resources sharing a name are counted
+// together, and resources without jobs are counted as 0. It also returns the
+// number of resources without jobs.
+func countJobsBy(shieldClient *client.Client, names map[string]string, uuid func(job api.Job) string) (map[string]float64, float64, error) {
+	jobs, err := shieldClient.GetJobs()
+	if err != nil {
+		logError(err, "Error while listing jobs: %v", err)
+		return nil, 0, err
+	}
+
+	used := make(map[string]bool)
+	jobsTotal := make(map[string]float64)
+	for _, name := range names {
+		jobsTotal[name] = 0
+	}
+	for _, job := range jobs {
+		if name, ok := names[uuid(job)]; ok {
+			used[uuid(job)] = true
+			jobsTotal[name]++
+		}
+	}
+
+	return jobsTotal, float64(len(names) - len(used)), nil
+}
+
+// scheduleInterval returns the interval between two runs of a Shield
+// schedule, ie `daily at 4am`, `weekly at 2am on sunday` or `every 4 hours
+// from 1am`.
+func scheduleInterval(when string) (time.Duration, bool) {
+	const day = 24 * time.Hour
+
+	fields := strings.Fields(strings.ToLower(when))
+	if len(fields) == 0 {
+		return 0, false
+	}
+
+	switch fields[0] {
+	case "hourly":
+		return time.Hour, true
+	case "daily":
+		return day, true
+	case "weekly", "sunday", "sundays", "monday", "mondays", "tuesday", "tuesdays", "wednesday", "wednesdays",
+		"thursday", "thursdays", "friday", "fridays", "saturday", "saturdays":
+		return 7 * day, true
+	case "monthly":
+		return 31 * day, true
+	case "every":
+		count := 1
+		unit := fields[1:]
+		if len(unit) > 0 {
+			if n, err := strconv.Atoi(unit[0]); err == nil && n > 0 {
+				count = n
+				unit = unit[1:]
+			}
+		}
+		if len(unit) == 0 {
+			return 0, false
+		}
+		switch strings.TrimSuffix(unit[0], "s") {
+		case "minute":
+			return time.Duration(count) * time.Minute, true
+		case "hour":
+			return time.Duration(count) * time.Hour, true
+		case "day":
+			return time.Duration(count) * day, true
+		case "week":
+			return time.Duration(count) * 7 * day, true
+		}
+	}
+
+	return 0, false
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+func isWeekday(field string) bool {
+	_, ok := weekdays[strings.TrimSuffix(field, "s")]
+	return ok
+}
+
+// scheduleNextRun predicts the first run after now of a Shield schedule, ie
+// `daily at 4am`, `hourly at :15`, `weekly at 2am on sunday`, `sundays at
+// 2am`, `monthly at 3am on 1st` or `every 4 hours from 1am`, in the time zone
+// of now.
+func scheduleNextRun(when string, now time.Time) (time.Time, bool) {
+	var (
+		kind         string
+		every        int
+		unit         string
+		hour, minute int
+		timeOfDay    bool
+		weekday      = time.Weekday(-1)
+		monthDay     = 1
+	)
+
+	fields := strings.Fields(strings.ToLower(when))
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		switch {
+		case field == "at" || field == "on" || field == "the" || field == "from" || field == "starting":
+		case field == "hourly" || field == "daily" || field == "weekly" || field == "monthly":
+			kind = field
+		case field == "every":
+			kind = field
+			every = 1
+			if i+1 < len(fields) {
+				if n, err := strconv.Atoi(fields[i+1]); err == nil && n > 0 {
+					every = n
+					i++
+				}
+			}
+			if i+1 >= len(fields) {
+				return time.Time{}, false
+			}
+			unit = strings.TrimSuffix(fields[i+1], "s")
+			i++
+		case isWeekday(field):
+			weekday = weekdays[strings.TrimSuffix(field, "s")]
+			if kind == "" {
+				kind = "weekly"
+			}
+		case strings.HasSuffix(field, "st") || strings.HasSuffix(field, "nd") || strings.HasSuffix(field, "rd") || strings.HasSuffix(field, "th"):
+			n, err := strconv.Atoi(field[:len(field)-2])
+			if err != nil || n < 1 || n > 31 {
+				return time.Time{}, false
+			}
+			monthDay = n
+		default:
+			if i+1 < len(fields) && (fields[i+1] == "am" || fields[i+1] == "pm") {
+				field += fields[i+1]
+				i++
+			}
+			h, m, ok := parseTimeOfDay(field, kind == "hourly")
+			if !ok {
+				return time.Time{}, false
+			}
+			hour, minute, timeOfDay = h, m, true
+		}
+	}
+
+	at := func(day time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, now.Location())
+	}
+
+	switch kind {
+	case "hourly":
+		next := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), minute, 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.Add(time.Hour)
+		}
+		return next, true
+	case "daily":
+		next := at(now)
+		if !next.After(now) {
+			next = at(now.AddDate(0, 0, 1))
+		}
+		return next, true
+	case "weekly":
+		if weekday < 0 {
+			weekday = time.Sunday
+		}
+		next := at(now.AddDate(0, 0, (int(weekday)-int(now.Weekday())+7)%7))
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 7)
+		}
+		return next, true
+	case "monthly":
+		for months := 0; months <= 12; months++ {
+			month := time.Date(now.Year(), now.Month()+time.Month(months), 1, 0, 0, 0, 0, now.Location())
+			if monthDay > time.Date(month.Year(), month.Month()+1, 0, 0, 0, 0, 0, now.Location()).Day() {
+				continue
+			}
+			if next := at(month.AddDate(0, 0, monthDay-1)); next.After(now) {
+				return next, true
+			}
+		}
+	case "every":
+		var interval time.Duration
+		switch unit {
+		case "minute":
+			interval = time.Duration(every) * time.Minute
+		case "hour":
+			interval = time.Duration(every) * time.Hour
+		case "day":
+			interval = time.Duration(every) * 24 * time.Hour
+		default:
+			return time.Time{}, false
+		}
+		if !timeOfDay {
+			hour, minute = 0, 0
+		}
+		next := at(now)
+		if next.After(now) {
+			next = next.Add(-next.Sub(now) / interval * interval)
+			if !next.After(now) {
+				next = next.Add(interval)
+			}
+		} else {
+			next = next.Add((now.Sub(next)/interval + 1) * interval)
+		}
+		return next, true
+	}
+
+	return time.Time{}, false
+}
+
+// parseTimeOfDay parses a time of day of a Shield schedule, ie `4am`,
+// `4:30pm` or `16:30`, or the minutes of an hourly schedule, ie `:15` or
+// `15`.
+func parseTimeOfDay(field string, hourly bool) (int, int, bool) {
+	var pm, am bool
+	if strings.HasSuffix(field, "pm") {
+		pm, field = true, strings.TrimSuffix(field, "pm")
+	} else if strings.HasSuffix(field, "am") {
+		am, field = true, strings.TrimSuffix(field, "am")
+	}
+
+	hourPart, minutePart := field, "0"
+	if i := strings.Index(field, ":"); i >= 0 {
+		hourPart, minutePart = field[:i], field[i+1:]
+	} else if hourly && !am && !pm {
+		hourPart, minutePart = "0", field
+	}
+	if hourPart == "" {
+		hourPart = "0"
+	}
+
+	hour, err := strconv.Atoi(hourPart)
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, false
+	}
+	minute, err := strconv.Atoi(minutePart)
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, 0, false
+	}
+
+	if am || pm {
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour %= 12
+		if pm {
+			hour += 12
+		}
+	}
+
+	return hour, minute, true
+}
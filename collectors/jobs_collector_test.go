@@ -3,15 +3,19 @@ package collectors_test
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/ghttp"
 
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/log"
+	"github.com/starkandwayne/goutils/timestamp"
 	"github.com/starkandwayne/shield/api"
 
+	"github.com/bosh-prometheus/shield_exporter/client"
 	. "github.com/bosh-prometheus/shield_exporter/collectors"
 	. "github.com/bosh-prometheus/shield_exporter/utils/test_matchers"
 )
@@ -25,6 +29,8 @@ var _ = Describe("JobsCollectors", func() {
 		err    error
 		server *ghttp.Server
 
+		shieldClient *client.Client
+
 		namespace   = "test_namespace"
 		environment = "test_environment"
 		backendName = "test_backend"
@@ -46,41 +52,49 @@ var _ = Describe("JobsCollectors", func() {
 		lastRun2      = 2
 		nextRun1      = 3
 		nextRun2      = 4
+		jobUUID1      = "fake_job_uuid_1"
+		jobUUID2      = "fake_job_uuid_2"
+		targetName1   = "fake_target_1"
+		targetName2   = "fake_target_2"
+		storeName     = "fake_store"
+
+		jobLabels1 = []string{jobName1, jobUUID1, targetName1, storeName}
+		jobLabels2 = []string{jobName2, jobUUID2, targetName2, storeName}
 
 		jobLastRunMetric                    *prometheus.GaugeVec
 		jobNextRunMetric                    *prometheus.GaugeVec
+		jobSecondsSinceLastRunMetric        *prometheus.GaugeVec
+		jobSecondsUntilNextRunMetric        *prometheus.GaugeVec
 		jobStatusMetric                     *prometheus.GaugeVec
 		jobPausedMetric                     *prometheus.GaugeVec
 		jobsTotalMetric                     *prometheus.GaugeVec
+		jobsPausedTotalMetric               prometheus.Gauge
+		jobSLAMetMetric                     *prometheus.GaugeVec
 		jobsScrapesTotalMetric              prometheus.Counter
 		jobsScrapeErrorsTotalMetric         prometheus.Counter
 		lastJobsScrapeErrorMetric           prometheus.Gauge
 		lastJobsScrapeTimestampMetric       prometheus.Gauge
 		lastJobsScrapeDurationSecondsMetric prometheus.Gauge
 
+		slaEnabled bool
+		slaMaxAge  time.Duration
+
+		legacyJobLabels bool
+
 		jobsCollector *JobsCollector
 	)
 
 	BeforeEach(func() {
-		server = ghttp.NewServer()
-		server.AppendHandlers(
-			ghttp.CombineHandlers(
-				ghttp.VerifyRequest("GET", "/v1/ping"),
-				ghttp.RespondWith(http.StatusOK, "{}"),
-			),
-		)
-
-		api.Cfg = &api.Config{
-			Backend:  "default",
-			Backends: map[string]string{},
-			Aliases:  map[string]string{},
-		}
+		slaEnabled = false
+		slaMaxAge = 0
+		legacyJobLabels = false
 
-		err = api.Cfg.AddBackend(server.URL(), "default")
-		Expect(err).ToNot(HaveOccurred())
-
-		authToken := api.BasicAuthToken(username, password)
-		err = api.Cfg.UpdateBackend("default", authToken)
+		server = ghttp.NewServer()
+		shieldClient, err = client.NewClient(client.Config{
+			BackendURL: server.URL(),
+			Username:   username,
+			Password:   password,
+		})
 		Expect(err).ToNot(HaveOccurred())
 
 		jobLastRunMetric = prometheus.NewGaugeVec(
@@ -91,10 +105,10 @@ var _ = Describe("JobsCollectors", func() {
 				Help:        "Number of seconds since 1970 since last run of a Shield Job.",
 				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
 			},
-			[]string{"job_name"},
+			[]string{"job_name", "job_uuid", "target_name", "store_name"},
 		)
-		jobLastRunMetric.WithLabelValues(jobName1).Set(float64(lastRun1))
-		jobLastRunMetric.WithLabelValues(jobName2).Set(float64(lastRun2))
+		jobLastRunMetric.WithLabelValues(jobLabels1...).Set(float64(lastRun1))
+		jobLastRunMetric.WithLabelValues(jobLabels2...).Set(float64(lastRun2))
 
 		jobNextRunMetric = prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -104,10 +118,32 @@ var _ = Describe("JobsCollectors", func() {
 				Help:        "Number of seconds since 1970 until next run of a Shield Job.",
 				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
 			},
-			[]string{"job_name"},
+			[]string{"job_name", "job_uuid", "target_name", "store_name"},
+		)
+		jobNextRunMetric.WithLabelValues(jobLabels1...).Set(float64(nextRun1))
+		jobNextRunMetric.WithLabelValues(jobLabels2...).Set(float64(nextRun2))
+
+		jobSecondsSinceLastRunMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "job",
+				Name:        "seconds_since_last_run",
+				Help:        "Number of seconds since last run of a Shield Job.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"job_name", "job_uuid", "target_name", "store_name"},
+		)
+
+		jobSecondsUntilNextRunMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "job",
+				Name:        "seconds_until_next_run",
+				Help:        "Number of seconds until next run of a Shield Job.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"job_name", "job_uuid", "target_name", "store_name"},
 		)
-		jobNextRunMetric.WithLabelValues(jobName1).Set(float64(nextRun1))
-		jobNextRunMetric.WithLabelValues(jobName2).Set(float64(nextRun2))
 
 		jobStatusMetric = prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -117,10 +153,10 @@ var _ = Describe("JobsCollectors", func() {
 				Help:        "Shield Job status (0 for unknow, 1 for pending, 2 for running, 3 for canceled, 4 for failed, 5 for done).",
 				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
 			},
-			[]string{"job_name"},
+			[]string{"job_name", "job_uuid", "target_name", "store_name"},
 		)
-		jobStatusMetric.WithLabelValues(jobName1).Set(float64(5))
-		jobStatusMetric.WithLabelValues(jobName2).Set(float64(4))
+		jobStatusMetric.WithLabelValues(jobLabels1...).Set(float64(5))
+		jobStatusMetric.WithLabelValues(jobLabels2...).Set(float64(4))
 
 		jobPausedMetric = prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -130,10 +166,10 @@ var _ = Describe("JobsCollectors", func() {
 				Help:        "Shield Job pause status (1 for paused, 0 for unpaused).",
 				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
 			},
-			[]string{"job_name"},
+			[]string{"job_name", "job_uuid", "target_name", "store_name"},
 		)
-		jobPausedMetric.WithLabelValues(jobName1).Set(float64(1))
-		jobPausedMetric.WithLabelValues(jobName2).Set(float64(0))
+		jobPausedMetric.WithLabelValues(jobLabels1...).Set(float64(1))
+		jobPausedMetric.WithLabelValues(jobLabels2...).Set(float64(0))
 
 		jobsTotalMetric = prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -150,6 +186,30 @@ var _ = Describe("JobsCollectors", func() {
 		jobsTotalMetric.WithLabelValues(strconv.FormatBool(jobPaused1), storePlugin2, targetPlugin1).Set(1)
 		jobsTotalMetric.WithLabelValues(strconv.FormatBool(jobPaused2), storePlugin2, targetPlugin2).Set(1)
 
+		jobsPausedTotalMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "jobs",
+				Name:        "paused_total",
+				Help:        "Total number of paused Shield Jobs.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+		jobsPausedTotalMetric.Set(2)
+
+		jobSLAMetMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "job",
+				Name:        "sla_met",
+				Help:        "Whether a Shield Job has a valid archive more recent than its SLA (1 for met, 0 for not met).",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"job_name", "job_uuid", "target_name", "store_name"},
+		)
+		jobSLAMetMetric.WithLabelValues(jobLabels1...).Set(1)
+		jobSLAMetMetric.WithLabelValues(jobLabels2...).Set(0)
+
 		jobsScrapesTotalMetric = prometheus.NewCounter(
 			prometheus.CounterOpts{
 				Namespace:   namespace,
@@ -203,7 +263,7 @@ var _ = Describe("JobsCollectors", func() {
 	})
 
 	JustBeforeEach(func() {
-		jobsCollector = NewJobsCollector(namespace, environment, backendName)
+		jobsCollector = NewJobsCollector(namespace, environment, backendName, shieldClient, slaEnabled, slaMaxAge, legacyJobLabels)
 	})
 
 	AfterEach(func() {
@@ -224,25 +284,41 @@ var _ = Describe("JobsCollectors", func() {
 		})
 
 		It("returns a job_last_run metric description", func() {
-			Eventually(descriptions).Should(Receive(Equal(jobLastRunMetric.WithLabelValues(jobName1).Desc())))
+			Eventually(descriptions).Should(Receive(Equal(jobLastRunMetric.WithLabelValues(jobLabels1...).Desc())))
 		})
 
 		It("returns a job_next_run metric description", func() {
-			Eventually(descriptions).Should(Receive(Equal(jobNextRunMetric.WithLabelValues(jobName1).Desc())))
+			Eventually(descriptions).Should(Receive(Equal(jobNextRunMetric.WithLabelValues(jobLabels1...).Desc())))
+		})
+
+		It("returns a job_seconds_since_last_run metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(jobSecondsSinceLastRunMetric.WithLabelValues(jobLabels1...).Desc())))
+		})
+
+		It("returns a job_seconds_until_next_run metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(jobSecondsUntilNextRunMetric.WithLabelValues(jobLabels1...).Desc())))
 		})
 
 		It("returns a job_status metric description", func() {
-			Eventually(descriptions).Should(Receive(Equal(jobStatusMetric.WithLabelValues(jobName1).Desc())))
+			Eventually(descriptions).Should(Receive(Equal(jobStatusMetric.WithLabelValues(jobLabels1...).Desc())))
 		})
 
 		It("returns a job_paused metric description", func() {
-			Eventually(descriptions).Should(Receive(Equal(jobPausedMetric.WithLabelValues(jobName1).Desc())))
+			Eventually(descriptions).Should(Receive(Equal(jobPausedMetric.WithLabelValues(jobLabels1...).Desc())))
 		})
 
 		It("returns a jobs_total metric description", func() {
 			Eventually(descriptions).Should(Receive(Equal(jobsTotalMetric.WithLabelValues(strconv.FormatBool(jobPaused1), storePlugin1, targetPlugin1).Desc())))
 		})
 
+		It("returns a jobs_paused_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(jobsPausedTotalMetric.Desc())))
+		})
+
+		It("returns a job_sla_met metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(jobSLAMetMetric.WithLabelValues(jobLabels1...).Desc())))
+		})
+
 		It("returns a jobs_scrapes_total metric description", func() {
 			Eventually(descriptions).Should(Receive(Equal(jobsScrapesTotalMetric.Desc())))
 		})
@@ -270,21 +346,34 @@ var _ = Describe("JobsCollectors", func() {
 			statusJobsStatusCode int
 			jobsResponse         []api.Job
 			jobsStatusResponse   api.JobsStatus
+			archivesStatusCode   int
+			archivesResponse     []client.Archive
 			metrics              chan prometheus.Metric
+			jobGauges            func(desc *prometheus.Desc) map[string]float64
 		)
 
 		BeforeEach(func() {
 			jobsStatusCode = http.StatusOK
 			statusJobsStatusCode = http.StatusOK
+			archivesStatusCode = http.StatusOK
+			archivesResponse = []client.Archive{}
 			jobsResponse = []api.Job{
 				api.Job{
+					UUID:         jobUUID1,
+					Name:         jobName1,
 					Paused:       jobPaused1,
+					StoreName:    storeName,
 					StorePlugin:  storePlugin1,
+					TargetName:   targetName1,
 					TargetPlugin: targetPlugin1,
 				},
 				api.Job{
+					UUID:         jobUUID2,
+					Name:         jobName2,
 					Paused:       jobPaused2,
+					StoreName:    storeName,
 					StorePlugin:  storePlugin1,
+					TargetName:   targetName2,
 					TargetPlugin: targetPlugin2,
 				},
 				api.Job{
@@ -315,6 +404,31 @@ var _ = Describe("JobsCollectors", func() {
 				},
 			}
 			metrics = make(chan prometheus.Metric)
+
+			// jobGauges receives the metrics until the jobs_scrapes_total one,
+			// which is sent after the job ones, and returns the values of the
+			// desc gauges by job name.
+			jobGauges = func(desc *prometheus.Desc) map[string]float64 {
+				values := make(map[string]float64)
+				for {
+					var metric prometheus.Metric
+					Eventually(metrics).Should(Receive(&metric))
+					if metric.Desc().String() == jobsScrapesTotalMetric.Desc().String() {
+						return values
+					}
+					if metric.Desc().String() != desc.String() {
+						continue
+					}
+
+					written := &dto.Metric{}
+					Expect(metric.Write(written)).To(Succeed())
+					for _, label := range written.GetLabel() {
+						if label.GetName() == "job_name" {
+							values[label.GetValue()] = written.GetGauge().GetValue()
+						}
+					}
+				}
+			}
 		})
 
 		JustBeforeEach(func() {
@@ -324,10 +438,17 @@ var _ = Describe("JobsCollectors", func() {
 					ghttp.VerifyBasicAuth(username, password),
 					ghttp.RespondWithJSONEncodedPtr(&jobsStatusCode, &jobsResponse),
 				),
-				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("GET", "/v1/ping"),
-					ghttp.RespondWith(http.StatusOK, "{}"),
-				),
+			)
+			if slaEnabled {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v1/archives"),
+						ghttp.VerifyBasicAuth(username, password),
+						ghttp.RespondWithJSONEncodedPtr(&archivesStatusCode, &archivesResponse),
+					),
+				)
+			}
+			server.AppendHandlers(
 				ghttp.CombineHandlers(
 					ghttp.VerifyRequest("GET", "/v1/status/jobs"),
 					ghttp.VerifyBasicAuth(username, password),
@@ -338,35 +459,114 @@ var _ = Describe("JobsCollectors", func() {
 		})
 
 		It("returns a job_last_run metric for job name 1", func() {
-			Eventually(metrics).Should(Receive(PrometheusMetric(jobLastRunMetric.WithLabelValues(jobName1))))
+			Eventually(metrics).Should(Receive(PrometheusMetric(jobLastRunMetric.WithLabelValues(jobLabels1...))))
 		})
 
 		It("returns a job_last_run metric for job name 2", func() {
-			Eventually(metrics).Should(Receive(PrometheusMetric(jobLastRunMetric.WithLabelValues(jobName2))))
+			Eventually(metrics).Should(Receive(PrometheusMetric(jobLastRunMetric.WithLabelValues(jobLabels2...))))
 		})
 
 		It("returns a job_next_run metric job name 1", func() {
-			Eventually(metrics).Should(Receive(PrometheusMetric(jobNextRunMetric.WithLabelValues(jobName1))))
+			Eventually(metrics).Should(Receive(PrometheusMetric(jobNextRunMetric.WithLabelValues(jobLabels1...))))
 		})
 
 		It("returns a job_next_run metric job name 2", func() {
-			Eventually(metrics).Should(Receive(PrometheusMetric(jobNextRunMetric.WithLabelValues(jobName2))))
+			Eventually(metrics).Should(Receive(PrometheusMetric(jobNextRunMetric.WithLabelValues(jobLabels2...))))
+		})
+
+		It("returns job_seconds_since_last_run metrics relative to the scrape time", func() {
+			now := float64(time.Now().Unix())
+			values := jobGauges(jobSecondsSinceLastRunMetric.WithLabelValues(jobLabels1...).Desc())
+			Expect(values).To(HaveLen(2))
+			Expect(values[jobName1]).To(BeNumerically("~", now-float64(lastRun1), 5))
+			Expect(values[jobName2]).To(BeNumerically("~", now-float64(lastRun2), 5))
+		})
+
+		It("returns job_seconds_until_next_run metrics relative to the scrape time", func() {
+			now := float64(time.Now().Unix())
+			values := jobGauges(jobSecondsUntilNextRunMetric.WithLabelValues(jobLabels1...).Desc())
+			Expect(values).To(HaveLen(2))
+			Expect(values[jobName1]).To(BeNumerically("~", float64(nextRun1)-now, 5))
+			Expect(values[jobName2]).To(BeNumerically("~", float64(nextRun2)-now, 5))
+		})
+
+		Context("when a job has never run", func() {
+			BeforeEach(func() {
+				jobHealth := jobsStatusResponse[jobName1]
+				jobHealth.LastRun = 0
+				jobsStatusResponse[jobName1] = jobHealth
+			})
+
+			It("does not return a job_seconds_since_last_run metric for the job", func() {
+				values := jobGauges(jobSecondsSinceLastRunMetric.WithLabelValues(jobLabels1...).Desc())
+				Expect(values).ToNot(HaveKey(jobName1))
+				Expect(values).To(HaveKey(jobName2))
+			})
+		})
+
+		Context("when a job has no next run", func() {
+			BeforeEach(func() {
+				jobHealth := jobsStatusResponse[jobName1]
+				jobHealth.NextRun = 0
+				jobsStatusResponse[jobName1] = jobHealth
+			})
+
+			It("does not return a job_seconds_until_next_run metric for the job", func() {
+				values := jobGauges(jobSecondsUntilNextRunMetric.WithLabelValues(jobLabels1...).Desc())
+				Expect(values).ToNot(HaveKey(jobName1))
+				Expect(values).To(HaveKey(jobName2))
+			})
 		})
 
 		It("returns a job_status metric job name 1", func() {
-			Eventually(metrics).Should(Receive(PrometheusMetric(jobStatusMetric.WithLabelValues(jobName1))))
+			Eventually(metrics).Should(Receive(PrometheusMetric(jobStatusMetric.WithLabelValues(jobLabels1...))))
 		})
 
 		It("returns a job_status metric job name 2", func() {
-			Eventually(metrics).Should(Receive(PrometheusMetric(jobStatusMetric.WithLabelValues(jobName2))))
+			Eventually(metrics).Should(Receive(PrometheusMetric(jobStatusMetric.WithLabelValues(jobLabels2...))))
 		})
 
 		It("returns a job_paused metric job name 1", func() {
-			Eventually(metrics).Should(Receive(PrometheusMetric(jobPausedMetric.WithLabelValues(jobName1))))
+			Eventually(metrics).Should(Receive(PrometheusMetric(jobPausedMetric.WithLabelValues(jobLabels1...))))
 		})
 
 		It("returns a job_paused metric job name 2", func() {
-			Eventually(metrics).Should(Receive(PrometheusMetric(jobPausedMetric.WithLabelValues(jobName2))))
+			Eventually(metrics).Should(Receive(PrometheusMetric(jobPausedMetric.WithLabelValues(jobLabels2...))))
+		})
+
+		Context("when the legacy job labels are enabled", func() {
+			var legacyJobStatusMetric *prometheus.GaugeVec
+
+			BeforeEach(func() {
+				legacyJobLabels = true
+
+				legacyJobStatusMetric = prometheus.NewGaugeVec(
+					prometheus.GaugeOpts{
+						Namespace:   namespace,
+						Subsystem:   "job",
+						Name:        "status",
+						Help:        "Shield Job status (0 for unknow, 1 for pending, 2 for running, 3 for canceled, 4 for failed, 5 for done).",
+						ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+					},
+					[]string{"job_name"},
+				)
+				legacyJobStatusMetric.WithLabelValues(jobName1).Set(float64(5))
+			})
+
+			It("returns a job_status metric labeled with the job name only", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(legacyJobStatusMetric.WithLabelValues(jobName1))))
+			})
+		})
+
+		Context("when a job of the jobs status is not listed", func() {
+			BeforeEach(func() {
+				jobsResponse = jobsResponse[1:]
+				jobStatusMetric.WithLabelValues(jobName1, "", "", "").Set(float64(5))
+			})
+
+			It("returns a job_status metric without the job uuid, target and store names", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(jobStatusMetric.WithLabelValues(jobName1, "", "", ""))))
+			})
 		})
 
 		It("returns a jobs_total metric for job paused 1, store plugin 1, target plugin 1", func() {
@@ -397,6 +597,87 @@ var _ = Describe("JobsCollectors", func() {
 			Eventually(metrics).Should(Receive(PrometheusMetric(lastJobsScrapeErrorMetric)))
 		})
 
+		It("returns a jobs_paused_total metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(jobsPausedTotalMetric)))
+		})
+
+		It("does not return a job_sla_met metric when the SLA is not enabled", func() {
+			Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobSLAMetMetric.WithLabelValues(jobLabels1...))))
+		})
+
+		Context("when the SLA is enabled", func() {
+			BeforeEach(func() {
+				slaEnabled = true
+
+				now := time.Now()
+				archive := func(status string, targetUUID string, takenAt time.Time) client.Archive {
+					return client.Archive{
+						Archive: api.Archive{
+							Status:     status,
+							TargetUUID: targetUUID,
+							StoreUUID:  "store_uuid",
+							TakenAt:    timestamp.NewTimestamp(takenAt),
+						},
+					}
+				}
+
+				jobsResponse[0].Name = jobName1
+				jobsResponse[0].TargetUUID = "target_uuid_1"
+				jobsResponse[0].StoreUUID = "store_uuid"
+				jobsResponse[0].ScheduleWhen = "daily at 4am"
+				jobsResponse[1].Name = jobName2
+				jobsResponse[1].TargetUUID = "target_uuid_2"
+				jobsResponse[1].StoreUUID = "store_uuid"
+				jobsResponse[1].ScheduleWhen = "every 4 hours from 1am"
+				jobsResponse[2].Name = "fake_job_3"
+				jobsResponse[2].ScheduleWhen = "whenever"
+				archivesResponse = []client.Archive{
+					archive(ValidArchiveStatus, "target_uuid_1", now.Add(-time.Hour)),
+					archive(ValidArchiveStatus, "target_uuid_2", now.Add(-5*time.Hour)),
+					archive("failed", "target_uuid_2", now.Add(-time.Minute)),
+				}
+			})
+
+			It("returns a job_sla_met metric for a job with a valid archive more recent than its schedule interval", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(jobSLAMetMetric.WithLabelValues(jobLabels1...))))
+			})
+
+			It("returns a job_sla_met metric for a job without a valid archive more recent than its schedule interval", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(jobSLAMetMetric.WithLabelValues(jobLabels2...))))
+			})
+
+			It("does not return a job_sla_met metric for a job with an unknown schedule", func() {
+				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobSLAMetMetric.WithLabelValues("fake_job_3", "", "", ""))))
+			})
+
+			Context("and a max age is configured", func() {
+				BeforeEach(func() {
+					slaMaxAge = 6 * time.Hour
+					jobSLAMetMetric.WithLabelValues(jobLabels2...).Set(1)
+				})
+
+				It("returns a job_sla_met metric against the max age", func() {
+					Eventually(metrics).Should(Receive(PrometheusMetric(jobSLAMetMetric.WithLabelValues(jobLabels2...))))
+				})
+			})
+
+			Context("and it fails to list the archives", func() {
+				BeforeEach(func() {
+					archivesStatusCode = http.StatusInternalServerError
+					jobsScrapeErrorsTotalMetric.Inc()
+					lastJobsScrapeErrorMetric.Set(1)
+				})
+
+				It("returns a jobs_scrape_errors_total metric", func() {
+					Eventually(metrics).Should(Receive(PrometheusMetric(jobsScrapeErrorsTotalMetric)))
+				})
+
+				It("returns a last_jobs_scrape_error metric", func() {
+					Eventually(metrics).Should(Receive(PrometheusMetric(lastJobsScrapeErrorMetric)))
+				})
+			})
+		})
+
 		Context("when it fails to list the jobs", func() {
 			BeforeEach(func() {
 				jobsStatusCode = http.StatusInternalServerError
@@ -421,19 +702,19 @@ var _ = Describe("JobsCollectors", func() {
 			})
 
 			It("does not returns a job_last_run metric for job name 1", func() {
-				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobLastRunMetric.WithLabelValues(jobName1))))
+				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobLastRunMetric.WithLabelValues(jobLabels1...))))
 			})
 
 			It("does not returns a job_next_run metric job name 1", func() {
-				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobNextRunMetric.WithLabelValues(jobName1))))
+				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobNextRunMetric.WithLabelValues(jobLabels1...))))
 			})
 
 			It("does not returns a job_status metric for job name 1", func() {
-				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobStatusMetric.WithLabelValues(jobName1))))
+				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobStatusMetric.WithLabelValues(jobLabels1...))))
 			})
 
 			It("does not returns a job_paused metric for job name 1", func() {
-				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobPausedMetric.WithLabelValues(jobName1))))
+				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobPausedMetric.WithLabelValues(jobLabels1...))))
 			})
 
 			It("returns a jobs_total metric for job paused 1, store plugin 1, target plugin 1", func() {
@@ -454,20 +735,52 @@ var _ = Describe("JobsCollectors", func() {
 				statusJobsStatusCode = http.StatusNotImplemented
 			})
 
+			Context("when the jobs have a schedule", func() {
+				BeforeEach(func() {
+					jobsResponse = []api.Job{
+						api.Job{Name: jobName1, ScheduleWhen: "daily at 4am"},
+						api.Job{Name: jobName2, ScheduleWhen: "whenever"},
+						api.Job{Name: "paused_job", ScheduleWhen: "daily at 4am", Paused: true},
+					}
+				})
+
+				It("returns a job_next_run metric predicted from the schedule", func() {
+					now := time.Now().Unix()
+					runs := jobGauges(jobNextRunMetric.WithLabelValues(jobLabels1...).Desc())
+					Expect(runs).To(HaveKey(jobName1))
+					Expect(runs[jobName1]).To(BeNumerically(">", now))
+					Expect(runs[jobName1]).To(BeNumerically("<=", now+24*60*60))
+					Expect(int64(runs[jobName1]) % (24 * 60 * 60)).To(Equal(int64(4 * 60 * 60)))
+				})
+
+				It("returns a job_seconds_until_next_run metric predicted from the schedule", func() {
+					untilNextRuns := jobGauges(jobSecondsUntilNextRunMetric.WithLabelValues(jobLabels1...).Desc())
+					Expect(untilNextRuns).To(HaveKey(jobName1))
+					Expect(untilNextRuns[jobName1]).To(BeNumerically(">", 0))
+					Expect(untilNextRuns[jobName1]).To(BeNumerically("<=", 24*60*60))
+				})
+
+				It("does not return a job_next_run metric for the jobs paused or whose schedule is not recognized", func() {
+					runs := jobGauges(jobNextRunMetric.WithLabelValues(jobLabels1...).Desc())
+					Expect(runs).ToNot(HaveKey(jobName2))
+					Expect(runs).ToNot(HaveKey("paused_job"))
+				})
+			})
+
 			It("does not returns a job_last_run metric for job name 1", func() {
-				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobLastRunMetric.WithLabelValues(jobName1))))
+				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobLastRunMetric.WithLabelValues(jobLabels1...))))
 			})
 
 			It("does not returns a job_next_run metric job name 1", func() {
-				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobNextRunMetric.WithLabelValues(jobName1))))
+				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobNextRunMetric.WithLabelValues(jobLabels1...))))
 			})
 
 			It("does not returns a job_status metric for job name 1", func() {
-				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobStatusMetric.WithLabelValues(jobName1))))
+				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobStatusMetric.WithLabelValues(jobLabels1...))))
 			})
 
 			It("does not returns a job_paused metric for job name 1", func() {
-				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobPausedMetric.WithLabelValues(jobName1))))
+				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobPausedMetric.WithLabelValues(jobLabels1...))))
 			})
 
 			It("returns a jobs_total metric for job paused 1, store plugin 1, target plugin 1", func() {
@@ -483,4 +796,58 @@ var _ = Describe("JobsCollectors", func() {
 			})
 		})
 	})
+
+	Describe("Staleness", func() {
+		var (
+			registry        *prometheus.Registry
+			jobsStatusFirst api.JobsStatus
+			jobsStatusNext  api.JobsStatus
+		)
+
+		jobNames := func() []string {
+			mfs, err := registry.Gather()
+			Expect(err).ToNot(HaveOccurred())
+
+			names := []string{}
+			for _, mf := range mfs {
+				if mf.GetName() != namespace+"_job_status" {
+					continue
+				}
+				for _, metric := range mf.GetMetric() {
+					for _, label := range metric.GetLabel() {
+						if label.GetName() == "job_name" {
+							names = append(names, label.GetValue())
+						}
+					}
+				}
+			}
+			return names
+		}
+
+		BeforeEach(func() {
+			jobsStatusFirst = api.JobsStatus{
+				jobName1: api.JobHealth{Name: jobName1, Status: jobStatus1},
+				jobName2: api.JobHealth{Name: jobName2, Status: jobStatus2},
+			}
+			jobsStatusNext = api.JobsStatus{
+				jobName1: api.JobHealth{Name: jobName1, Status: jobStatus1},
+			}
+			server.AppendHandlers(
+				ghttp.RespondWithJSONEncoded(http.StatusOK, []api.Job{}),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, jobsStatusFirst),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, []api.Job{}),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, jobsStatusNext),
+			)
+		})
+
+		JustBeforeEach(func() {
+			registry = prometheus.NewRegistry()
+			registry.MustRegister(jobsCollector)
+		})
+
+		It("stops exporting the series of a job deleted between scrapes", func() {
+			Expect(jobNames()).To(ConsistOf(jobName1, jobName2))
+			Expect(jobNames()).To(ConsistOf(jobName1))
+		})
+	})
 })
@@ -0,0 +1,38 @@
+package collectors
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+)
+
+// maintenanceMetrics keeps the metrics built from the last successful scrape
+// of the streamed archives and tasks listings, which the client does not keep
+// in its response cache, so they are reported again while the Shield backend
+// is in maintenance mode, like the metrics of the other listings.
+type maintenanceMetrics struct {
+	sync.Mutex
+	metrics []prometheus.Metric
+}
+
+// report sends metrics to ch and keeps them when the scrape succeeded, or
+// sends the metrics kept from the last successful scrape again when it failed
+// because the Shield backend is in maintenance mode.
+func (m *maintenanceMetrics) report(ch chan<- prometheus.Metric, metrics []prometheus.Metric, err error) {
+	m.Lock()
+	switch {
+	case err == nil:
+		m.metrics = metrics
+	case client.IsMaintenance(err):
+		metrics = m.metrics
+	default:
+		metrics = nil
+	}
+	m.Unlock()
+
+	for _, metric := range metrics {
+		ch <- metric
+	}
+}
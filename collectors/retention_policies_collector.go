@@ -1,40 +1,63 @@
 package collectors
 
 import (
+	"context"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/common/log"
 	"github.com/starkandwayne/shield/api"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+	"github.com/bosh-prometheus/shield_exporter/filters"
 )
 
 type RetentionPoliciesCollector struct {
-	namespace                                        string
-	environment                                      string
-	backendName                                      string
-	retentionPoliciesTotalMetric                     prometheus.Gauge
-	retentionPoliciesScrapesTotalMetric              prometheus.Counter
-	retentionPoliciesScrapeErrorsTotalMetric         prometheus.Counter
-	lastRetentionPoliciesScrapeErrorMetric           prometheus.Gauge
-	lastRetentionPoliciesScrapeTimestampMetric       prometheus.Gauge
-	lastRetentionPoliciesScrapeDurationSecondsMetric prometheus.Gauge
+	namespace                                      string
+	environment                                    string
+	backendName                                    string
+	shieldClient                                   *client.Client
+	retentionPoliciesTotalDesc                     *prometheus.Desc
+	retentionPoliciesUnusedTotalDesc               *prometheus.Desc
+	jobsByRetentionPolicyTotalDesc                 *prometheus.Desc
+	collectorAvailableDesc                         *prometheus.Desc
+	retentionPoliciesScrapesTotalMetric            prometheus.Counter
+	retentionPoliciesScrapeErrorsTotalMetric       prometheus.Counter
+	scrapeErrorsByReasonTotalMetric                *prometheus.CounterVec
+	lastRetentionPoliciesScrapeErrorDesc           *prometheus.Desc
+	lastRetentionPoliciesScrapeTimestampDesc       *prometheus.Desc
+	lastRetentionPoliciesScrapeDurationSecondsDesc *prometheus.Desc
 }
 
 func NewRetentionPoliciesCollector(
 	namespace string,
 	environment string,
 	backendName string,
+	shieldClient *client.Client,
 ) *RetentionPoliciesCollector {
-	retentionPoliciesTotalMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "retention_policies",
-			Name:        "total",
-			Help:        "Total number of Shield Retention Policies.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	retentionPoliciesTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "retention_policies", "total"),
+		"Total number of Shield Retention Policies.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	jobsByRetentionPolicyTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "jobs", "by_retention_policy_total"),
+		"Total number of Shield Jobs using a Shield Retention Policy.",
+		[]string{"policy_name"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
+	retentionPoliciesUnusedTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "retention_policies", "unused_total"),
+		"Total number of Shield Retention Policies not used by any Shield Job.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	collectorAvailableDesc := newCollectorAvailableDesc(namespace, environment, backendName, filters.RetentionPoliciesCollector)
+	scrapeErrorsByReasonTotalMetric := newScrapeErrorsByReasonTotalMetric(namespace, environment, backendName, filters.RetentionPoliciesCollector)
+
 	retentionPoliciesScrapesTotalMetric := prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Namespace:   namespace,
@@ -55,46 +78,42 @@ func NewRetentionPoliciesCollector(
 		},
 	)
 
-	lastRetentionPoliciesScrapeErrorMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_retention_policies_scrape_error",
-			Help:        "Whether the last scrape of Retention Policies metrics from Shield resulted in an error (1 for error, 0 for success).",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastRetentionPoliciesScrapeErrorDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_retention_policies_scrape_error"),
+		"Whether the last scrape of Retention Policies metrics from Shield resulted in an error (1 for error, 0 for success).",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	lastRetentionPoliciesScrapeTimestampMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_retention_policies_scrape_timestamp",
-			Help:        "Number of seconds since 1970 since last scrape of Retention Policies metrics from Shield.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastRetentionPoliciesScrapeTimestampDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_retention_policies_scrape_timestamp"),
+		"Number of seconds since 1970 since last scrape of Retention Policies metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	lastRetentionPoliciesScrapeDurationSecondsMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_retention_policies_scrape_duration_seconds",
-			Help:        "Duration of the last scrape of Retention Policies metrics from Shield.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastRetentionPoliciesScrapeDurationSecondsDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_retention_policies_scrape_duration_seconds"),
+		"Duration of the last scrape of Retention Policies metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
 	return &RetentionPoliciesCollector{
-		namespace:                                        namespace,
-		environment:                                      environment,
-		backendName:                                      backendName,
-		retentionPoliciesTotalMetric:                     retentionPoliciesTotalMetric,
-		retentionPoliciesScrapesTotalMetric:              retentionPoliciesScrapesTotalMetric,
-		retentionPoliciesScrapeErrorsTotalMetric:         retentionPoliciesScrapeErrorsTotalMetric,
-		lastRetentionPoliciesScrapeErrorMetric:           lastRetentionPoliciesScrapeErrorMetric,
-		lastRetentionPoliciesScrapeTimestampMetric:       lastRetentionPoliciesScrapeTimestampMetric,
-		lastRetentionPoliciesScrapeDurationSecondsMetric: lastRetentionPoliciesScrapeDurationSecondsMetric,
+		namespace:                                      namespace,
+		environment:                                    environment,
+		backendName:                                    backendName,
+		shieldClient:                                   shieldClient,
+		retentionPoliciesTotalDesc:                     retentionPoliciesTotalDesc,
+		retentionPoliciesUnusedTotalDesc:               retentionPoliciesUnusedTotalDesc,
+		jobsByRetentionPolicyTotalDesc:                 jobsByRetentionPolicyTotalDesc,
+		collectorAvailableDesc:                         collectorAvailableDesc,
+		retentionPoliciesScrapesTotalMetric:            retentionPoliciesScrapesTotalMetric,
+		retentionPoliciesScrapeErrorsTotalMetric:       retentionPoliciesScrapeErrorsTotalMetric,
+		scrapeErrorsByReasonTotalMetric:                scrapeErrorsByReasonTotalMetric,
+		lastRetentionPoliciesScrapeErrorDesc:           lastRetentionPoliciesScrapeErrorDesc,
+		lastRetentionPoliciesScrapeTimestampDesc:       lastRetentionPoliciesScrapeTimestampDesc,
+		lastRetentionPoliciesScrapeDurationSecondsDesc: lastRetentionPoliciesScrapeDurationSecondsDesc,
 	}
 }
 
@@ -102,43 +121,66 @@ func (c RetentionPoliciesCollector) Collect(ch chan<- prometheus.Metric) {
 	var begun = time.Now()
 
 	errorMetric := float64(0)
-	if err := c.reportRetentionPoliciesMetrics(ch); err != nil {
+	err := c.reportRetentionPoliciesMetrics(ch)
+	if isScrapeError(err) {
 		errorMetric = float64(1)
 		c.retentionPoliciesScrapeErrorsTotalMetric.Inc()
+		c.scrapeErrorsByReasonTotalMetric.WithLabelValues(client.ErrorReason(err)).Inc()
 	}
 	c.retentionPoliciesScrapeErrorsTotalMetric.Collect(ch)
+	c.scrapeErrorsByReasonTotalMetric.Collect(ch)
 
 	c.retentionPoliciesScrapesTotalMetric.Inc()
 	c.retentionPoliciesScrapesTotalMetric.Collect(ch)
 
-	c.lastRetentionPoliciesScrapeErrorMetric.Set(errorMetric)
-	c.lastRetentionPoliciesScrapeErrorMetric.Collect(ch)
-
-	c.lastRetentionPoliciesScrapeTimestampMetric.Set(float64(time.Now().Unix()))
-	c.lastRetentionPoliciesScrapeTimestampMetric.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(c.collectorAvailableDesc, prometheus.GaugeValue, availability(err))
+	ch <- prometheus.MustNewConstMetric(c.lastRetentionPoliciesScrapeErrorDesc, prometheus.GaugeValue, errorMetric)
+	ch <- prometheus.MustNewConstMetric(c.lastRetentionPoliciesScrapeTimestampDesc, prometheus.GaugeValue, float64(time.Now().Unix()))
+	ch <- prometheus.MustNewConstMetric(c.lastRetentionPoliciesScrapeDurationSecondsDesc, prometheus.GaugeValue, time.Since(begun).Seconds())
+}
 
-	c.lastRetentionPoliciesScrapeDurationSecondsMetric.Set(time.Since(begun).Seconds())
-	c.lastRetentionPoliciesScrapeDurationSecondsMetric.Collect(ch)
+func (c RetentionPoliciesCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	c.shieldClient = c.shieldClient.WithContext(ctx)
+	c.Collect(ch)
 }
 
 func (c RetentionPoliciesCollector) Describe(ch chan<- *prometheus.Desc) {
-	c.retentionPoliciesTotalMetric.Describe(ch)
+	ch <- c.retentionPoliciesTotalDesc
+	ch <- c.retentionPoliciesUnusedTotalDesc
+	ch <- c.jobsByRetentionPolicyTotalDesc
+	ch <- c.collectorAvailableDesc
 	c.retentionPoliciesScrapesTotalMetric.Describe(ch)
 	c.retentionPoliciesScrapeErrorsTotalMetric.Describe(ch)
-	c.lastRetentionPoliciesScrapeErrorMetric.Describe(ch)
-	c.lastRetentionPoliciesScrapeTimestampMetric.Describe(ch)
-	c.lastRetentionPoliciesScrapeDurationSecondsMetric.Describe(ch)
+	c.scrapeErrorsByReasonTotalMetric.Describe(ch)
+	ch <- c.lastRetentionPoliciesScrapeErrorDesc
+	ch <- c.lastRetentionPoliciesScrapeTimestampDesc
+	ch <- c.lastRetentionPoliciesScrapeDurationSecondsDesc
 }
 
 func (c RetentionPoliciesCollector) reportRetentionPoliciesMetrics(ch chan<- prometheus.Metric) error {
-	retentionPolicies, err := api.GetRetentionPolicies(api.RetentionPolicyFilter{})
+	retentionPolicies, err := c.shieldClient.GetRetentionPolicies()
 	if err != nil {
-		log.Errorf("Error while listing retention policies: %v", err)
+		logError(err, "Error while listing retention policies: %v", err)
 		return err
 	}
 
-	c.retentionPoliciesTotalMetric.Set(float64(len(retentionPolicies)))
-	c.retentionPoliciesTotalMetric.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(c.retentionPoliciesTotalDesc, prometheus.GaugeValue, float64(len(retentionPolicies)))
+
+	retentionPolicyNames := make(map[string]string)
+	for _, retentionPolicy := range retentionPolicies {
+		retentionPolicyNames[retentionPolicy.UUID] = retentionPolicy.Name
+	}
+
+	jobsByRetentionPolicy, unused, err := countJobsBy(c.shieldClient, retentionPolicyNames, func(job api.Job) string { return job.RetentionUUID })
+	if err != nil {
+		return err
+	}
+
+	for name, total := range jobsByRetentionPolicy {
+		ch <- prometheus.MustNewConstMetric(c.jobsByRetentionPolicyTotalDesc, prometheus.GaugeValue, total, name)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.retentionPoliciesUnusedTotalDesc, prometheus.GaugeValue, unused)
 
 	return nil
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/prometheus/common/log"
 	"github.com/starkandwayne/shield/api"
 
+	"github.com/bosh-prometheus/shield_exporter/client"
 	. "github.com/bosh-prometheus/shield_exporter/collectors"
 	. "github.com/bosh-prometheus/shield_exporter/utils/test_matchers"
 )
@@ -24,6 +25,8 @@ var _ = Describe("RetentionPoliciesCollectors", func() {
 		err    error
 		server *ghttp.Server
 
+		shieldClient *client.Client
+
 		namespace   = "test_namespace"
 		environment = "test_environment"
 		backendName = "test_backend"
@@ -32,6 +35,8 @@ var _ = Describe("RetentionPoliciesCollectors", func() {
 		password = "fake_password"
 
 		retentionPoliciesTotalMetric                     prometheus.Gauge
+		retentionPoliciesUnusedTotalMetric               prometheus.Gauge
+		jobsByRetentionPolicyTotalMetric                 *prometheus.GaugeVec
 		retentionPoliciesScrapesTotalMetric              prometheus.Counter
 		retentionPoliciesScrapeErrorsTotalMetric         prometheus.Counter
 		lastRetentionPoliciesScrapeErrorMetric           prometheus.Gauge
@@ -43,24 +48,11 @@ var _ = Describe("RetentionPoliciesCollectors", func() {
 
 	BeforeEach(func() {
 		server = ghttp.NewServer()
-		server.AppendHandlers(
-			ghttp.CombineHandlers(
-				ghttp.VerifyRequest("GET", "/v1/ping"),
-				ghttp.RespondWith(http.StatusOK, "{}"),
-			),
-		)
-
-		api.Cfg = &api.Config{
-			Backend:  "default",
-			Backends: map[string]string{},
-			Aliases:  map[string]string{},
-		}
-
-		err = api.Cfg.AddBackend(server.URL(), "default")
-		Expect(err).ToNot(HaveOccurred())
-
-		authToken := api.BasicAuthToken(username, password)
-		err = api.Cfg.UpdateBackend("default", authToken)
+		shieldClient, err = client.NewClient(client.Config{
+			BackendURL: server.URL(),
+			Username:   username,
+			Password:   password,
+		})
 		Expect(err).ToNot(HaveOccurred())
 
 		retentionPoliciesTotalMetric = prometheus.NewGauge(
@@ -74,6 +66,30 @@ var _ = Describe("RetentionPoliciesCollectors", func() {
 		)
 		retentionPoliciesTotalMetric.Set(2)
 
+		jobsByRetentionPolicyTotalMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "jobs",
+				Name:        "by_retention_policy_total",
+				Help:        "Total number of Shield Jobs using a Shield Retention Policy.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"policy_name"},
+		)
+		jobsByRetentionPolicyTotalMetric.WithLabelValues("fake_retention_policiy_1").Set(2)
+		jobsByRetentionPolicyTotalMetric.WithLabelValues("fake_retention_policiy_2").Set(0)
+
+		retentionPoliciesUnusedTotalMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "retention_policies",
+				Name:        "unused_total",
+				Help:        "Total number of Shield Retention Policies not used by any Shield Job.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+		retentionPoliciesUnusedTotalMetric.Set(1)
+
 		retentionPoliciesScrapesTotalMetric = prometheus.NewCounter(
 			prometheus.CounterOpts{
 				Namespace:   namespace,
@@ -127,7 +143,7 @@ var _ = Describe("RetentionPoliciesCollectors", func() {
 	})
 
 	JustBeforeEach(func() {
-		retentionPoliciesCollector = NewRetentionPoliciesCollector(namespace, environment, backendName)
+		retentionPoliciesCollector = NewRetentionPoliciesCollector(namespace, environment, backendName, shieldClient)
 	})
 
 	AfterEach(func() {
@@ -151,6 +167,14 @@ var _ = Describe("RetentionPoliciesCollectors", func() {
 			Eventually(descriptions).Should(Receive(Equal(retentionPoliciesTotalMetric.Desc())))
 		})
 
+		It("returns a jobs_by_retention_policy_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(jobsByRetentionPolicyTotalMetric.WithLabelValues("fake_retention_policiy_1").Desc())))
+		})
+
+		It("returns a retention_policies_unused_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(retentionPoliciesUnusedTotalMetric.Desc())))
+		})
+
 		It("returns a retention_policies_scrapes_total metric description", func() {
 			Eventually(descriptions).Should(Receive(Equal(retentionPoliciesScrapesTotalMetric.Desc())))
 		})
@@ -176,6 +200,8 @@ var _ = Describe("RetentionPoliciesCollectors", func() {
 		var (
 			statusCode                int
 			retentionPoliciesResponse []api.RetentionPolicy
+			jobsStatusCode            int
+			jobsResponse              []api.Job
 			metrics                   chan prometheus.Metric
 		)
 
@@ -183,12 +209,19 @@ var _ = Describe("RetentionPoliciesCollectors", func() {
 			statusCode = http.StatusOK
 			retentionPoliciesResponse = []api.RetentionPolicy{
 				api.RetentionPolicy{
+					UUID: "fake_retention_policiy_uuid_1",
 					Name: "fake_retention_policiy_1",
 				},
 				api.RetentionPolicy{
+					UUID: "fake_retention_policiy_uuid_2",
 					Name: "fake_retention_policiy_2",
 				},
 			}
+			jobsStatusCode = http.StatusOK
+			jobsResponse = []api.Job{
+				api.Job{RetentionUUID: "fake_retention_policiy_uuid_1"},
+				api.Job{RetentionUUID: "fake_retention_policiy_uuid_1"},
+			}
 			metrics = make(chan prometheus.Metric)
 		})
 
@@ -199,6 +232,11 @@ var _ = Describe("RetentionPoliciesCollectors", func() {
 					ghttp.VerifyBasicAuth(username, password),
 					ghttp.RespondWithJSONEncodedPtr(&statusCode, &retentionPoliciesResponse),
 				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/jobs"),
+					ghttp.VerifyBasicAuth(username, password),
+					ghttp.RespondWithJSONEncodedPtr(&jobsStatusCode, &jobsResponse),
+				),
 			)
 			go retentionPoliciesCollector.Collect(metrics)
 		})
@@ -207,6 +245,18 @@ var _ = Describe("RetentionPoliciesCollectors", func() {
 			Eventually(metrics).Should(Receive(PrometheusMetric(retentionPoliciesTotalMetric)))
 		})
 
+		It("returns a jobs_by_retention_policy_total metric for a retention policy with jobs", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(jobsByRetentionPolicyTotalMetric.WithLabelValues("fake_retention_policiy_1"))))
+		})
+
+		It("returns a jobs_by_retention_policy_total metric for an unused retention policy", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(jobsByRetentionPolicyTotalMetric.WithLabelValues("fake_retention_policiy_2"))))
+		})
+
+		It("returns a retention_policies_unused_total metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(retentionPoliciesUnusedTotalMetric)))
+		})
+
 		It("returns a retention_policiess_scrapes_total metric", func() {
 			Eventually(metrics).Should(Receive(PrometheusMetric(retentionPoliciesScrapesTotalMetric)))
 		})
@@ -219,6 +269,22 @@ var _ = Describe("RetentionPoliciesCollectors", func() {
 			Eventually(metrics).Should(Receive(PrometheusMetric(lastRetentionPoliciesScrapeErrorMetric)))
 		})
 
+		Context("when it fails to list the jobs", func() {
+			BeforeEach(func() {
+				jobsStatusCode = http.StatusInternalServerError
+				retentionPoliciesScrapeErrorsTotalMetric.Inc()
+				lastRetentionPoliciesScrapeErrorMetric.Set(1)
+			})
+
+			It("returns a retention_policies_scrape_errors_total metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(retentionPoliciesScrapeErrorsTotalMetric)))
+			})
+
+			It("returns a last_retention_policies_scrape_error metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(lastRetentionPoliciesScrapeErrorMetric)))
+			})
+		})
+
 		Context("when it fails to list the retention policies", func() {
 			BeforeEach(func() {
 				statusCode = http.StatusInternalServerError
@@ -1,40 +1,63 @@
 package collectors
 
 import (
+	"context"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/common/log"
 	"github.com/starkandwayne/shield/api"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+	"github.com/bosh-prometheus/shield_exporter/filters"
 )
 
 type SchedulesCollector struct {
-	namespace                                string
-	environment                              string
-	backendName                              string
-	schedulesTotalMetric                     prometheus.Gauge
-	schedulesScrapesTotalMetric              prometheus.Counter
-	schedulesScrapeErrorsTotalMetric         prometheus.Counter
-	lastSchedulesScrapeErrorMetric           prometheus.Gauge
-	lastSchedulesScrapeTimestampMetric       prometheus.Gauge
-	lastSchedulesScrapeDurationSecondsMetric prometheus.Gauge
+	namespace                              string
+	environment                            string
+	backendName                            string
+	shieldClient                           *client.Client
+	schedulesTotalDesc                     *prometheus.Desc
+	schedulesUnusedTotalDesc               *prometheus.Desc
+	jobsByScheduleTotalDesc                *prometheus.Desc
+	collectorAvailableDesc                 *prometheus.Desc
+	schedulesScrapesTotalMetric            prometheus.Counter
+	schedulesScrapeErrorsTotalMetric       prometheus.Counter
+	scrapeErrorsByReasonTotalMetric        *prometheus.CounterVec
+	lastSchedulesScrapeErrorDesc           *prometheus.Desc
+	lastSchedulesScrapeTimestampDesc       *prometheus.Desc
+	lastSchedulesScrapeDurationSecondsDesc *prometheus.Desc
 }
 
 func NewSchedulesCollector(
 	namespace string,
 	environment string,
 	backendName string,
+	shieldClient *client.Client,
 ) *SchedulesCollector {
-	schedulesTotalMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "schedules",
-			Name:        "total",
-			Help:        "Total number of Shield Schedules.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	schedulesTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "schedules", "total"),
+		"Total number of Shield Schedules.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	jobsByScheduleTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "jobs", "by_schedule_total"),
+		"Total number of Shield Jobs run on a Shield Schedule.",
+		[]string{"schedule_name"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
+	schedulesUnusedTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "schedules", "unused_total"),
+		"Total number of Shield Schedules not used by any Shield Job.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	collectorAvailableDesc := newCollectorAvailableDesc(namespace, environment, backendName, filters.SchedulesCollector)
+	scrapeErrorsByReasonTotalMetric := newScrapeErrorsByReasonTotalMetric(namespace, environment, backendName, filters.SchedulesCollector)
+
 	schedulesScrapesTotalMetric := prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Namespace:   namespace,
@@ -55,46 +78,42 @@ func NewSchedulesCollector(
 		},
 	)
 
-	lastSchedulesScrapeErrorMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_schedules_scrape_error",
-			Help:        "Whether the last scrape of Schedule metrics from Shield resulted in an error (1 for error, 0 for success).",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastSchedulesScrapeErrorDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_schedules_scrape_error"),
+		"Whether the last scrape of Schedule metrics from Shield resulted in an error (1 for error, 0 for success).",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	lastSchedulesScrapeTimestampMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_schedules_scrape_timestamp",
-			Help:        "Number of seconds since 1970 since last scrape of Schedule metrics from Shield.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastSchedulesScrapeTimestampDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_schedules_scrape_timestamp"),
+		"Number of seconds since 1970 since last scrape of Schedule metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	lastSchedulesScrapeDurationSecondsMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_schedules_scrape_duration_seconds",
-			Help:        "Duration of the last scrape of Schedule metrics from Shield.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastSchedulesScrapeDurationSecondsDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_schedules_scrape_duration_seconds"),
+		"Duration of the last scrape of Schedule metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
 	return &SchedulesCollector{
-		namespace:                                namespace,
-		environment:                              environment,
-		backendName:                              backendName,
-		schedulesTotalMetric:                     schedulesTotalMetric,
-		schedulesScrapesTotalMetric:              schedulesScrapesTotalMetric,
-		schedulesScrapeErrorsTotalMetric:         schedulesScrapeErrorsTotalMetric,
-		lastSchedulesScrapeErrorMetric:           lastSchedulesScrapeErrorMetric,
-		lastSchedulesScrapeTimestampMetric:       lastSchedulesScrapeTimestampMetric,
-		lastSchedulesScrapeDurationSecondsMetric: lastSchedulesScrapeDurationSecondsMetric,
+		namespace:                              namespace,
+		environment:                            environment,
+		backendName:                            backendName,
+		shieldClient:                           shieldClient,
+		schedulesTotalDesc:                     schedulesTotalDesc,
+		schedulesUnusedTotalDesc:               schedulesUnusedTotalDesc,
+		jobsByScheduleTotalDesc:                jobsByScheduleTotalDesc,
+		collectorAvailableDesc:                 collectorAvailableDesc,
+		schedulesScrapesTotalMetric:            schedulesScrapesTotalMetric,
+		schedulesScrapeErrorsTotalMetric:       schedulesScrapeErrorsTotalMetric,
+		scrapeErrorsByReasonTotalMetric:        scrapeErrorsByReasonTotalMetric,
+		lastSchedulesScrapeErrorDesc:           lastSchedulesScrapeErrorDesc,
+		lastSchedulesScrapeTimestampDesc:       lastSchedulesScrapeTimestampDesc,
+		lastSchedulesScrapeDurationSecondsDesc: lastSchedulesScrapeDurationSecondsDesc,
 	}
 }
 
@@ -102,43 +121,66 @@ func (c SchedulesCollector) Collect(ch chan<- prometheus.Metric) {
 	var begun = time.Now()
 
 	errorMetric := float64(0)
-	if err := c.reportSchedulesMetrics(ch); err != nil {
+	err := c.reportSchedulesMetrics(ch)
+	if isScrapeError(err) {
 		errorMetric = float64(1)
 		c.schedulesScrapeErrorsTotalMetric.Inc()
+		c.scrapeErrorsByReasonTotalMetric.WithLabelValues(client.ErrorReason(err)).Inc()
 	}
 	c.schedulesScrapeErrorsTotalMetric.Collect(ch)
+	c.scrapeErrorsByReasonTotalMetric.Collect(ch)
 
 	c.schedulesScrapesTotalMetric.Inc()
 	c.schedulesScrapesTotalMetric.Collect(ch)
 
-	c.lastSchedulesScrapeErrorMetric.Set(errorMetric)
-	c.lastSchedulesScrapeErrorMetric.Collect(ch)
-
-	c.lastSchedulesScrapeTimestampMetric.Set(float64(time.Now().Unix()))
-	c.lastSchedulesScrapeTimestampMetric.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(c.collectorAvailableDesc, prometheus.GaugeValue, availability(err))
+	ch <- prometheus.MustNewConstMetric(c.lastSchedulesScrapeErrorDesc, prometheus.GaugeValue, errorMetric)
+	ch <- prometheus.MustNewConstMetric(c.lastSchedulesScrapeTimestampDesc, prometheus.GaugeValue, float64(time.Now().Unix()))
+	ch <- prometheus.MustNewConstMetric(c.lastSchedulesScrapeDurationSecondsDesc, prometheus.GaugeValue, time.Since(begun).Seconds())
+}
 
-	c.lastSchedulesScrapeDurationSecondsMetric.Set(time.Since(begun).Seconds())
-	c.lastSchedulesScrapeDurationSecondsMetric.Collect(ch)
+func (c SchedulesCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	c.shieldClient = c.shieldClient.WithContext(ctx)
+	c.Collect(ch)
 }
 
 func (c SchedulesCollector) Describe(ch chan<- *prometheus.Desc) {
-	c.schedulesTotalMetric.Describe(ch)
+	ch <- c.schedulesTotalDesc
+	ch <- c.schedulesUnusedTotalDesc
+	ch <- c.jobsByScheduleTotalDesc
+	ch <- c.collectorAvailableDesc
 	c.schedulesScrapesTotalMetric.Describe(ch)
 	c.schedulesScrapeErrorsTotalMetric.Describe(ch)
-	c.lastSchedulesScrapeErrorMetric.Describe(ch)
-	c.lastSchedulesScrapeTimestampMetric.Describe(ch)
-	c.lastSchedulesScrapeDurationSecondsMetric.Describe(ch)
+	c.scrapeErrorsByReasonTotalMetric.Describe(ch)
+	ch <- c.lastSchedulesScrapeErrorDesc
+	ch <- c.lastSchedulesScrapeTimestampDesc
+	ch <- c.lastSchedulesScrapeDurationSecondsDesc
 }
 
 func (c SchedulesCollector) reportSchedulesMetrics(ch chan<- prometheus.Metric) error {
-	schedules, err := api.GetSchedules(api.ScheduleFilter{})
+	schedules, err := c.shieldClient.GetSchedules()
 	if err != nil {
-		log.Errorf("Error while listing schedules: %v", err)
+		logError(err, "Error while listing schedules: %v", err)
 		return err
 	}
 
-	c.schedulesTotalMetric.Set(float64(len(schedules)))
-	c.schedulesTotalMetric.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(c.schedulesTotalDesc, prometheus.GaugeValue, float64(len(schedules)))
+
+	scheduleNames := make(map[string]string)
+	for _, schedule := range schedules {
+		scheduleNames[schedule.UUID] = schedule.Name
+	}
+
+	jobsBySchedule, unused, err := countJobsBy(c.shieldClient, scheduleNames, func(job api.Job) string { return job.ScheduleUUID })
+	if err != nil {
+		return err
+	}
+
+	for name, total := range jobsBySchedule {
+		ch <- prometheus.MustNewConstMetric(c.jobsByScheduleTotalDesc, prometheus.GaugeValue, total, name)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.schedulesUnusedTotalDesc, prometheus.GaugeValue, unused)
 
 	return nil
 }
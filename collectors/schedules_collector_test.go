@@ -11,6 +11,7 @@ import (
 	"github.com/prometheus/common/log"
 	"github.com/starkandwayne/shield/api"
 
+	"github.com/bosh-prometheus/shield_exporter/client"
 	. "github.com/bosh-prometheus/shield_exporter/collectors"
 	. "github.com/bosh-prometheus/shield_exporter/utils/test_matchers"
 )
@@ -24,6 +25,8 @@ var _ = Describe("SchedulesCollectors", func() {
 		err    error
 		server *ghttp.Server
 
+		shieldClient *client.Client
+
 		namespace   = "test_namespace"
 		environment = "test_environment"
 		backendName = "test_backend"
@@ -32,6 +35,8 @@ var _ = Describe("SchedulesCollectors", func() {
 		password = "fake_password"
 
 		schedulesTotalMetric                     prometheus.Gauge
+		schedulesUnusedTotalMetric               prometheus.Gauge
+		jobsByScheduleTotalMetric                *prometheus.GaugeVec
 		schedulesScrapesTotalMetric              prometheus.Counter
 		schedulesScrapeErrorsTotalMetric         prometheus.Counter
 		lastSchedulesScrapeErrorMetric           prometheus.Gauge
@@ -43,24 +48,11 @@ var _ = Describe("SchedulesCollectors", func() {
 
 	BeforeEach(func() {
 		server = ghttp.NewServer()
-		server.AppendHandlers(
-			ghttp.CombineHandlers(
-				ghttp.VerifyRequest("GET", "/v1/ping"),
-				ghttp.RespondWith(http.StatusOK, "{}"),
-			),
-		)
-
-		api.Cfg = &api.Config{
-			Backend:  "default",
-			Backends: map[string]string{},
-			Aliases:  map[string]string{},
-		}
-
-		err = api.Cfg.AddBackend(server.URL(), "default")
-		Expect(err).ToNot(HaveOccurred())
-
-		authToken := api.BasicAuthToken(username, password)
-		err = api.Cfg.UpdateBackend("default", authToken)
+		shieldClient, err = client.NewClient(client.Config{
+			BackendURL: server.URL(),
+			Username:   username,
+			Password:   password,
+		})
 		Expect(err).ToNot(HaveOccurred())
 
 		schedulesTotalMetric = prometheus.NewGauge(
@@ -75,6 +67,30 @@ var _ = Describe("SchedulesCollectors", func() {
 
 		schedulesTotalMetric.Set(2)
 
+		jobsByScheduleTotalMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "jobs",
+				Name:        "by_schedule_total",
+				Help:        "Total number of Shield Jobs run on a Shield Schedule.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"schedule_name"},
+		)
+		jobsByScheduleTotalMetric.WithLabelValues("fake_schedule_1").Set(2)
+		jobsByScheduleTotalMetric.WithLabelValues("fake_schedule_2").Set(0)
+
+		schedulesUnusedTotalMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "schedules",
+				Name:        "unused_total",
+				Help:        "Total number of Shield Schedules not used by any Shield Job.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+		schedulesUnusedTotalMetric.Set(1)
+
 		schedulesScrapesTotalMetric = prometheus.NewCounter(
 			prometheus.CounterOpts{
 				Namespace:   namespace,
@@ -128,7 +144,7 @@ var _ = Describe("SchedulesCollectors", func() {
 	})
 
 	JustBeforeEach(func() {
-		schedulesCollector = NewSchedulesCollector(namespace, environment, backendName)
+		schedulesCollector = NewSchedulesCollector(namespace, environment, backendName, shieldClient)
 	})
 
 	AfterEach(func() {
@@ -152,6 +168,14 @@ var _ = Describe("SchedulesCollectors", func() {
 			Eventually(descriptions).Should(Receive(Equal(schedulesTotalMetric.Desc())))
 		})
 
+		It("returns a jobs_by_schedule_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(jobsByScheduleTotalMetric.WithLabelValues("fake_schedule_1").Desc())))
+		})
+
+		It("returns a schedules_unused_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(schedulesUnusedTotalMetric.Desc())))
+		})
+
 		It("returns a schedules_scrapes_total metric description", func() {
 			Eventually(descriptions).Should(Receive(Equal(schedulesScrapesTotalMetric.Desc())))
 		})
@@ -177,6 +201,8 @@ var _ = Describe("SchedulesCollectors", func() {
 		var (
 			statusCode        int
 			schedulesResponse []api.Schedule
+			jobsStatusCode    int
+			jobsResponse      []api.Job
 			metrics           chan prometheus.Metric
 		)
 
@@ -184,12 +210,19 @@ var _ = Describe("SchedulesCollectors", func() {
 			statusCode = http.StatusOK
 			schedulesResponse = []api.Schedule{
 				api.Schedule{
+					UUID: "fake_schedule_uuid_1",
 					Name: "fake_schedule_1",
 				},
 				api.Schedule{
+					UUID: "fake_schedule_uuid_2",
 					Name: "fake_schedule_2",
 				},
 			}
+			jobsStatusCode = http.StatusOK
+			jobsResponse = []api.Job{
+				api.Job{ScheduleUUID: "fake_schedule_uuid_1"},
+				api.Job{ScheduleUUID: "fake_schedule_uuid_1"},
+			}
 			metrics = make(chan prometheus.Metric)
 		})
 
@@ -200,6 +233,11 @@ var _ = Describe("SchedulesCollectors", func() {
 					ghttp.VerifyBasicAuth(username, password),
 					ghttp.RespondWithJSONEncodedPtr(&statusCode, &schedulesResponse),
 				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/jobs"),
+					ghttp.VerifyBasicAuth(username, password),
+					ghttp.RespondWithJSONEncodedPtr(&jobsStatusCode, &jobsResponse),
+				),
 			)
 			go schedulesCollector.Collect(metrics)
 		})
@@ -208,6 +246,18 @@ var _ = Describe("SchedulesCollectors", func() {
 			Eventually(metrics).Should(Receive(PrometheusMetric(schedulesTotalMetric)))
 		})
 
+		It("returns a jobs_by_schedule_total metric for a schedule with jobs", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(jobsByScheduleTotalMetric.WithLabelValues("fake_schedule_1"))))
+		})
+
+		It("returns a jobs_by_schedule_total metric for an unused schedule", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(jobsByScheduleTotalMetric.WithLabelValues("fake_schedule_2"))))
+		})
+
+		It("returns a schedules_unused_total metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(schedulesUnusedTotalMetric)))
+		})
+
 		It("returns a schedules_scrapes_total metric", func() {
 			Eventually(metrics).Should(Receive(PrometheusMetric(schedulesScrapesTotalMetric)))
 		})
@@ -220,6 +270,22 @@ var _ = Describe("SchedulesCollectors", func() {
 			Eventually(metrics).Should(Receive(PrometheusMetric(lastSchedulesScrapeErrorMetric)))
 		})
 
+		Context("when it fails to list the jobs", func() {
+			BeforeEach(func() {
+				jobsStatusCode = http.StatusInternalServerError
+				schedulesScrapeErrorsTotalMetric.Inc()
+				lastSchedulesScrapeErrorMetric.Set(1)
+			})
+
+			It("returns a schedules_scrape_errors_total metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(schedulesScrapeErrorsTotalMetric)))
+			})
+
+			It("returns a last_schedules_scrape_error metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(lastSchedulesScrapeErrorMetric)))
+			})
+		})
+
 		Context("when it fails to list the schedules", func() {
 			BeforeEach(func() {
 				statusCode = http.StatusInternalServerError
@@ -0,0 +1,63 @@
+package collectors
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+// SlowScrapeCollector wraps a collector and logs a warning whenever
+// collecting its metrics takes longer than the given threshold.
+type SlowScrapeCollector struct {
+	collector     prometheus.Collector
+	collectorName string
+	backendName   string
+	endpoints     []string
+	threshold     time.Duration
+	logger        log.Logger
+}
+
+func NewSlowScrapeCollector(
+	collector prometheus.Collector,
+	collectorName string,
+	backendName string,
+	endpoints []string,
+	threshold time.Duration,
+	logger log.Logger,
+) *SlowScrapeCollector {
+	return &SlowScrapeCollector{
+		collector:     collector,
+		collectorName: collectorName,
+		backendName:   backendName,
+		endpoints:     endpoints,
+		threshold:     threshold,
+		logger:        logger,
+	}
+}
+
+func (c SlowScrapeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c SlowScrapeCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	var begun = time.Now()
+
+	WithContext(ctx, c.collector).Collect(ch)
+
+	duration := time.Since(begun)
+	if duration > c.threshold {
+		c.logger.
+			With("collector", c.collectorName).
+			With("backend_name", c.backendName).
+			With("duration", duration.String()).
+			With("endpoints", strings.Join(c.endpoints, ",")).
+			Warnf("Slow scrape of %s metrics, exceeding the threshold of %s", c.collectorName, c.threshold)
+	}
+}
+
+func (c SlowScrapeCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.collector.Describe(ch)
+}
@@ -0,0 +1,94 @@
+package collectors_test
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+
+	. "github.com/bosh-prometheus/shield_exporter/collectors"
+)
+
+type sleepingCollector struct {
+	gauge prometheus.Gauge
+	sleep time.Duration
+}
+
+func (c sleepingCollector) Collect(ch chan<- prometheus.Metric) {
+	time.Sleep(c.sleep)
+	c.gauge.Collect(ch)
+}
+
+func (c sleepingCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.gauge.Describe(ch)
+}
+
+var _ = Describe("SlowScrapeCollector", func() {
+	var (
+		output    *bytes.Buffer
+		collector sleepingCollector
+
+		slowScrapeCollector *SlowScrapeCollector
+	)
+
+	BeforeEach(func() {
+		output = &bytes.Buffer{}
+		collector = sleepingCollector{
+			gauge: prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_gauge", Help: "Test gauge."}),
+		}
+	})
+
+	JustBeforeEach(func() {
+		slowScrapeCollector = NewSlowScrapeCollector(
+			collector,
+			"Jobs",
+			"test_backend",
+			[]string{"/v1/jobs", "/v1/status/jobs"},
+			20*time.Millisecond,
+			log.NewLogger(output),
+		)
+	})
+
+	Describe("Describe", func() {
+		It("returns the descriptions of the wrapped collector", func() {
+			descriptions := make(chan *prometheus.Desc, 1)
+			slowScrapeCollector.Describe(descriptions)
+			Expect(descriptions).To(Receive(Equal(collector.gauge.Desc())))
+		})
+	})
+
+	Describe("Collect", func() {
+		var metrics chan prometheus.Metric
+
+		JustBeforeEach(func() {
+			metrics = make(chan prometheus.Metric, 1)
+			slowScrapeCollector.Collect(metrics)
+		})
+
+		It("returns the metrics of the wrapped collector", func() {
+			Expect(metrics).To(Receive())
+		})
+
+		It("does not log anything", func() {
+			Expect(output.String()).To(BeEmpty())
+		})
+
+		Context("when the wrapped collector exceeds the threshold", func() {
+			BeforeEach(func() {
+				collector.sleep = 50 * time.Millisecond
+			})
+
+			It("logs a warning", func() {
+				Expect(output.String()).To(ContainSubstring("level=warning"))
+				Expect(output.String()).To(ContainSubstring("Slow scrape of Jobs metrics, exceeding the threshold of 20ms"))
+				Expect(output.String()).To(ContainSubstring("collector=Jobs"))
+				Expect(output.String()).To(ContainSubstring("backend_name=test_backend"))
+				Expect(output.String()).To(ContainSubstring("endpoints=\"/v1/jobs,/v1/status/jobs\""))
+			})
+		})
+	})
+})
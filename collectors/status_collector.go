@@ -1,11 +1,14 @@
 package collectors
 
 import (
+	"context"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/common/log"
-	"github.com/starkandwayne/shield/api"
+	"github.com/starkandwayne/goutils/timestamp"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+	"github.com/bosh-prometheus/shield_exporter/filters"
 )
 
 type InternalStatus struct {
@@ -16,65 +19,84 @@ type InternalStatus struct {
 }
 
 type StatusCollector struct {
-	namespace                             string
-	environment                           string
-	backendName                           string
-	pendingTasksTotalMetric               prometheus.Gauge
-	runningTasksTotalMetric               prometheus.Gauge
-	scheduleQueueTotalMetric              prometheus.Gauge
-	runQueueTotalMetric                   prometheus.Gauge
-	statusScrapesTotalMetric              prometheus.Counter
-	statusScrapeErrorsTotalMetric         prometheus.Counter
-	lastStatusScrapeErrorMetric           prometheus.Gauge
-	lastStatusScrapeTimestampMetric       prometheus.Gauge
-	lastStatusScrapeDurationSecondsMetric prometheus.Gauge
+	namespace                           string
+	environment                         string
+	backendName                         string
+	shieldClient                        *client.Client
+	pendingTasksTotalDesc               *prometheus.Desc
+	runningTasksTotalDesc               *prometheus.Desc
+	scheduleQueueTotalDesc              *prometheus.Desc
+	runQueueTotalDesc                   *prometheus.Desc
+	queuesLatenessSecondsDesc           *prometheus.Desc
+	queuesMaxLatenessSecondsDesc        *prometheus.Desc
+	backendTLSCertExpiryTimestampDesc   *prometheus.Desc
+	collectorAvailableDesc              *prometheus.Desc
+	statusScrapesTotalMetric            prometheus.Counter
+	statusScrapeErrorsTotalMetric       prometheus.Counter
+	scrapeErrorsByReasonTotalMetric     *prometheus.CounterVec
+	lastStatusScrapeErrorDesc           *prometheus.Desc
+	lastStatusScrapeTimestampDesc       *prometheus.Desc
+	lastStatusScrapeDurationSecondsDesc *prometheus.Desc
 }
 
 func NewStatusCollector(
 	namespace string,
 	environment string,
 	backendName string,
+	shieldClient *client.Client,
 ) *StatusCollector {
-	pendingTasksTotalMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "status",
-			Name:        "pending_tasks_total",
-			Help:        "Total number of Shield pending Tasks.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	pendingTasksTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "status", "pending_tasks_total"),
+		"Total number of Shield pending Tasks.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	runningTasksTotalMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "status",
-			Name:        "running_tasks_total",
-			Help:        "Total number of Shield running Tasks.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	runningTasksTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "status", "running_tasks_total"),
+		"Total number of Shield running Tasks.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	scheduleQueueTotalMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "status",
-			Name:        "schedule_queue_total",
-			Help:        "Total number of Shield Tasks in the supervisor scheduler queue.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	scheduleQueueTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "status", "schedule_queue_total"),
+		"Total number of Shield Tasks in the supervisor scheduler queue.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	runQueueTotalMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "status",
-			Name:        "run_queue_total",
-			Help:        "Total number of Shield Tasks in the supervisor run queue.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	runQueueTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "status", "run_queue_total"),
+		"Total number of Shield Tasks in the supervisor run queue.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	queuesLatenessSecondsDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "status", "queues_lateness_seconds"),
+		"Sum of the time the Shield Tasks in the supervisor scheduler and run queues have been waiting for since requested.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	queuesMaxLatenessSecondsDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "status", "queues_max_lateness_seconds"),
+		"Longest time a Shield Task in the supervisor scheduler and run queues has been waiting for since requested.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	backendTLSCertExpiryTimestampDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "backend", "tls_cert_expiry_timestamp"),
+		"Expiry of the TLS certificate presented by the Shield backend in seconds since 1970.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
+	collectorAvailableDesc := newCollectorAvailableDesc(namespace, environment, backendName, filters.StatusCollector)
+	scrapeErrorsByReasonTotalMetric := newScrapeErrorsByReasonTotalMetric(namespace, environment, backendName, filters.StatusCollector)
+
 	statusScrapesTotalMetric := prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Namespace:   namespace,
@@ -95,49 +117,46 @@ func NewStatusCollector(
 		},
 	)
 
-	lastStatusScrapeErrorMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_status_scrape_error",
-			Help:        "Whether the last scrape of Status metrics from Shield resulted in an error (1 for error, 0 for success).",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastStatusScrapeErrorDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_status_scrape_error"),
+		"Whether the last scrape of Status metrics from Shield resulted in an error (1 for error, 0 for success).",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	lastStatusScrapeTimestampMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_status_scrape_timestamp",
-			Help:        "Number of seconds since 1970 since last scrape of Status metrics from Shield.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastStatusScrapeTimestampDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_status_scrape_timestamp"),
+		"Number of seconds since 1970 since last scrape of Status metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	lastStatusScrapeDurationSecondsMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_status_scrape_duration_seconds",
-			Help:        "Duration of the last scrape of Status metrics from Shield.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastStatusScrapeDurationSecondsDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_status_scrape_duration_seconds"),
+		"Duration of the last scrape of Status metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
 	return &StatusCollector{
-		namespace:                             namespace,
-		environment:                           environment,
-		backendName:                           backendName,
-		pendingTasksTotalMetric:               pendingTasksTotalMetric,
-		runningTasksTotalMetric:               runningTasksTotalMetric,
-		scheduleQueueTotalMetric:              scheduleQueueTotalMetric,
-		runQueueTotalMetric:                   runQueueTotalMetric,
-		statusScrapesTotalMetric:              statusScrapesTotalMetric,
-		statusScrapeErrorsTotalMetric:         statusScrapeErrorsTotalMetric,
-		lastStatusScrapeErrorMetric:           lastStatusScrapeErrorMetric,
-		lastStatusScrapeTimestampMetric:       lastStatusScrapeTimestampMetric,
-		lastStatusScrapeDurationSecondsMetric: lastStatusScrapeDurationSecondsMetric,
+		namespace:                           namespace,
+		environment:                         environment,
+		backendName:                         backendName,
+		shieldClient:                        shieldClient,
+		pendingTasksTotalDesc:               pendingTasksTotalDesc,
+		runningTasksTotalDesc:               runningTasksTotalDesc,
+		scheduleQueueTotalDesc:              scheduleQueueTotalDesc,
+		runQueueTotalDesc:                   runQueueTotalDesc,
+		queuesLatenessSecondsDesc:           queuesLatenessSecondsDesc,
+		queuesMaxLatenessSecondsDesc:        queuesMaxLatenessSecondsDesc,
+		backendTLSCertExpiryTimestampDesc:   backendTLSCertExpiryTimestampDesc,
+		collectorAvailableDesc:              collectorAvailableDesc,
+		statusScrapesTotalMetric:            statusScrapesTotalMetric,
+		statusScrapeErrorsTotalMetric:       statusScrapeErrorsTotalMetric,
+		scrapeErrorsByReasonTotalMetric:     scrapeErrorsByReasonTotalMetric,
+		lastStatusScrapeErrorDesc:           lastStatusScrapeErrorDesc,
+		lastStatusScrapeTimestampDesc:       lastStatusScrapeTimestampDesc,
+		lastStatusScrapeDurationSecondsDesc: lastStatusScrapeDurationSecondsDesc,
 	}
 }
 
@@ -145,62 +164,105 @@ func (c StatusCollector) Collect(ch chan<- prometheus.Metric) {
 	var begun = time.Now()
 
 	errorMetric := float64(0)
-	if err := c.reportStatusMetrics(ch); err != nil {
+	err := c.reportStatusMetrics(ch)
+	if isScrapeError(err) {
 		errorMetric = float64(1)
 		c.statusScrapeErrorsTotalMetric.Inc()
+		c.scrapeErrorsByReasonTotalMetric.WithLabelValues(client.ErrorReason(err)).Inc()
 	}
 	c.statusScrapeErrorsTotalMetric.Collect(ch)
+	c.scrapeErrorsByReasonTotalMetric.Collect(ch)
 
 	c.statusScrapesTotalMetric.Inc()
 	c.statusScrapesTotalMetric.Collect(ch)
 
-	c.lastStatusScrapeErrorMetric.Set(errorMetric)
-	c.lastStatusScrapeErrorMetric.Collect(ch)
-
-	c.lastStatusScrapeTimestampMetric.Set(float64(time.Now().Unix()))
-	c.lastStatusScrapeTimestampMetric.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(c.collectorAvailableDesc, prometheus.GaugeValue, availability(err))
+	ch <- prometheus.MustNewConstMetric(c.lastStatusScrapeErrorDesc, prometheus.GaugeValue, errorMetric)
+	ch <- prometheus.MustNewConstMetric(c.lastStatusScrapeTimestampDesc, prometheus.GaugeValue, float64(time.Now().Unix()))
+	ch <- prometheus.MustNewConstMetric(c.lastStatusScrapeDurationSecondsDesc, prometheus.GaugeValue, time.Since(begun).Seconds())
+}
 
-	c.lastStatusScrapeDurationSecondsMetric.Set(time.Since(begun).Seconds())
-	c.lastStatusScrapeDurationSecondsMetric.Collect(ch)
+func (c StatusCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	c.shieldClient = c.shieldClient.WithContext(ctx)
+	c.Collect(ch)
 }
 
 func (c StatusCollector) Describe(ch chan<- *prometheus.Desc) {
-	c.pendingTasksTotalMetric.Describe(ch)
-	c.runningTasksTotalMetric.Describe(ch)
-	c.scheduleQueueTotalMetric.Describe(ch)
-	c.runQueueTotalMetric.Describe(ch)
+	ch <- c.pendingTasksTotalDesc
+	ch <- c.runningTasksTotalDesc
+	ch <- c.scheduleQueueTotalDesc
+	ch <- c.runQueueTotalDesc
+	ch <- c.queuesLatenessSecondsDesc
+	ch <- c.queuesMaxLatenessSecondsDesc
+	ch <- c.backendTLSCertExpiryTimestampDesc
+	ch <- c.collectorAvailableDesc
 	c.statusScrapesTotalMetric.Describe(ch)
 	c.statusScrapeErrorsTotalMetric.Describe(ch)
-	c.lastStatusScrapeErrorMetric.Describe(ch)
-	c.lastStatusScrapeTimestampMetric.Describe(ch)
-	c.lastStatusScrapeDurationSecondsMetric.Describe(ch)
+	c.scrapeErrorsByReasonTotalMetric.Describe(ch)
+	ch <- c.lastStatusScrapeErrorDesc
+	ch <- c.lastStatusScrapeTimestampDesc
+	ch <- c.lastStatusScrapeDurationSecondsDesc
 }
 
 func (c StatusCollector) reportStatusMetrics(ch chan<- prometheus.Metric) error {
 	var internalStatus InternalStatus
 
-	uri, err := api.ShieldURI("/v1/status/internal")
-	if err != nil {
-		log.Errorf("Error while creating the Shield URI: %v", err)
+	if err := c.shieldClient.Get("/v1/status/internal", &internalStatus); err != nil {
+		logError(err, "Error while getting internal status: %v", err)
 		return err
 	}
 
-	if err := uri.Get(&internalStatus); err != nil {
-		log.Errorf("Error while getting internal status: %v", err)
-		return err
+	ch <- prometheus.MustNewConstMetric(c.pendingTasksTotalDesc, prometheus.GaugeValue, float64(len(internalStatus.PendingTasks)))
+	ch <- prometheus.MustNewConstMetric(c.runningTasksTotalDesc, prometheus.GaugeValue, float64(len(internalStatus.RunningTasks)))
+	ch <- prometheus.MustNewConstMetric(c.scheduleQueueTotalDesc, prometheus.GaugeValue, float64(len(internalStatus.ScheduleQueue)))
+	ch <- prometheus.MustNewConstMetric(c.runQueueTotalDesc, prometheus.GaugeValue, float64(len(internalStatus.RunQueue)))
+
+	lateness, maxLateness := queuesLateness(time.Now(), internalStatus.ScheduleQueue, internalStatus.RunQueue)
+	ch <- prometheus.MustNewConstMetric(c.queuesLatenessSecondsDesc, prometheus.GaugeValue, lateness.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.queuesMaxLatenessSecondsDesc, prometheus.GaugeValue, maxLateness.Seconds())
+
+	if notAfter := c.shieldClient.Stats().TLSCertNotAfter; notAfter != nil {
+		ch <- prometheus.MustNewConstMetric(c.backendTLSCertExpiryTimestampDesc, prometheus.GaugeValue, float64(notAfter.Unix()))
 	}
 
-	c.pendingTasksTotalMetric.Set(float64(len(internalStatus.PendingTasks)))
-	c.pendingTasksTotalMetric.Collect(ch)
+	return nil
+}
 
-	c.runningTasksTotalMetric.Set(float64(len(internalStatus.RunningTasks)))
-	c.runningTasksTotalMetric.Collect(ch)
+// queuesLateness returns the sum and the maximum of the time the tasks of
+// the supervisor queues have been waiting for since requested. Tasks queued
+// twice are accounted for once, and tasks without a request time are ignored.
+func queuesLateness(now time.Time, queues ...[]interface{}) (time.Duration, time.Duration) {
+	var lateness, maxLateness time.Duration
 
-	c.scheduleQueueTotalMetric.Set(float64(len(internalStatus.ScheduleQueue)))
-	c.scheduleQueueTotalMetric.Collect(ch)
+	seen := make(map[string]bool)
+	for _, queue := range queues {
+		for _, task := range queue {
+			fields, ok := task.(map[string]interface{})
+			if !ok {
+				continue
+			}
 
-	c.runQueueTotalMetric.Set(float64(len(internalStatus.RunQueue)))
-	c.runQueueTotalMetric.Collect(ch)
+			uuid, _ := fields["uuid"].(string)
+			if uuid != "" {
+				if seen[uuid] {
+					continue
+				}
+				seen[uuid] = true
+			}
 
-	return nil
+			requestedAt, _ := fields["requested_at"].(string)
+			requested, err := time.Parse(timestamp.Format, requestedAt)
+			if err != nil || !requested.Before(now) {
+				continue
+			}
+
+			taskLateness := now.Sub(requested)
+			lateness += taskLateness
+			if taskLateness > maxLateness {
+				maxLateness = taskLateness
+			}
+		}
+	}
+
+	return lateness, maxLateness
 }
@@ -2,6 +2,7 @@ package collectors_test
 
 import (
 	"net/http"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -9,8 +10,11 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/log"
-	"github.com/starkandwayne/shield/api"
+	"github.com/starkandwayne/goutils/timestamp"
 
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
 	. "github.com/bosh-prometheus/shield_exporter/collectors"
 	. "github.com/bosh-prometheus/shield_exporter/utils/test_matchers"
 )
@@ -24,6 +28,8 @@ var _ = Describe("StatusCollectors", func() {
 		err    error
 		server *ghttp.Server
 
+		shieldClient *client.Client
+
 		namespace   = "test_namespace"
 		environment = "test_environment"
 		backendName = "test_backend"
@@ -40,8 +46,13 @@ var _ = Describe("StatusCollectors", func() {
 		runningTasksTotalMetric               prometheus.Gauge
 		scheduleQueueTotalMetric              prometheus.Gauge
 		runQueueTotalMetric                   prometheus.Gauge
+		queuesLatenessSecondsMetric           prometheus.Gauge
+		queuesMaxLatenessSecondsMetric        prometheus.Gauge
+		backendTLSCertExpiryTimestampMetric   prometheus.Gauge
+		collectorAvailableMetric              prometheus.Gauge
 		statusScrapesTotalMetric              prometheus.Counter
 		statusScrapeErrorsTotalMetric         prometheus.Counter
+		scrapeErrorsByReasonTotalMetric       *prometheus.CounterVec
 		lastStatusScrapeErrorMetric           prometheus.Gauge
 		lastStatusScrapeTimestampMetric       prometheus.Gauge
 		lastStatusScrapeDurationSecondsMetric prometheus.Gauge
@@ -51,24 +62,11 @@ var _ = Describe("StatusCollectors", func() {
 
 	BeforeEach(func() {
 		server = ghttp.NewServer()
-		server.AppendHandlers(
-			ghttp.CombineHandlers(
-				ghttp.VerifyRequest("GET", "/v1/ping"),
-				ghttp.RespondWith(http.StatusOK, "{}"),
-			),
-		)
-
-		api.Cfg = &api.Config{
-			Backend:  "default",
-			Backends: map[string]string{},
-			Aliases:  map[string]string{},
-		}
-
-		err = api.Cfg.AddBackend(server.URL(), "default")
-		Expect(err).ToNot(HaveOccurred())
-
-		authToken := api.BasicAuthToken(username, password)
-		err = api.Cfg.UpdateBackend("default", authToken)
+		shieldClient, err = client.NewClient(client.Config{
+			BackendURL: server.URL(),
+			Username:   username,
+			Password:   password,
+		})
 		Expect(err).ToNot(HaveOccurred())
 
 		pendingTasksTotalMetric = prometheus.NewGauge(
@@ -115,6 +113,47 @@ var _ = Describe("StatusCollectors", func() {
 		)
 		runQueueTotalMetric.Set(float64(len(runQueue)))
 
+		queuesLatenessSecondsMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "status",
+				Name:        "queues_lateness_seconds",
+				Help:        "Sum of the time the Shield Tasks in the supervisor scheduler and run queues have been waiting for since requested.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+
+		queuesMaxLatenessSecondsMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "status",
+				Name:        "queues_max_lateness_seconds",
+				Help:        "Longest time a Shield Task in the supervisor scheduler and run queues has been waiting for since requested.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+
+		backendTLSCertExpiryTimestampMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "backend",
+				Name:        "tls_cert_expiry_timestamp",
+				Help:        "Expiry of the TLS certificate presented by the Shield backend in seconds since 1970.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+
+		collectorAvailableMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "collector",
+				Name:        "available",
+				Help:        "Whether the Shield backend implements the API endpoints of a collector (1 for available, 0 for not implemented).",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName, "collector": "Status"},
+			},
+		)
+		collectorAvailableMetric.Set(1)
+
 		statusScrapesTotalMetric = prometheus.NewCounter(
 			prometheus.CounterOpts{
 				Namespace:   namespace,
@@ -136,6 +175,17 @@ var _ = Describe("StatusCollectors", func() {
 			},
 		)
 
+		scrapeErrorsByReasonTotalMetric = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   namespace,
+				Subsystem:   "exporter",
+				Name:        "scrape_errors_total",
+				Help:        "Total number of scrape errors of a collector's metrics from Shield by reason.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName, "collector": "Status"},
+			},
+			[]string{"reason"},
+		)
+
 		lastStatusScrapeErrorMetric = prometheus.NewGauge(
 			prometheus.GaugeOpts{
 				Namespace:   namespace,
@@ -168,7 +218,7 @@ var _ = Describe("StatusCollectors", func() {
 	})
 
 	JustBeforeEach(func() {
-		statusCollector = NewStatusCollector(namespace, environment, backendName)
+		statusCollector = NewStatusCollector(namespace, environment, backendName, shieldClient)
 	})
 
 	AfterEach(func() {
@@ -204,6 +254,22 @@ var _ = Describe("StatusCollectors", func() {
 			Eventually(descriptions).Should(Receive(Equal(runQueueTotalMetric.Desc())))
 		})
 
+		It("returns a status_queues_lateness_seconds metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(queuesLatenessSecondsMetric.Desc())))
+		})
+
+		It("returns a status_queues_max_lateness_seconds metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(queuesMaxLatenessSecondsMetric.Desc())))
+		})
+
+		It("returns a backend_tls_cert_expiry_timestamp metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(backendTLSCertExpiryTimestampMetric.Desc())))
+		})
+
+		It("returns a collector_available metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(collectorAvailableMetric.Desc())))
+		})
+
 		It("returns a status_scrapes_total metric description", func() {
 			Eventually(descriptions).Should(Receive(Equal(statusScrapesTotalMetric.Desc())))
 		})
@@ -212,6 +278,10 @@ var _ = Describe("StatusCollectors", func() {
 			Eventually(descriptions).Should(Receive(Equal(statusScrapeErrorsTotalMetric.Desc())))
 		})
 
+		It("returns an exporter_scrape_errors_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(scrapeErrorsByReasonTotalMetric.WithLabelValues("timeout").Desc())))
+		})
+
 		It("returns a last_status_scrape_error metric description", func() {
 			Eventually(descriptions).Should(Receive(Equal(lastStatusScrapeErrorMetric.Desc())))
 		})
@@ -270,6 +340,70 @@ var _ = Describe("StatusCollectors", func() {
 			Eventually(metrics).Should(Receive(PrometheusMetric(runQueueTotalMetric)))
 		})
 
+		It("returns a status_queues_lateness_seconds metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(queuesLatenessSecondsMetric)))
+		})
+
+		It("returns a status_queues_max_lateness_seconds metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(queuesMaxLatenessSecondsMetric)))
+		})
+
+		Context("when tasks are waiting in the queues", func() {
+			BeforeEach(func() {
+				now := time.Now().UTC()
+				task1 := map[string]interface{}{"uuid": "task_1", "requested_at": now.Add(-1 * time.Hour).Format(timestamp.Format)}
+				task2 := map[string]interface{}{"uuid": "task_2", "requested_at": now.Add(-2 * time.Hour).Format(timestamp.Format)}
+				statusResponse.ScheduleQueue = []interface{}{task1}
+				statusResponse.RunQueue = []interface{}{task1, task2}
+			})
+
+			receiveGaugeValue := func(desc *prometheus.Desc) float64 {
+				var metric prometheus.Metric
+				for i := 0; i < 6; i++ {
+					Eventually(metrics).Should(Receive(&metric))
+					if metric.Desc().String() == desc.String() {
+						break
+					}
+				}
+				Expect(metric.Desc()).To(Equal(desc))
+
+				written := &dto.Metric{}
+				Expect(metric.Write(written)).To(Succeed())
+				return written.GetGauge().GetValue()
+			}
+
+			It("returns the sum of the queued tasks lateness", func() {
+				Expect(receiveGaugeValue(queuesLatenessSecondsMetric.Desc())).To(BeNumerically("~", (3 * time.Hour).Seconds(), 5))
+			})
+
+			It("returns the longest queued task lateness", func() {
+				Expect(receiveGaugeValue(queuesMaxLatenessSecondsMetric.Desc())).To(BeNumerically("~", (2 * time.Hour).Seconds(), 5))
+			})
+		})
+
+		It("does not return a backend_tls_cert_expiry_timestamp metric when Shield is not reached over TLS", func() {
+			Consistently(metrics).ShouldNot(Receive(PrometheusMetric(backendTLSCertExpiryTimestampMetric)))
+		})
+
+		Context("when Shield is reached over TLS", func() {
+			BeforeEach(func() {
+				server.Close()
+				server = ghttp.NewTLSServer()
+				shieldClient, err = client.NewClient(client.Config{
+					BackendURL:        server.URL(),
+					Username:          username,
+					Password:          password,
+					SkipSSLValidation: true,
+				})
+				Expect(err).ToNot(HaveOccurred())
+				backendTLSCertExpiryTimestampMetric.Set(float64(server.HTTPTestServer.Certificate().NotAfter.Unix()))
+			})
+
+			It("returns a backend_tls_cert_expiry_timestamp metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(backendTLSCertExpiryTimestampMetric)))
+			})
+		})
+
 		It("returns a status_scrapes_total metric", func() {
 			Eventually(metrics).Should(Receive(PrometheusMetric(statusScrapesTotalMetric)))
 		})
@@ -282,10 +416,56 @@ var _ = Describe("StatusCollectors", func() {
 			Eventually(metrics).Should(Receive(PrometheusMetric(lastStatusScrapeErrorMetric)))
 		})
 
+		It("returns an exporter_scrape_errors_total metric for the timeout reason", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(scrapeErrorsByReasonTotalMetric.WithLabelValues("timeout"))))
+		})
+
+		It("returns an exporter_scrape_errors_total metric for the http_5xx reason", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(scrapeErrorsByReasonTotalMetric.WithLabelValues("http_5xx"))))
+		})
+
+		Context("when the Shield backend is in maintenance mode", func() {
+			BeforeEach(func() {
+				statusCode = http.StatusServiceUnavailable
+			})
+
+			It("does not count a scrape error", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(statusScrapeErrorsTotalMetric)))
+			})
+
+			It("returns a last_status_scrape_error metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(lastStatusScrapeErrorMetric)))
+			})
+		})
+
+		It("returns a collector_available metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(collectorAvailableMetric)))
+		})
+
+		Context("when the Shield backend does not implement the internal status", func() {
+			BeforeEach(func() {
+				statusCode = http.StatusNotImplemented
+				collectorAvailableMetric.Set(0)
+			})
+
+			It("returns a collector_available metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(collectorAvailableMetric)))
+			})
+
+			It("does not count a scrape error", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(statusScrapeErrorsTotalMetric)))
+			})
+
+			It("returns a last_status_scrape_error metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(lastStatusScrapeErrorMetric)))
+			})
+		})
+
 		Context("when it fails to the the internal status", func() {
 			BeforeEach(func() {
 				statusCode = http.StatusInternalServerError
 				statusScrapeErrorsTotalMetric.Inc()
+				scrapeErrorsByReasonTotalMetric.WithLabelValues("http_5xx").Inc()
 				lastStatusScrapeErrorMetric.Set(1)
 			})
 
@@ -296,6 +476,10 @@ var _ = Describe("StatusCollectors", func() {
 			It("returns a last_status_scrape_error metric", func() {
 				Eventually(metrics).Should(Receive(PrometheusMetric(lastStatusScrapeErrorMetric)))
 			})
+
+			It("counts the scrape error by reason", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(scrapeErrorsByReasonTotalMetric.WithLabelValues("http_5xx"))))
+			})
 		})
 	})
 })
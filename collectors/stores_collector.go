@@ -1,41 +1,71 @@
 package collectors
 
 import (
+	"context"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/common/log"
 	"github.com/starkandwayne/shield/api"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+	"github.com/bosh-prometheus/shield_exporter/filters"
 )
 
 type StoresCollector struct {
-	namespace                             string
-	environment                           string
-	backendName                           string
-	storesTotalMetric                     *prometheus.GaugeVec
-	storesScrapesTotalMetric              prometheus.Counter
-	storesScrapeErrorsTotalMetric         prometheus.Counter
-	lastStoresScrapeErrorMetric           prometheus.Gauge
-	lastStoresScrapeTimestampMetric       prometheus.Gauge
-	lastStoresScrapeDurationSecondsMetric prometheus.Gauge
+	namespace                           string
+	environment                         string
+	backendName                         string
+	shieldClient                        *client.Client
+	storesTotalDesc                     *prometheus.Desc
+	storeHealthyDesc                    *prometheus.Desc
+	jobsByStoreTotalDesc                *prometheus.Desc
+	storesUnusedTotalDesc               *prometheus.Desc
+	collectorAvailableDesc              *prometheus.Desc
+	storesScrapesTotalMetric            prometheus.Counter
+	storesScrapeErrorsTotalMetric       prometheus.Counter
+	scrapeErrorsByReasonTotalMetric     *prometheus.CounterVec
+	lastStoresScrapeErrorDesc           *prometheus.Desc
+	lastStoresScrapeTimestampDesc       *prometheus.Desc
+	lastStoresScrapeDurationSecondsDesc *prometheus.Desc
 }
 
 func NewStoresCollector(
 	namespace string,
 	environment string,
 	backendName string,
+	shieldClient *client.Client,
 ) *StoresCollector {
-	storesTotalMetric := prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "stores",
-			Name:        "total",
-			Help:        "Labeled total number of Shield Stores.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	storesTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "stores", "total"),
+		"Labeled total number of Shield Stores.",
 		[]string{"store_plugin"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
+	storeHealthyDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "store", "healthy"),
+		"Whether the last test of a Shield Store succeeded (1 for healthy, 0 for unhealthy).",
+		[]string{"store_name", "store_plugin"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	jobsByStoreTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "jobs", "by_store_total"),
+		"Total number of Shield Jobs backing up to a Shield Store.",
+		[]string{"store_name"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	storesUnusedTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "stores", "unused_total"),
+		"Total number of Shield Stores not used by any Shield Job.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	collectorAvailableDesc := newCollectorAvailableDesc(namespace, environment, backendName, filters.StoresCollector)
+	scrapeErrorsByReasonTotalMetric := newScrapeErrorsByReasonTotalMetric(namespace, environment, backendName, filters.StoresCollector)
+
 	storesScrapesTotalMetric := prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Namespace:   namespace,
@@ -56,46 +86,43 @@ func NewStoresCollector(
 		},
 	)
 
-	lastStoresScrapeErrorMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_stores_scrape_error",
-			Help:        "Whether the last scrape of Store metrics from Shield resulted in an error (1 for error, 0 for success).",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastStoresScrapeErrorDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_stores_scrape_error"),
+		"Whether the last scrape of Store metrics from Shield resulted in an error (1 for error, 0 for success).",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	lastStoresScrapeTimestampMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_stores_scrape_timestamp",
-			Help:        "Number of seconds since 1970 since last scrape of Store metrics from Shield.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastStoresScrapeTimestampDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_stores_scrape_timestamp"),
+		"Number of seconds since 1970 since last scrape of Store metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	lastStoresScrapeDurationSecondsMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_stores_scrape_duration_seconds",
-			Help:        "Duration of the last scrape of Store metrics from Shield.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastStoresScrapeDurationSecondsDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_stores_scrape_duration_seconds"),
+		"Duration of the last scrape of Store metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
 	return &StoresCollector{
-		namespace:                             namespace,
-		environment:                           environment,
-		backendName:                           backendName,
-		storesTotalMetric:                     storesTotalMetric,
-		storesScrapesTotalMetric:              storesScrapesTotalMetric,
-		storesScrapeErrorsTotalMetric:         storesScrapeErrorsTotalMetric,
-		lastStoresScrapeErrorMetric:           lastStoresScrapeErrorMetric,
-		lastStoresScrapeTimestampMetric:       lastStoresScrapeTimestampMetric,
-		lastStoresScrapeDurationSecondsMetric: lastStoresScrapeDurationSecondsMetric,
+		namespace:                           namespace,
+		environment:                         environment,
+		backendName:                         backendName,
+		shieldClient:                        shieldClient,
+		storesTotalDesc:                     storesTotalDesc,
+		storeHealthyDesc:                    storeHealthyDesc,
+		jobsByStoreTotalDesc:                jobsByStoreTotalDesc,
+		storesUnusedTotalDesc:               storesUnusedTotalDesc,
+		collectorAvailableDesc:              collectorAvailableDesc,
+		storesScrapesTotalMetric:            storesScrapesTotalMetric,
+		storesScrapeErrorsTotalMetric:       storesScrapeErrorsTotalMetric,
+		scrapeErrorsByReasonTotalMetric:     scrapeErrorsByReasonTotalMetric,
+		lastStoresScrapeErrorDesc:           lastStoresScrapeErrorDesc,
+		lastStoresScrapeTimestampDesc:       lastStoresScrapeTimestampDesc,
+		lastStoresScrapeDurationSecondsDesc: lastStoresScrapeDurationSecondsDesc,
 	}
 }
 
@@ -103,48 +130,95 @@ func (c StoresCollector) Collect(ch chan<- prometheus.Metric) {
 	var begun = time.Now()
 
 	errorMetric := float64(0)
-	if err := c.reportStoresMetrics(ch); err != nil {
+	err := c.reportStoresMetrics(ch)
+	if isScrapeError(err) {
 		errorMetric = float64(1)
 		c.storesScrapeErrorsTotalMetric.Inc()
+		c.scrapeErrorsByReasonTotalMetric.WithLabelValues(client.ErrorReason(err)).Inc()
 	}
 	c.storesScrapeErrorsTotalMetric.Collect(ch)
+	c.scrapeErrorsByReasonTotalMetric.Collect(ch)
 
 	c.storesScrapesTotalMetric.Inc()
 	c.storesScrapesTotalMetric.Collect(ch)
 
-	c.lastStoresScrapeErrorMetric.Set(errorMetric)
-	c.lastStoresScrapeErrorMetric.Collect(ch)
-
-	c.lastStoresScrapeTimestampMetric.Set(float64(time.Now().Unix()))
-	c.lastStoresScrapeTimestampMetric.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(c.collectorAvailableDesc, prometheus.GaugeValue, availability(err))
+	ch <- prometheus.MustNewConstMetric(c.lastStoresScrapeErrorDesc, prometheus.GaugeValue, errorMetric)
+	ch <- prometheus.MustNewConstMetric(c.lastStoresScrapeTimestampDesc, prometheus.GaugeValue, float64(time.Now().Unix()))
+	ch <- prometheus.MustNewConstMetric(c.lastStoresScrapeDurationSecondsDesc, prometheus.GaugeValue, time.Since(begun).Seconds())
+}
 
-	c.lastStoresScrapeDurationSecondsMetric.Set(time.Since(begun).Seconds())
-	c.lastStoresScrapeDurationSecondsMetric.Collect(ch)
+func (c StoresCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	c.shieldClient = c.shieldClient.WithContext(ctx)
+	c.Collect(ch)
 }
 
 func (c StoresCollector) Describe(ch chan<- *prometheus.Desc) {
-	c.storesTotalMetric.Describe(ch)
+	ch <- c.storesTotalDesc
+	ch <- c.storeHealthyDesc
+	ch <- c.jobsByStoreTotalDesc
+	ch <- c.storesUnusedTotalDesc
+	ch <- c.collectorAvailableDesc
 	c.storesScrapesTotalMetric.Describe(ch)
 	c.storesScrapeErrorsTotalMetric.Describe(ch)
-	c.lastStoresScrapeErrorMetric.Describe(ch)
-	c.lastStoresScrapeTimestampMetric.Describe(ch)
-	c.lastStoresScrapeDurationSecondsMetric.Describe(ch)
+	c.scrapeErrorsByReasonTotalMetric.Describe(ch)
+	ch <- c.lastStoresScrapeErrorDesc
+	ch <- c.lastStoresScrapeTimestampDesc
+	ch <- c.lastStoresScrapeDurationSecondsDesc
 }
 
 func (c StoresCollector) reportStoresMetrics(ch chan<- prometheus.Metric) error {
-	c.storesTotalMetric.Reset()
-
-	stores, err := api.GetStores(api.StoreFilter{})
+	stores, err := c.shieldClient.GetStores()
 	if err != nil {
-		log.Errorf("Error while listing stores: %v", err)
+		logError(err, "Error while listing stores: %v", err)
 		return err
 	}
 
+	// Stores sharing a name and plugin are only healthy if all of them are.
+	type storeKey struct {
+		name   string
+		plugin string
+	}
+	storesHealthy := make(map[storeKey]float64)
+	storesTotal := make(map[string]float64)
+	for _, store := range stores {
+		storesTotal[store.Plugin]++
+
+		if store.Healthy == nil {
+			continue
+		}
+		key := storeKey{store.Name, store.Plugin}
+		if healthy, ok := storesHealthy[key]; ok && healthy == 0 {
+			continue
+		}
+		storesHealthy[key] = 0
+		if *store.Healthy {
+			storesHealthy[key] = 1
+		}
+	}
+
+	for key, healthy := range storesHealthy {
+		ch <- prometheus.MustNewConstMetric(c.storeHealthyDesc, prometheus.GaugeValue, healthy, key.name, key.plugin)
+	}
+
+	for plugin, total := range storesTotal {
+		ch <- prometheus.MustNewConstMetric(c.storesTotalDesc, prometheus.GaugeValue, total, plugin)
+	}
+
+	storeNames := make(map[string]string)
 	for _, store := range stores {
-		c.storesTotalMetric.WithLabelValues(store.Plugin).Inc()
+		storeNames[store.UUID] = store.Name
 	}
 
-	c.storesTotalMetric.Collect(ch)
+	jobsByStore, unused, err := countJobsBy(c.shieldClient, storeNames, func(job api.Job) string { return job.StoreUUID })
+	if err != nil {
+		return err
+	}
+
+	for name, total := range jobsByStore {
+		ch <- prometheus.MustNewConstMetric(c.jobsByStoreTotalDesc, prometheus.GaugeValue, total, name)
+	}
+	ch <- prometheus.MustNewConstMetric(c.storesUnusedTotalDesc, prometheus.GaugeValue, unused)
 
 	return nil
 }
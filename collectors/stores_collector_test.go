@@ -11,6 +11,7 @@ import (
 	"github.com/prometheus/common/log"
 	"github.com/starkandwayne/shield/api"
 
+	"github.com/bosh-prometheus/shield_exporter/client"
 	. "github.com/bosh-prometheus/shield_exporter/collectors"
 	. "github.com/bosh-prometheus/shield_exporter/utils/test_matchers"
 )
@@ -24,6 +25,8 @@ var _ = Describe("StoresCollectors", func() {
 		err    error
 		server *ghttp.Server
 
+		shieldClient *client.Client
+
 		namespace   = "test_namespace"
 		environment = "test_environment"
 		backendName = "test_backend"
@@ -33,8 +36,13 @@ var _ = Describe("StoresCollectors", func() {
 
 		storePlugin1 = "store_plugin_1"
 		storePlugin2 = "store_plugin_2"
+		storeName1   = "store_name_1"
+		storeName2   = "store_name_2"
 
 		storesTotalMetric                     *prometheus.GaugeVec
+		storesUnusedTotalMetric               prometheus.Gauge
+		storeHealthyMetric                    *prometheus.GaugeVec
+		jobsByStoreTotalMetric                *prometheus.GaugeVec
 		storesScrapesTotalMetric              prometheus.Counter
 		storesScrapeErrorsTotalMetric         prometheus.Counter
 		lastStoresScrapeErrorMetric           prometheus.Gauge
@@ -46,24 +54,11 @@ var _ = Describe("StoresCollectors", func() {
 
 	BeforeEach(func() {
 		server = ghttp.NewServer()
-		server.AppendHandlers(
-			ghttp.CombineHandlers(
-				ghttp.VerifyRequest("GET", "/v1/ping"),
-				ghttp.RespondWith(http.StatusOK, "{}"),
-			),
-		)
-
-		api.Cfg = &api.Config{
-			Backend:  "default",
-			Backends: map[string]string{},
-			Aliases:  map[string]string{},
-		}
-
-		err = api.Cfg.AddBackend(server.URL(), "default")
-		Expect(err).ToNot(HaveOccurred())
-
-		authToken := api.BasicAuthToken(username, password)
-		err = api.Cfg.UpdateBackend("default", authToken)
+		shieldClient, err = client.NewClient(client.Config{
+			BackendURL: server.URL(),
+			Username:   username,
+			Password:   password,
+		})
 		Expect(err).ToNot(HaveOccurred())
 
 		storesTotalMetric = prometheus.NewGaugeVec(
@@ -79,6 +74,43 @@ var _ = Describe("StoresCollectors", func() {
 		storesTotalMetric.WithLabelValues(storePlugin1).Set(2)
 		storesTotalMetric.WithLabelValues(storePlugin2).Set(1)
 
+		storeHealthyMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "store",
+				Name:        "healthy",
+				Help:        "Whether the last test of a Shield Store succeeded (1 for healthy, 0 for unhealthy).",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"store_name", "store_plugin"},
+		)
+		storeHealthyMetric.WithLabelValues(storeName1, storePlugin1).Set(1)
+		storeHealthyMetric.WithLabelValues(storeName2, storePlugin2).Set(0)
+
+		jobsByStoreTotalMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "jobs",
+				Name:        "by_store_total",
+				Help:        "Total number of Shield Jobs backing up to a Shield Store.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"store_name"},
+		)
+		jobsByStoreTotalMetric.WithLabelValues(storeName1).Set(3)
+		jobsByStoreTotalMetric.WithLabelValues(storeName2).Set(0)
+
+		storesUnusedTotalMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "stores",
+				Name:        "unused_total",
+				Help:        "Total number of Shield Stores not used by any Shield Job.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+		storesUnusedTotalMetric.Set(1)
+
 		storesScrapesTotalMetric = prometheus.NewCounter(
 			prometheus.CounterOpts{
 				Namespace:   namespace,
@@ -132,7 +164,7 @@ var _ = Describe("StoresCollectors", func() {
 	})
 
 	JustBeforeEach(func() {
-		storesCollector = NewStoresCollector(namespace, environment, backendName)
+		storesCollector = NewStoresCollector(namespace, environment, backendName, shieldClient)
 	})
 
 	AfterEach(func() {
@@ -156,6 +188,18 @@ var _ = Describe("StoresCollectors", func() {
 			Eventually(descriptions).Should(Receive(Equal(storesTotalMetric.WithLabelValues(storePlugin1).Desc())))
 		})
 
+		It("returns a store_healthy metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(storeHealthyMetric.WithLabelValues(storeName1, storePlugin1).Desc())))
+		})
+
+		It("returns a jobs_by_store_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(jobsByStoreTotalMetric.WithLabelValues(storeName1).Desc())))
+		})
+
+		It("returns a stores_unused_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(storesUnusedTotalMetric.Desc())))
+		})
+
 		It("returns a stores_scrapes_total metric description", func() {
 			Eventually(descriptions).Should(Receive(Equal(storesScrapesTotalMetric.Desc())))
 		})
@@ -180,23 +224,31 @@ var _ = Describe("StoresCollectors", func() {
 	Describe("Collect", func() {
 		var (
 			statusCode     int
-			storesResponse []api.Store
+			storesResponse []client.Store
+			jobsStatusCode int
+			jobsResponse   []api.Job
 			metrics        chan prometheus.Metric
 		)
 
 		BeforeEach(func() {
 			statusCode = http.StatusOK
-			storesResponse = []api.Store{
-				api.Store{
-					Plugin: storePlugin1,
+			storesResponse = []client.Store{
+				client.Store{
+					Store: api.Store{UUID: "store_uuid_1", Name: storeName1, Plugin: storePlugin1},
 				},
-				api.Store{
-					Plugin: storePlugin1,
+				client.Store{
+					Store: api.Store{UUID: "store_uuid_2", Name: storeName1, Plugin: storePlugin1},
 				},
-				api.Store{
-					Plugin: storePlugin2,
+				client.Store{
+					Store: api.Store{UUID: "store_uuid_3", Name: storeName2, Plugin: storePlugin2},
 				},
 			}
+			jobsStatusCode = http.StatusOK
+			jobsResponse = []api.Job{
+				api.Job{StoreUUID: "store_uuid_1"},
+				api.Job{StoreUUID: "store_uuid_1"},
+				api.Job{StoreUUID: "store_uuid_2"},
+			}
 			metrics = make(chan prometheus.Metric)
 		})
 
@@ -207,6 +259,11 @@ var _ = Describe("StoresCollectors", func() {
 					ghttp.VerifyBasicAuth(username, password),
 					ghttp.RespondWithJSONEncodedPtr(&statusCode, &storesResponse),
 				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/jobs"),
+					ghttp.VerifyBasicAuth(username, password),
+					ghttp.RespondWithJSONEncodedPtr(&jobsStatusCode, &jobsResponse),
+				),
 			)
 			go storesCollector.Collect(metrics)
 		})
@@ -219,6 +276,18 @@ var _ = Describe("StoresCollectors", func() {
 			Eventually(metrics).Should(Receive(PrometheusMetric(storesTotalMetric.WithLabelValues(storePlugin2))))
 		})
 
+		It("returns a jobs_by_store_total metric summing the stores sharing a name", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(jobsByStoreTotalMetric.WithLabelValues(storeName1))))
+		})
+
+		It("returns a jobs_by_store_total metric for an unused store", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(jobsByStoreTotalMetric.WithLabelValues(storeName2))))
+		})
+
+		It("returns a stores_unused_total metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(storesUnusedTotalMetric)))
+		})
+
 		It("returns a stores_scrapes_total metric", func() {
 			Eventually(metrics).Should(Receive(PrometheusMetric(storesScrapesTotalMetric)))
 		})
@@ -231,6 +300,43 @@ var _ = Describe("StoresCollectors", func() {
 			Eventually(metrics).Should(Receive(PrometheusMetric(lastStoresScrapeErrorMetric)))
 		})
 
+		It("does not return a store_healthy metric when Shield does not report store health", func() {
+			Consistently(metrics).ShouldNot(Receive(PrometheusMetric(storeHealthyMetric.WithLabelValues(storeName1, storePlugin1))))
+		})
+
+		Context("when Shield reports store health", func() {
+			BeforeEach(func() {
+				healthy := true
+				unhealthy := false
+				storesResponse[0].Healthy = &healthy
+				storesResponse[2].Healthy = &unhealthy
+			})
+
+			It("returns a store_healthy metric for a healthy store", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(storeHealthyMetric.WithLabelValues(storeName1, storePlugin1))))
+			})
+
+			It("returns a store_healthy metric for an unhealthy store", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(storeHealthyMetric.WithLabelValues(storeName2, storePlugin2))))
+			})
+		})
+
+		Context("when it fails to list the jobs", func() {
+			BeforeEach(func() {
+				jobsStatusCode = http.StatusInternalServerError
+				storesScrapeErrorsTotalMetric.Inc()
+				lastStoresScrapeErrorMetric.Set(1)
+			})
+
+			It("returns a stores_scrape_errors_total metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(storesScrapeErrorsTotalMetric)))
+			})
+
+			It("returns a last_stores_scrape_error metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(lastStoresScrapeErrorMetric)))
+			})
+		})
+
 		Context("when it fails to list the stores", func() {
 			BeforeEach(func() {
 				statusCode = http.StatusInternalServerError
@@ -1,41 +1,74 @@
 package collectors
 
 import (
+	"context"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/log"
 	"github.com/starkandwayne/shield/api"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+	"github.com/bosh-prometheus/shield_exporter/filters"
 )
 
 type TargetsCollector struct {
-	namespace                              string
-	environment                            string
-	backendName                            string
-	targetsTotalMetric                     *prometheus.GaugeVec
-	targetsScrapesTotalMetric              prometheus.Counter
-	targetsScrapeErrorsTotalMetric         prometheus.Counter
-	lastTargetsScrapeErrorMetric           prometheus.Gauge
-	lastTargetsScrapeTimestampMetric       prometheus.Gauge
-	lastTargetsScrapeDurationSecondsMetric prometheus.Gauge
+	namespace                            string
+	environment                          string
+	backendName                          string
+	shieldClient                         *client.Client
+	targetsTotalDesc                     *prometheus.Desc
+	targetReachableDesc                  *prometheus.Desc
+	jobsByTargetTotalDesc                *prometheus.Desc
+	targetsUnusedTotalDesc               *prometheus.Desc
+	collectorAvailableDesc               *prometheus.Desc
+	targetsScrapesTotalMetric            prometheus.Counter
+	targetsScrapeErrorsTotalMetric       prometheus.Counter
+	scrapeErrorsByReasonTotalMetric      *prometheus.CounterVec
+	deprecatedScrapeErrorsTotalMetric    prometheus.Counter
+	lastTargetsScrapeErrorDesc           *prometheus.Desc
+	lastTargetsScrapeTimestampDesc       *prometheus.Desc
+	lastTargetsScrapeDurationSecondsDesc *prometheus.Desc
 }
 
 func NewTargetsCollector(
 	namespace string,
 	environment string,
 	backendName string,
+	shieldClient *client.Client,
+	deprecatedNames bool,
 ) *TargetsCollector {
-	targetsTotalMetric := prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "targets",
-			Name:        "total",
-			Help:        "Labeled total number of Shield Targets.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	targetsTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "targets", "total"),
+		"Labeled total number of Shield Targets.",
 		[]string{"target_plugin"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	targetReachableDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "target", "reachable"),
+		"Whether the Shield agent of a Shield Target is connected to the core (1 for reachable, 0 for unreachable).",
+		[]string{"target_name", "target_plugin"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
+	jobsByTargetTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "jobs", "by_target_total"),
+		"Total number of Shield Jobs backing up a Shield Target.",
+		[]string{"target_name"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	targetsUnusedTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "targets", "unused_total"),
+		"Total number of Shield Targets not backed up by any Shield Job.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	collectorAvailableDesc := newCollectorAvailableDesc(namespace, environment, backendName, filters.TargetsCollector)
+	scrapeErrorsByReasonTotalMetric := newScrapeErrorsByReasonTotalMetric(namespace, environment, backendName, filters.TargetsCollector)
+
 	targetsScrapesTotalMetric := prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Namespace:   namespace,
@@ -50,52 +83,63 @@ func NewTargetsCollector(
 		prometheus.CounterOpts{
 			Namespace:   namespace,
 			Subsystem:   "targets",
-			Name:        "scrape_errorstotal",
+			Name:        "scrape_errors_total",
 			Help:        "Total number of scrape errors of Shield Targets.",
 			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
 		},
 	)
 
-	lastTargetsScrapeErrorMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_targets_scrape_error",
-			Help:        "Whether the last scrape of Target metrics from Shield resulted in an error (1 for error, 0 for success).",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	var deprecatedScrapeErrorsTotalMetric prometheus.Counter
+	if deprecatedNames {
+		deprecatedScrapeErrorsTotalMetric = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace:   namespace,
+				Subsystem:   "targets",
+				Name:        "scrape_errorstotal",
+				Help:        "DEPRECATED: use scrape_errors_total. Total number of scrape errors of Shield Targets.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+	}
+
+	lastTargetsScrapeErrorDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_targets_scrape_error"),
+		"Whether the last scrape of Target metrics from Shield resulted in an error (1 for error, 0 for success).",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	lastTargetsScrapeTimestampMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_targets_scrape_timestamp",
-			Help:        "Number of seconds since 1970 since last scrape of Target metrics from Shield.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastTargetsScrapeTimestampDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_targets_scrape_timestamp"),
+		"Number of seconds since 1970 since last scrape of Target metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	lastTargetsScrapeDurationSecondsMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_targets_scrape_duration_seconds",
-			Help:        "Duration of the last scrape of Target metrics from Shield.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastTargetsScrapeDurationSecondsDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_targets_scrape_duration_seconds"),
+		"Duration of the last scrape of Target metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
 	return &TargetsCollector{
-		namespace:                              namespace,
-		environment:                            environment,
-		backendName:                            backendName,
-		targetsTotalMetric:                     targetsTotalMetric,
-		targetsScrapesTotalMetric:              targetsScrapesTotalMetric,
-		targetsScrapeErrorsTotalMetric:         targetsScrapeErrorsTotalMetric,
-		lastTargetsScrapeErrorMetric:           lastTargetsScrapeErrorMetric,
-		lastTargetsScrapeTimestampMetric:       lastTargetsScrapeTimestampMetric,
-		lastTargetsScrapeDurationSecondsMetric: lastTargetsScrapeDurationSecondsMetric,
+		namespace:                            namespace,
+		environment:                          environment,
+		backendName:                          backendName,
+		shieldClient:                         shieldClient,
+		targetsTotalDesc:                     targetsTotalDesc,
+		targetReachableDesc:                  targetReachableDesc,
+		jobsByTargetTotalDesc:                jobsByTargetTotalDesc,
+		targetsUnusedTotalDesc:               targetsUnusedTotalDesc,
+		collectorAvailableDesc:               collectorAvailableDesc,
+		targetsScrapesTotalMetric:            targetsScrapesTotalMetric,
+		targetsScrapeErrorsTotalMetric:       targetsScrapeErrorsTotalMetric,
+		scrapeErrorsByReasonTotalMetric:      scrapeErrorsByReasonTotalMetric,
+		deprecatedScrapeErrorsTotalMetric:    deprecatedScrapeErrorsTotalMetric,
+		lastTargetsScrapeErrorDesc:           lastTargetsScrapeErrorDesc,
+		lastTargetsScrapeTimestampDesc:       lastTargetsScrapeTimestampDesc,
+		lastTargetsScrapeDurationSecondsDesc: lastTargetsScrapeDurationSecondsDesc,
 	}
 }
 
@@ -103,48 +147,123 @@ func (c TargetsCollector) Collect(ch chan<- prometheus.Metric) {
 	var begun = time.Now()
 
 	errorMetric := float64(0)
-	if err := c.reportTargetsMetrics(ch); err != nil {
+	err := c.reportTargetsMetrics(ch)
+	if isScrapeError(err) {
 		errorMetric = float64(1)
 		c.targetsScrapeErrorsTotalMetric.Inc()
+		c.scrapeErrorsByReasonTotalMetric.WithLabelValues(client.ErrorReason(err)).Inc()
+		if c.deprecatedScrapeErrorsTotalMetric != nil {
+			c.deprecatedScrapeErrorsTotalMetric.Inc()
+		}
 	}
 	c.targetsScrapeErrorsTotalMetric.Collect(ch)
+	c.scrapeErrorsByReasonTotalMetric.Collect(ch)
+	if c.deprecatedScrapeErrorsTotalMetric != nil {
+		c.deprecatedScrapeErrorsTotalMetric.Collect(ch)
+	}
 
 	c.targetsScrapesTotalMetric.Inc()
 	c.targetsScrapesTotalMetric.Collect(ch)
 
-	c.lastTargetsScrapeErrorMetric.Set(errorMetric)
-	c.lastTargetsScrapeErrorMetric.Collect(ch)
-
-	c.lastTargetsScrapeTimestampMetric.Set(float64(time.Now().Unix()))
-	c.lastTargetsScrapeTimestampMetric.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(c.collectorAvailableDesc, prometheus.GaugeValue, availability(err))
+	ch <- prometheus.MustNewConstMetric(c.lastTargetsScrapeErrorDesc, prometheus.GaugeValue, errorMetric)
+	ch <- prometheus.MustNewConstMetric(c.lastTargetsScrapeTimestampDesc, prometheus.GaugeValue, float64(time.Now().Unix()))
+	ch <- prometheus.MustNewConstMetric(c.lastTargetsScrapeDurationSecondsDesc, prometheus.GaugeValue, time.Since(begun).Seconds())
+}
 
-	c.lastTargetsScrapeDurationSecondsMetric.Set(time.Since(begun).Seconds())
-	c.lastTargetsScrapeDurationSecondsMetric.Collect(ch)
+func (c TargetsCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	c.shieldClient = c.shieldClient.WithContext(ctx)
+	c.Collect(ch)
 }
 
 func (c TargetsCollector) Describe(ch chan<- *prometheus.Desc) {
-	c.targetsTotalMetric.Describe(ch)
+	ch <- c.targetsTotalDesc
+	ch <- c.targetReachableDesc
+	ch <- c.jobsByTargetTotalDesc
+	ch <- c.targetsUnusedTotalDesc
+	ch <- c.collectorAvailableDesc
 	c.targetsScrapesTotalMetric.Describe(ch)
 	c.targetsScrapeErrorsTotalMetric.Describe(ch)
-	c.lastTargetsScrapeErrorMetric.Describe(ch)
-	c.lastTargetsScrapeTimestampMetric.Describe(ch)
-	c.lastTargetsScrapeDurationSecondsMetric.Describe(ch)
+	c.scrapeErrorsByReasonTotalMetric.Describe(ch)
+	if c.deprecatedScrapeErrorsTotalMetric != nil {
+		c.deprecatedScrapeErrorsTotalMetric.Describe(ch)
+	}
+	ch <- c.lastTargetsScrapeErrorDesc
+	ch <- c.lastTargetsScrapeTimestampDesc
+	ch <- c.lastTargetsScrapeDurationSecondsDesc
 }
 
 func (c TargetsCollector) reportTargetsMetrics(ch chan<- prometheus.Metric) error {
-	c.targetsTotalMetric.Reset()
+	targets, err := c.shieldClient.GetTargets()
+	if err != nil {
+		logError(err, "Error while listing targets: %v", err)
+		return err
+	}
+
+	targetsTotal := make(map[string]float64)
+	for _, target := range targets {
+		targetsTotal[target.Plugin]++
+	}
+
+	for plugin, total := range targetsTotal {
+		ch <- prometheus.MustNewConstMetric(c.targetsTotalDesc, prometheus.GaugeValue, total, plugin)
+	}
+
+	targetNames := make(map[string]string)
+	for _, target := range targets {
+		targetNames[target.UUID] = target.Name
+	}
+
+	jobsByTarget, unused, err := countJobsBy(c.shieldClient, targetNames, func(job api.Job) string { return job.TargetUUID })
+	if err != nil {
+		return err
+	}
+
+	for name, total := range jobsByTarget {
+		ch <- prometheus.MustNewConstMetric(c.jobsByTargetTotalDesc, prometheus.GaugeValue, total, name)
+	}
+	ch <- prometheus.MustNewConstMetric(c.targetsUnusedTotalDesc, prometheus.GaugeValue, unused)
 
-	targets, err := api.GetTargets(api.TargetFilter{})
+	// Only Shield v8 cores know about the connectivity of their agents, older
+	// ones do not implement the agents listing, whether or not the listings
+	// are scoped to a tenant.
+	agents, err := c.shieldClient.GetAgents()
+	if client.IsNotImplemented(err) || client.IsNotFound(err) {
+		log.Debugf("Not reporting the reachability of targets, the Shield backend does not list agents: %v", err)
+		return nil
+	}
 	if err != nil {
-		log.Errorf("Error while listing targets: %v", err)
+		logError(err, "Error while listing agents: %v", err)
 		return err
 	}
 
+	reachableAgents := make(map[string]bool)
+	for _, agent := range agents {
+		if agent.Status == "ok" {
+			reachableAgents[agent.Address] = true
+		}
+	}
+
+	// Targets sharing a name and plugin are only reachable if all of them are.
+	type targetKey struct {
+		name   string
+		plugin string
+	}
+	targetsReachable := make(map[targetKey]float64)
 	for _, target := range targets {
-		c.targetsTotalMetric.WithLabelValues(target.Plugin).Inc()
+		key := targetKey{target.Name, target.Plugin}
+		if reachable, ok := targetsReachable[key]; ok && reachable == 0 {
+			continue
+		}
+		targetsReachable[key] = 0
+		if reachableAgents[target.Agent] {
+			targetsReachable[key] = 1
+		}
 	}
 
-	c.targetsTotalMetric.Collect(ch)
+	for key, reachable := range targetsReachable {
+		ch <- prometheus.MustNewConstMetric(c.targetReachableDesc, prometheus.GaugeValue, reachable, key.name, key.plugin)
+	}
 
 	return nil
 }
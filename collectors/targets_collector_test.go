@@ -11,6 +11,7 @@ import (
 	"github.com/prometheus/common/log"
 	"github.com/starkandwayne/shield/api"
 
+	"github.com/bosh-prometheus/shield_exporter/client"
 	. "github.com/bosh-prometheus/shield_exporter/collectors"
 	. "github.com/bosh-prometheus/shield_exporter/utils/test_matchers"
 )
@@ -24,6 +25,8 @@ var _ = Describe("TargetsCollectors", func() {
 		err    error
 		server *ghttp.Server
 
+		shieldClient *client.Client
+
 		namespace   = "test_namespace"
 		environment = "test_environment"
 		backendName = "test_backend"
@@ -33,10 +36,20 @@ var _ = Describe("TargetsCollectors", func() {
 
 		targetPlugin1 = "target_plugin_1"
 		targetPlugin2 = "target_plugin_2"
+		targetName1   = "target_name_1"
+		targetName2   = "target_name_2"
+		agentAddress1 = "10.0.0.1:5444"
+		agentAddress2 = "10.0.0.2:5444"
+
+		deprecatedNames bool
 
 		targetsTotalMetric                     *prometheus.GaugeVec
+		targetsUnusedTotalMetric               prometheus.Gauge
+		targetReachableMetric                  *prometheus.GaugeVec
+		jobsByTargetTotalMetric                *prometheus.GaugeVec
 		targetsScrapesTotalMetric              prometheus.Counter
 		targetsScrapeErrorsTotalMetric         prometheus.Counter
+		deprecatedScrapeErrorsTotalMetric      prometheus.Counter
 		lastTargetsScrapeErrorMetric           prometheus.Gauge
 		lastTargetsScrapeTimestampMetric       prometheus.Gauge
 		lastTargetsScrapeDurationSecondsMetric prometheus.Gauge
@@ -45,25 +58,14 @@ var _ = Describe("TargetsCollectors", func() {
 	)
 
 	BeforeEach(func() {
-		server = ghttp.NewServer()
-		server.AppendHandlers(
-			ghttp.CombineHandlers(
-				ghttp.VerifyRequest("GET", "/v1/ping"),
-				ghttp.RespondWith(http.StatusOK, "{}"),
-			),
-		)
+		deprecatedNames = false
 
-		api.Cfg = &api.Config{
-			Backend:  "default",
-			Backends: map[string]string{},
-			Aliases:  map[string]string{},
-		}
-
-		err = api.Cfg.AddBackend(server.URL(), "default")
-		Expect(err).ToNot(HaveOccurred())
-
-		authToken := api.BasicAuthToken(username, password)
-		err = api.Cfg.UpdateBackend("default", authToken)
+		server = ghttp.NewServer()
+		shieldClient, err = client.NewClient(client.Config{
+			BackendURL: server.URL(),
+			Username:   username,
+			Password:   password,
+		})
 		Expect(err).ToNot(HaveOccurred())
 
 		targetsTotalMetric = prometheus.NewGaugeVec(
@@ -79,6 +81,43 @@ var _ = Describe("TargetsCollectors", func() {
 		targetsTotalMetric.WithLabelValues(targetPlugin1).Set(2)
 		targetsTotalMetric.WithLabelValues(targetPlugin2).Set(1)
 
+		targetReachableMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "target",
+				Name:        "reachable",
+				Help:        "Whether the Shield agent of a Shield Target is connected to the core (1 for reachable, 0 for unreachable).",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"target_name", "target_plugin"},
+		)
+		targetReachableMetric.WithLabelValues(targetName1, targetPlugin1).Set(1)
+		targetReachableMetric.WithLabelValues(targetName2, targetPlugin2).Set(0)
+
+		jobsByTargetTotalMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "jobs",
+				Name:        "by_target_total",
+				Help:        "Total number of Shield Jobs backing up a Shield Target.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"target_name"},
+		)
+		jobsByTargetTotalMetric.WithLabelValues(targetName1).Set(3)
+		jobsByTargetTotalMetric.WithLabelValues(targetName2).Set(0)
+
+		targetsUnusedTotalMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "targets",
+				Name:        "unused_total",
+				Help:        "Total number of Shield Targets not backed up by any Shield Job.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+		targetsUnusedTotalMetric.Set(1)
+
 		targetsScrapesTotalMetric = prometheus.NewCounter(
 			prometheus.CounterOpts{
 				Namespace:   namespace,
@@ -94,12 +133,22 @@ var _ = Describe("TargetsCollectors", func() {
 			prometheus.CounterOpts{
 				Namespace:   namespace,
 				Subsystem:   "targets",
-				Name:        "scrape_errorstotal",
+				Name:        "scrape_errors_total",
 				Help:        "Total number of scrape errors of Shield Targets.",
 				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
 			},
 		)
 
+		deprecatedScrapeErrorsTotalMetric = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace:   namespace,
+				Subsystem:   "targets",
+				Name:        "scrape_errorstotal",
+				Help:        "DEPRECATED: use scrape_errors_total. Total number of scrape errors of Shield Targets.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+
 		lastTargetsScrapeErrorMetric = prometheus.NewGauge(
 			prometheus.GaugeOpts{
 				Namespace:   namespace,
@@ -132,7 +181,7 @@ var _ = Describe("TargetsCollectors", func() {
 	})
 
 	JustBeforeEach(func() {
-		targetsCollector = NewTargetsCollector(namespace, environment, backendName)
+		targetsCollector = NewTargetsCollector(namespace, environment, backendName, shieldClient, deprecatedNames)
 	})
 
 	AfterEach(func() {
@@ -156,6 +205,18 @@ var _ = Describe("TargetsCollectors", func() {
 			Eventually(descriptions).Should(Receive(Equal(targetsTotalMetric.WithLabelValues(targetPlugin1).Desc())))
 		})
 
+		It("returns a target_reachable metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(targetReachableMetric.WithLabelValues(targetName1, targetPlugin1).Desc())))
+		})
+
+		It("returns a jobs_by_target_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(jobsByTargetTotalMetric.WithLabelValues(targetName1).Desc())))
+		})
+
+		It("returns a targets_unused_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(targetsUnusedTotalMetric.Desc())))
+		})
+
 		It("returns a targets_scrapes_total metric description", func() {
 			Eventually(descriptions).Should(Receive(Equal(targetsScrapesTotalMetric.Desc())))
 		})
@@ -175,12 +236,27 @@ var _ = Describe("TargetsCollectors", func() {
 		It("returns a last_targets_scrape_duration_seconds metric description", func() {
 			Eventually(descriptions).Should(Receive(Equal(lastTargetsScrapeDurationSecondsMetric.Desc())))
 		})
+
+		Context("when deprecated names are enabled", func() {
+			BeforeEach(func() {
+				deprecatedNames = true
+			})
+
+			It("returns a targets_scrape_errorstotal metric description", func() {
+				Eventually(descriptions).Should(Receive(Equal(deprecatedScrapeErrorsTotalMetric.Desc())))
+			})
+		})
 	})
 
 	Describe("Collect", func() {
 		var (
 			statusCode      int
 			targetsResponse []api.Target
+			jobsStatusCode  int
+			jobsResponse    []api.Job
+			tenant          string
+			agentsCode      int
+			agentsResponse  map[string][]client.Agent
 			metrics         chan prometheus.Metric
 		)
 
@@ -188,26 +264,81 @@ var _ = Describe("TargetsCollectors", func() {
 			statusCode = http.StatusOK
 			targetsResponse = []api.Target{
 				api.Target{
+					UUID:   "target_uuid_1",
+					Name:   targetName1,
 					Plugin: targetPlugin1,
+					Agent:  agentAddress1,
 				},
 				api.Target{
+					UUID:   "target_uuid_2",
+					Name:   targetName1,
 					Plugin: targetPlugin1,
+					Agent:  agentAddress1,
 				},
 				api.Target{
+					UUID:   "target_uuid_3",
+					Name:   targetName2,
 					Plugin: targetPlugin2,
+					Agent:  agentAddress2,
+				},
+			}
+			jobsStatusCode = http.StatusOK
+			jobsResponse = []api.Job{
+				api.Job{TargetUUID: "target_uuid_1"},
+				api.Job{TargetUUID: "target_uuid_1"},
+				api.Job{TargetUUID: "target_uuid_2"},
+			}
+			tenant = ""
+			agentsCode = http.StatusOK
+			agentsResponse = map[string][]client.Agent{
+				"agents": []client.Agent{
+					client.Agent{Address: agentAddress1, Status: "ok"},
+					client.Agent{Address: agentAddress2, Status: "failing"},
 				},
 			}
 			metrics = make(chan prometheus.Metric)
 		})
 
 		JustBeforeEach(func() {
-			server.AppendHandlers(
-				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("GET", "/v1/targets"),
-					ghttp.VerifyBasicAuth(username, password),
-					ghttp.RespondWithJSONEncodedPtr(&statusCode, &targetsResponse),
-				),
-			)
+			if tenant == "" {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v1/targets"),
+						ghttp.VerifyBasicAuth(username, password),
+						ghttp.RespondWithJSONEncodedPtr(&statusCode, &targetsResponse),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v1/jobs"),
+						ghttp.VerifyBasicAuth(username, password),
+						ghttp.RespondWithJSONEncodedPtr(&jobsStatusCode, &jobsResponse),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v2/agents"),
+						ghttp.VerifyBasicAuth(username, password),
+						ghttp.RespondWithJSONEncodedPtr(&agentsCode, &agentsResponse),
+					),
+				)
+			} else {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v2/tenants"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, []client.Tenant{client.Tenant{UUID: "tenant-uuid", Name: tenant}}),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v2/tenants/tenant-uuid/targets"),
+						ghttp.RespondWithJSONEncodedPtr(&statusCode, &targetsResponse),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v2/tenants/tenant-uuid/jobs"),
+						ghttp.RespondWithJSONEncodedPtr(&jobsStatusCode, &jobsResponse),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v2/agents"),
+						ghttp.RespondWithJSONEncodedPtr(&agentsCode, &agentsResponse),
+					),
+				)
+				targetsCollector = NewTargetsCollector(namespace, environment, backendName, shieldClient.WithTenant(tenant), deprecatedNames)
+			}
 			go targetsCollector.Collect(metrics)
 		})
 
@@ -219,6 +350,10 @@ var _ = Describe("TargetsCollectors", func() {
 			Eventually(metrics).Should(Receive(PrometheusMetric(targetsTotalMetric.WithLabelValues(targetPlugin2))))
 		})
 
+		It("returns a targets_unused_total metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(targetsUnusedTotalMetric)))
+		})
+
 		It("returns a targets_scrapes_total metric", func() {
 			Eventually(metrics).Should(Receive(PrometheusMetric(targetsScrapesTotalMetric)))
 		})
@@ -231,6 +366,92 @@ var _ = Describe("TargetsCollectors", func() {
 			Eventually(metrics).Should(Receive(PrometheusMetric(lastTargetsScrapeErrorMetric)))
 		})
 
+		It("returns a jobs_by_target_total metric summing the targets sharing a name", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(jobsByTargetTotalMetric.WithLabelValues(targetName1))))
+		})
+
+		It("returns a jobs_by_target_total metric for a target without jobs", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(jobsByTargetTotalMetric.WithLabelValues(targetName2))))
+		})
+
+		Context("when it fails to list the jobs", func() {
+			BeforeEach(func() {
+				jobsStatusCode = http.StatusInternalServerError
+				targetsScrapeErrorsTotalMetric.Inc()
+				lastTargetsScrapeErrorMetric.Set(1)
+			})
+
+			It("returns a targets_scrape_errors_total metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(targetsScrapeErrorsTotalMetric)))
+			})
+
+			It("returns a last_targets_scrape_error metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(lastTargetsScrapeErrorMetric)))
+			})
+		})
+
+		It("returns a target_reachable metric when the targets are not scoped to a tenant", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(targetReachableMetric.WithLabelValues(targetName1, targetPlugin1))))
+		})
+
+		Context("when the Shield backend does not implement the agents listing", func() {
+			BeforeEach(func() {
+				agentsCode = http.StatusNotImplemented
+			})
+
+			It("does not return a target_reachable metric", func() {
+				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(targetReachableMetric.WithLabelValues(targetName1, targetPlugin1))))
+			})
+
+			It("returns a last_targets_scrape_error metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(lastTargetsScrapeErrorMetric)))
+			})
+		})
+
+		Context("when the Shield backend does not know the agents listing", func() {
+			BeforeEach(func() {
+				agentsCode = http.StatusNotFound
+			})
+
+			It("does not return a target_reachable metric", func() {
+				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(targetReachableMetric.WithLabelValues(targetName1, targetPlugin1))))
+			})
+
+			It("returns a last_targets_scrape_error metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(lastTargetsScrapeErrorMetric)))
+			})
+		})
+
+		Context("when the targets are scoped to a tenant", func() {
+			BeforeEach(func() {
+				tenant = "tenant"
+			})
+
+			It("returns a target_reachable metric for a target with a connected agent", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(targetReachableMetric.WithLabelValues(targetName1, targetPlugin1))))
+			})
+
+			It("returns a target_reachable metric for a target with a failing agent", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(targetReachableMetric.WithLabelValues(targetName2, targetPlugin2))))
+			})
+
+			Context("and targets sharing a name have failing agents", func() {
+				BeforeEach(func() {
+					targetsResponse[1].Agent = agentAddress2
+					targetReachableMetric.WithLabelValues(targetName1, targetPlugin1).Set(0)
+				})
+
+				It("returns a single unreachable target_reachable metric for them", func() {
+					Eventually(metrics).Should(Receive(PrometheusMetric(targetReachableMetric.WithLabelValues(targetName1, targetPlugin1))))
+					Consistently(metrics).ShouldNot(Receive(PrometheusMetric(targetReachableMetric.WithLabelValues(targetName1, targetPlugin1))))
+				})
+			})
+
+			It("returns a targets_total metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(targetsTotalMetric.WithLabelValues(targetPlugin1))))
+			})
+		})
+
 		Context("when it fails to list the targets", func() {
 			BeforeEach(func() {
 				statusCode = http.StatusInternalServerError
@@ -246,5 +467,26 @@ var _ = Describe("TargetsCollectors", func() {
 				Eventually(metrics).Should(Receive(PrometheusMetric(lastTargetsScrapeErrorMetric)))
 			})
 		})
+
+		Context("when deprecated names are enabled", func() {
+			BeforeEach(func() {
+				deprecatedNames = true
+			})
+
+			It("returns a targets_scrape_errorstotal metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(deprecatedScrapeErrorsTotalMetric)))
+			})
+
+			Context("and it fails to list the targets", func() {
+				BeforeEach(func() {
+					statusCode = http.StatusInternalServerError
+					deprecatedScrapeErrorsTotalMetric.Inc()
+				})
+
+				It("returns a targets_scrape_errorstotal metric", func() {
+					Eventually(metrics).Should(Receive(PrometheusMetric(deprecatedScrapeErrorsTotalMetric)))
+				})
+			})
+		})
 	})
 })
@@ -1,42 +1,149 @@
 package collectors
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/common/log"
 	"github.com/starkandwayne/shield/api"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+	"github.com/bosh-prometheus/shield_exporter/filters"
+)
+
+const (
+	RestoreOperation = "restore"
+	PurgeOperation   = "purge"
 )
 
+type taskLabels struct {
+	operation    string
+	status       string
+	storePlugin  string
+	targetPlugin string
+	jobName      string
+}
+
+type taskDurations struct {
+	min   float64
+	max   float64
+	sum   float64
+	count float64
+}
+
+func (d *taskDurations) observe(duration float64) {
+	if d.count == 0 || duration < d.min {
+		d.min = duration
+	}
+	if d.count == 0 || duration > d.max {
+		d.max = duration
+	}
+	d.sum += duration
+	d.count++
+}
+
+type taskBytesLabels struct {
+	operation string
+	jobName   string
+}
+
+// tasksArchives are the tasks whose metrics need their archive, which is
+// resolved by listing the archives.
+type tasksArchives struct {
+	lastPurgeSuccessByArchive map[string]int64
+	lastTasksByLabels         map[taskBytesLabels]api.Task
+	restoresByArchive         map[string]float64
+}
+
+func (a tasksArchives) empty() bool {
+	return len(a.lastPurgeSuccessByArchive) == 0 && len(a.lastTasksByLabels) == 0 && len(a.restoresByArchive) == 0
+}
+
+// countedTasks are the UUIDs of the tasks already counted by a metric, ie the
+// job_tasks_total counters, among the ones still in the Shield task history.
+type countedTasks struct {
+	sync.Mutex
+	uuids map[string]bool
+}
+
+type jobTask struct {
+	uuid        string
+	labelValues []string
+}
+
+type taskDuration struct {
+	uuid        string
+	labelValues []string
+	duration    float64
+}
+
+func (l taskLabels) values(jobNameLabel bool) []string {
+	if jobNameLabel {
+		return []string{l.operation, l.status, l.storePlugin, l.targetPlugin, l.jobName}
+	}
+	return []string{l.operation, l.status, l.storePlugin, l.targetPlugin}
+}
+
 type TasksCollector struct {
-	namespace                            string
-	environment                          string
-	backendName                          string
-	tasksTotalMetric                     *prometheus.GaugeVec
-	tasksDurationSecondsMetric           *prometheus.SummaryVec
-	tasksScrapesTotalMetric              prometheus.Counter
-	tasksScrapeErrorsTotalMetric         prometheus.Counter
-	lastTasksScrapeErrorMetric           prometheus.Gauge
-	lastTasksScrapeTimestampMetric       prometheus.Gauge
-	lastTasksScrapeDurationSecondsMetric prometheus.Gauge
+	namespace                          string
+	environment                        string
+	backendName                        string
+	shieldClient                       *client.Client
+	jobNameLabel                       bool
+	restoreSuccessWindow               time.Duration
+	legacyJobLabels                    bool
+	tasksTotalDesc                     *prometheus.Desc
+	restoreSuccessRatioDesc            *prometheus.Desc
+	purgeTasksTotalDesc                *prometheus.Desc
+	storeLastPurgeSuccessTimestampDesc *prometheus.Desc
+	taskBytesDesc                      *prometheus.Desc
+	targetRestoresTotalDesc            *prometheus.Desc
+	tasksDurationSecondsMetric         *prometheus.SummaryVec
+	tasksDurationHistogramMetric       *prometheus.HistogramVec
+	observedTasks                      *countedTasks
+	jobTasksTotalMetric                *prometheus.CounterVec
+	countedTasks                       *countedTasks
+	tasksDurationSecondsMinDesc        *prometheus.Desc
+	tasksDurationSecondsMaxDesc        *prometheus.Desc
+	tasksDurationSecondsAvgDesc        *prometheus.Desc
+	collectorAvailableDesc             *prometheus.Desc
+	tasksScrapesTotalMetric            prometheus.Counter
+	tasksScrapeErrorsTotalMetric       prometheus.Counter
+	scrapeErrorsByReasonTotalMetric    *prometheus.CounterVec
+	lastTasksScrapeErrorDesc           *prometheus.Desc
+	lastTasksScrapeTimestampDesc       *prometheus.Desc
+	lastTasksScrapeDurationSecondsDesc *prometheus.Desc
+	maintenanceMetrics                 *maintenanceMetrics
 }
 
 func NewTasksCollector(
 	namespace string,
 	environment string,
 	backendName string,
+	shieldClient *client.Client,
+	durationObjectives map[float64]float64,
+	durationMaxAge time.Duration,
+	durationAgeBuckets uint32,
+	durationNativeHistogramBucketFactor float64,
+	jobNameLabel bool,
+	restoreSuccessWindow time.Duration,
+	legacyJobLabels bool,
 ) *TasksCollector {
-	tasksTotalMetric := prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "tasks",
-			Name:        "total",
-			Help:        "Labeled total number of Shield Tasks.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
-		[]string{"task_operation", "task_status"},
+	labelNames := []string{"task_operation", "task_status", "store_plugin", "target_plugin"}
+	if jobNameLabel {
+		labelNames = append(labelNames, "job_name")
+	}
+
+	tasksTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "tasks", "total"),
+		"Labeled total number of Shield Tasks.",
+		labelNames,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
+	// Every task is observed once by the durations summary, so the tasks
+	// leave its quantiles after the max age.
 	tasksDurationSecondsMetric := prometheus.NewSummaryVec(
 		prometheus.SummaryOpts{
 			Namespace:   namespace,
@@ -44,10 +151,104 @@ func NewTasksCollector(
 			Name:        "duration_seconds",
 			Help:        "Labeled summary of Shield Task durations in seconds.",
 			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			Objectives:  durationObjectives,
+			MaxAge:      durationMaxAge,
+			AgeBuckets:  durationAgeBuckets,
+		},
+		labelNames,
+	)
+
+	// The native histogram is only exposed when a bucket factor is set, and
+	// has no classic buckets.
+	var tasksDurationHistogramMetric *prometheus.HistogramVec
+	if durationNativeHistogramBucketFactor > 1 {
+		tasksDurationHistogramMetric = prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace:                   namespace,
+				Subsystem:                   "tasks",
+				Name:                        "duration_histogram_seconds",
+				Help:                        "Labeled native histogram of Shield Task durations in seconds.",
+				ConstLabels:                 prometheus.Labels{"environment": environment, "backend_name": backendName},
+				NativeHistogramBucketFactor: durationNativeHistogramBucketFactor,
+			},
+			labelNames,
+		)
+	}
+
+	tasksDurationSecondsMinDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "tasks", "duration_seconds_min"),
+		"Minimum duration in seconds of the listed Shield Tasks.",
+		[]string{"task_operation"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	tasksDurationSecondsMaxDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "tasks", "duration_seconds_max"),
+		"Maximum duration in seconds of the listed Shield Tasks.",
+		[]string{"task_operation"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	tasksDurationSecondsAvgDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "tasks", "duration_seconds_avg"),
+		"Average duration in seconds of the listed Shield Tasks.",
+		[]string{"task_operation"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	restoreSuccessRatioDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "restore", "success_ratio"),
+		"Ratio of the Shield restore Tasks finished in the window that succeeded.",
+		[]string{"target_plugin"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	purgeTasksTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "purge_tasks", "total"),
+		"Labeled total number of Shield purge Tasks.",
+		[]string{"task_status"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	storeLastPurgeSuccessTimestampDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "store", "last_purge_success_timestamp"),
+		"Number of seconds since 1970 since the last successful Shield purge Task of a Shield Store.",
+		[]string{"store_name"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	taskBytesDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "task", "bytes"),
+		"Size in bytes of the archive of the last successful Shield Task of a Shield Job by operation.",
+		[]string{"task_operation", "job_name"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	targetRestoresTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "target", "restores_total"),
+		"Total number of successful Shield restore Tasks of a Shield Target finished in the window.",
+		[]string{"target_name"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	jobLabels := jobLabelNames
+	if legacyJobLabels {
+		jobLabels = legacyJobLabelNames
+	}
+	jobTasksTotalMetric := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "job",
+			Name:        "tasks_total",
+			Help:        "Total number of finished Shield Tasks of a Shield Job by status.",
+			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
 		},
-		[]string{"task_operation", "task_status"},
+		append(append([]string{}, jobLabels...), "status"),
 	)
 
+	collectorAvailableDesc := newCollectorAvailableDesc(namespace, environment, backendName, filters.TasksCollector)
+	scrapeErrorsByReasonTotalMetric := newScrapeErrorsByReasonTotalMetric(namespace, environment, backendName, filters.TasksCollector)
+
 	tasksScrapesTotalMetric := prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Namespace:   namespace,
@@ -68,47 +269,57 @@ func NewTasksCollector(
 		},
 	)
 
-	lastTasksScrapeErrorMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_tasks_scrape_error",
-			Help:        "Whether the last scrape of Task metrics from Shield resulted in an error (1 for error, 0 for success).",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastTasksScrapeErrorDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_tasks_scrape_error"),
+		"Whether the last scrape of Task metrics from Shield resulted in an error (1 for error, 0 for success).",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	lastTasksScrapeTimestampMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_tasks_scrape_timestamp",
-			Help:        "Number of seconds since 1970 since last scrape of Task metrics from Shield.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastTasksScrapeTimestampDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_tasks_scrape_timestamp"),
+		"Number of seconds since 1970 since last scrape of Task metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
-	lastTasksScrapeDurationSecondsMetric := prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Namespace:   namespace,
-			Subsystem:   "",
-			Name:        "last_tasks_scrape_duration_seconds",
-			Help:        "Duration of the last scrape of Task metrics from Shield.",
-			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
-		},
+	lastTasksScrapeDurationSecondsDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_tasks_scrape_duration_seconds"),
+		"Duration of the last scrape of Task metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
 	)
 
 	return &TasksCollector{
-		namespace:                            namespace,
-		environment:                          environment,
-		backendName:                          backendName,
-		tasksTotalMetric:                     tasksTotalMetric,
-		tasksDurationSecondsMetric:           tasksDurationSecondsMetric,
-		tasksScrapesTotalMetric:              tasksScrapesTotalMetric,
-		tasksScrapeErrorsTotalMetric:         tasksScrapeErrorsTotalMetric,
-		lastTasksScrapeErrorMetric:           lastTasksScrapeErrorMetric,
-		lastTasksScrapeTimestampMetric:       lastTasksScrapeTimestampMetric,
-		lastTasksScrapeDurationSecondsMetric: lastTasksScrapeDurationSecondsMetric,
+		namespace:                          namespace,
+		environment:                        environment,
+		backendName:                        backendName,
+		shieldClient:                       shieldClient,
+		jobNameLabel:                       jobNameLabel,
+		restoreSuccessWindow:               restoreSuccessWindow,
+		legacyJobLabels:                    legacyJobLabels,
+		tasksTotalDesc:                     tasksTotalDesc,
+		restoreSuccessRatioDesc:            restoreSuccessRatioDesc,
+		purgeTasksTotalDesc:                purgeTasksTotalDesc,
+		storeLastPurgeSuccessTimestampDesc: storeLastPurgeSuccessTimestampDesc,
+		taskBytesDesc:                      taskBytesDesc,
+		targetRestoresTotalDesc:            targetRestoresTotalDesc,
+		tasksDurationSecondsMetric:         tasksDurationSecondsMetric,
+		tasksDurationHistogramMetric:       tasksDurationHistogramMetric,
+		observedTasks:                      &countedTasks{uuids: make(map[string]bool)},
+		jobTasksTotalMetric:                jobTasksTotalMetric,
+		countedTasks:                       &countedTasks{uuids: make(map[string]bool)},
+		tasksDurationSecondsMinDesc:        tasksDurationSecondsMinDesc,
+		tasksDurationSecondsMaxDesc:        tasksDurationSecondsMaxDesc,
+		tasksDurationSecondsAvgDesc:        tasksDurationSecondsAvgDesc,
+		collectorAvailableDesc:             collectorAvailableDesc,
+		tasksScrapesTotalMetric:            tasksScrapesTotalMetric,
+		tasksScrapeErrorsTotalMetric:       tasksScrapeErrorsTotalMetric,
+		scrapeErrorsByReasonTotalMetric:    scrapeErrorsByReasonTotalMetric,
+		lastTasksScrapeErrorDesc:           lastTasksScrapeErrorDesc,
+		lastTasksScrapeTimestampDesc:       lastTasksScrapeTimestampDesc,
+		lastTasksScrapeDurationSecondsDesc: lastTasksScrapeDurationSecondsDesc,
+		maintenanceMetrics:                 &maintenanceMetrics{},
 	}
 }
 
@@ -116,58 +327,320 @@ func (c TasksCollector) Collect(ch chan<- prometheus.Metric) {
 	var begun = time.Now()
 
 	errorMetric := float64(0)
-	if err := c.reportTasksMetrics(ch); err != nil {
+	err := c.reportTasksMetrics(ch)
+	if isScrapeError(err) {
 		errorMetric = float64(1)
 		c.tasksScrapeErrorsTotalMetric.Inc()
+		c.scrapeErrorsByReasonTotalMetric.WithLabelValues(client.ErrorReason(err)).Inc()
 	}
 	c.tasksScrapeErrorsTotalMetric.Collect(ch)
+	c.scrapeErrorsByReasonTotalMetric.Collect(ch)
 
 	c.tasksScrapesTotalMetric.Inc()
 	c.tasksScrapesTotalMetric.Collect(ch)
 
-	c.lastTasksScrapeErrorMetric.Set(errorMetric)
-	c.lastTasksScrapeErrorMetric.Collect(ch)
-
-	c.lastTasksScrapeTimestampMetric.Set(float64(time.Now().Unix()))
-	c.lastTasksScrapeTimestampMetric.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(c.collectorAvailableDesc, prometheus.GaugeValue, availability(err))
+	ch <- prometheus.MustNewConstMetric(c.lastTasksScrapeErrorDesc, prometheus.GaugeValue, errorMetric)
+	ch <- prometheus.MustNewConstMetric(c.lastTasksScrapeTimestampDesc, prometheus.GaugeValue, float64(time.Now().Unix()))
+	ch <- prometheus.MustNewConstMetric(c.lastTasksScrapeDurationSecondsDesc, prometheus.GaugeValue, time.Since(begun).Seconds())
+}
 
-	c.lastTasksScrapeDurationSecondsMetric.Set(time.Since(begun).Seconds())
-	c.lastTasksScrapeDurationSecondsMetric.Collect(ch)
+func (c TasksCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	c.shieldClient = c.shieldClient.WithContext(ctx)
+	c.Collect(ch)
 }
 
 func (c TasksCollector) Describe(ch chan<- *prometheus.Desc) {
-	c.tasksTotalMetric.Describe(ch)
+	ch <- c.tasksTotalDesc
+	ch <- c.restoreSuccessRatioDesc
+	ch <- c.purgeTasksTotalDesc
+	ch <- c.storeLastPurgeSuccessTimestampDesc
+	ch <- c.taskBytesDesc
+	ch <- c.targetRestoresTotalDesc
 	c.tasksDurationSecondsMetric.Describe(ch)
+	if c.tasksDurationHistogramMetric != nil {
+		c.tasksDurationHistogramMetric.Describe(ch)
+	}
+	c.jobTasksTotalMetric.Describe(ch)
+	ch <- c.tasksDurationSecondsMinDesc
+	ch <- c.tasksDurationSecondsMaxDesc
+	ch <- c.tasksDurationSecondsAvgDesc
+	ch <- c.collectorAvailableDesc
 	c.tasksScrapesTotalMetric.Describe(ch)
 	c.tasksScrapeErrorsTotalMetric.Describe(ch)
-	c.lastTasksScrapeErrorMetric.Describe(ch)
-	c.lastTasksScrapeTimestampMetric.Describe(ch)
-	c.lastTasksScrapeDurationSecondsMetric.Describe(ch)
+	c.scrapeErrorsByReasonTotalMetric.Describe(ch)
+	ch <- c.lastTasksScrapeErrorDesc
+	ch <- c.lastTasksScrapeTimestampDesc
+	ch <- c.lastTasksScrapeDurationSecondsDesc
 }
 
 func (c TasksCollector) reportTasksMetrics(ch chan<- prometheus.Metric) error {
-	c.tasksTotalMetric.Reset()
-	c.tasksDurationSecondsMetric.Reset()
+	metrics, err := c.tasksMetrics()
+	c.maintenanceMetrics.report(ch, metrics, err)
+	if err != nil && !client.IsMaintenance(err) {
+		return err
+	}
 
-	tasks, err := api.GetTasks(api.TaskFilter{})
+	// The tasks counters and durations are kept by the collector across
+	// scrapes, so they are reported during maintenance too.
+	c.jobTasksTotalMetric.Collect(ch)
+	c.tasksDurationSecondsMetric.Collect(ch)
+	if c.tasksDurationHistogramMetric != nil {
+		c.tasksDurationHistogramMetric.Collect(ch)
+	}
+
+	return err
+}
+
+func (c TasksCollector) tasksMetrics() ([]prometheus.Metric, error) {
+	jobs, err := c.shieldClient.GetJobs()
 	if err != nil {
-		log.Errorf("Error while listing tasks: %v", err)
-		return err
+		logError(err, "Error while listing jobs: %v", err)
+		return nil, err
+	}
+
+	// Every target of a job is reported, so targets never restored in the
+	// window are too.
+	jobsByUUID := make(map[string]api.Job)
+	targetNames := make(map[string]string)
+	targetRestoresTotal := make(map[string]float64)
+	for _, job := range jobs {
+		jobsByUUID[job.UUID] = job
+		if job.TargetName != "" {
+			targetNames[job.TargetUUID] = job.TargetName
+			targetRestoresTotal[job.TargetName] = 0
+		}
 	}
 
-	for _, task := range tasks {
-		c.tasksTotalMetric.WithLabelValues(task.Op, task.Status).Inc()
+	// Only restores stopped in the window, or in the whole Shield task
+	// history without a window, count towards the success ratio and the
+	// restores of the targets.
+	var restoresSince int64
+	if c.restoreSuccessWindow > 0 {
+		restoresSince = time.Now().Add(-c.restoreSuccessWindow).Unix()
+	}
+	restoresFinished := make(map[string]float64)
+	restoresSucceeded := make(map[string]float64)
+
+	purgeTasksTotal := make(map[string]float64)
+	archives := tasksArchives{
+		lastPurgeSuccessByArchive: make(map[string]int64),
+		lastTasksByLabels:         make(map[taskBytesLabels]api.Task),
+		restoresByArchive:         make(map[string]float64),
+	}
+
+	tasksTotal := make(map[taskLabels]float64)
+	tasksDurations := make(map[string]*taskDurations)
+	finishedJobTasks := []jobTask{}
+	durationObservations := []taskDuration{}
+	err = c.shieldClient.ForEachTask(func(task api.Task) {
+		job := jobsByUUID[task.JobUUID]
+		labels := taskLabels{
+			operation:    task.Op,
+			status:       task.Status,
+			storePlugin:  job.StorePlugin,
+			targetPlugin: job.TargetPlugin,
+		}
+		// Without the job name label, the tasks of every job are aggregated
+		// so that no two tasks_total metrics share the same labels.
+		if c.jobNameLabel {
+			labels.jobName = job.Name
+		}
+		tasksTotal[labels]++
+
+		if job.UUID != "" && task.UUID != "" && (task.Status == DoneStatus || task.Status == FailedStatus || task.Status == CanceledStatus) {
+			finishedJobTasks = append(finishedJobTasks, jobTask{
+				uuid:        task.UUID,
+				labelValues: append(jobLabelValues(job, c.legacyJobLabels), task.Status),
+			})
+		}
+
+		if task.Op == RestoreOperation && (task.Status == DoneStatus || task.Status == FailedStatus) &&
+			!task.StoppedAt.IsZero() && task.StoppedAt.Time().Unix() >= restoresSince {
+			restoresFinished[labels.targetPlugin]++
+			if task.Status == DoneStatus {
+				restoresSucceeded[labels.targetPlugin]++
+				if job.TargetName != "" {
+					targetRestoresTotal[job.TargetName]++
+				} else if task.ArchiveUUID != "" {
+					archives.restoresByArchive[task.ArchiveUUID]++
+				}
+			}
+		}
+
+		if task.Op == PurgeOperation {
+			purgeTasksTotal[task.Status]++
+			if task.Status == DoneStatus && !task.StoppedAt.IsZero() && task.StoppedAt.Time().Unix() > archives.lastPurgeSuccessByArchive[task.ArchiveUUID] {
+				archives.lastPurgeSuccessByArchive[task.ArchiveUUID] = task.StoppedAt.Time().Unix()
+			}
+		}
+
+		if task.Status == DoneStatus && task.ArchiveUUID != "" && !task.StoppedAt.IsZero() {
+			bytesLabels := taskBytesLabels{operation: task.Op, jobName: job.Name}
+			if lastTask, ok := archives.lastTasksByLabels[bytesLabels]; !ok || task.StoppedAt.Time().After(lastTask.StoppedAt.Time()) {
+				archives.lastTasksByLabels[bytesLabels] = task
+			}
+		}
 
 		if !task.StartedAt.IsZero() && !task.StoppedAt.IsZero() {
 			duration := task.StoppedAt.Time().Unix() - task.StartedAt.Time().Unix()
 			if duration >= 0 {
-				c.tasksDurationSecondsMetric.WithLabelValues(task.Op, task.Status).Observe(float64(duration))
+				if task.UUID != "" {
+					durationObservations = append(durationObservations, taskDuration{
+						uuid:        task.UUID,
+						labelValues: labels.values(c.jobNameLabel),
+						duration:    float64(duration),
+					})
+				}
+				if _, ok := tasksDurations[task.Op]; !ok {
+					tasksDurations[task.Op] = &taskDurations{}
+				}
+				tasksDurations[task.Op].observe(float64(duration))
 			}
 		}
+	})
+	if err != nil {
+		logError(err, "Error while listing tasks: %v", err)
+		return nil, err
 	}
 
-	c.tasksTotalMetric.Collect(ch)
-	c.tasksDurationSecondsMetric.Collect(ch)
+	// No metric is reported until every listing succeeded, so that a failed
+	// scrape does not export partial metrics.
+	archivesMetrics, err := c.archivesMetrics(archives, targetNames, targetRestoresTotal)
+	if err != nil {
+		return nil, err
+	}
+
+	c.countJobTasks(finishedJobTasks)
+	c.observeTaskDurations(durationObservations)
+
+	var metrics []prometheus.Metric
+	for labels, total := range tasksTotal {
+		metrics = append(metrics, prometheus.MustNewConstMetric(c.tasksTotalDesc, prometheus.GaugeValue, total, labels.values(c.jobNameLabel)...))
+	}
+
+	for operation, durations := range tasksDurations {
+		metrics = append(metrics,
+			prometheus.MustNewConstMetric(c.tasksDurationSecondsMinDesc, prometheus.GaugeValue, durations.min, operation),
+			prometheus.MustNewConstMetric(c.tasksDurationSecondsMaxDesc, prometheus.GaugeValue, durations.max, operation),
+			prometheus.MustNewConstMetric(c.tasksDurationSecondsAvgDesc, prometheus.GaugeValue, durations.sum/durations.count, operation),
+		)
+	}
+
+	for targetPlugin, finished := range restoresFinished {
+		metrics = append(metrics, prometheus.MustNewConstMetric(c.restoreSuccessRatioDesc, prometheus.GaugeValue, restoresSucceeded[targetPlugin]/finished, targetPlugin))
+	}
+
+	for status, total := range purgeTasksTotal {
+		metrics = append(metrics, prometheus.MustNewConstMetric(c.purgeTasksTotalDesc, prometheus.GaugeValue, total, status))
+	}
+
+	metrics = append(metrics, archivesMetrics...)
+
+	for targetName, total := range targetRestoresTotal {
+		metrics = append(metrics, prometheus.MustNewConstMetric(c.targetRestoresTotalDesc, prometheus.GaugeValue, total, targetName))
+	}
+
+	return metrics, nil
+}
+
+// countJobTasks counts the finished tasks not counted at a previous scrape,
+// and forgets the ones no longer in the Shield task history.
+func (c TasksCollector) countJobTasks(finishedJobTasks []jobTask) {
+	c.countedTasks.Lock()
+	defer c.countedTasks.Unlock()
+
+	uuids := make(map[string]bool)
+	for _, task := range finishedJobTasks {
+		if !c.countedTasks.uuids[task.uuid] && !uuids[task.uuid] {
+			c.jobTasksTotalMetric.WithLabelValues(task.labelValues...).Inc()
+		}
+		uuids[task.uuid] = true
+	}
+	c.countedTasks.uuids = uuids
+}
+
+// observeTaskDurations observes the duration of the tasks not observed at a
+// previous scrape, and forgets the ones no longer in the Shield task history.
+func (c TasksCollector) observeTaskDurations(durationObservations []taskDuration) {
+	c.observedTasks.Lock()
+	defer c.observedTasks.Unlock()
+
+	uuids := make(map[string]bool)
+	for _, task := range durationObservations {
+		if !c.observedTasks.uuids[task.uuid] && !uuids[task.uuid] {
+			c.tasksDurationSecondsMetric.WithLabelValues(task.labelValues...).Observe(task.duration)
+			if c.tasksDurationHistogramMetric != nil {
+				c.tasksDurationHistogramMetric.WithLabelValues(task.labelValues...).Observe(task.duration)
+			}
+		}
+		uuids[task.uuid] = true
+	}
+	c.observedTasks.uuids = uuids
+}
+
+// archivesMetrics resolves the store of the purged archives, which purge
+// tasks do not reference, the size of the archives of the last successful
+// tasks, and the target of the restores not run by a job, adding them to
+// targetRestoresTotal, so the archives and stores are only listed when there
+// are such tasks. The metrics are only returned once every listing succeeded.
+func (c TasksCollector) archivesMetrics(archives tasksArchives, targetNames map[string]string, targetRestoresTotal map[string]float64) ([]prometheus.Metric, error) {
+	if archives.empty() {
+		return nil, nil
+	}
+
+	lastTasksArchives := make(map[string]bool)
+	for _, task := range archives.lastTasksByLabels {
+		lastTasksArchives[task.ArchiveUUID] = true
+	}
+
+	archivesSize := make(map[string]int64)
+	lastPurgeSuccessByStore := make(map[string]int64)
+	err := c.shieldClient.ForEachArchive(func(archive client.Archive) {
+		if lastTasksArchives[archive.UUID] && archive.Size > 0 {
+			archivesSize[archive.UUID] = archive.Size
+		}
+		if purgedAt, ok := archives.lastPurgeSuccessByArchive[archive.UUID]; ok && purgedAt > lastPurgeSuccessByStore[archive.StoreUUID] {
+			lastPurgeSuccessByStore[archive.StoreUUID] = purgedAt
+		}
+		if restores, ok := archives.restoresByArchive[archive.UUID]; ok {
+			if targetName, ok := targetNames[archive.TargetUUID]; ok {
+				targetRestoresTotal[targetName] += restores
+			}
+		}
+	})
+	if err != nil {
+		logError(err, "Error while listing archives: %v", err)
+		return nil, err
+	}
+
+	var metrics []prometheus.Metric
+	for labels, task := range archives.lastTasksByLabels {
+		if size, ok := archivesSize[task.ArchiveUUID]; ok {
+			metrics = append(metrics, prometheus.MustNewConstMetric(c.taskBytesDesc, prometheus.GaugeValue, float64(size), labels.operation, labels.jobName))
+		}
+	}
+
+	if len(archives.lastPurgeSuccessByArchive) == 0 {
+		return metrics, nil
+	}
+
+	stores, err := c.shieldClient.GetStores()
+	if err != nil {
+		logError(err, "Error while listing stores: %v", err)
+		return nil, err
+	}
+
+	lastPurgeSuccessByName := make(map[string]int64)
+	for _, store := range stores {
+		if purgedAt, ok := lastPurgeSuccessByStore[store.UUID]; ok && purgedAt > lastPurgeSuccessByName[store.Name] {
+			lastPurgeSuccessByName[store.Name] = purgedAt
+		}
+	}
+
+	for storeName, purgedAt := range lastPurgeSuccessByName {
+		metrics = append(metrics, prometheus.MustNewConstMetric(c.storeLastPurgeSuccessTimestampDesc, prometheus.GaugeValue, float64(purgedAt), storeName))
+	}
 
-	return nil
+	return metrics, nil
 }
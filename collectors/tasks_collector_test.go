@@ -1,6 +1,7 @@
 package collectors_test
 
 import (
+	"math"
 	"net/http"
 	"time"
 
@@ -9,10 +10,12 @@ import (
 	"github.com/onsi/gomega/ghttp"
 
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/log"
 	"github.com/starkandwayne/goutils/timestamp"
 	"github.com/starkandwayne/shield/api"
 
+	"github.com/bosh-prometheus/shield_exporter/client"
 	. "github.com/bosh-prometheus/shield_exporter/collectors"
 	. "github.com/bosh-prometheus/shield_exporter/utils/test_matchers"
 )
@@ -26,6 +29,8 @@ var _ = Describe("TasksCollectors", func() {
 		err    error
 		server *ghttp.Server
 
+		shieldClient *client.Client
+
 		namespace   = "test_namespace"
 		environment = "test_environment"
 		backendName = "test_backend"
@@ -38,8 +43,30 @@ var _ = Describe("TasksCollectors", func() {
 		TaskStatus1    = "task_status_1"
 		TaskStatus2    = "task_status_2"
 
+		storePlugin  = "store_plugin"
+		targetPlugin = "target_plugin"
+
+		durationObjectives = map[float64]float64{0.5: 0.05, 0.9: 0.01}
+		durationMaxAge     = 5 * time.Minute
+		durationAgeBuckets = uint32(3)
+
+		durationNativeHistogramBucketFactor float64
+
+		jobNameLabel         bool
+		restoreSuccessWindow = time.Hour
+		legacyJobLabels      bool
+
 		tasksTotalMetric                     *prometheus.GaugeVec
+		restoreSuccessRatioMetric            *prometheus.GaugeVec
+		purgeTasksTotalMetric                *prometheus.GaugeVec
+		storeLastPurgeSuccessTimestampMetric *prometheus.GaugeVec
+		taskBytesMetric                      *prometheus.GaugeVec
+		targetRestoresTotalMetric            *prometheus.GaugeVec
+		jobTasksTotalMetric                  *prometheus.CounterVec
 		tasksDurationSecondsMetric           *prometheus.SummaryVec
+		tasksDurationSecondsMinMetric        *prometheus.GaugeVec
+		tasksDurationSecondsMaxMetric        *prometheus.GaugeVec
+		tasksDurationSecondsAvgMetric        *prometheus.GaugeVec
 		tasksScrapesTotalMetric              prometheus.Counter
 		tasksScrapeErrorsTotalMetric         prometheus.Counter
 		lastTasksScrapeErrorMetric           prometheus.Gauge
@@ -50,25 +77,15 @@ var _ = Describe("TasksCollectors", func() {
 	)
 
 	BeforeEach(func() {
-		server = ghttp.NewServer()
-		server.AppendHandlers(
-			ghttp.CombineHandlers(
-				ghttp.VerifyRequest("GET", "/v1/ping"),
-				ghttp.RespondWith(http.StatusOK, "{}"),
-			),
-		)
+		jobNameLabel = false
+		legacyJobLabels = false
 
-		api.Cfg = &api.Config{
-			Backend:  "default",
-			Backends: map[string]string{},
-			Aliases:  map[string]string{},
-		}
-
-		err = api.Cfg.AddBackend(server.URL(), "default")
-		Expect(err).ToNot(HaveOccurred())
-
-		authToken := api.BasicAuthToken(username, password)
-		err = api.Cfg.UpdateBackend("default", authToken)
+		server = ghttp.NewServer()
+		shieldClient, err = client.NewClient(client.Config{
+			BackendURL: server.URL(),
+			Username:   username,
+			Password:   password,
+		})
 		Expect(err).ToNot(HaveOccurred())
 
 		tasksTotalMetric = prometheus.NewGaugeVec(
@@ -79,12 +96,12 @@ var _ = Describe("TasksCollectors", func() {
 				Help:        "Labeled total number of Shield Tasks.",
 				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
 			},
-			[]string{"task_operation", "task_status"},
+			[]string{"task_operation", "task_status", "store_plugin", "target_plugin"},
 		)
-		tasksTotalMetric.WithLabelValues(TaskOperation1, TaskStatus1).Set(1)
-		tasksTotalMetric.WithLabelValues(TaskOperation1, TaskStatus2).Set(1)
-		tasksTotalMetric.WithLabelValues(TaskOperation2, TaskStatus1).Set(1)
-		tasksTotalMetric.WithLabelValues(TaskOperation2, TaskStatus2).Set(1)
+		tasksTotalMetric.WithLabelValues(TaskOperation1, TaskStatus1, "", "").Set(1)
+		tasksTotalMetric.WithLabelValues(TaskOperation1, TaskStatus2, "", "").Set(1)
+		tasksTotalMetric.WithLabelValues(TaskOperation2, TaskStatus1, "", "").Set(1)
+		tasksTotalMetric.WithLabelValues(TaskOperation2, TaskStatus2, "", "").Set(1)
 
 		tasksDurationSecondsMetric = prometheus.NewSummaryVec(
 			prometheus.SummaryOpts{
@@ -93,13 +110,118 @@ var _ = Describe("TasksCollectors", func() {
 				Name:        "duration_seconds",
 				Help:        "Labeled summary of Shield Task durations in seconds.",
 				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+				Objectives:  durationObjectives,
+				MaxAge:      durationMaxAge,
+				AgeBuckets:  durationAgeBuckets,
+			},
+			[]string{"task_operation", "task_status", "store_plugin", "target_plugin"},
+		)
+		tasksDurationSecondsMetric.WithLabelValues(TaskOperation1, TaskStatus1, "", "").Observe(1)
+		tasksDurationSecondsMetric.WithLabelValues(TaskOperation1, TaskStatus2, "", "").Observe(0)
+		tasksDurationSecondsMetric.WithLabelValues(TaskOperation2, TaskStatus1, "", "").Observe(0)
+		tasksDurationSecondsMetric.WithLabelValues(TaskOperation2, TaskStatus2, "", "").Observe(0)
+
+		tasksDurationSecondsMinMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "tasks",
+				Name:        "duration_seconds_min",
+				Help:        "Minimum duration in seconds of the listed Shield Tasks.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"task_operation"},
+		)
+		tasksDurationSecondsMinMetric.WithLabelValues(TaskOperation1).Set(0)
+
+		tasksDurationSecondsMaxMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "tasks",
+				Name:        "duration_seconds_max",
+				Help:        "Maximum duration in seconds of the listed Shield Tasks.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"task_operation"},
+		)
+		tasksDurationSecondsMaxMetric.WithLabelValues(TaskOperation1).Set(1)
+
+		tasksDurationSecondsAvgMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "tasks",
+				Name:        "duration_seconds_avg",
+				Help:        "Average duration in seconds of the listed Shield Tasks.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"task_operation"},
+		)
+		tasksDurationSecondsAvgMetric.WithLabelValues(TaskOperation1).Set(0.5)
+
+		restoreSuccessRatioMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "restore",
+				Name:        "success_ratio",
+				Help:        "Ratio of the Shield restore Tasks finished in the window that succeeded.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"target_plugin"},
+		)
+
+		purgeTasksTotalMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "purge_tasks",
+				Name:        "total",
+				Help:        "Labeled total number of Shield purge Tasks.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"task_status"},
+		)
+
+		storeLastPurgeSuccessTimestampMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "store",
+				Name:        "last_purge_success_timestamp",
+				Help:        "Number of seconds since 1970 since the last successful Shield purge Task of a Shield Store.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"store_name"},
+		)
+
+		taskBytesMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "task",
+				Name:        "bytes",
+				Help:        "Size in bytes of the archive of the last successful Shield Task of a Shield Job by operation.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"task_operation", "job_name"},
+		)
+
+		targetRestoresTotalMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "target",
+				Name:        "restores_total",
+				Help:        "Total number of successful Shield restore Tasks of a Shield Target finished in the window.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"target_name"},
+		)
+
+		jobTasksTotalMetric = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   namespace,
+				Subsystem:   "job",
+				Name:        "tasks_total",
+				Help:        "Total number of finished Shield Tasks of a Shield Job by status.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
 			},
-			[]string{"task_operation", "task_status"},
+			[]string{"job_name", "job_uuid", "target_name", "store_name", "status"},
 		)
-		tasksDurationSecondsMetric.WithLabelValues(TaskOperation1, TaskStatus1).Observe(1)
-		tasksDurationSecondsMetric.WithLabelValues(TaskOperation1, TaskStatus2).Observe(0)
-		tasksDurationSecondsMetric.WithLabelValues(TaskOperation2, TaskStatus1).Observe(0)
-		tasksDurationSecondsMetric.WithLabelValues(TaskOperation2, TaskStatus2).Observe(0)
 
 		tasksScrapesTotalMetric = prometheus.NewCounter(
 			prometheus.CounterOpts{
@@ -154,7 +276,7 @@ var _ = Describe("TasksCollectors", func() {
 	})
 
 	JustBeforeEach(func() {
-		tasksCollector = NewTasksCollector(namespace, environment, backendName)
+		tasksCollector = NewTasksCollector(namespace, environment, backendName, shieldClient, durationObjectives, durationMaxAge, durationAgeBuckets, durationNativeHistogramBucketFactor, jobNameLabel, restoreSuccessWindow, legacyJobLabels)
 	})
 
 	AfterEach(func() {
@@ -175,11 +297,47 @@ var _ = Describe("TasksCollectors", func() {
 		})
 
 		It("returns a tasks_total metric description", func() {
-			Eventually(descriptions).Should(Receive(Equal(tasksTotalMetric.WithLabelValues(TaskOperation1, TaskStatus1).Desc())))
+			Eventually(descriptions).Should(Receive(Equal(tasksTotalMetric.WithLabelValues(TaskOperation1, TaskStatus1, "", "").Desc())))
 		})
 
 		It("returns a tasks_duration_seconds metric description", func() {
-			Eventually(descriptions).Should(Receive(Equal(tasksDurationSecondsMetric.WithLabelValues(TaskOperation1, TaskStatus1).Desc())))
+			Eventually(descriptions).Should(Receive(Equal(tasksDurationSecondsMetric.WithLabelValues(TaskOperation1, TaskStatus1, "", "").(prometheus.Metric).Desc())))
+		})
+
+		It("returns a tasks_duration_seconds_min metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(tasksDurationSecondsMinMetric.WithLabelValues(TaskOperation1).Desc())))
+		})
+
+		It("returns a tasks_duration_seconds_max metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(tasksDurationSecondsMaxMetric.WithLabelValues(TaskOperation1).Desc())))
+		})
+
+		It("returns a tasks_duration_seconds_avg metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(tasksDurationSecondsAvgMetric.WithLabelValues(TaskOperation1).Desc())))
+		})
+
+		It("returns a restore_success_ratio metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(restoreSuccessRatioMetric.WithLabelValues(targetPlugin).Desc())))
+		})
+
+		It("returns a purge_tasks_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(purgeTasksTotalMetric.WithLabelValues(DoneStatus).Desc())))
+		})
+
+		It("returns a store_last_purge_success_timestamp metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(storeLastPurgeSuccessTimestampMetric.WithLabelValues("").Desc())))
+		})
+
+		It("returns a task_bytes metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(taskBytesMetric.WithLabelValues("", "").Desc())))
+		})
+
+		It("returns a job_tasks_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(jobTasksTotalMetric.WithLabelValues("", "", "", "", "").Desc())))
+		})
+
+		It("returns a target_restores_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(targetRestoresTotalMetric.WithLabelValues("").Desc())))
 		})
 
 		It("returns a tasks_scrapes_total metric description", func() {
@@ -205,73 +363,251 @@ var _ = Describe("TasksCollectors", func() {
 
 	Describe("Collect", func() {
 		var (
-			statusCode    int
-			tasksResponse []api.Task
-			metrics       chan prometheus.Metric
+			statusCode       int
+			tasksResponse    []api.Task
+			jobsResponse     []api.Job
+			purgeStatusCode  int
+			archivesResponse []client.Archive
+			storesResponse   []client.Store
+			metrics          chan prometheus.Metric
 		)
 
 		BeforeEach(func() {
 			statusCode = http.StatusOK
 			tasksResponse = []api.Task{
 				api.Task{
+					UUID:      "task_uuid_1",
 					Op:        TaskOperation1,
 					Status:    TaskStatus1,
 					StartedAt: timestamp.NewTimestamp(time.Unix(1, 0)),
 					StoppedAt: timestamp.NewTimestamp(time.Unix(2, 0)),
 				},
 				api.Task{
+					UUID:      "task_uuid_2",
 					Op:        TaskOperation1,
 					Status:    TaskStatus2,
 					StartedAt: timestamp.NewTimestamp(time.Unix(1, 0)),
 					StoppedAt: timestamp.NewTimestamp(time.Unix(1, 0)),
 				},
 				api.Task{
+					UUID:      "task_uuid_3",
 					Op:        TaskOperation2,
 					Status:    TaskStatus1,
 					StartedAt: timestamp.NewTimestamp(time.Unix(1, 0)),
 				},
 				api.Task{
+					UUID:      "task_uuid_4",
 					Op:        TaskOperation2,
 					Status:    TaskStatus2,
 					StoppedAt: timestamp.NewTimestamp(time.Unix(1, 0)),
 				},
 			}
+			jobsResponse = []api.Job{}
+			purgeStatusCode = http.StatusOK
+			archivesResponse = []client.Archive{}
+			storesResponse = []client.Store{}
 			metrics = make(chan prometheus.Metric)
 		})
 
 		JustBeforeEach(func() {
 			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/jobs"),
+					ghttp.VerifyBasicAuth(username, password),
+					ghttp.RespondWithJSONEncodedPtr(&statusCode, &jobsResponse),
+				),
 				ghttp.CombineHandlers(
 					ghttp.VerifyRequest("GET", "/v1/tasks"),
 					ghttp.VerifyBasicAuth(username, password),
 					ghttp.RespondWithJSONEncodedPtr(&statusCode, &tasksResponse),
 				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/archives"),
+					ghttp.VerifyBasicAuth(username, password),
+					ghttp.RespondWithJSONEncodedPtr(&purgeStatusCode, &archivesResponse),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/stores"),
+					ghttp.VerifyBasicAuth(username, password),
+					ghttp.RespondWithJSONEncodedPtr(&purgeStatusCode, &storesResponse),
+				),
 			)
 			go tasksCollector.Collect(metrics)
 		})
 
 		It("returns a tasks_total metric for task operation 1, task status 1", func() {
-			Eventually(metrics).Should(Receive(PrometheusMetric(tasksTotalMetric.WithLabelValues(TaskOperation1, TaskStatus1))))
+			Eventually(metrics).Should(Receive(PrometheusMetric(tasksTotalMetric.WithLabelValues(TaskOperation1, TaskStatus1, "", ""))))
 		})
 
 		It("returns a tasks_total metric for task operation 1, task status 2", func() {
-			Eventually(metrics).Should(Receive(PrometheusMetric(tasksTotalMetric.WithLabelValues(TaskOperation1, TaskStatus2))))
+			Eventually(metrics).Should(Receive(PrometheusMetric(tasksTotalMetric.WithLabelValues(TaskOperation1, TaskStatus2, "", ""))))
 		})
 
 		It("returns a tasks_total metric for task operation 2, task status 1", func() {
-			Eventually(metrics).Should(Receive(PrometheusMetric(tasksTotalMetric.WithLabelValues(TaskOperation2, TaskStatus1))))
+			Eventually(metrics).Should(Receive(PrometheusMetric(tasksTotalMetric.WithLabelValues(TaskOperation2, TaskStatus1, "", ""))))
 		})
 
 		It("returns a tasks_total metric for task operation 2, task status 2", func() {
-			Eventually(metrics).Should(Receive(PrometheusMetric(tasksTotalMetric.WithLabelValues(TaskOperation2, TaskStatus2))))
+			Eventually(metrics).Should(Receive(PrometheusMetric(tasksTotalMetric.WithLabelValues(TaskOperation2, TaskStatus2, "", ""))))
 		})
 
 		It("returns a tasks_duration_seconds metric for task operation 1, task status 1", func() {
-			Eventually(metrics).Should(Receive(PrometheusMetric(tasksDurationSecondsMetric.WithLabelValues(TaskOperation1, TaskStatus1))))
+			Eventually(metrics).Should(Receive(PrometheusMetric(tasksDurationSecondsMetric.WithLabelValues(TaskOperation1, TaskStatus1, "", "").(prometheus.Metric))))
 		})
 
 		It("returns a tasks_duration_seconds metric for task operation 1, task status 2", func() {
-			Eventually(metrics).Should(Receive(PrometheusMetric(tasksDurationSecondsMetric.WithLabelValues(TaskOperation1, TaskStatus2))))
+			Eventually(metrics).Should(Receive(PrometheusMetric(tasksDurationSecondsMetric.WithLabelValues(TaskOperation1, TaskStatus2, "", "").(prometheus.Metric))))
+		})
+
+		It("returns a tasks_duration_seconds_min metric for task operation 1", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(tasksDurationSecondsMinMetric.WithLabelValues(TaskOperation1))))
+		})
+
+		It("returns a tasks_duration_seconds_max metric for task operation 1", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(tasksDurationSecondsMaxMetric.WithLabelValues(TaskOperation1))))
+		})
+
+		It("returns a tasks_duration_seconds_avg metric for task operation 1", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(tasksDurationSecondsAvgMetric.WithLabelValues(TaskOperation1))))
+		})
+
+		It("does not return a tasks_duration_seconds_avg metric for an operation without finished tasks", func() {
+			Consistently(metrics).ShouldNot(Receive(PrometheusMetric(tasksDurationSecondsAvgMetric.WithLabelValues(TaskOperation2))))
+		})
+
+		Context("when it is scraped concurrently", func() {
+			var otherMetrics chan prometheus.Metric
+
+			BeforeEach(func() {
+				server.RouteToHandler("GET", "/v1/jobs", ghttp.RespondWithJSONEncodedPtr(&statusCode, &jobsResponse))
+				server.RouteToHandler("GET", "/v1/tasks", ghttp.RespondWithJSONEncodedPtr(&statusCode, &tasksResponse))
+				otherMetrics = make(chan prometheus.Metric)
+			})
+
+			JustBeforeEach(func() {
+				go tasksCollector.Collect(otherMetrics)
+			})
+
+			It("returns a tasks_duration_seconds metric observing the listed tasks once across scrapes", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(tasksDurationSecondsMetric.WithLabelValues(TaskOperation1, TaskStatus1, "", "").(prometheus.Metric))))
+				Eventually(otherMetrics).Should(Receive(PrometheusMetric(tasksDurationSecondsMetric.WithLabelValues(TaskOperation1, TaskStatus1, "", "").(prometheus.Metric))))
+			})
+		})
+
+		Context("when the tasks were observed at a previous scrape", func() {
+			var (
+				durationCollector *TasksCollector
+				gatherQuantile    func() (uint64, float64)
+			)
+
+			BeforeEach(func() {
+				server.RouteToHandler("GET", "/v1/jobs", ghttp.RespondWithJSONEncodedPtr(&statusCode, &jobsResponse))
+				server.RouteToHandler("GET", "/v1/tasks", ghttp.RespondWithJSONEncodedPtr(&statusCode, &tasksResponse))
+
+				durationCollector = NewTasksCollector(namespace, environment, backendName, shieldClient, durationObjectives, 500*time.Millisecond, 1, durationNativeHistogramBucketFactor, jobNameLabel, restoreSuccessWindow, legacyJobLabels)
+				registry := prometheus.NewRegistry()
+				registry.MustRegister(durationCollector)
+
+				// gatherQuantile returns the sample count and the median of the
+				// durations of the task operation 1, task status 1 tasks.
+				gatherQuantile = func() (uint64, float64) {
+					mfs, err := registry.Gather()
+					Expect(err).ToNot(HaveOccurred())
+					for _, mf := range mfs {
+						if mf.GetName() != namespace+"_tasks_duration_seconds" {
+							continue
+						}
+						for _, metric := range mf.GetMetric() {
+							for _, label := range metric.GetLabel() {
+								if label.GetName() == "task_status" && label.GetValue() == TaskStatus1 {
+									return metric.GetSummary().GetSampleCount(), metric.GetSummary().GetQuantile()[0].GetValue()
+								}
+							}
+						}
+					}
+					Fail("tasks_duration_seconds metric not gathered")
+					return 0, 0
+				}
+			})
+
+			It("does not observe them again", func() {
+				count, _ := gatherQuantile()
+				Expect(count).To(Equal(uint64(1)))
+				count, _ = gatherQuantile()
+				Expect(count).To(Equal(uint64(1)))
+			})
+
+			It("drops them from the tasks_duration_seconds quantiles after the max age", func() {
+				_, median := gatherQuantile()
+				Expect(median).To(Equal(float64(1)))
+
+				time.Sleep(time.Second)
+				count, median := gatherQuantile()
+				Expect(count).To(Equal(uint64(1)))
+				Expect(math.IsNaN(median)).To(BeTrue())
+			})
+		})
+
+		Context("when the tasks duration native histogram", func() {
+			var gatherHistogram func() *dto.Histogram
+
+			BeforeEach(func() {
+				server.RouteToHandler("GET", "/v1/jobs", ghttp.RespondWithJSONEncodedPtr(&statusCode, &jobsResponse))
+				server.RouteToHandler("GET", "/v1/tasks", ghttp.RespondWithJSONEncodedPtr(&statusCode, &tasksResponse))
+			})
+
+			JustBeforeEach(func() {
+				histogramCollector := NewTasksCollector(namespace, environment, backendName, shieldClient, durationObjectives, durationMaxAge, durationAgeBuckets, durationNativeHistogramBucketFactor, jobNameLabel, restoreSuccessWindow, legacyJobLabels)
+				registry := prometheus.NewRegistry()
+				registry.MustRegister(histogramCollector)
+
+				// gatherHistogram returns the histogram of the durations of the
+				// task operation 1, task status 1 tasks, if gathered.
+				gatherHistogram = func() *dto.Histogram {
+					mfs, err := registry.Gather()
+					Expect(err).ToNot(HaveOccurred())
+					for _, mf := range mfs {
+						if mf.GetName() != namespace+"_tasks_duration_histogram_seconds" {
+							continue
+						}
+						for _, metric := range mf.GetMetric() {
+							for _, label := range metric.GetLabel() {
+								if label.GetName() == "task_status" && label.GetValue() == TaskStatus1 {
+									return metric.GetHistogram()
+								}
+							}
+						}
+					}
+					return nil
+				}
+			})
+
+			Context("is disabled", func() {
+				It("does not return a tasks_duration_histogram_seconds metric", func() {
+					Expect(gatherHistogram()).To(BeNil())
+				})
+			})
+
+			Context("is enabled", func() {
+				BeforeEach(func() {
+					durationNativeHistogramBucketFactor = 1.1
+				})
+
+				AfterEach(func() {
+					durationNativeHistogramBucketFactor = 0
+				})
+
+				It("returns a tasks_duration_histogram_seconds native histogram observing the listed tasks once", func() {
+					histogram := gatherHistogram()
+					Expect(histogram).ToNot(BeNil())
+					Expect(histogram.GetSampleCount()).To(Equal(uint64(1)))
+					Expect(histogram.GetSampleSum()).To(Equal(float64(1)))
+					Expect(histogram.GetSchema()).To(Equal(int32(3)))
+					Expect(histogram.GetBucket()).To(BeEmpty())
+
+					Expect(gatherHistogram().GetSampleCount()).To(Equal(uint64(1)))
+				})
+			})
 		})
 
 		It("returns a tasks_scrapes_total metric", func() {
@@ -286,6 +622,412 @@ var _ = Describe("TasksCollectors", func() {
 			Eventually(metrics).Should(Receive(PrometheusMetric(lastTasksScrapeErrorMetric)))
 		})
 
+		Context("when the job name label is enabled", func() {
+			var (
+				jobName                       = "job_name"
+				tasksTotalJobMetric           *prometheus.GaugeVec
+				tasksDurationSecondsJobMetric *prometheus.SummaryVec
+			)
+
+			BeforeEach(func() {
+				jobNameLabel = true
+				jobsResponse = []api.Job{
+					api.Job{UUID: "job_uuid", Name: jobName, StorePlugin: storePlugin, TargetPlugin: targetPlugin},
+				}
+				tasksResponse[0].JobUUID = "job_uuid"
+
+				tasksTotalJobMetric = prometheus.NewGaugeVec(
+					prometheus.GaugeOpts{
+						Namespace:   namespace,
+						Subsystem:   "tasks",
+						Name:        "total",
+						Help:        "Labeled total number of Shield Tasks.",
+						ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+					},
+					[]string{"task_operation", "task_status", "store_plugin", "target_plugin", "job_name"},
+				)
+				tasksTotalJobMetric.WithLabelValues(TaskOperation1, TaskStatus1, storePlugin, targetPlugin, jobName).Set(1)
+				tasksTotalJobMetric.WithLabelValues(TaskOperation1, TaskStatus2, "", "", "").Set(1)
+
+				tasksDurationSecondsJobMetric = prometheus.NewSummaryVec(
+					prometheus.SummaryOpts{
+						Namespace:   namespace,
+						Subsystem:   "tasks",
+						Name:        "duration_seconds",
+						Help:        "Labeled summary of Shield Task durations in seconds.",
+						ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+						Objectives:  durationObjectives,
+						MaxAge:      durationMaxAge,
+						AgeBuckets:  durationAgeBuckets,
+					},
+					[]string{"task_operation", "task_status", "store_plugin", "target_plugin", "job_name"},
+				)
+				tasksDurationSecondsJobMetric.WithLabelValues(TaskOperation1, TaskStatus1, storePlugin, targetPlugin, jobName).Observe(1)
+			})
+
+			It("returns a tasks_total metric for the tasks of a job", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(tasksTotalJobMetric.WithLabelValues(TaskOperation1, TaskStatus1, storePlugin, targetPlugin, jobName))))
+			})
+
+			It("returns a tasks_total metric with an empty job name for the tasks of an unknown job", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(tasksTotalJobMetric.WithLabelValues(TaskOperation1, TaskStatus2, "", "", ""))))
+			})
+
+			It("returns a tasks_duration_seconds metric for the tasks of a job", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(tasksDurationSecondsJobMetric.WithLabelValues(TaskOperation1, TaskStatus1, storePlugin, targetPlugin, jobName).(prometheus.Metric))))
+			})
+
+		})
+
+		Context("when the tasks are run by a job", func() {
+			BeforeEach(func() {
+				jobsResponse = []api.Job{
+					api.Job{UUID: "job_uuid", StorePlugin: storePlugin, TargetPlugin: targetPlugin},
+				}
+				tasksResponse[0].JobUUID = "job_uuid"
+				tasksTotalMetric.WithLabelValues(TaskOperation1, TaskStatus1, storePlugin, targetPlugin).Set(1)
+				tasksDurationSecondsMetric.WithLabelValues(TaskOperation1, TaskStatus1, storePlugin, targetPlugin).Observe(1)
+			})
+
+			It("returns a tasks_total metric labeled with the plugins of the job", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(tasksTotalMetric.WithLabelValues(TaskOperation1, TaskStatus1, storePlugin, targetPlugin))))
+			})
+
+			It("returns a tasks_duration_seconds metric labeled with the plugins of the job", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(tasksDurationSecondsMetric.WithLabelValues(TaskOperation1, TaskStatus1, storePlugin, targetPlugin).(prometheus.Metric))))
+			})
+		})
+
+		Context("when the tasks of several jobs share the same plugins", func() {
+			BeforeEach(func() {
+				jobsResponse = []api.Job{
+					api.Job{UUID: "job_uuid_1", Name: "job_name_1", StorePlugin: storePlugin, TargetPlugin: targetPlugin},
+					api.Job{UUID: "job_uuid_2", Name: "job_name_2", StorePlugin: storePlugin, TargetPlugin: targetPlugin},
+				}
+				tasksResponse[0].JobUUID = "job_uuid_1"
+				tasksResponse = append(tasksResponse, api.Task{
+					UUID:    "task_uuid_5",
+					Op:      TaskOperation1,
+					Status:  TaskStatus1,
+					JobUUID: "job_uuid_2",
+				})
+				tasksTotalMetric.WithLabelValues(TaskOperation1, TaskStatus1, storePlugin, targetPlugin).Set(2)
+			})
+
+			It("returns a single tasks_total metric aggregating the tasks of the jobs", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(tasksTotalMetric.WithLabelValues(TaskOperation1, TaskStatus1, storePlugin, targetPlugin))))
+				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(tasksTotalMetric.WithLabelValues(TaskOperation1, TaskStatus1, storePlugin, targetPlugin))))
+			})
+		})
+
+		It("does not return a restore_success_ratio metric without restores", func() {
+			Consistently(metrics).ShouldNot(Receive(PrometheusMetric(restoreSuccessRatioMetric.WithLabelValues(""))))
+		})
+
+		Context("when there are restores", func() {
+			BeforeEach(func() {
+				jobsResponse = []api.Job{
+					api.Job{UUID: "job_uuid", StorePlugin: storePlugin, TargetPlugin: targetPlugin, TargetUUID: "target_uuid_1", TargetName: "target_name_1"},
+					api.Job{UUID: "other_job_uuid", TargetUUID: "target_uuid_2", TargetName: "target_name_2"},
+					api.Job{UUID: "never_restored_job_uuid", TargetUUID: "target_uuid_3", TargetName: "target_name_3"},
+				}
+				now := time.Now()
+				restore := func(status string, stoppedAt time.Time) api.Task {
+					return api.Task{
+						Op:        RestoreOperation,
+						Status:    status,
+						JobUUID:   "job_uuid",
+						StoppedAt: timestamp.NewTimestamp(stoppedAt),
+					}
+				}
+				tasksResponse = []api.Task{
+					restore(DoneStatus, now.Add(-time.Minute)),
+					restore(DoneStatus, now.Add(-time.Minute)),
+					restore(DoneStatus, now.Add(-time.Minute)),
+					restore(FailedStatus, now.Add(-time.Minute)),
+					restore(FailedStatus, now.Add(-2*time.Hour)),
+					restore(RunningStatus, time.Time{}),
+					api.Task{
+						Op:          RestoreOperation,
+						Status:      DoneStatus,
+						ArchiveUUID: "archive_uuid",
+						StoppedAt:   timestamp.NewTimestamp(now.Add(-time.Minute)),
+					},
+				}
+				archivesResponse = []client.Archive{
+					client.Archive{Archive: api.Archive{UUID: "archive_uuid", TargetUUID: "target_uuid_2"}},
+				}
+				restoreSuccessRatioMetric.WithLabelValues(targetPlugin).Set(0.75)
+				targetRestoresTotalMetric.WithLabelValues("target_name_1").Set(3)
+				targetRestoresTotalMetric.WithLabelValues("target_name_2").Set(1)
+				targetRestoresTotalMetric.WithLabelValues("target_name_3").Set(0)
+			})
+
+			It("returns a target_restores_total metric for the successful restores of the jobs of a target", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(targetRestoresTotalMetric.WithLabelValues("target_name_1"))))
+			})
+
+			It("returns a target_restores_total metric for the successful restores of the archives of a target", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(targetRestoresTotalMetric.WithLabelValues("target_name_2"))))
+			})
+
+			It("returns a target_restores_total metric for the targets never restored", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(targetRestoresTotalMetric.WithLabelValues("target_name_3"))))
+			})
+
+			It("returns a restore_success_ratio metric for the restores finished in the window", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(restoreSuccessRatioMetric.WithLabelValues(targetPlugin))))
+			})
+		})
+
+		Context("when jobs have finished tasks", func() {
+			var (
+				otherMetrics chan prometheus.Metric
+				jobLabels    = []string{"job_name", "job_uuid", "target_name", "store_name"}
+			)
+
+			BeforeEach(func() {
+				jobsResponse = []api.Job{
+					api.Job{UUID: "job_uuid", Name: "job_name", TargetName: "target_name", StoreName: "store_name"},
+				}
+				tasksResponse = []api.Task{
+					api.Task{UUID: "task_uuid_1", Op: "backup", Status: DoneStatus, JobUUID: "job_uuid"},
+					api.Task{UUID: "task_uuid_2", Op: "backup", Status: FailedStatus, JobUUID: "job_uuid"},
+					api.Task{UUID: "task_uuid_3", Op: "backup", Status: RunningStatus, JobUUID: "job_uuid"},
+					api.Task{UUID: "task_uuid_4", Op: PurgeOperation, Status: DoneStatus},
+				}
+				server.RouteToHandler("GET", "/v1/jobs", ghttp.RespondWithJSONEncodedPtr(&statusCode, &jobsResponse))
+				server.RouteToHandler("GET", "/v1/tasks", ghttp.RespondWithJSONEncodedPtr(&statusCode, &tasksResponse))
+				otherMetrics = make(chan prometheus.Metric)
+				jobTasksTotalMetric.WithLabelValues(append(jobLabels, DoneStatus)...).Inc()
+				jobTasksTotalMetric.WithLabelValues(append(jobLabels, FailedStatus)...).Inc()
+			})
+
+			It("returns a job_tasks_total metric for the done tasks", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(jobTasksTotalMetric.WithLabelValues(append(jobLabels, DoneStatus)...))))
+			})
+
+			It("returns a job_tasks_total metric for the failed tasks", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(jobTasksTotalMetric.WithLabelValues(append(jobLabels, FailedStatus)...))))
+			})
+
+			It("does not return a job_tasks_total metric for the running tasks", func() {
+				Consistently(metrics).ShouldNot(Receive(PrometheusMetric(jobTasksTotalMetric.WithLabelValues(append(jobLabels, RunningStatus)...))))
+			})
+
+			It("does not count the tasks again at the next scrape", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(jobTasksTotalMetric.WithLabelValues(append(jobLabels, DoneStatus)...))))
+				go tasksCollector.Collect(otherMetrics)
+				Eventually(otherMetrics).Should(Receive(PrometheusMetric(jobTasksTotalMetric.WithLabelValues(append(jobLabels, DoneStatus)...))))
+			})
+
+			It("counts the tasks finished since the previous scrape", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(jobTasksTotalMetric.WithLabelValues(append(jobLabels, DoneStatus)...))))
+				tasksResponse = append(tasksResponse, api.Task{UUID: "task_uuid_5", Op: "backup", Status: DoneStatus, JobUUID: "job_uuid"})
+				jobTasksTotalMetric.WithLabelValues(append(jobLabels, DoneStatus)...).Inc()
+				go tasksCollector.Collect(otherMetrics)
+				Eventually(otherMetrics).Should(Receive(PrometheusMetric(jobTasksTotalMetric.WithLabelValues(append(jobLabels, DoneStatus)...))))
+			})
+
+			Context("when the legacy job labels are enabled", func() {
+				var legacyJobTasksTotalMetric *prometheus.CounterVec
+
+				BeforeEach(func() {
+					legacyJobLabels = true
+					legacyJobTasksTotalMetric = prometheus.NewCounterVec(
+						prometheus.CounterOpts{
+							Namespace:   namespace,
+							Subsystem:   "job",
+							Name:        "tasks_total",
+							Help:        "Total number of finished Shield Tasks of a Shield Job by status.",
+							ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+						},
+						[]string{"job_name", "status"},
+					)
+					legacyJobTasksTotalMetric.WithLabelValues("job_name", DoneStatus).Inc()
+				})
+
+				It("returns a job_tasks_total metric labeled with the job name only", func() {
+					Eventually(metrics).Should(Receive(PrometheusMetric(legacyJobTasksTotalMetric.WithLabelValues("job_name", DoneStatus))))
+				})
+			})
+		})
+
+		Context("when there are backups", func() {
+			BeforeEach(func() {
+				jobsResponse = []api.Job{
+					api.Job{UUID: "job_uuid", Name: "job_name"},
+				}
+				backup := func(status string, archiveUUID string, stoppedAt int64) api.Task {
+					return api.Task{
+						Op:          "backup",
+						Status:      status,
+						JobUUID:     "job_uuid",
+						ArchiveUUID: archiveUUID,
+						StoppedAt:   timestamp.NewTimestamp(time.Unix(stoppedAt, 0)),
+					}
+				}
+				tasksResponse = []api.Task{
+					backup(DoneStatus, "archive_uuid_1", 200),
+					backup(DoneStatus, "archive_uuid_2", 100),
+					backup(FailedStatus, "archive_uuid_3", 300),
+				}
+				archivesResponse = []client.Archive{
+					client.Archive{Archive: api.Archive{UUID: "archive_uuid_1"}, Size: 2048},
+					client.Archive{Archive: api.Archive{UUID: "archive_uuid_2"}, Size: 1024},
+					client.Archive{Archive: api.Archive{UUID: "archive_uuid_3"}, Size: 512},
+				}
+				taskBytesMetric.WithLabelValues("backup", "job_name").Set(2048)
+			})
+
+			It("returns a task_bytes metric for the last successful backup of the job", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(taskBytesMetric.WithLabelValues("backup", "job_name"))))
+			})
+
+			Context("when Shield does not report the size of the archives", func() {
+				BeforeEach(func() {
+					archivesResponse = []client.Archive{
+						client.Archive{Archive: api.Archive{UUID: "archive_uuid_1"}},
+					}
+					taskBytesMetric.WithLabelValues("backup", "job_name").Set(0)
+				})
+
+				It("does not return a task_bytes metric", func() {
+					Consistently(metrics).ShouldNot(Receive(PrometheusMetric(taskBytesMetric.WithLabelValues("backup", "job_name"))))
+				})
+			})
+
+			Context("when it fails to list the archives", func() {
+				BeforeEach(func() {
+					purgeStatusCode = http.StatusInternalServerError
+					tasksScrapeErrorsTotalMetric.Inc()
+					lastTasksScrapeErrorMetric.Set(1)
+				})
+
+				It("returns a last_tasks_scrape_error metric", func() {
+					Eventually(metrics).Should(Receive(PrometheusMetric(lastTasksScrapeErrorMetric)))
+				})
+			})
+		})
+
+		It("does not return a purge_tasks_total metric without purges", func() {
+			Consistently(metrics).ShouldNot(Receive(PrometheusMetric(purgeTasksTotalMetric.WithLabelValues(DoneStatus))))
+		})
+
+		Context("when there are purges", func() {
+			BeforeEach(func() {
+				purge := func(status string, archiveUUID string, stoppedAt int64) api.Task {
+					return api.Task{
+						Op:          PurgeOperation,
+						Status:      status,
+						ArchiveUUID: archiveUUID,
+						StoppedAt:   timestamp.NewTimestamp(time.Unix(stoppedAt, 0)),
+					}
+				}
+				tasksResponse = []api.Task{
+					purge(DoneStatus, "archive_uuid_1", 100),
+					purge(DoneStatus, "archive_uuid_2", 200),
+					purge(DoneStatus, "archive_uuid_3", 50),
+					purge(FailedStatus, "archive_uuid_4", 300),
+				}
+				archivesResponse = []client.Archive{
+					client.Archive{Archive: api.Archive{UUID: "archive_uuid_1", StoreUUID: "store_uuid_1"}},
+					client.Archive{Archive: api.Archive{UUID: "archive_uuid_2", StoreUUID: "store_uuid_2"}},
+					client.Archive{Archive: api.Archive{UUID: "archive_uuid_3", StoreUUID: "store_uuid_1"}},
+					client.Archive{Archive: api.Archive{UUID: "archive_uuid_4", StoreUUID: "store_uuid_1"}},
+				}
+				storesResponse = []client.Store{
+					client.Store{Store: api.Store{UUID: "store_uuid_1", Name: "store_name_1"}},
+					client.Store{Store: api.Store{UUID: "store_uuid_2", Name: "store_name_2"}},
+				}
+				purgeTasksTotalMetric.WithLabelValues(DoneStatus).Set(3)
+				purgeTasksTotalMetric.WithLabelValues(FailedStatus).Set(1)
+				storeLastPurgeSuccessTimestampMetric.WithLabelValues("store_name_1").Set(100)
+				storeLastPurgeSuccessTimestampMetric.WithLabelValues("store_name_2").Set(200)
+			})
+
+			It("returns a purge_tasks_total metric for the done purges", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(purgeTasksTotalMetric.WithLabelValues(DoneStatus))))
+			})
+
+			It("returns a purge_tasks_total metric for the failed purges", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(purgeTasksTotalMetric.WithLabelValues(FailedStatus))))
+			})
+
+			It("returns a store_last_purge_success_timestamp metric for store 1", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(storeLastPurgeSuccessTimestampMetric.WithLabelValues("store_name_1"))))
+			})
+
+			It("returns a store_last_purge_success_timestamp metric for store 2", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(storeLastPurgeSuccessTimestampMetric.WithLabelValues("store_name_2"))))
+			})
+
+			Context("when it fails to list the archives", func() {
+				BeforeEach(func() {
+					purgeStatusCode = http.StatusInternalServerError
+					tasksScrapeErrorsTotalMetric.Inc()
+					lastTasksScrapeErrorMetric.Set(1)
+				})
+
+				It("returns a tasks_scrape_errors_total metric", func() {
+					Eventually(metrics).Should(Receive(PrometheusMetric(tasksScrapeErrorsTotalMetric)))
+				})
+
+				It("returns a last_tasks_scrape_error metric", func() {
+					Eventually(metrics).Should(Receive(PrometheusMetric(lastTasksScrapeErrorMetric)))
+				})
+
+				It("does not return the metrics built from the tasks listed before", func() {
+					Consistently(metrics).ShouldNot(Receive(PrometheusMetric(purgeTasksTotalMetric.WithLabelValues(DoneStatus))))
+				})
+			})
+		})
+
+		Context("when the Shield backend enters maintenance mode after a scrape", func() {
+			var maintenanceMetrics chan prometheus.Metric
+
+			BeforeEach(func() {
+				metrics = make(chan prometheus.Metric, 100)
+				maintenanceMetrics = make(chan prometheus.Metric)
+			})
+
+			JustBeforeEach(func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(lastTasksScrapeErrorMetric)))
+
+				server.RouteToHandler("GET", "/v1/jobs", ghttp.RespondWith(http.StatusServiceUnavailable, nil))
+				server.RouteToHandler("GET", "/v1/tasks", ghttp.RespondWith(http.StatusServiceUnavailable, nil))
+				go tasksCollector.Collect(maintenanceMetrics)
+			})
+
+			It("returns the tasks_total metrics of the previous scrape", func() {
+				Eventually(maintenanceMetrics).Should(Receive(PrometheusMetric(tasksTotalMetric.WithLabelValues(TaskOperation1, TaskStatus1, "", ""))))
+			})
+
+			It("returns a tasks_duration_seconds metric", func() {
+				Eventually(maintenanceMetrics).Should(Receive(PrometheusMetric(tasksDurationSecondsMetric.WithLabelValues(TaskOperation1, TaskStatus1, "", "").(prometheus.Metric))))
+			})
+
+			It("returns a last_tasks_scrape_error metric", func() {
+				Eventually(maintenanceMetrics).Should(Receive(PrometheusMetric(lastTasksScrapeErrorMetric)))
+			})
+		})
+
+		Context("when it fails to list the jobs", func() {
+			BeforeEach(func() {
+				statusCode = http.StatusInternalServerError
+				tasksScrapeErrorsTotalMetric.Inc()
+				lastTasksScrapeErrorMetric.Set(1)
+			})
+
+			It("returns a tasks_scrape_errors_total metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(tasksScrapeErrorsTotalMetric)))
+			})
+
+			It("returns a last_tasks_scrape_error metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(lastTasksScrapeErrorMetric)))
+			})
+		})
+
 		Context("when it fails to list the tasks", func() {
 			BeforeEach(func() {
 				statusCode = http.StatusInternalServerError
@@ -0,0 +1,190 @@
+package collectors
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+	"github.com/bosh-prometheus/shield_exporter/filters"
+)
+
+type TenantsCollector struct {
+	namespace                            string
+	environment                          string
+	backendName                          string
+	shieldClient                         *client.Client
+	tenantStorageUsedBytesDesc           *prometheus.Desc
+	tenantArchivesTotalDesc              *prometheus.Desc
+	tenantStorageDailyIncreaseBytesDesc  *prometheus.Desc
+	tenantMembersTotalDesc               *prometheus.Desc
+	collectorAvailableDesc               *prometheus.Desc
+	tenantsScrapesTotalMetric            prometheus.Counter
+	tenantsScrapeErrorsTotalMetric       prometheus.Counter
+	scrapeErrorsByReasonTotalMetric      *prometheus.CounterVec
+	lastTenantsScrapeErrorDesc           *prometheus.Desc
+	lastTenantsScrapeTimestampDesc       *prometheus.Desc
+	lastTenantsScrapeDurationSecondsDesc *prometheus.Desc
+}
+
+func NewTenantsCollector(
+	namespace string,
+	environment string,
+	backendName string,
+	shieldClient *client.Client,
+) *TenantsCollector {
+	tenantStorageUsedBytesDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "tenant", "storage_used_bytes"),
+		"Storage used by the archives of a Shield Tenant in bytes.",
+		[]string{"tenant"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	tenantArchivesTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "tenant", "archives_total"),
+		"Total number of Shield Archives of a Shield Tenant.",
+		[]string{"tenant"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	tenantStorageDailyIncreaseBytesDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "tenant", "storage_daily_increase_bytes"),
+		"Increase of the storage used by the archives of a Shield Tenant over the last day in bytes.",
+		[]string{"tenant"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	tenantMembersTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "tenant", "members_total"),
+		"Total number of Shield Users member of a Shield Tenant.",
+		[]string{"tenant"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	collectorAvailableDesc := newCollectorAvailableDesc(namespace, environment, backendName, filters.TenantsCollector)
+	scrapeErrorsByReasonTotalMetric := newScrapeErrorsByReasonTotalMetric(namespace, environment, backendName, filters.TenantsCollector)
+
+	tenantsScrapesTotalMetric := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "tenants",
+			Name:        "scrapes_total",
+			Help:        "Total number of scrapes for Shield Tenants.",
+			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+		},
+	)
+
+	tenantsScrapeErrorsTotalMetric := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "tenants",
+			Name:        "scrape_errors_total",
+			Help:        "Total number of scrape errors of Shield Tenants.",
+			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+		},
+	)
+
+	lastTenantsScrapeErrorDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_tenants_scrape_error"),
+		"Whether the last scrape of Tenant metrics from Shield resulted in an error (1 for error, 0 for success).",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	lastTenantsScrapeTimestampDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_tenants_scrape_timestamp"),
+		"Number of seconds since 1970 since last scrape of Tenant metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	lastTenantsScrapeDurationSecondsDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_tenants_scrape_duration_seconds"),
+		"Duration of the last scrape of Tenant metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	return &TenantsCollector{
+		namespace:                            namespace,
+		environment:                          environment,
+		backendName:                          backendName,
+		shieldClient:                         shieldClient,
+		tenantStorageUsedBytesDesc:           tenantStorageUsedBytesDesc,
+		tenantArchivesTotalDesc:              tenantArchivesTotalDesc,
+		tenantStorageDailyIncreaseBytesDesc:  tenantStorageDailyIncreaseBytesDesc,
+		tenantMembersTotalDesc:               tenantMembersTotalDesc,
+		collectorAvailableDesc:               collectorAvailableDesc,
+		tenantsScrapesTotalMetric:            tenantsScrapesTotalMetric,
+		tenantsScrapeErrorsTotalMetric:       tenantsScrapeErrorsTotalMetric,
+		scrapeErrorsByReasonTotalMetric:      scrapeErrorsByReasonTotalMetric,
+		lastTenantsScrapeErrorDesc:           lastTenantsScrapeErrorDesc,
+		lastTenantsScrapeTimestampDesc:       lastTenantsScrapeTimestampDesc,
+		lastTenantsScrapeDurationSecondsDesc: lastTenantsScrapeDurationSecondsDesc,
+	}
+}
+
+func (c TenantsCollector) Collect(ch chan<- prometheus.Metric) {
+	var begun = time.Now()
+
+	errorMetric := float64(0)
+	err := c.reportTenantsMetrics(ch)
+	if isScrapeError(err) {
+		errorMetric = float64(1)
+		c.tenantsScrapeErrorsTotalMetric.Inc()
+		c.scrapeErrorsByReasonTotalMetric.WithLabelValues(client.ErrorReason(err)).Inc()
+	}
+	c.tenantsScrapeErrorsTotalMetric.Collect(ch)
+	c.scrapeErrorsByReasonTotalMetric.Collect(ch)
+
+	c.tenantsScrapesTotalMetric.Inc()
+	c.tenantsScrapesTotalMetric.Collect(ch)
+
+	ch <- prometheus.MustNewConstMetric(c.collectorAvailableDesc, prometheus.GaugeValue, availability(err))
+	ch <- prometheus.MustNewConstMetric(c.lastTenantsScrapeErrorDesc, prometheus.GaugeValue, errorMetric)
+	ch <- prometheus.MustNewConstMetric(c.lastTenantsScrapeTimestampDesc, prometheus.GaugeValue, float64(time.Now().Unix()))
+	ch <- prometheus.MustNewConstMetric(c.lastTenantsScrapeDurationSecondsDesc, prometheus.GaugeValue, time.Since(begun).Seconds())
+}
+
+func (c TenantsCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	c.shieldClient = c.shieldClient.WithContext(ctx)
+	c.Collect(ch)
+}
+
+func (c TenantsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.tenantStorageUsedBytesDesc
+	ch <- c.tenantArchivesTotalDesc
+	ch <- c.tenantStorageDailyIncreaseBytesDesc
+	ch <- c.tenantMembersTotalDesc
+	ch <- c.collectorAvailableDesc
+	c.tenantsScrapesTotalMetric.Describe(ch)
+	c.tenantsScrapeErrorsTotalMetric.Describe(ch)
+	c.scrapeErrorsByReasonTotalMetric.Describe(ch)
+	ch <- c.lastTenantsScrapeErrorDesc
+	ch <- c.lastTenantsScrapeTimestampDesc
+	ch <- c.lastTenantsScrapeDurationSecondsDesc
+}
+
+func (c TenantsCollector) reportTenantsMetrics(ch chan<- prometheus.Metric) error {
+	tenants, err := c.shieldClient.GetTenants()
+	if err != nil {
+		logError(err, "Error while listing tenants: %v", err)
+		return err
+	}
+
+	for _, tenant := range tenants {
+		ch <- prometheus.MustNewConstMetric(c.tenantStorageUsedBytesDesc, prometheus.GaugeValue, float64(tenant.StorageUsed), tenant.Name)
+		ch <- prometheus.MustNewConstMetric(c.tenantArchivesTotalDesc, prometheus.GaugeValue, float64(tenant.ArchiveCount), tenant.Name)
+		ch <- prometheus.MustNewConstMetric(c.tenantStorageDailyIncreaseBytesDesc, prometheus.GaugeValue, float64(tenant.DailyIncrease), tenant.Name)
+
+		members, err := c.shieldClient.GetTenant(tenant.UUID)
+		if err != nil {
+			logError(err, "Error while getting tenant `%s`: %v", tenant.Name, err)
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(c.tenantMembersTotalDesc, prometheus.GaugeValue, float64(len(members.Members)), tenant.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,314 @@
+package collectors_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+	. "github.com/bosh-prometheus/shield_exporter/collectors"
+	. "github.com/bosh-prometheus/shield_exporter/utils/test_matchers"
+)
+
+var _ = Describe("TenantsCollector", func() {
+	var (
+		err    error
+		server *ghttp.Server
+
+		shieldClient *client.Client
+
+		namespace   = "test_namespace"
+		environment = "test_environment"
+		backendName = "test_backend"
+
+		username = "fake_username"
+		password = "fake_password"
+
+		tenantUUID1 = "tenant_uuid_1"
+		tenantName1 = "tenant_1"
+		tenantUUID2 = "tenant_uuid_2"
+		tenantName2 = "tenant_2"
+
+		tenantStorageUsedBytesMetric          *prometheus.GaugeVec
+		tenantArchivesTotalMetric             *prometheus.GaugeVec
+		tenantStorageDailyIncreaseBytesMetric *prometheus.GaugeVec
+		tenantMembersTotalMetric              *prometheus.GaugeVec
+		tenantsScrapesTotalMetric             prometheus.Counter
+		tenantsScrapeErrorsTotalMetric        prometheus.Counter
+		lastTenantsScrapeErrorMetric          prometheus.Gauge
+
+		tenantsCollector *TenantsCollector
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		shieldClient, err = client.NewClient(client.Config{
+			BackendURL: server.URL(),
+			Username:   username,
+			Password:   password,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		tenantStorageUsedBytesMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "tenant",
+				Name:        "storage_used_bytes",
+				Help:        "Storage used by the archives of a Shield Tenant in bytes.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"tenant"},
+		)
+		tenantStorageUsedBytesMetric.WithLabelValues(tenantName1).Set(2048)
+		tenantStorageUsedBytesMetric.WithLabelValues(tenantName2).Set(0)
+
+		tenantArchivesTotalMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "tenant",
+				Name:        "archives_total",
+				Help:        "Total number of Shield Archives of a Shield Tenant.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"tenant"},
+		)
+		tenantArchivesTotalMetric.WithLabelValues(tenantName1).Set(4)
+
+		tenantStorageDailyIncreaseBytesMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "tenant",
+				Name:        "storage_daily_increase_bytes",
+				Help:        "Increase of the storage used by the archives of a Shield Tenant over the last day in bytes.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"tenant"},
+		)
+		tenantStorageDailyIncreaseBytesMetric.WithLabelValues(tenantName1).Set(512)
+
+		tenantMembersTotalMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "tenant",
+				Name:        "members_total",
+				Help:        "Total number of Shield Users member of a Shield Tenant.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"tenant"},
+		)
+		tenantMembersTotalMetric.WithLabelValues(tenantName1).Set(2)
+		tenantMembersTotalMetric.WithLabelValues(tenantName2).Set(0)
+
+		tenantsScrapesTotalMetric = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace:   namespace,
+				Subsystem:   "tenants",
+				Name:        "scrapes_total",
+				Help:        "Total number of scrapes for Shield Tenants.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+		tenantsScrapesTotalMetric.Inc()
+
+		tenantsScrapeErrorsTotalMetric = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace:   namespace,
+				Subsystem:   "tenants",
+				Name:        "scrape_errors_total",
+				Help:        "Total number of scrape errors of Shield Tenants.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+
+		lastTenantsScrapeErrorMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "",
+				Name:        "last_tenants_scrape_error",
+				Help:        "Whether the last scrape of Tenant metrics from Shield resulted in an error (1 for error, 0 for success).",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+	})
+
+	JustBeforeEach(func() {
+		tenantsCollector = NewTenantsCollector(namespace, environment, backendName, shieldClient)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Describe", func() {
+		var (
+			descriptions chan *prometheus.Desc
+		)
+
+		BeforeEach(func() {
+			descriptions = make(chan *prometheus.Desc)
+		})
+
+		JustBeforeEach(func() {
+			go tenantsCollector.Describe(descriptions)
+		})
+
+		It("returns a tenant_storage_used_bytes metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(tenantStorageUsedBytesMetric.WithLabelValues(tenantName1).Desc())))
+		})
+
+		It("returns a tenant_archives_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(tenantArchivesTotalMetric.WithLabelValues(tenantName1).Desc())))
+		})
+
+		It("returns a tenant_storage_daily_increase_bytes metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(tenantStorageDailyIncreaseBytesMetric.WithLabelValues(tenantName1).Desc())))
+		})
+
+		It("returns a tenant_members_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(tenantMembersTotalMetric.WithLabelValues(tenantName1).Desc())))
+		})
+
+		It("returns a tenants_scrapes_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(tenantsScrapesTotalMetric.Desc())))
+		})
+
+		It("returns a tenants_scrape_errors_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(tenantsScrapeErrorsTotalMetric.Desc())))
+		})
+
+		It("returns a last_tenants_scrape_error metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(lastTenantsScrapeErrorMetric.Desc())))
+		})
+	})
+
+	Describe("Collect", func() {
+		var (
+			statusCode       int
+			tenantsResponse  []client.Tenant
+			tenantStatusCode int
+			tenant1Response  client.Tenant
+			tenant2Response  client.Tenant
+			metrics          chan prometheus.Metric
+		)
+
+		BeforeEach(func() {
+			statusCode = http.StatusOK
+			tenantsResponse = []client.Tenant{
+				client.Tenant{
+					UUID:          tenantUUID1,
+					Name:          tenantName1,
+					StorageUsed:   2048,
+					ArchiveCount:  4,
+					DailyIncrease: 512,
+				},
+				client.Tenant{
+					UUID: tenantUUID2,
+					Name: tenantName2,
+				},
+			}
+			tenantStatusCode = http.StatusOK
+			tenant1Response = client.Tenant{
+				UUID: tenantUUID1,
+				Name: tenantName1,
+				Members: []client.TenantMember{
+					{Name: "user_1", Account: "user_1", Role: "admin"},
+					{Name: "user_2", Account: "user_2", Role: "operator"},
+				},
+			}
+			tenant2Response = client.Tenant{
+				UUID: tenantUUID2,
+				Name: tenantName2,
+			}
+			metrics = make(chan prometheus.Metric)
+		})
+
+		JustBeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/tenants"),
+					ghttp.VerifyBasicAuth(username, password),
+					ghttp.RespondWithJSONEncodedPtr(&statusCode, &tenantsResponse),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/tenants/"+tenantUUID1),
+					ghttp.VerifyBasicAuth(username, password),
+					ghttp.RespondWithJSONEncodedPtr(&tenantStatusCode, &tenant1Response),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/tenants/"+tenantUUID2),
+					ghttp.VerifyBasicAuth(username, password),
+					ghttp.RespondWithJSONEncodedPtr(&tenantStatusCode, &tenant2Response),
+				),
+			)
+			go tenantsCollector.Collect(metrics)
+		})
+
+		It("returns a tenant_storage_used_bytes metric for tenant 1", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(tenantStorageUsedBytesMetric.WithLabelValues(tenantName1))))
+		})
+
+		It("returns a tenant_storage_used_bytes metric for tenant 2", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(tenantStorageUsedBytesMetric.WithLabelValues(tenantName2))))
+		})
+
+		It("returns a tenant_archives_total metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(tenantArchivesTotalMetric.WithLabelValues(tenantName1))))
+		})
+
+		It("returns a tenant_storage_daily_increase_bytes metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(tenantStorageDailyIncreaseBytesMetric.WithLabelValues(tenantName1))))
+		})
+
+		It("returns a tenant_members_total metric for tenant 1", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(tenantMembersTotalMetric.WithLabelValues(tenantName1))))
+		})
+
+		It("returns a tenant_members_total metric for a tenant without members", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(tenantMembersTotalMetric.WithLabelValues(tenantName2))))
+		})
+
+		It("returns a tenants_scrapes_total metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(tenantsScrapesTotalMetric)))
+		})
+
+		It("returns a last_tenants_scrape_error metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(lastTenantsScrapeErrorMetric)))
+		})
+
+		Context("when it fails to list the tenants", func() {
+			BeforeEach(func() {
+				statusCode = http.StatusInternalServerError
+				tenantsScrapeErrorsTotalMetric.Inc()
+				lastTenantsScrapeErrorMetric.Set(1)
+			})
+
+			It("returns a tenants_scrape_errors_total metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(tenantsScrapeErrorsTotalMetric)))
+			})
+
+			It("returns a last_tenants_scrape_error metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(lastTenantsScrapeErrorMetric)))
+			})
+		})
+
+		Context("when it fails to get a tenant", func() {
+			BeforeEach(func() {
+				tenantStatusCode = http.StatusInternalServerError
+				tenantsScrapeErrorsTotalMetric.Inc()
+				lastTenantsScrapeErrorMetric.Set(1)
+			})
+
+			It("returns a tenants_scrape_errors_total metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(tenantsScrapeErrorsTotalMetric)))
+			})
+
+			It("returns a last_tenants_scrape_error metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(lastTenantsScrapeErrorMetric)))
+			})
+		})
+	})
+})
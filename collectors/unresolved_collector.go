@@ -0,0 +1,66 @@
+package collectors
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// UnresolvedCollector stands for a collector of a Shield backend whose name
+// is not resolved yet, ie when the exporter started while Shield was not
+// available: it reports its scrapes as failed, so that the alerts on the
+// scrape errors fire instead of the metrics silently missing.
+type UnresolvedCollector struct {
+	lastScrapeErrorDesc *prometheus.Desc
+}
+
+// NewUnresolvedCollector returns an UnresolvedCollector exporting the
+// `last_<collectorName>_scrape_error` metric of a collector, given its snake
+// cased name, ie `retention_policies`.
+func NewUnresolvedCollector(
+	namespace string,
+	environment string,
+	backendName string,
+	collectorName string,
+) *UnresolvedCollector {
+	lastScrapeErrorDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_"+collectorName+"_scrape_error"),
+		"Whether the last scrape of the collector metrics from Shield resulted in an error (1 for error, 0 for success).",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	return &UnresolvedCollector{
+		lastScrapeErrorDesc: lastScrapeErrorDesc,
+	}
+}
+
+func (c UnresolvedCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.lastScrapeErrorDesc, prometheus.GaugeValue, 1)
+}
+
+func (c UnresolvedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.lastScrapeErrorDesc
+}
+
+// UnresolvedBackendCollector stands for the BackendCollector of a Shield
+// backend whose name is not resolved yet.
+type UnresolvedBackendCollector struct {
+	backendResolvedDesc *prometheus.Desc
+}
+
+func NewUnresolvedBackendCollector(
+	namespace string,
+	environment string,
+	backendName string,
+) *UnresolvedBackendCollector {
+	return &UnresolvedBackendCollector{
+		backendResolvedDesc: newBackendResolvedDesc(namespace, environment, backendName),
+	}
+}
+
+func (c UnresolvedBackendCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.backendResolvedDesc, prometheus.GaugeValue, 0)
+}
+
+func (c UnresolvedBackendCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.backendResolvedDesc
+}
@@ -0,0 +1,96 @@
+package collectors_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	. "github.com/bosh-prometheus/shield_exporter/collectors"
+	. "github.com/bosh-prometheus/shield_exporter/utils/test_matchers"
+)
+
+var _ = Describe("UnresolvedCollector", func() {
+	var (
+		namespace   = "test_namespace"
+		environment = "test_environment"
+		backendName = "unresolved"
+
+		lastRetentionPoliciesScrapeErrorMetric prometheus.Gauge
+
+		unresolvedCollector *UnresolvedCollector
+	)
+
+	BeforeEach(func() {
+		lastRetentionPoliciesScrapeErrorMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "",
+				Name:        "last_retention_policies_scrape_error",
+				Help:        "Whether the last scrape of the collector metrics from Shield resulted in an error (1 for error, 0 for success).",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+		lastRetentionPoliciesScrapeErrorMetric.Set(1)
+
+		unresolvedCollector = NewUnresolvedCollector(namespace, environment, backendName, "retention_policies")
+	})
+
+	Describe("Describe", func() {
+		It("returns a last_<collector>_scrape_error metric description", func() {
+			descriptions := make(chan *prometheus.Desc)
+			go unresolvedCollector.Describe(descriptions)
+			Eventually(descriptions).Should(Receive(Equal(lastRetentionPoliciesScrapeErrorMetric.Desc())))
+		})
+	})
+
+	Describe("Collect", func() {
+		It("returns a failed last_<collector>_scrape_error metric", func() {
+			metrics := make(chan prometheus.Metric)
+			go unresolvedCollector.Collect(metrics)
+			Eventually(metrics).Should(Receive(PrometheusMetric(lastRetentionPoliciesScrapeErrorMetric)))
+		})
+	})
+})
+
+var _ = Describe("UnresolvedBackendCollector", func() {
+	var (
+		namespace   = "test_namespace"
+		environment = "test_environment"
+		backendName = "unresolved"
+
+		backendResolvedMetric prometheus.Gauge
+
+		unresolvedBackendCollector *UnresolvedBackendCollector
+	)
+
+	BeforeEach(func() {
+		backendResolvedMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "backend",
+				Name:        "resolved",
+				Help:        "Whether the name of the Shield backend is resolved from the Shield Status, so that its metrics are gathered (1 for resolved, 0 for unresolved).",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+
+		unresolvedBackendCollector = NewUnresolvedBackendCollector(namespace, environment, backendName)
+	})
+
+	Describe("Describe", func() {
+		It("returns a backend_resolved metric description", func() {
+			descriptions := make(chan *prometheus.Desc)
+			go unresolvedBackendCollector.Describe(descriptions)
+			Eventually(descriptions).Should(Receive(Equal(backendResolvedMetric.Desc())))
+		})
+	})
+
+	Describe("Collect", func() {
+		It("returns an unresolved backend_resolved metric", func() {
+			metrics := make(chan prometheus.Metric)
+			go unresolvedBackendCollector.Collect(metrics)
+			Eventually(metrics).Should(Receive(PrometheusMetric(backendResolvedMetric)))
+		})
+	})
+})
@@ -0,0 +1,206 @@
+package collectors
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+	"github.com/bosh-prometheus/shield_exporter/filters"
+)
+
+const (
+	AdminSysRole    = "admin"
+	ManagerSysRole  = "manager"
+	EngineerSysRole = "engineer"
+
+	LocalAuthProvider = "local"
+)
+
+type UsersCollector struct {
+	namespace                          string
+	environment                        string
+	backendName                        string
+	shieldClient                       *client.Client
+	usersTotalDesc                     *prometheus.Desc
+	usersByAuthProviderTotalDesc       *prometheus.Desc
+	collectorAvailableDesc             *prometheus.Desc
+	usersScrapesTotalMetric            prometheus.Counter
+	usersScrapeErrorsTotalMetric       prometheus.Counter
+	scrapeErrorsByReasonTotalMetric    *prometheus.CounterVec
+	lastUsersScrapeErrorDesc           *prometheus.Desc
+	lastUsersScrapeTimestampDesc       *prometheus.Desc
+	lastUsersScrapeDurationSecondsDesc *prometheus.Desc
+}
+
+func NewUsersCollector(
+	namespace string,
+	environment string,
+	backendName string,
+	shieldClient *client.Client,
+) *UsersCollector {
+	usersTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "users", "total"),
+		"Total number of Shield local Users by system role.",
+		[]string{"system_role"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	usersByAuthProviderTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "users", "by_auth_provider_total"),
+		"Total number of Shield Users by authentication provider.",
+		[]string{"provider"},
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	collectorAvailableDesc := newCollectorAvailableDesc(namespace, environment, backendName, filters.UsersCollector)
+	scrapeErrorsByReasonTotalMetric := newScrapeErrorsByReasonTotalMetric(namespace, environment, backendName, filters.UsersCollector)
+
+	usersScrapesTotalMetric := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "users",
+			Name:        "scrapes_total",
+			Help:        "Total number of scrapes for Shield Users.",
+			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+		},
+	)
+
+	usersScrapeErrorsTotalMetric := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "users",
+			Name:        "scrape_errors_total",
+			Help:        "Total number of scrape errors of Shield Users.",
+			ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+		},
+	)
+
+	lastUsersScrapeErrorDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_users_scrape_error"),
+		"Whether the last scrape of User metrics from Shield resulted in an error (1 for error, 0 for success).",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	lastUsersScrapeTimestampDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_users_scrape_timestamp"),
+		"Number of seconds since 1970 since last scrape of User metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	lastUsersScrapeDurationSecondsDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_users_scrape_duration_seconds"),
+		"Duration of the last scrape of User metrics from Shield.",
+		nil,
+		prometheus.Labels{"environment": environment, "backend_name": backendName},
+	)
+
+	return &UsersCollector{
+		namespace:                          namespace,
+		environment:                        environment,
+		backendName:                        backendName,
+		shieldClient:                       shieldClient,
+		usersTotalDesc:                     usersTotalDesc,
+		usersByAuthProviderTotalDesc:       usersByAuthProviderTotalDesc,
+		collectorAvailableDesc:             collectorAvailableDesc,
+		usersScrapesTotalMetric:            usersScrapesTotalMetric,
+		usersScrapeErrorsTotalMetric:       usersScrapeErrorsTotalMetric,
+		scrapeErrorsByReasonTotalMetric:    scrapeErrorsByReasonTotalMetric,
+		lastUsersScrapeErrorDesc:           lastUsersScrapeErrorDesc,
+		lastUsersScrapeTimestampDesc:       lastUsersScrapeTimestampDesc,
+		lastUsersScrapeDurationSecondsDesc: lastUsersScrapeDurationSecondsDesc,
+	}
+}
+
+func (c UsersCollector) Collect(ch chan<- prometheus.Metric) {
+	var begun = time.Now()
+
+	errorMetric := float64(0)
+	err := c.reportUsersMetrics(ch)
+	if isScrapeError(err) {
+		errorMetric = float64(1)
+		c.usersScrapeErrorsTotalMetric.Inc()
+		c.scrapeErrorsByReasonTotalMetric.WithLabelValues(client.ErrorReason(err)).Inc()
+	}
+	c.usersScrapeErrorsTotalMetric.Collect(ch)
+	c.scrapeErrorsByReasonTotalMetric.Collect(ch)
+
+	c.usersScrapesTotalMetric.Inc()
+	c.usersScrapesTotalMetric.Collect(ch)
+
+	ch <- prometheus.MustNewConstMetric(c.collectorAvailableDesc, prometheus.GaugeValue, availability(err))
+	ch <- prometheus.MustNewConstMetric(c.lastUsersScrapeErrorDesc, prometheus.GaugeValue, errorMetric)
+	ch <- prometheus.MustNewConstMetric(c.lastUsersScrapeTimestampDesc, prometheus.GaugeValue, float64(time.Now().Unix()))
+	ch <- prometheus.MustNewConstMetric(c.lastUsersScrapeDurationSecondsDesc, prometheus.GaugeValue, time.Since(begun).Seconds())
+}
+
+func (c UsersCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	c.shieldClient = c.shieldClient.WithContext(ctx)
+	c.Collect(ch)
+}
+
+func (c UsersCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.usersTotalDesc
+	ch <- c.usersByAuthProviderTotalDesc
+	ch <- c.collectorAvailableDesc
+	c.usersScrapesTotalMetric.Describe(ch)
+	c.usersScrapeErrorsTotalMetric.Describe(ch)
+	c.scrapeErrorsByReasonTotalMetric.Describe(ch)
+	ch <- c.lastUsersScrapeErrorDesc
+	ch <- c.lastUsersScrapeTimestampDesc
+	ch <- c.lastUsersScrapeDurationSecondsDesc
+}
+
+func (c UsersCollector) reportUsersMetrics(ch chan<- prometheus.Metric) error {
+	users, err := c.shieldClient.GetUsers()
+	if err != nil {
+		logError(err, "Error while listing users: %v", err)
+		return err
+	}
+
+	usersByRole := map[string]float64{AdminSysRole: 0, ManagerSysRole: 0, EngineerSysRole: 0}
+	usersProvider := make(map[string]string)
+	for _, user := range users {
+		usersByRole[user.SysRole]++
+		usersProvider[user.UUID] = LocalAuthProvider
+	}
+
+	// Users authenticated by other providers are only known to Shield once
+	// they have been granted a role on a tenant.
+	tenants, err := c.shieldClient.GetTenants()
+	if err != nil {
+		logError(err, "Error while listing tenants: %v", err)
+		return err
+	}
+
+	for _, tenant := range tenants {
+		members, err := c.shieldClient.GetTenant(tenant.UUID)
+		if err != nil {
+			logError(err, "Error while getting tenant `%s`: %v", tenant.Name, err)
+			return err
+		}
+		for _, member := range members.Members {
+			if _, ok := usersProvider[member.UUID]; !ok {
+				usersProvider[member.UUID] = member.Backend
+			}
+		}
+	}
+
+	for role, count := range usersByRole {
+		ch <- prometheus.MustNewConstMetric(c.usersTotalDesc, prometheus.GaugeValue, count, role)
+	}
+
+	usersByProvider := map[string]float64{LocalAuthProvider: 0}
+	for _, provider := range usersProvider {
+		usersByProvider[provider]++
+	}
+
+	for provider, count := range usersByProvider {
+		ch <- prometheus.MustNewConstMetric(c.usersByAuthProviderTotalDesc, prometheus.GaugeValue, count, provider)
+	}
+
+	return nil
+}
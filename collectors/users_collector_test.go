@@ -0,0 +1,285 @@
+package collectors_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+	. "github.com/bosh-prometheus/shield_exporter/collectors"
+	. "github.com/bosh-prometheus/shield_exporter/utils/test_matchers"
+)
+
+var _ = Describe("UsersCollector", func() {
+	var (
+		err    error
+		server *ghttp.Server
+
+		shieldClient *client.Client
+
+		namespace   = "test_namespace"
+		environment = "test_environment"
+		backendName = "test_backend"
+
+		username = "fake_username"
+		password = "fake_password"
+
+		usersTotalMetric               *prometheus.GaugeVec
+		usersByAuthProviderTotalMetric *prometheus.GaugeVec
+		usersScrapesTotalMetric        prometheus.Counter
+		usersScrapeErrorsTotalMetric   prometheus.Counter
+		lastUsersScrapeErrorMetric     prometheus.Gauge
+		lastUsersScrapeDurationMetric  prometheus.Gauge
+
+		usersCollector *UsersCollector
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		shieldClient, err = client.NewClient(client.Config{
+			BackendURL: server.URL(),
+			Username:   username,
+			Password:   password,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		usersTotalMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "users",
+				Name:        "total",
+				Help:        "Total number of Shield local Users by system role.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"system_role"},
+		)
+		usersTotalMetric.WithLabelValues(AdminSysRole).Set(2)
+		usersTotalMetric.WithLabelValues(ManagerSysRole).Set(0)
+		usersTotalMetric.WithLabelValues(EngineerSysRole).Set(1)
+		usersTotalMetric.WithLabelValues("").Set(1)
+
+		usersByAuthProviderTotalMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "users",
+				Name:        "by_auth_provider_total",
+				Help:        "Total number of Shield Users by authentication provider.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+			[]string{"provider"},
+		)
+		usersByAuthProviderTotalMetric.WithLabelValues(LocalAuthProvider).Set(4)
+		usersByAuthProviderTotalMetric.WithLabelValues("github").Set(2)
+
+		usersScrapesTotalMetric = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace:   namespace,
+				Subsystem:   "users",
+				Name:        "scrapes_total",
+				Help:        "Total number of scrapes for Shield Users.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+		usersScrapesTotalMetric.Inc()
+
+		usersScrapeErrorsTotalMetric = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace:   namespace,
+				Subsystem:   "users",
+				Name:        "scrape_errors_total",
+				Help:        "Total number of scrape errors of Shield Users.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+
+		lastUsersScrapeErrorMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "",
+				Name:        "last_users_scrape_error",
+				Help:        "Whether the last scrape of User metrics from Shield resulted in an error (1 for error, 0 for success).",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+
+		lastUsersScrapeDurationMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   namespace,
+				Subsystem:   "",
+				Name:        "last_users_scrape_duration_seconds",
+				Help:        "Duration of the last scrape of User metrics from Shield.",
+				ConstLabels: prometheus.Labels{"environment": environment, "backend_name": backendName},
+			},
+		)
+	})
+
+	JustBeforeEach(func() {
+		usersCollector = NewUsersCollector(namespace, environment, backendName, shieldClient)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Describe", func() {
+		var (
+			descriptions chan *prometheus.Desc
+		)
+
+		BeforeEach(func() {
+			descriptions = make(chan *prometheus.Desc)
+		})
+
+		JustBeforeEach(func() {
+			go usersCollector.Describe(descriptions)
+		})
+
+		It("returns a users_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(usersTotalMetric.WithLabelValues(AdminSysRole).Desc())))
+		})
+
+		It("returns a users_by_auth_provider_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(usersByAuthProviderTotalMetric.WithLabelValues(LocalAuthProvider).Desc())))
+		})
+
+		It("returns a users_scrapes_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(usersScrapesTotalMetric.Desc())))
+		})
+
+		It("returns a users_scrape_errors_total metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(usersScrapeErrorsTotalMetric.Desc())))
+		})
+
+		It("returns a last_users_scrape_error metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(lastUsersScrapeErrorMetric.Desc())))
+		})
+
+		It("returns a last_users_scrape_duration_seconds metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(lastUsersScrapeDurationMetric.Desc())))
+		})
+	})
+
+	Describe("Collect", func() {
+		var (
+			statusCode       int
+			usersResponse    []client.User
+			tenantsResponse  []client.Tenant
+			tenantStatusCode int
+			tenantResponse   client.Tenant
+			metrics          chan prometheus.Metric
+		)
+
+		BeforeEach(func() {
+			statusCode = http.StatusOK
+			usersResponse = []client.User{
+				client.User{UUID: "user_uuid_1", Name: "admin", Account: "admin", SysRole: AdminSysRole},
+				client.User{UUID: "user_uuid_2", Name: "root", Account: "root", SysRole: AdminSysRole},
+				client.User{UUID: "user_uuid_3", Name: "operator", Account: "operator", SysRole: EngineerSysRole},
+				client.User{UUID: "user_uuid_4", Name: "guest", Account: "guest"},
+			}
+			tenantsResponse = []client.Tenant{
+				client.Tenant{UUID: "tenant_uuid_1", Name: "tenant_1"},
+			}
+			tenantStatusCode = http.StatusOK
+			tenantResponse = client.Tenant{
+				UUID: "tenant_uuid_1",
+				Name: "tenant_1",
+				Members: []client.TenantMember{
+					{UUID: "user_uuid_1", Account: "admin", Backend: LocalAuthProvider, Role: "admin"},
+					{UUID: "user_uuid_5", Account: "octocat", Backend: "github", Role: "operator"},
+					{UUID: "user_uuid_6", Account: "hubot", Backend: "github", Role: "operator"},
+				},
+			}
+			metrics = make(chan prometheus.Metric)
+		})
+
+		JustBeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/auth/local/users"),
+					ghttp.VerifyBasicAuth(username, password),
+					ghttp.RespondWithJSONEncodedPtr(&statusCode, &usersResponse),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/tenants"),
+					ghttp.VerifyBasicAuth(username, password),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, tenantsResponse),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/tenants/tenant_uuid_1"),
+					ghttp.VerifyBasicAuth(username, password),
+					ghttp.RespondWithJSONEncodedPtr(&tenantStatusCode, &tenantResponse),
+				),
+			)
+			go usersCollector.Collect(metrics)
+		})
+
+		It("returns a users_total metric for admin users", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(usersTotalMetric.WithLabelValues(AdminSysRole))))
+		})
+
+		It("returns a users_total metric for manager users", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(usersTotalMetric.WithLabelValues(ManagerSysRole))))
+		})
+
+		It("returns a users_total metric for engineer users", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(usersTotalMetric.WithLabelValues(EngineerSysRole))))
+		})
+
+		It("returns a users_total metric for users without a system role", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(usersTotalMetric.WithLabelValues(""))))
+		})
+
+		It("returns a users_by_auth_provider_total metric for local users", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(usersByAuthProviderTotalMetric.WithLabelValues(LocalAuthProvider))))
+		})
+
+		It("returns a users_by_auth_provider_total metric for tenant members of other providers", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(usersByAuthProviderTotalMetric.WithLabelValues("github"))))
+		})
+
+		It("returns a users_scrapes_total metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(usersScrapesTotalMetric)))
+		})
+
+		It("returns a last_users_scrape_error metric", func() {
+			Eventually(metrics).Should(Receive(PrometheusMetric(lastUsersScrapeErrorMetric)))
+		})
+
+		Context("when it fails to list the users", func() {
+			BeforeEach(func() {
+				statusCode = http.StatusInternalServerError
+				usersScrapeErrorsTotalMetric.Inc()
+				lastUsersScrapeErrorMetric.Set(1)
+			})
+
+			It("returns a users_scrape_errors_total metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(usersScrapeErrorsTotalMetric)))
+			})
+
+			It("returns a last_users_scrape_error metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(lastUsersScrapeErrorMetric)))
+			})
+		})
+
+		Context("when it fails to get a tenant", func() {
+			BeforeEach(func() {
+				tenantStatusCode = http.StatusInternalServerError
+				usersScrapeErrorsTotalMetric.Inc()
+				lastUsersScrapeErrorMetric.Set(1)
+			})
+
+			It("returns a users_scrape_errors_total metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(usersScrapeErrorsTotalMetric)))
+			})
+
+			It("returns a last_users_scrape_error metric", func() {
+				Eventually(metrics).Should(Receive(PrometheusMetric(lastUsersScrapeErrorMetric)))
+			})
+		})
+	})
+})
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bosh-prometheus/shield_exporter/filters"
+)
+
+// Backend overrides, for a single Shield backend, the collectors and labels
+// set through flags.
+type Backend struct {
+	Collectors  []string `yaml:"collectors,omitempty"`
+	Namespace   string   `yaml:"namespace,omitempty"`
+	Environment string   `yaml:"environment,omitempty"`
+}
+
+// Config is the content of the configuration file, with the backend
+// stanzas keyed by Shield backend URL.
+type Config struct {
+	Backends map[string]Backend `yaml:"backends"`
+
+	collectorsFilters map[string]*filters.CollectorsFilter
+}
+
+func Load(path string) (*Config, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(content, config); err != nil {
+		return nil, fmt.Errorf("Invalid configuration file `%s`: %v", path, err)
+	}
+
+	backends := make(map[string]Backend, len(config.Backends))
+	collectorsFilters := make(map[string]*filters.CollectorsFilter)
+	for backendURL, backend := range config.Backends {
+		collectorsFilter, err := filters.NewCollectorsFilter(backend.Collectors)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid configuration of Shield backend `%s`: %v", backendURL, err)
+		}
+		backendURL = strings.TrimSuffix(backendURL, "/")
+		backends[backendURL] = backend
+		if len(backend.Collectors) > 0 {
+			collectorsFilters[backendURL] = collectorsFilter
+		}
+	}
+	config.Backends = backends
+	config.collectorsFilters = collectorsFilters
+
+	return config, nil
+}
+
+// Backend returns the stanza of the Shield backend at backendURL, empty
+// when not configured.
+func (c *Config) Backend(backendURL string) Backend {
+	if c == nil {
+		return Backend{}
+	}
+
+	return c.Backends[strings.TrimSuffix(backendURL, "/")]
+}
+
+// CollectorsFilter returns the filter of the collectors set in the stanza of
+// the Shield backend at backendURL, or collectorsFilter when not set.
+func (c *Config) CollectorsFilter(backendURL string, collectorsFilter *filters.CollectorsFilter) *filters.CollectorsFilter {
+	if c == nil {
+		return collectorsFilter
+	}
+
+	if backendCollectorsFilter, ok := c.collectorsFilters[strings.TrimSuffix(backendURL, "/")]; ok {
+		return backendCollectorsFilter
+	}
+
+	return collectorsFilter
+}
+
+// CollectorsFilters returns the filters of the collectors set in the stanzas
+// of the Shield backends.
+func (c *Config) CollectorsFilters() []*filters.CollectorsFilter {
+	if c == nil {
+		return nil
+	}
+
+	collectorsFilters := make([]*filters.CollectorsFilter, 0, len(c.collectorsFilters))
+	for _, collectorsFilter := range c.collectorsFilters {
+		collectorsFilters = append(collectorsFilters, collectorsFilter)
+	}
+
+	return collectorsFilters
+}
+
+// Namespaces returns namespace along with the metrics namespaces set in the
+// stanzas of the Shield backends, without duplicates.
+func (c *Config) Namespaces(namespace string) []string {
+	namespaces := []string{namespace}
+	if c == nil {
+		return namespaces
+	}
+
+	for _, backend := range c.Backends {
+		if backend.Namespace == "" || contains(namespaces, backend.Namespace) {
+			continue
+		}
+		namespaces = append(namespaces, backend.Namespace)
+	}
+	sort.Strings(namespaces[1:])
+
+	return namespaces
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/bosh-prometheus/shield_exporter/config"
+	"github.com/bosh-prometheus/shield_exporter/filters"
+)
+
+var _ = Describe("Config", func() {
+	var (
+		path    string
+		content string
+		config  *Config
+		err     error
+	)
+
+	BeforeEach(func() {
+		content = `
+backends:
+  https://shield-prod.example.com/:
+    collectors: [Jobs, Tasks, Archives]
+    environment: prod
+  https://shield-staging.example.com:
+    collectors: [Jobs]
+    namespace: staging_shield
+`
+	})
+
+	JustBeforeEach(func() {
+		file, tempErr := ioutil.TempFile("", "config")
+		Expect(tempErr).ToNot(HaveOccurred())
+		_, tempErr = file.WriteString(content)
+		Expect(tempErr).ToNot(HaveOccurred())
+		file.Close()
+		path = file.Name()
+
+		config, err = Load(path)
+	})
+
+	AfterEach(func() {
+		os.Remove(path)
+	})
+
+	Describe("Load", func() {
+		It("returns the backend stanzas", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.Backends).To(HaveLen(2))
+		})
+
+		Context("when a collector is not supported", func() {
+			BeforeEach(func() {
+				content = `
+backends:
+  https://shield.example.com:
+    collectors: [Unknown]
+`
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Invalid configuration of Shield backend `https://shield.example.com`: Collector filter `Unknown` is not supported"))
+			})
+		})
+
+		Context("when a field is unknown", func() {
+			BeforeEach(func() {
+				content = `
+backends:
+  https://shield.example.com:
+    collector: [Jobs]
+`
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(HavePrefix("Invalid configuration file"))
+			})
+		})
+
+		Context("when the file does not exist", func() {
+			It("returns an error", func() {
+				_, err := Load("/nonexistent/config.yml")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Backend", func() {
+		It("returns the stanza of a backend, ignoring trailing slashes", func() {
+			Expect(config.Backend("https://shield-prod.example.com")).To(Equal(Backend{
+				Collectors:  []string{"Jobs", "Tasks", "Archives"},
+				Environment: "prod",
+			}))
+			Expect(config.Backend("https://shield-staging.example.com/")).To(Equal(Backend{
+				Collectors: []string{"Jobs"},
+				Namespace:  "staging_shield",
+			}))
+		})
+
+		It("returns an empty stanza for a backend not configured", func() {
+			Expect(config.Backend("https://shield.example.com")).To(Equal(Backend{}))
+		})
+
+		It("returns an empty stanza without configuration", func() {
+			var noConfig *Config
+			Expect(noConfig.Backend("https://shield.example.com")).To(Equal(Backend{}))
+		})
+	})
+
+	Describe("CollectorsFilter", func() {
+		var collectorsFilter *filters.CollectorsFilter
+
+		BeforeEach(func() {
+			collectorsFilter, err = filters.NewCollectorsFilter([]string{"Status"})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns the collectors set in the stanza of a backend", func() {
+			backendCollectorsFilter := config.CollectorsFilter("https://shield-staging.example.com/", collectorsFilter)
+			Expect(backendCollectorsFilter.Enabled("Jobs")).To(BeTrue())
+			Expect(backendCollectorsFilter.Enabled("Status")).To(BeFalse())
+		})
+
+		It("returns the given filter for a backend not configured", func() {
+			Expect(config.CollectorsFilter("https://shield.example.com", collectorsFilter)).To(BeIdenticalTo(collectorsFilter))
+		})
+
+		It("returns the given filter without configuration", func() {
+			var noConfig *Config
+			Expect(noConfig.CollectorsFilter("https://shield.example.com", collectorsFilter)).To(BeIdenticalTo(collectorsFilter))
+		})
+	})
+
+	Describe("CollectorsFilters", func() {
+		It("returns the filters of the backend stanzas", func() {
+			collectorsFilters := config.CollectorsFilters()
+			Expect(collectorsFilters).To(HaveLen(2))
+			for _, collectorsFilter := range collectorsFilters {
+				Expect(collectorsFilter.Enabled("Jobs")).To(BeTrue())
+				Expect(collectorsFilter.Enabled("Status")).To(BeFalse())
+			}
+		})
+
+		It("returns no filter without configuration", func() {
+			var noConfig *Config
+			Expect(noConfig.CollectorsFilters()).To(BeEmpty())
+		})
+	})
+
+	Describe("Namespaces", func() {
+		It("returns the namespaces of the backend stanzas along with the default one", func() {
+			Expect(config.Namespaces("shield")).To(Equal([]string{"shield", "staging_shield"}))
+		})
+
+		It("returns the default namespace without configuration", func() {
+			var noConfig *Config
+			Expect(noConfig.Namespaces("shield")).To(Equal([]string{"shield"}))
+		})
+	})
+})
@@ -0,0 +1,177 @@
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+)
+
+const TaskStatusUpdateEvent = "task-status-update"
+
+type taskStatusUpdate struct {
+	UUID   string `json:"uuid"`
+	Status string `json:"status"`
+}
+
+// Subscriber counts the task status updates received from the events stream
+// of a Shield v8 core as they arrive, reconnecting whenever the stream is
+// closed or fails.
+type Subscriber struct {
+	shieldClient     *client.Client
+	environment      string
+	backendName      func() string
+	reconnectBackoff time.Duration
+	pingInterval     time.Duration
+	stop             chan struct{}
+
+	eventsConnectedDesc     *prometheus.Desc
+	eventsReceivedTotalDesc *prometheus.Desc
+	eventsTasksTotalDesc    *prometheus.Desc
+
+	mu            sync.Mutex
+	connected     bool
+	receivedTotal float64
+	tasksTotal    map[string]float64
+}
+
+func NewSubscriber(
+	namespace string,
+	environment string,
+	backendName func() string,
+	shieldClient *client.Client,
+	reconnectBackoff time.Duration,
+	pingInterval time.Duration,
+) *Subscriber {
+	eventsConnectedDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "events", "connected"),
+		"Whether the Shield Exporter is connected to the Shield events stream (1 for connected, 0 for disconnected).",
+		[]string{"environment", "backend_name"},
+		nil,
+	)
+
+	eventsReceivedTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "events", "received_total"),
+		"Total number of events received from the Shield events stream.",
+		[]string{"environment", "backend_name"},
+		nil,
+	)
+
+	eventsTasksTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "events", "tasks_total"),
+		"Labeled total number of Shield Task status updates received from the Shield events stream.",
+		[]string{"environment", "backend_name", "task_status"},
+		nil,
+	)
+
+	return &Subscriber{
+		shieldClient:            shieldClient,
+		environment:             environment,
+		backendName:             backendName,
+		reconnectBackoff:        reconnectBackoff,
+		pingInterval:            pingInterval,
+		stop:                    make(chan struct{}),
+		eventsConnectedDesc:     eventsConnectedDesc,
+		eventsReceivedTotalDesc: eventsReceivedTotalDesc,
+		eventsTasksTotalDesc:    eventsTasksTotalDesc,
+		tasksTotal:              make(map[string]float64),
+	}
+}
+
+// Start subscribes to the events stream in the background.
+func (s *Subscriber) Start() {
+	go func() {
+		for {
+			err := s.receive()
+			s.setConnected(false)
+
+			select {
+			case <-s.stop:
+				return
+			default:
+			}
+			log.Errorf("Error while receiving Shield events, reconnecting in %s: %v", s.reconnectBackoff, err)
+
+			select {
+			case <-s.stop:
+				return
+			case <-time.After(s.reconnectBackoff):
+			}
+		}
+	}()
+}
+
+// Stop stops reconnecting to the events stream once it is closed.
+func (s *Subscriber) Stop() {
+	close(s.stop)
+}
+
+// receive handles the events of the stream until it is closed or fails.
+func (s *Subscriber) receive() error {
+	stream, err := s.shieldClient.OpenEvents(s.pingInterval)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	s.setConnected(true)
+
+	for {
+		event, err := stream.Receive()
+		if err != nil {
+			return err
+		}
+		s.handle(event)
+	}
+}
+
+func (s *Subscriber) setConnected(connected bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.connected = connected
+}
+
+func (s *Subscriber) handle(event client.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.receivedTotal++
+	if event.Event != TaskStatusUpdateEvent {
+		return
+	}
+
+	var update taskStatusUpdate
+	if err := json.Unmarshal(event.Data, &update); err != nil || update.Status == "" {
+		log.Debugf("Ignoring Shield task status update `%s`: %v", string(event.Data), err)
+		return
+	}
+	s.tasksTotal[update.Status]++
+}
+
+func (s *Subscriber) Collect(ch chan<- prometheus.Metric) {
+	backendName := s.backendName()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	connected := float64(0)
+	if s.connected {
+		connected = 1
+	}
+	ch <- prometheus.MustNewConstMetric(s.eventsConnectedDesc, prometheus.GaugeValue, connected, s.environment, backendName)
+	ch <- prometheus.MustNewConstMetric(s.eventsReceivedTotalDesc, prometheus.CounterValue, s.receivedTotal, s.environment, backendName)
+
+	for status, total := range s.tasksTotal {
+		ch <- prometheus.MustNewConstMetric(s.eventsTasksTotalDesc, prometheus.CounterValue, total, s.environment, backendName, status)
+	}
+}
+
+func (s *Subscriber) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.eventsConnectedDesc
+	ch <- s.eventsReceivedTotalDesc
+	ch <- s.eventsTasksTotalDesc
+}
@@ -0,0 +1,302 @@
+package events_test
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/websocket"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+
+	"github.com/bosh-prometheus/shield_exporter/client"
+	. "github.com/bosh-prometheus/shield_exporter/events"
+	. "github.com/bosh-prometheus/shield_exporter/utils/test_matchers"
+)
+
+func init() {
+	log.Base().SetLevel("fatal")
+}
+
+var _ = Describe("Subscriber", func() {
+	var (
+		server        *httptest.Server
+		proxyServer   *httptest.Server
+		authorization chan string
+		connections   chan struct{}
+		connectHosts  chan string
+		sentEvents    []client.Event
+		stall         bool
+		proxied       bool
+		credentials   *client.Credentials
+
+		namespace   = "test_namespace"
+		environment = "test_environment"
+		backendName = "test_backend"
+
+		eventsConnectedMetric     *prometheus.GaugeVec
+		eventsReceivedTotalMetric *prometheus.CounterVec
+		eventsTasksTotalMetric    *prometheus.CounterVec
+
+		subscriber *Subscriber
+	)
+
+	BeforeEach(func() {
+		authorization = make(chan string, 1)
+		connections = make(chan struct{}, 16)
+		connectHosts = make(chan string, 16)
+		stall = false
+		proxied = false
+		credentials = nil
+		sentEvents = []client.Event{
+			{Event: TaskStatusUpdateEvent, Type: "task", Data: []byte(`{"uuid":"task_1","status":"running"}`)},
+			{Event: TaskStatusUpdateEvent, Type: "task", Data: []byte(`{"uuid":"task_1","status":"done"}`)},
+			{Event: TaskStatusUpdateEvent, Type: "task", Data: []byte(`{"uuid":"task_2","status":"failed"}`)},
+			{Event: "task-log-update", Type: "task", Data: []byte(`{"uuid":"task_2","tail":"..."}`)},
+		}
+
+		eventsConnectedMetric = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "events",
+				Name:      "connected",
+				Help:      "Whether the Shield Exporter is connected to the Shield events stream (1 for connected, 0 for disconnected).",
+			},
+			[]string{"environment", "backend_name"},
+		)
+		eventsConnectedMetric.WithLabelValues(environment, backendName).Set(1)
+
+		eventsReceivedTotalMetric = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "events",
+				Name:      "received_total",
+				Help:      "Total number of events received from the Shield events stream.",
+			},
+			[]string{"environment", "backend_name"},
+		)
+		eventsReceivedTotalMetric.WithLabelValues(environment, backendName).Add(4)
+
+		eventsTasksTotalMetric = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "events",
+				Name:      "tasks_total",
+				Help:      "Labeled total number of Shield Task status updates received from the Shield events stream.",
+			},
+			[]string{"environment", "backend_name", "task_status"},
+		)
+		eventsTasksTotalMetric.WithLabelValues(environment, backendName, "running").Inc()
+		eventsTasksTotalMetric.WithLabelValues(environment, backendName, "done").Inc()
+		eventsTasksTotalMetric.WithLabelValues(environment, backendName, "failed").Inc()
+	})
+
+	JustBeforeEach(func() {
+		events := sentEvents
+		stalled := stall
+		authorization, connections, connectHosts := authorization, connections, connectHosts
+		done := make(chan struct{})
+		eventsServer := websocket.Server{
+			Handler: func(conn *websocket.Conn) {
+				select {
+				case authorization <- conn.Request().Header.Get("Authorization"):
+				default:
+				}
+				for _, event := range events {
+					if err := websocket.JSON.Send(conn, event); err != nil {
+						return
+					}
+				}
+				if stalled {
+					// Neither read the pings nor answer them.
+					<-done
+					return
+				}
+				// Keep the stream open until the server is closed.
+				conn.Read(make([]byte, 1))
+			},
+		}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case connections <- struct{}{}:
+			default:
+			}
+			if _, password, _ := r.BasicAuth(); password != "fake_password" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			eventsServer.ServeHTTP(w, r)
+		}))
+		server.Config.RegisterOnShutdown(func() { close(done) })
+
+		config := client.Config{
+			BackendURL:  server.URL,
+			Username:    "fake_username",
+			Password:    "fake_password",
+			Credentials: credentials,
+		}
+
+		if proxied {
+			eventsAddress := server.Listener.Addr().String()
+			proxyServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodConnect {
+					w.WriteHeader(http.StatusMethodNotAllowed)
+					return
+				}
+				connectHosts <- r.Host
+
+				upstream, err := net.Dial("tcp", eventsAddress)
+				if err != nil {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					upstream.Close()
+					return
+				}
+				io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+				go func() {
+					io.Copy(upstream, conn)
+					upstream.Close()
+				}()
+				io.Copy(conn, upstream)
+				conn.Close()
+			}))
+			// Loopback addresses are never proxied.
+			config.BackendURL = "http://shield.example.com"
+			config.ProxyURL = proxyServer.URL
+		}
+
+		shieldClient, err := client.NewClient(config)
+		Expect(err).ToNot(HaveOccurred())
+
+		subscriber = NewSubscriber(namespace, environment, func() string { return backendName }, shieldClient, 10*time.Millisecond, 20*time.Millisecond)
+		subscriber.Start()
+	})
+
+	AfterEach(func() {
+		subscriber.Stop()
+		server.CloseClientConnections()
+		server.Close()
+		if proxyServer != nil {
+			proxyServer.CloseClientConnections()
+			proxyServer.Close()
+			proxyServer = nil
+		}
+	})
+
+	collect := func() []prometheus.Metric {
+		ch := make(chan prometheus.Metric, 16)
+		subscriber.Collect(ch)
+		close(ch)
+
+		metrics := []prometheus.Metric{}
+		for metric := range ch {
+			metrics = append(metrics, metric)
+		}
+		return metrics
+	}
+
+	Describe("Describe", func() {
+		var descriptions chan *prometheus.Desc
+
+		JustBeforeEach(func() {
+			descriptions = make(chan *prometheus.Desc, 3)
+			subscriber.Describe(descriptions)
+		})
+
+		It("returns a events_connected metric description", func() {
+			Eventually(descriptions).Should(Receive(Equal(eventsConnectedMetric.WithLabelValues(environment, backendName).Desc())))
+		})
+
+		It("returns a events_received_total metric description", func() {
+			Eventually(descriptions).Should(Receive())
+			Eventually(descriptions).Should(Receive(Equal(eventsReceivedTotalMetric.WithLabelValues(environment, backendName).Desc())))
+		})
+
+		It("returns a events_tasks_total metric description", func() {
+			Eventually(descriptions).Should(Receive())
+			Eventually(descriptions).Should(Receive())
+			Eventually(descriptions).Should(Receive(Equal(eventsTasksTotalMetric.WithLabelValues(environment, backendName, "done").Desc())))
+		})
+	})
+
+	Describe("Collect", func() {
+		It("authenticates to the Shield events stream", func() {
+			Eventually(authorization).Should(Receive(HavePrefix("Basic ")))
+		})
+
+		It("returns a events_connected metric", func() {
+			Eventually(collect).Should(ContainElement(PrometheusMetric(eventsConnectedMetric.WithLabelValues(environment, backendName))))
+		})
+
+		It("returns a events_received_total metric", func() {
+			Eventually(collect).Should(ContainElement(PrometheusMetric(eventsReceivedTotalMetric.WithLabelValues(environment, backendName))))
+		})
+
+		It("returns a events_tasks_total metric for every task status", func() {
+			Eventually(collect).Should(ContainElement(PrometheusMetric(eventsTasksTotalMetric.WithLabelValues(environment, backendName, "running"))))
+			Eventually(collect).Should(ContainElement(PrometheusMetric(eventsTasksTotalMetric.WithLabelValues(environment, backendName, "done"))))
+			Eventually(collect).Should(ContainElement(PrometheusMetric(eventsTasksTotalMetric.WithLabelValues(environment, backendName, "failed"))))
+		})
+
+		It("keeps a single connection to the Shield events stream", func() {
+			Eventually(collect).Should(ContainElement(PrometheusMetric(eventsReceivedTotalMetric.WithLabelValues(environment, backendName))))
+			Consistently(connections, 200*time.Millisecond).Should(HaveLen(1))
+		})
+
+		Context("when the Shield events stream stops responding", func() {
+			BeforeEach(func() {
+				stall = true
+			})
+
+			It("reconnects to the Shield events stream", func() {
+				Eventually(connections).Should(HaveLen(2))
+			})
+		})
+
+		Context("when the Shield events stream rejects the credentials", func() {
+			BeforeEach(func() {
+				credentials = client.NewCredentials("fake_username", "stale_password")
+				credentials.SetReauthenticate(func() error {
+					credentials.Set("fake_username", "fake_password")
+					return nil
+				})
+			})
+
+			It("re-authenticates to the Shield events stream", func() {
+				Eventually(collect).Should(ContainElement(PrometheusMetric(eventsConnectedMetric.WithLabelValues(environment, backendName))))
+				Expect(credentials.Reauthentications()).To(Equal(uint64(1)))
+			})
+		})
+
+		Context("when the Shield API requests go through a proxy", func() {
+			BeforeEach(func() {
+				proxied = true
+			})
+
+			It("connects to the Shield events stream through the proxy", func() {
+				Eventually(connectHosts).Should(Receive(Equal("shield.example.com:80")))
+				Eventually(collect).Should(ContainElement(PrometheusMetric(eventsReceivedTotalMetric.WithLabelValues(environment, backendName))))
+			})
+		})
+
+		Context("when the Shield events stream is unavailable", func() {
+			JustBeforeEach(func() {
+				server.CloseClientConnections()
+				server.Close()
+				eventsConnectedMetric.WithLabelValues(environment, backendName).Set(0)
+			})
+
+			It("returns a events_connected metric", func() {
+				Eventually(collect).Should(ContainElement(PrometheusMetric(eventsConnectedMetric.WithLabelValues(environment, backendName))))
+			})
+		})
+	})
+})
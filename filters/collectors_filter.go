@@ -7,7 +7,9 @@ import (
 )
 
 const (
+	AgentsCollector            = "Agents"
 	ArchivesCollector          = "Archives"
+	AuthTokensCollector        = "AuthTokens"
 	JobsCollector              = "Jobs"
 	RetentionPoliciesCollector = "RetentionPolicies"
 	SchedulesCollector         = "Schedules"
@@ -15,8 +17,34 @@ const (
 	StoresCollector            = "Stores"
 	TargetsCollector           = "Targets"
 	TasksCollector             = "Tasks"
+	TenantsCollector           = "Tenants"
+	UsersCollector             = "Users"
 )
 
+var Collectors = []string{
+	AgentsCollector,
+	ArchivesCollector,
+	AuthTokensCollector,
+	JobsCollector,
+	RetentionPoliciesCollector,
+	SchedulesCollector,
+	StatusCollector,
+	StoresCollector,
+	TargetsCollector,
+	TasksCollector,
+	TenantsCollector,
+	UsersCollector,
+}
+
+// optInCollectors are only enabled when explicitly filtered, as they rely
+// on the v2 API of Shield v8 cores.
+var optInCollectors = map[string]bool{
+	AgentsCollector:     true,
+	AuthTokensCollector: true,
+	TenantsCollector:    true,
+	UsersCollector:      true,
+}
+
 type CollectorsFilter struct {
 	collectorsEnabled map[string]bool
 }
@@ -26,8 +54,12 @@ func NewCollectorsFilter(filters []string) (*CollectorsFilter, error) {
 
 	for _, collectorName := range filters {
 		switch strings.Trim(collectorName, " ") {
+		case AgentsCollector:
+			collectorsEnabled[AgentsCollector] = true
 		case ArchivesCollector:
 			collectorsEnabled[ArchivesCollector] = true
+		case AuthTokensCollector:
+			collectorsEnabled[AuthTokensCollector] = true
 		case JobsCollector:
 			collectorsEnabled[JobsCollector] = true
 		case RetentionPoliciesCollector:
@@ -42,6 +74,10 @@ func NewCollectorsFilter(filters []string) (*CollectorsFilter, error) {
 			collectorsEnabled[TargetsCollector] = true
 		case TasksCollector:
 			collectorsEnabled[TasksCollector] = true
+		case TenantsCollector:
+			collectorsEnabled[TenantsCollector] = true
+		case UsersCollector:
+			collectorsEnabled[UsersCollector] = true
 		default:
 			return &CollectorsFilter{}, errors.New(fmt.Sprintf("Collector filter `%s` is not supported", collectorName))
 		}
@@ -52,7 +88,7 @@ func NewCollectorsFilter(filters []string) (*CollectorsFilter, error) {
 
 func (f *CollectorsFilter) Enabled(collectorName string) bool {
 	if len(f.collectorsEnabled) == 0 {
-		return true
+		return !optInCollectors[collectorName]
 	}
 
 	if f.collectorsEnabled[collectorName] {
@@ -23,7 +23,9 @@ var _ = Describe("CollectorsFilter", func() {
 		Context("when filters are supported", func() {
 			BeforeEach(func() {
 				filters = []string{
+					AgentsCollector,
 					ArchivesCollector,
+					AuthTokensCollector,
 					JobsCollector,
 					RetentionPoliciesCollector,
 					SchedulesCollector,
@@ -31,6 +33,8 @@ var _ = Describe("CollectorsFilter", func() {
 					StoresCollector,
 					TargetsCollector,
 					TasksCollector,
+					TenantsCollector,
+					UsersCollector,
 				}
 			})
 
@@ -64,13 +68,21 @@ var _ = Describe("CollectorsFilter", func() {
 	Describe("Enabled", func() {
 		Context("when collector is enabled", func() {
 			BeforeEach(func() {
-				filters = []string{ArchivesCollector, JobsCollector, RetentionPoliciesCollector, SchedulesCollector, StatusCollector, StoresCollector, TargetsCollector, TasksCollector}
+				filters = []string{AgentsCollector, ArchivesCollector, AuthTokensCollector, JobsCollector, RetentionPoliciesCollector, SchedulesCollector, StatusCollector, StoresCollector, TargetsCollector, TasksCollector, TenantsCollector, UsersCollector}
+			})
+
+			It("Agents collector returns true", func() {
+				Expect(collectorsFilter.Enabled(AgentsCollector)).To(BeTrue())
 			})
 
 			It("Archives collector returns true", func() {
 				Expect(collectorsFilter.Enabled(ArchivesCollector)).To(BeTrue())
 			})
 
+			It("Auth Tokens collector returns true", func() {
+				Expect(collectorsFilter.Enabled(AuthTokensCollector)).To(BeTrue())
+			})
+
 			It("Jobs collector returns true", func() {
 				Expect(collectorsFilter.Enabled(JobsCollector)).To(BeTrue())
 			})
@@ -98,6 +110,14 @@ var _ = Describe("CollectorsFilter", func() {
 			It("Tasks collector returns true", func() {
 				Expect(collectorsFilter.Enabled(TasksCollector)).To(BeTrue())
 			})
+
+			It("Tenants collector returns true", func() {
+				Expect(collectorsFilter.Enabled(TenantsCollector)).To(BeTrue())
+			})
+
+			It("Users collector returns true", func() {
+				Expect(collectorsFilter.Enabled(UsersCollector)).To(BeTrue())
+			})
 		})
 
 		Context("when collector is not enabled", func() {
@@ -105,6 +125,14 @@ var _ = Describe("CollectorsFilter", func() {
 				filters = []string{ArchivesCollector}
 			})
 
+			It("Agents collector returns false", func() {
+				Expect(collectorsFilter.Enabled(AgentsCollector)).To(BeFalse())
+			})
+
+			It("Auth Tokens collector returns false", func() {
+				Expect(collectorsFilter.Enabled(AuthTokensCollector)).To(BeFalse())
+			})
+
 			It("Jobs collector returns false", func() {
 				Expect(collectorsFilter.Enabled(JobsCollector)).To(BeFalse())
 			})
@@ -132,6 +160,14 @@ var _ = Describe("CollectorsFilter", func() {
 			It("Tasks collector returns false", func() {
 				Expect(collectorsFilter.Enabled(TasksCollector)).To(BeFalse())
 			})
+
+			It("Tenants collector returns false", func() {
+				Expect(collectorsFilter.Enabled(TenantsCollector)).To(BeFalse())
+			})
+
+			It("Users collector returns false", func() {
+				Expect(collectorsFilter.Enabled(UsersCollector)).To(BeFalse())
+			})
 		})
 
 		Context("when there are no filters", func() {
@@ -143,6 +179,14 @@ var _ = Describe("CollectorsFilter", func() {
 				Expect(collectorsFilter.Enabled(ArchivesCollector)).To(BeTrue())
 			})
 
+			It("Agents collector returns false", func() {
+				Expect(collectorsFilter.Enabled(AgentsCollector)).To(BeFalse())
+			})
+
+			It("Auth Tokens collector returns false", func() {
+				Expect(collectorsFilter.Enabled(AuthTokensCollector)).To(BeFalse())
+			})
+
 			It("Jobs collector returns true", func() {
 				Expect(collectorsFilter.Enabled(JobsCollector)).To(BeTrue())
 			})
@@ -170,6 +214,14 @@ var _ = Describe("CollectorsFilter", func() {
 			It("Tasks collector returns true", func() {
 				Expect(collectorsFilter.Enabled(TasksCollector)).To(BeTrue())
 			})
+
+			It("Tenants collector returns false", func() {
+				Expect(collectorsFilter.Enabled(TenantsCollector)).To(BeFalse())
+			})
+
+			It("Users collector returns false", func() {
+				Expect(collectorsFilter.Enabled(UsersCollector)).To(BeFalse())
+			})
 		})
 	})
 })
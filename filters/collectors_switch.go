@@ -0,0 +1,72 @@
+package filters
+
+import (
+	"fmt"
+	"sync"
+)
+
+// CollectorsSwitch holds the collectors enabled or disabled at runtime. They
+// override the CollectorsFilter configured at startup, for the exporter as a
+// whole and for every Shield backend alike.
+type CollectorsSwitch struct {
+	mu               sync.RWMutex
+	collectorsFilter *CollectorsFilter
+	overrides        map[string]bool
+}
+
+func NewCollectorsSwitch(collectorsFilter *CollectorsFilter) *CollectorsSwitch {
+	return &CollectorsSwitch{
+		collectorsFilter: collectorsFilter,
+		overrides:        make(map[string]bool),
+	}
+}
+
+// Enabled reports whether a collector is enabled by the CollectorsFilter
+// configured at startup, unless overridden at runtime.
+func (s *CollectorsSwitch) Enabled(collectorName string) bool {
+	return s.EnabledFor(collectorName, s.collectorsFilter)
+}
+
+// EnabledFor reports whether a collector is enabled by collectorsFilter,
+// ie the one of a single Shield backend, unless overridden at runtime.
+func (s *CollectorsSwitch) EnabledFor(collectorName string, collectorsFilter *CollectorsFilter) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if enabled, ok := s.overrides[collectorName]; ok {
+		return enabled
+	}
+
+	return collectorsFilter.Enabled(collectorName)
+}
+
+func (s *CollectorsSwitch) SetEnabled(collectorName string, enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	supported := false
+	for _, name := range Collectors {
+		if name == collectorName {
+			supported = true
+		}
+	}
+	if !supported {
+		return fmt.Errorf("Collector `%s` is not supported", collectorName)
+	}
+	s.overrides[collectorName] = enabled
+
+	return nil
+}
+
+// EnabledCollectors returns the names of the enabled collectors, in the order
+// of Collectors.
+func (s *CollectorsSwitch) EnabledCollectors() []string {
+	enabled := []string{}
+	for _, collectorName := range Collectors {
+		if s.Enabled(collectorName) {
+			enabled = append(enabled, collectorName)
+		}
+	}
+
+	return enabled
+}
@@ -0,0 +1,58 @@
+package filters_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/bosh-prometheus/shield_exporter/filters"
+)
+
+var _ = Describe("CollectorsSwitch", func() {
+	var collectorsSwitch *CollectorsSwitch
+
+	BeforeEach(func() {
+		collectorsFilter, err := NewCollectorsFilter([]string{TasksCollector, JobsCollector})
+		Expect(err).ToNot(HaveOccurred())
+		collectorsSwitch = NewCollectorsSwitch(collectorsFilter)
+	})
+
+	It("starts with the collectors enabled by the filter", func() {
+		Expect(collectorsSwitch.Enabled(TasksCollector)).To(BeTrue())
+		Expect(collectorsSwitch.Enabled(ArchivesCollector)).To(BeFalse())
+		Expect(collectorsSwitch.EnabledCollectors()).To(Equal([]string{JobsCollector, TasksCollector}))
+	})
+
+	It("enables and disables collectors", func() {
+		Expect(collectorsSwitch.SetEnabled(TasksCollector, false)).To(Succeed())
+		Expect(collectorsSwitch.SetEnabled(ArchivesCollector, true)).To(Succeed())
+		Expect(collectorsSwitch.EnabledCollectors()).To(Equal([]string{ArchivesCollector, JobsCollector}))
+	})
+
+	Describe("EnabledFor", func() {
+		var backendCollectorsFilter *CollectorsFilter
+
+		BeforeEach(func() {
+			var err error
+			backendCollectorsFilter, err = NewCollectorsFilter([]string{ArchivesCollector})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns the collectors enabled by the given filter", func() {
+			Expect(collectorsSwitch.EnabledFor(ArchivesCollector, backendCollectorsFilter)).To(BeTrue())
+			Expect(collectorsSwitch.EnabledFor(TasksCollector, backendCollectorsFilter)).To(BeFalse())
+		})
+
+		It("returns the collectors enabled or disabled at runtime", func() {
+			Expect(collectorsSwitch.SetEnabled(ArchivesCollector, false)).To(Succeed())
+			Expect(collectorsSwitch.SetEnabled(TasksCollector, true)).To(Succeed())
+			Expect(collectorsSwitch.EnabledFor(ArchivesCollector, backendCollectorsFilter)).To(BeFalse())
+			Expect(collectorsSwitch.EnabledFor(TasksCollector, backendCollectorsFilter)).To(BeTrue())
+		})
+	})
+
+	It("returns an error for an unknown collector", func() {
+		err := collectorsSwitch.SetEnabled("Unknown", true)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Collector `Unknown` is not supported"))
+	})
+})
@@ -0,0 +1,107 @@
+package ha
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+type Elector interface {
+	IsLeader() bool
+}
+
+type FileLockElector struct {
+	path          string
+	retryInterval time.Duration
+	leaderMetric  prometheus.Gauge
+
+	mu     sync.Mutex
+	file   *os.File
+	leader bool
+}
+
+func NewFileLockElector(path string, retryInterval time.Duration, namespace string) *FileLockElector {
+	leaderMetric := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "exporter",
+			Name:      "leader",
+			Help:      "Whether this Shield Exporter instance is the active one scraping Shield (1 for leader, 0 for standby).",
+		},
+	)
+
+	return &FileLockElector{
+		path:          path,
+		retryInterval: retryInterval,
+		leaderMetric:  leaderMetric,
+	}
+}
+
+// Start tries to acquire the lock file until it succeeds. The lock is held
+// for the lifetime of the process, so a standby instance only takes over
+// once the leader exits and the operating system releases its lock.
+func (e *FileLockElector) Start() {
+	if e.tryAcquire() {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(e.retryInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if e.tryAcquire() {
+				return
+			}
+		}
+	}()
+}
+
+func (e *FileLockElector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.leader
+}
+
+func (e *FileLockElector) Describe(ch chan<- *prometheus.Desc) {
+	e.leaderMetric.Describe(ch)
+}
+
+func (e *FileLockElector) Collect(ch chan<- prometheus.Metric) {
+	leader := float64(0)
+	if e.IsLeader() {
+		leader = 1
+	}
+	e.leaderMetric.Set(leader)
+	e.leaderMetric.Collect(ch)
+}
+
+func (e *FileLockElector) tryAcquire() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.leader {
+		return true
+	}
+
+	file, err := os.OpenFile(e.path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		log.Errorf("Error while opening lock file `%s`: %v", e.path, err)
+		return false
+	}
+
+	if err := lockFile(file); err != nil {
+		log.Debugf("Lock file `%s` is held by another instance: %v", e.path, err)
+		file.Close()
+		return false
+	}
+
+	log.Infof("Acquired lock file `%s`, this instance is now the leader", e.path)
+	e.file = file
+	e.leader = true
+
+	return true
+}
@@ -0,0 +1,90 @@
+package ha_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+
+	. "github.com/bosh-prometheus/shield_exporter/ha"
+	. "github.com/bosh-prometheus/shield_exporter/utils/test_matchers"
+)
+
+func init() {
+	log.Base().SetLevel("fatal")
+}
+
+var _ = Describe("FileLockElector", func() {
+	var (
+		err      error
+		lockDir  string
+		lockFile string
+
+		namespace = "test_namespace"
+
+		leaderMetric prometheus.Gauge
+
+		elector *FileLockElector
+	)
+
+	BeforeEach(func() {
+		lockDir, err = ioutil.TempDir("", "shield_exporter_ha")
+		Expect(err).ToNot(HaveOccurred())
+		lockFile = filepath.Join(lockDir, "lock")
+
+		leaderMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "exporter",
+				Name:      "leader",
+				Help:      "Whether this Shield Exporter instance is the active one scraping Shield (1 for leader, 0 for standby).",
+			},
+		)
+
+		elector = NewFileLockElector(lockFile, 10*time.Millisecond, namespace)
+		elector.Start()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(lockDir)
+	})
+
+	It("becomes the leader when nobody holds the lock", func() {
+		Expect(elector.IsLeader()).To(BeTrue())
+	})
+
+	It("returns a leader metric", func() {
+		metrics := make(chan prometheus.Metric)
+		go elector.Collect(metrics)
+
+		leaderMetric.Set(1)
+		Eventually(metrics).Should(Receive(PrometheusMetric(leaderMetric)))
+	})
+
+	Context("when another instance holds the lock", func() {
+		var standbyElector *FileLockElector
+
+		BeforeEach(func() {
+			standbyElector = NewFileLockElector(lockFile, 10*time.Millisecond, namespace)
+			standbyElector.Start()
+		})
+
+		It("stays on standby", func() {
+			Consistently(standbyElector.IsLeader, 100*time.Millisecond).Should(BeFalse())
+		})
+
+		It("returns a leader metric", func() {
+			metrics := make(chan prometheus.Metric)
+			go standbyElector.Collect(metrics)
+
+			leaderMetric.Set(0)
+			Eventually(metrics).Should(Receive(PrometheusMetric(leaderMetric)))
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package ha_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestHA(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "HA Suite")
+}
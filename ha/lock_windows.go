@@ -0,0 +1,13 @@
+//go:build windows
+// +build windows
+
+package ha
+
+import (
+	"errors"
+	"os"
+)
+
+func lockFile(file *os.File) error {
+	return errors.New("lock files are not supported on Windows")
+}
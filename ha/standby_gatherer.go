@@ -0,0 +1,111 @@
+package ha
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/log"
+)
+
+// StandbyGatherer only gathers the wrapped Gatherer on every request while its
+// instance is the leader. On a standby instance it serves the metrics it
+// gathers in the background at the refresh interval, or the ones gathered
+// the last time the instance was leader, flagged through the stale metric.
+type StandbyGatherer struct {
+	gatherer        prometheus.Gatherer
+	elector         Elector
+	refreshInterval time.Duration
+	staleMetric     prometheus.Gauge
+
+	mu     sync.Mutex
+	cached []*dto.MetricFamily
+}
+
+func NewStandbyGatherer(gatherer prometheus.Gatherer, elector Elector, namespace string, refreshInterval time.Duration) *StandbyGatherer {
+	staleMetric := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "exporter",
+			Name:      "stale_metrics",
+			Help:      "Whether the Shield metrics served are cached ones from a standby instance (1 for stale, 0 for fresh).",
+		},
+	)
+
+	return &StandbyGatherer{
+		gatherer:        gatherer,
+		elector:         elector,
+		refreshInterval: refreshInterval,
+		staleMetric:     staleMetric,
+	}
+}
+
+// Start gathers the wrapped Gatherer at the refresh interval while the
+// instance is on standby, so that a standby instance which was never leader
+// still serves metrics. A zero refresh interval disables it.
+func (g *StandbyGatherer) Start() {
+	if g.refreshInterval <= 0 {
+		return
+	}
+
+	go func() {
+		g.refresh()
+
+		ticker := time.NewTicker(g.refreshInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			g.refresh()
+		}
+	}()
+}
+
+func (g *StandbyGatherer) refresh() {
+	if g.elector.IsLeader() {
+		return
+	}
+
+	mfs, err := g.gatherer.Gather()
+	if err != nil {
+		log.Errorf("Error while gathering the metrics of the standby instance: %v", err)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.cached = mfs
+}
+
+// Gather only holds its lock to read or replace the cached metrics, so that
+// concurrent requests on the leader are not serialized behind its gathering.
+func (g *StandbyGatherer) Gather() ([]*dto.MetricFamily, error) {
+	if !g.elector.IsLeader() {
+		g.mu.Lock()
+		cached := g.cached
+		g.mu.Unlock()
+
+		g.staleMetric.Set(1)
+		return cached, nil
+	}
+
+	mfs, err := g.gatherer.Gather()
+
+	g.mu.Lock()
+	g.cached = mfs
+	g.mu.Unlock()
+
+	g.staleMetric.Set(0)
+
+	return mfs, err
+}
+
+func (g *StandbyGatherer) Describe(ch chan<- *prometheus.Desc) {
+	g.staleMetric.Describe(ch)
+}
+
+func (g *StandbyGatherer) Collect(ch chan<- prometheus.Metric) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.staleMetric.Collect(ch)
+}
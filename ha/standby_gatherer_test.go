@@ -0,0 +1,191 @@
+package ha_test
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	. "github.com/bosh-prometheus/shield_exporter/ha"
+	. "github.com/bosh-prometheus/shield_exporter/utils/test_matchers"
+)
+
+type fakeElector struct {
+	mu     sync.Mutex
+	leader bool
+}
+
+func (e *fakeElector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.leader
+}
+
+func (e *fakeElector) setLeader(leader bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.leader = leader
+}
+
+var _ = Describe("StandbyGatherer", func() {
+	var (
+		namespace = "test_namespace"
+
+		registry        *prometheus.Registry
+		fakeMetric      prometheus.Gauge
+		staleMetric     prometheus.Gauge
+		refreshInterval time.Duration
+		elector         *fakeElector
+		staleGatherer   *StandbyGatherer
+	)
+
+	BeforeEach(func() {
+		registry = prometheus.NewRegistry()
+		fakeMetric = prometheus.NewGauge(prometheus.GaugeOpts{Name: "fake_metric", Help: "Fake metric."})
+		registry.MustRegister(fakeMetric)
+
+		staleMetric = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "exporter",
+				Name:      "stale_metrics",
+				Help:      "Whether the Shield metrics served are cached ones from a standby instance (1 for stale, 0 for fresh).",
+			},
+		)
+
+		refreshInterval = 0
+		elector = &fakeElector{leader: true}
+	})
+
+	JustBeforeEach(func() {
+		staleGatherer = NewStandbyGatherer(registry, elector, namespace, refreshInterval)
+		staleGatherer.Start()
+	})
+
+	It("gathers fresh metrics when leader", func() {
+		fakeMetric.Set(1)
+		mfs, err := staleGatherer.Gather()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mfs).To(HaveLen(1))
+		Expect(mfs[0].GetMetric()[0].GetGauge().GetValue()).To(Equal(float64(1)))
+	})
+
+	It("does not serialize concurrent gatherings when leader", func() {
+		entered := make(chan struct{}, 2)
+		release := make(chan struct{})
+		blockingGatherer := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+			entered <- struct{}{}
+			<-release
+			return registry.Gather()
+		})
+		staleGatherer = NewStandbyGatherer(blockingGatherer, elector, namespace, refreshInterval)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				_, err := staleGatherer.Gather()
+				Expect(err).ToNot(HaveOccurred())
+			}()
+		}
+
+		Eventually(entered).Should(Receive())
+		Eventually(entered).Should(Receive())
+		close(release)
+		wg.Wait()
+	})
+
+	Context("when standby", func() {
+		JustBeforeEach(func() {
+			fakeMetric.Set(1)
+			_, err := staleGatherer.Gather()
+			Expect(err).ToNot(HaveOccurred())
+
+			elector.setLeader(false)
+			fakeMetric.Set(2)
+		})
+
+		It("serves the metrics gathered while leader", func() {
+			mfs, err := staleGatherer.Gather()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mfs).To(HaveLen(1))
+			Expect(mfs[0].GetMetric()[0].GetGauge().GetValue()).To(Equal(float64(1)))
+		})
+
+		It("flags the metrics as stale", func() {
+			_, err := staleGatherer.Gather()
+			Expect(err).ToNot(HaveOccurred())
+
+			metrics := make(chan prometheus.Metric)
+			go staleGatherer.Collect(metrics)
+
+			staleMetric.Set(1)
+			Eventually(metrics).Should(Receive(PrometheusMetric(staleMetric)))
+		})
+
+		Context("when the refresh interval is set", func() {
+			BeforeEach(func() {
+				refreshInterval = 10 * time.Millisecond
+			})
+
+			It("serves the metrics gathered in the background", func() {
+				gatheredValue := func() float64 {
+					mfs, err := staleGatherer.Gather()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(mfs).To(HaveLen(1))
+					return mfs[0].GetMetric()[0].GetGauge().GetValue()
+				}
+				Eventually(gatheredValue).Should(Equal(float64(2)))
+			})
+		})
+	})
+
+	Context("when standby since the start", func() {
+		BeforeEach(func() {
+			elector.setLeader(false)
+			fakeMetric.Set(1)
+		})
+
+		It("does not serve any metrics", func() {
+			mfs, err := staleGatherer.Gather()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mfs).To(BeEmpty())
+		})
+
+		Context("when the refresh interval is set", func() {
+			BeforeEach(func() {
+				refreshInterval = time.Hour
+			})
+
+			It("serves the metrics gathered in the background", func() {
+				Eventually(func() []*dto.MetricFamily {
+					mfs, _ := staleGatherer.Gather()
+					return mfs
+				}).Should(HaveLen(1))
+
+				mfs, err := staleGatherer.Gather()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(mfs[0].GetMetric()[0].GetGauge().GetValue()).To(Equal(float64(1)))
+			})
+
+			It("flags the metrics as stale", func() {
+				_, err := staleGatherer.Gather()
+				Expect(err).ToNot(HaveOccurred())
+
+				metrics := make(chan prometheus.Metric)
+				go staleGatherer.Collect(metrics)
+
+				staleMetric.Set(1)
+				Eventually(metrics).Should(Receive(PrometheusMetric(staleMetric)))
+			})
+		})
+	})
+})
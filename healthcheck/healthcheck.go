@@ -0,0 +1,39 @@
+package healthcheck
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Path is the path of the health endpoint, relative to the route prefix.
+const Path = "/-/healthy"
+
+// Handler answers health checks as long as the exporter serves requests.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "Healthy")
+	})
+}
+
+// Check queries the health endpoint of the exporter reachable at url and
+// returns an error unless it is healthy.
+func Check(url string, timeout time.Duration) error {
+	httpClient := &http.Client{Timeout: timeout}
+
+	res, err := httpClient.Get(strings.TrimSuffix(url, "/") + Path)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unhealthy exporter, health endpoint returned %s", res.Status)
+	}
+
+	return nil
+}
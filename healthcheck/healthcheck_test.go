@@ -0,0 +1,76 @@
+package healthcheck_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/bosh-prometheus/shield_exporter/healthcheck"
+)
+
+var _ = Describe("Healthcheck", func() {
+	Describe("Handler", func() {
+		It("answers that the exporter is healthy", func() {
+			recorder := httptest.NewRecorder()
+			Handler().ServeHTTP(recorder, httptest.NewRequest("GET", Path, nil))
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Body.String()).To(Equal("Healthy\n"))
+		})
+	})
+
+	Describe("Check", func() {
+		var (
+			server  *httptest.Server
+			handler http.Handler
+			timeout time.Duration
+			err     error
+		)
+
+		BeforeEach(func() {
+			timeout = time.Second
+			mux := http.NewServeMux()
+			mux.Handle(Path, Handler())
+			handler = mux
+		})
+
+		JustBeforeEach(func() {
+			server = httptest.NewServer(handler)
+			err = Check(server.URL+"/", timeout)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("does not return an error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("when the health endpoint does not answer OK", func() {
+			BeforeEach(func() {
+				handler = http.NotFoundHandler()
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Unhealthy exporter, health endpoint returned 404 Not Found"))
+			})
+		})
+
+		Context("when the health endpoint is too slow to answer", func() {
+			BeforeEach(func() {
+				timeout = 50 * time.Millisecond
+				handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					time.Sleep(200 * time.Millisecond)
+				})
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
@@ -0,0 +1,58 @@
+package htpasswd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// File holds the users of an htpasswd file. Only bcrypt hashes (`htpasswd -B`)
+// are supported, so no plaintext or weakly hashed password is accepted.
+type File struct {
+	users map[string][]byte
+}
+
+func Load(path string) (*File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	users := map[string][]byte{}
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid htpasswd entry at line %d of `%s`", lineNumber, path)
+		}
+		if _, err := bcrypt.Cost([]byte(parts[1])); err != nil {
+			return nil, fmt.Errorf("Unsupported password hash for user `%s` in `%s`, only bcrypt is supported", parts[0], path)
+		}
+		users[parts[0]] = []byte(parts[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &File{users: users}, nil
+}
+
+func (f *File) Authenticate(username string, password string) bool {
+	hash, ok := f.users[username]
+	if !ok {
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil
+}
@@ -0,0 +1,93 @@
+package htpasswd_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/crypto/bcrypt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/bosh-prometheus/shield_exporter/htpasswd"
+)
+
+var _ = Describe("File", func() {
+	var (
+		err      error
+		path     string
+		content  string
+		htpasswd *File
+	)
+
+	hash := func(password string) string {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		Expect(err).ToNot(HaveOccurred())
+		return string(hashed)
+	}
+
+	BeforeEach(func() {
+		content = "# Prometheus servers\n" +
+			"prometheus:" + hash("fake_password") + "\n" +
+			"\n" +
+			"other:" + hash("other_password") + "\n"
+	})
+
+	JustBeforeEach(func() {
+		file, tempErr := ioutil.TempFile("", "shield_exporter_htpasswd")
+		Expect(tempErr).ToNot(HaveOccurred())
+		_, tempErr = file.WriteString(content)
+		Expect(tempErr).ToNot(HaveOccurred())
+		file.Close()
+		path = file.Name()
+
+		htpasswd, err = Load(path)
+	})
+
+	AfterEach(func() {
+		os.Remove(path)
+	})
+
+	Describe("Load", func() {
+		It("does not return an error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("when a password is not hashed with bcrypt", func() {
+			BeforeEach(func() {
+				content = "prometheus:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n"
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Unsupported password hash for user `prometheus`"))
+			})
+		})
+
+		Context("when an entry is invalid", func() {
+			BeforeEach(func() {
+				content = "prometheus\n"
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Invalid htpasswd entry at line 1"))
+			})
+		})
+	})
+
+	Describe("Authenticate", func() {
+		It("accepts every user with its password", func() {
+			Expect(htpasswd.Authenticate("prometheus", "fake_password")).To(BeTrue())
+			Expect(htpasswd.Authenticate("other", "other_password")).To(BeTrue())
+		})
+
+		It("rejects a wrong password", func() {
+			Expect(htpasswd.Authenticate("prometheus", "other_password")).To(BeFalse())
+		})
+
+		It("rejects an unknown user", func() {
+			Expect(htpasswd.Authenticate("unknown", "fake_password")).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package landing_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLanding(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Landing Suite")
+}
@@ -0,0 +1,170 @@
+package landing
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/prometheus/common/log"
+	"github.com/prometheus/common/version"
+)
+
+type Backend struct {
+	URL  string
+	Name string
+}
+
+type Collector struct {
+	Name string
+	// Subsystem is the snake cased collector name used in its metric names.
+	Subsystem string
+	// MetricsPath is the link to the collector own metrics endpoint, if any.
+	MetricsPath string
+}
+
+type Config struct {
+	Namespace   string
+	MetricsPath string
+	Collectors  []Collector
+	Backends    func() []Backend
+	// CollectorEnabled reports whether a collector is enabled at runtime,
+	// all collectors are considered enabled when nil.
+	CollectorEnabled func(collectorName string) bool
+}
+
+// Page renders the exporter status: build information, Shield backends,
+// enabled collectors with the outcome of their last scrape, and links to the
+// metrics endpoints.
+type Page struct {
+	config   Config
+	gatherer *RecordingGatherer
+}
+
+type collectorStatus struct {
+	Collector
+	Disabled       bool
+	BackendName    string
+	Scraped        bool
+	Error          bool
+	ScrapeDuration string
+}
+
+type pageData struct {
+	Version     map[string]string
+	MetricsPath string
+	Backends    []Backend
+	Collectors  []Collector
+	Statuses    []collectorStatus
+	LastScrape  string
+}
+
+func NewPage(config Config, gatherer *RecordingGatherer) *Page {
+	return &Page{config: config, gatherer: gatherer}
+}
+
+func (p *Page) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data := pageData{
+		Version: map[string]string{
+			"Version":    version.Version,
+			"Revision":   version.Revision,
+			"Branch":     version.Branch,
+			"Build user": version.BuildUser,
+			"Build date": version.BuildDate,
+			"Go version": version.GoVersion,
+		},
+		MetricsPath: p.config.MetricsPath,
+		Backends:    p.config.Backends(),
+		Collectors:  p.config.Collectors,
+		Statuses:    p.collectorStatuses(),
+		LastScrape:  "never",
+	}
+
+	if _, gatheredAt := p.gatherer.LastGathered(); !gatheredAt.IsZero() {
+		data.LastScrape = gatheredAt.UTC().Format(time.RFC3339)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pageTemplate.Execute(w, data); err != nil {
+		log.Errorf("Error while rendering landing page: %v", err)
+	}
+}
+
+// collectorStatuses extracts the last scrape error and duration of every
+// collector, per backend name, from the last gathered metrics.
+func (p *Page) collectorStatuses() []collectorStatus {
+	values := map[string]map[string]float64{}
+
+	mfs, _ := p.gatherer.LastGathered()
+	for _, mf := range mfs {
+		values[mf.GetName()] = map[string]float64{}
+		for _, metric := range mf.GetMetric() {
+			backendName := ""
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "backend_name" {
+					backendName = label.GetValue()
+				}
+			}
+			values[mf.GetName()][backendName] = metric.GetGauge().GetValue()
+		}
+	}
+
+	statuses := []collectorStatus{}
+	for _, collector := range p.config.Collectors {
+		errorName := fmt.Sprintf("%s_last_%s_scrape_error", p.config.Namespace, collector.Subsystem)
+		durationName := fmt.Sprintf("%s_last_%s_scrape_duration_seconds", p.config.Namespace, collector.Subsystem)
+
+		if p.config.CollectorEnabled != nil && !p.config.CollectorEnabled(collector.Name) {
+			statuses = append(statuses, collectorStatus{Collector: collector, Disabled: true})
+			continue
+		}
+
+		if len(values[errorName]) == 0 {
+			statuses = append(statuses, collectorStatus{Collector: collector})
+			continue
+		}
+
+		backendNames := make([]string, 0, len(values[errorName]))
+		for backendName := range values[errorName] {
+			backendNames = append(backendNames, backendName)
+		}
+		sort.Strings(backendNames)
+
+		for _, backendName := range backendNames {
+			statuses = append(statuses, collectorStatus{
+				Collector:      collector,
+				BackendName:    backendName,
+				Scraped:        true,
+				Error:          values[errorName][backendName] == 1,
+				ScrapeDuration: fmt.Sprintf("%.3fs", values[durationName][backendName]),
+			})
+		}
+	}
+
+	return statuses
+}
+
+var pageTemplate = template.Must(template.New("landing").Parse(`<html>
+<head><title>Shield Exporter</title></head>
+<body>
+<h1>Shield Exporter</h1>
+<p><a href='{{ .MetricsPath }}'>Metrics</a></p>
+<h2>Build</h2>
+<table>
+{{ range $name, $value := .Version }}<tr><th align='left'>{{ $name }}</th><td>{{ $value }}</td></tr>
+{{ end }}</table>
+<h2>Shield backends</h2>
+<table>
+<tr><th align='left'>URL</th><th align='left'>Name</th></tr>
+{{ range .Backends }}<tr><td>{{ .URL }}</td><td>{{ if .Name }}{{ .Name }}{{ else }}<i>unresolved</i>{{ end }}</td></tr>
+{{ end }}</table>
+<h2>Collectors</h2>
+<p>Last scrape: {{ .LastScrape }}</p>
+<table>
+<tr><th align='left'>Collector</th><th align='left'>Backend</th><th align='left'>Last scrape</th><th align='left'>Duration</th><th align='left'>Endpoint</th></tr>
+{{ range .Statuses }}<tr><td>{{ .Name }}</td><td>{{ .BackendName }}</td><td>{{ if .Disabled }}<i>disabled</i>{{ else if not .Scraped }}<i>not scraped yet</i>{{ else if .Error }}<b>error</b>{{ else }}ok{{ end }}</td><td>{{ .ScrapeDuration }}</td><td>{{ if .MetricsPath }}<a href='{{ .MetricsPath }}'>{{ .MetricsPath }}</a>{{ end }}</td></tr>
+{{ end }}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,100 @@
+package landing_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/bosh-prometheus/shield_exporter/landing"
+)
+
+var _ = Describe("Page", func() {
+	var (
+		registry *prometheus.Registry
+		gatherer *RecordingGatherer
+		page     *Page
+		body     string
+	)
+
+	BeforeEach(func() {
+		registry = prometheus.NewRegistry()
+		scrapeError := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "shield",
+			Name:      "last_tasks_scrape_error",
+			Help:      "Fake scrape error.",
+		}, []string{"backend_name"})
+		scrapeError.WithLabelValues("fake_backend").Set(1)
+		scrapeDuration := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "shield",
+			Name:      "last_tasks_scrape_duration_seconds",
+			Help:      "Fake scrape duration.",
+		}, []string{"backend_name"})
+		scrapeDuration.WithLabelValues("fake_backend").Set(0.25)
+		registry.MustRegister(scrapeError, scrapeDuration)
+
+		gatherer = NewRecordingGatherer(registry)
+		page = NewPage(Config{
+			Namespace:   "shield",
+			MetricsPath: "/metrics",
+			Collectors: []Collector{
+				{Name: "Tasks", Subsystem: "tasks", MetricsPath: "/metrics/tasks"},
+				{Name: "RetentionPolicies", Subsystem: "retention_policies"},
+			},
+			Backends: func() []Backend {
+				return []Backend{{URL: "https://shield.example.com", Name: "fake_backend"}}
+			},
+		}, gatherer)
+	})
+
+	JustBeforeEach(func() {
+		recorder := httptest.NewRecorder()
+		page.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		body = recorder.Body.String()
+	})
+
+	It("shows the backends and the metrics links", func() {
+		Expect(body).To(ContainSubstring("<td>https://shield.example.com</td><td>fake_backend</td>"))
+		Expect(body).To(ContainSubstring("<a href='/metrics'>Metrics</a>"))
+		Expect(body).To(ContainSubstring("Last scrape: never"))
+	})
+
+	It("shows collectors as not scraped yet", func() {
+		Expect(body).To(ContainSubstring("<td>Tasks</td><td></td><td><i>not scraped yet</i></td>"))
+	})
+
+	Context("after a scrape", func() {
+		BeforeEach(func() {
+			_, err := gatherer.Gather()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("shows the last scrape outcome of each collector", func() {
+			Expect(body).To(ContainSubstring("<td>Tasks</td><td>fake_backend</td><td><b>error</b></td><td>0.250s</td><td><a href='/metrics/tasks'>/metrics/tasks</a></td>"))
+			Expect(body).To(ContainSubstring("<td>RetentionPolicies</td><td></td><td><i>not scraped yet</i></td>"))
+			Expect(body).ToNot(ContainSubstring("Last scrape: never"))
+		})
+	})
+
+	Context("when a collector is disabled at runtime", func() {
+		BeforeEach(func() {
+			page = NewPage(Config{
+				Namespace:   "shield",
+				MetricsPath: "/metrics",
+				Collectors:  []Collector{{Name: "Tasks", Subsystem: "tasks"}},
+				Backends:    func() []Backend { return []Backend{} },
+				CollectorEnabled: func(collectorName string) bool {
+					return collectorName != "Tasks"
+				},
+			}, gatherer)
+		})
+
+		It("shows the collector as disabled", func() {
+			Expect(body).To(ContainSubstring("<td>Tasks</td><td></td><td><i>disabled</i></td>"))
+		})
+	})
+})
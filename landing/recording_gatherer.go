@@ -0,0 +1,43 @@
+package landing
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// RecordingGatherer keeps the metrics of the last gathering, so the landing
+// page can report on the last scrape without scraping Shield itself.
+type RecordingGatherer struct {
+	gatherer prometheus.Gatherer
+
+	mu         sync.Mutex
+	last       []*dto.MetricFamily
+	gatheredAt time.Time
+}
+
+func NewRecordingGatherer(gatherer prometheus.Gatherer) *RecordingGatherer {
+	return &RecordingGatherer{gatherer: gatherer}
+}
+
+func (g *RecordingGatherer) Gather() ([]*dto.MetricFamily, error) {
+	mfs, err := g.gatherer.Gather()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.last = mfs
+	g.gatheredAt = time.Now()
+
+	return mfs, err
+}
+
+// LastGathered returns the metrics of the last gathering and when it happened,
+// with a zero time when nothing has been gathered yet.
+func (g *RecordingGatherer) LastGathered() ([]*dto.MetricFamily, time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.last, g.gatheredAt
+}
@@ -0,0 +1,52 @@
+package redact
+
+import (
+	"regexp"
+)
+
+// Secret replaces the credentials found in a string.
+const Secret = "<secret>"
+
+var (
+	userinfoRegexp      = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/?#@\s"'` + "`" + `]+@`)
+	authorizationRegexp = regexp.MustCompile(`(?i)\b(Basic|Bearer|Token)(\s+)[A-Za-z0-9._~+/=-]+`)
+	queryRegexp         = regexp.MustCompile(`(?i)([?&](?:access_token|api_key|apikey|password|secret|token)=)[^&#\s"'` + "`" + `]+`)
+)
+
+// String returns s with the URL userinfo, authorization credentials and
+// secret query parameters it contains replaced by Secret.
+func String(s string) string {
+	s = userinfoRegexp.ReplaceAllString(s, "${1}"+Secret+"@")
+	s = authorizationRegexp.ReplaceAllString(s, "${1}${2}"+Secret)
+	s = queryRegexp.ReplaceAllString(s, "${1}"+Secret)
+
+	return s
+}
+
+type redactedError struct {
+	err     error
+	message string
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// Error returns err unchanged when its message does not contain credentials,
+// or an error wrapping err with a redacted message otherwise.
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	message := String(err.Error())
+	if message == err.Error() {
+		return err
+	}
+
+	return &redactedError{err: err, message: message}
+}
@@ -0,0 +1,147 @@
+package rollup
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// failedJobStatus is the value of the job status metric for failed jobs.
+const failedJobStatus = 4
+
+type rollup struct {
+	source     string
+	name       string
+	help       string
+	dropLabels []string
+	filter     func(value float64) bool
+}
+
+var rollups = []rollup{
+	{source: "archives_total", name: "rollup_archives_total", help: "Labeled total number of Shield Archives across all backends"},
+	{source: "jobs_total", name: "rollup_jobs_total", help: "Labeled total number of Shield Jobs across all backends"},
+	{source: "jobs_paused_total", name: "rollup_jobs_paused_total", help: "Total number of paused Shield Jobs across all backends"},
+	{
+		source:     "job_status",
+		name:       "rollup_jobs_failed_total",
+		help:       "Total number of failed Shield Jobs across all backends",
+		dropLabels: []string{"job_name", "job_uuid", "target_name", "store_name"},
+		filter:     func(value float64) bool { return value == failedJobStatus },
+	},
+	{source: "retention_policies_total", name: "rollup_retention_policies_total", help: "Total number of Shield Retention Policies across all backends"},
+	{source: "schedules_total", name: "rollup_schedules_total", help: "Total number of Shield Schedules across all backends"},
+	{source: "status_pending_tasks_total", name: "rollup_status_pending_tasks_total", help: "Total number of Shield pending Tasks across all backends"},
+	{source: "status_running_tasks_total", name: "rollup_status_running_tasks_total", help: "Total number of Shield running Tasks across all backends"},
+	{source: "stores_total", name: "rollup_stores_total", help: "Labeled total number of Shield Stores across all backends"},
+	{source: "targets_total", name: "rollup_targets_total", help: "Labeled total number of Shield Targets across all backends"},
+	{source: "tasks_total", name: "rollup_tasks_total", help: "Labeled total number of Shield Tasks across all backends"},
+}
+
+// RollupGatherer adds to the metrics gathered by the wrapped Gatherer their
+// sum across all Shield backends, without the `backend_name` label. Metrics
+// are rolled up within each of the namespaces, as Shield backends may
+// override the metrics namespace in their configuration stanza.
+type RollupGatherer struct {
+	gatherer   prometheus.Gatherer
+	namespaces []string
+}
+
+func NewRollupGatherer(gatherer prometheus.Gatherer, namespaces []string) *RollupGatherer {
+	return &RollupGatherer{
+		gatherer:   gatherer,
+		namespaces: namespaces,
+	}
+}
+
+func (g *RollupGatherer) Gather() ([]*dto.MetricFamily, error) {
+	mfs, err := g.gatherer.Gather()
+
+	sources := map[string]*dto.MetricFamily{}
+	for _, mf := range mfs {
+		sources[mf.GetName()] = mf
+	}
+
+	rolledUpMfs := append([]*dto.MetricFamily{}, mfs...)
+	for _, namespace := range g.namespaces {
+		for _, r := range rollups {
+			source, ok := sources[namespace+"_"+r.source]
+			if !ok {
+				continue
+			}
+			rolledUpMfs = append(rolledUpMfs, g.rollUp(namespace, r, source))
+		}
+	}
+	sort.Slice(rolledUpMfs, func(i, j int) bool { return rolledUpMfs[i].GetName() < rolledUpMfs[j].GetName() })
+
+	return rolledUpMfs, err
+}
+
+func (g *RollupGatherer) rollUp(namespace string, r rollup, source *dto.MetricFamily) *dto.MetricFamily {
+	dropLabels := append([]string{"backend_name"}, r.dropLabels...)
+
+	keys := []string{}
+	metrics := map[string]*dto.Metric{}
+	for _, metric := range source.GetMetric() {
+		labels := []*dto.LabelPair{}
+		labelValues := []string{}
+		for _, label := range metric.GetLabel() {
+			if contains(dropLabels, label.GetName()) {
+				continue
+			}
+			labels = append(labels, label)
+			labelValues = append(labelValues, label.GetName()+"="+label.GetValue())
+		}
+
+		key := strings.Join(labelValues, ",")
+		rolledUp, ok := metrics[key]
+		if !ok {
+			rolledUp = &dto.Metric{Label: labels, Gauge: &dto.Gauge{Value: proto.Float64(0)}}
+			metrics[key] = rolledUp
+			keys = append(keys, key)
+		}
+
+		value := metricValue(metric)
+		if r.filter != nil {
+			if !r.filter(value) {
+				continue
+			}
+			value = 1
+		}
+		rolledUp.Gauge.Value = proto.Float64(rolledUp.Gauge.GetValue() + value)
+	}
+
+	mf := &dto.MetricFamily{
+		Name: proto.String(namespace + "_" + r.name),
+		Help: proto.String(r.help),
+		Type: dto.MetricType_GAUGE.Enum(),
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		mf.Metric = append(mf.Metric, metrics[key])
+	}
+
+	return mf
+}
+
+func metricValue(metric *dto.Metric) float64 {
+	switch {
+	case metric.Gauge != nil:
+		return metric.Gauge.GetValue()
+	case metric.Counter != nil:
+		return metric.Counter.GetValue()
+	default:
+		return metric.Untyped.GetValue()
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
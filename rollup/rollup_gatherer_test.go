@@ -0,0 +1,118 @@
+package rollup_test
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/bosh-prometheus/shield_exporter/rollup"
+)
+
+var _ = Describe("RollupGatherer", func() {
+	var (
+		registry *prometheus.Registry
+		gatherer *RollupGatherer
+	)
+
+	BeforeEach(func() {
+		registry = prometheus.NewRegistry()
+
+		jobsTotal := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "test_jobs_total",
+			Help: "Fake jobs total",
+		}, []string{"environment", "backend_name", "job_paused"})
+		jobsTotal.WithLabelValues("prod", "shield-1", "false").Set(3)
+		jobsTotal.WithLabelValues("prod", "shield-2", "false").Set(2)
+		jobsTotal.WithLabelValues("prod", "shield-2", "true").Set(1)
+		jobsTotal.WithLabelValues("staging", "shield-3", "false").Set(4)
+		registry.MustRegister(jobsTotal)
+
+		jobStatus := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "test_job_status",
+			Help: "Fake job status",
+		}, []string{"environment", "backend_name", "job_name"})
+		jobStatus.WithLabelValues("prod", "shield-1", "job-1").Set(4)
+		jobStatus.WithLabelValues("prod", "shield-2", "job-2").Set(4)
+		jobStatus.WithLabelValues("prod", "shield-2", "job-3").Set(5)
+		jobStatus.WithLabelValues("staging", "shield-3", "job-4").Set(5)
+		registry.MustRegister(jobStatus)
+
+		gatherer = NewRollupGatherer(registry, []string{"test", "test_staging"})
+	})
+
+	rolledUp := func(name string) map[string]float64 {
+		mfs, err := gatherer.Gather()
+		Expect(err).ToNot(HaveOccurred())
+
+		values := map[string]float64{}
+		for _, mf := range mfs {
+			if mf.GetName() != name {
+				continue
+			}
+			Expect(mf.GetType()).To(Equal(dto.MetricType_GAUGE))
+			for _, metric := range mf.GetMetric() {
+				key := ""
+				for _, label := range metric.GetLabel() {
+					key += label.GetName() + "=" + label.GetValue() + ","
+				}
+				values[key] = metric.GetGauge().GetValue()
+			}
+		}
+		return values
+	}
+
+	It("keeps the gathered metrics", func() {
+		mfs, err := gatherer.Gather()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mfs).To(HaveLen(4))
+		Expect(mfs[0].GetName()).To(Equal("test_job_status"))
+		Expect(mfs[1].GetName()).To(Equal("test_jobs_total"))
+	})
+
+	It("sums the metrics across backends", func() {
+		Expect(rolledUp("test_rollup_jobs_total")).To(Equal(map[string]float64{
+			"environment=prod,job_paused=false,":    5,
+			"environment=prod,job_paused=true,":     1,
+			"environment=staging,job_paused=false,": 4,
+		}))
+	})
+
+	It("counts the failed jobs across backends", func() {
+		Expect(rolledUp("test_rollup_jobs_failed_total")).To(Equal(map[string]float64{
+			"environment=prod,":    2,
+			"environment=staging,": 0,
+		}))
+	})
+
+	It("does not roll up metrics not gathered", func() {
+		Expect(rolledUp("test_rollup_archives_total")).To(BeEmpty())
+	})
+
+	Context("when a backend overrides the metrics namespace", func() {
+		BeforeEach(func() {
+			jobsTotal := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name: "test_staging_jobs_total",
+				Help: "Fake jobs total",
+			}, []string{"environment", "backend_name", "job_paused"})
+			jobsTotal.WithLabelValues("staging", "shield-4", "false").Set(2)
+			jobsTotal.WithLabelValues("staging", "shield-5", "false").Set(6)
+			registry.MustRegister(jobsTotal)
+		})
+
+		It("sums the metrics across backends within that namespace", func() {
+			Expect(rolledUp("test_staging_rollup_jobs_total")).To(Equal(map[string]float64{
+				"environment=staging,job_paused=false,": 8,
+			}))
+		})
+
+		It("does not sum them with the metrics of the other namespaces", func() {
+			Expect(rolledUp("test_rollup_jobs_total")).To(Equal(map[string]float64{
+				"environment=prod,job_paused=false,":    5,
+				"environment=prod,job_paused=true,":     1,
+				"environment=staging,job_paused=false,": 4,
+			}))
+		})
+	})
+})
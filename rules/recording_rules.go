@@ -0,0 +1,72 @@
+package rules
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+type RuleGroups struct {
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+type RuleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+type Rule struct {
+	Record string `yaml:"record"`
+	Expr   string `yaml:"expr"`
+}
+
+// aggregationLevels are the label sets the recording rules aggregate by,
+// following the `level:metric:operations` naming convention.
+var aggregationLevels = []struct {
+	name   string
+	labels string
+}{
+	{name: "environment", labels: "environment"},
+	{name: "environment_backend_name", labels: "environment, backend_name"},
+}
+
+// RecordingRules returns the recording rules aggregating the metrics
+// exported under namespace.
+func RecordingRules(namespace string) RuleGroups {
+	rules := []Rule{}
+	for _, level := range aggregationLevels {
+		rules = append(rules,
+			Rule{
+				Record: fmt.Sprintf("%s:%s_jobs_failed:sum", level.name, namespace),
+				Expr:   fmt.Sprintf("sum by (%s) (%s_job_status == bool 4)", level.labels, namespace),
+			},
+			Rule{
+				Record: fmt.Sprintf("%s:%s_jobs_success:ratio", level.name, namespace),
+				Expr: fmt.Sprintf(
+					"sum by (%[1]s) (%[2]s_job_status == bool 5) / sum by (%[1]s) ((%[2]s_job_status == bool 4) + (%[2]s_job_status == bool 5))",
+					level.labels,
+					namespace,
+				),
+			},
+			Rule{
+				Record: fmt.Sprintf("%s:%s_archives_total:sum", level.name, namespace),
+				Expr:   fmt.Sprintf("sum by (%s, archive_status) (%s_archives_total)", level.labels, namespace),
+			},
+			Rule{
+				Record: fmt.Sprintf("%s:%s_archives_total:delta1h", level.name, namespace),
+				Expr:   fmt.Sprintf("sum by (%s, archive_status) (delta(%s_archives_total[1h]))", level.labels, namespace),
+			},
+		)
+	}
+
+	return RuleGroups{
+		Groups: []RuleGroup{
+			{Name: namespace + "_exporter.rules", Rules: rules},
+		},
+	}
+}
+
+// YAML returns the recording rules in the Prometheus rules file format.
+func (g RuleGroups) YAML() ([]byte, error) {
+	return yaml.Marshal(g)
+}
@@ -0,0 +1,43 @@
+package rules_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/bosh-prometheus/shield_exporter/rules"
+)
+
+var _ = Describe("RecordingRules", func() {
+	var ruleGroups RuleGroups
+
+	BeforeEach(func() {
+		ruleGroups = RecordingRules("test_namespace")
+	})
+
+	It("returns a single rule group named after the namespace", func() {
+		Expect(ruleGroups.Groups).To(HaveLen(1))
+		Expect(ruleGroups.Groups[0].Name).To(Equal("test_namespace_exporter.rules"))
+	})
+
+	It("aggregates the job success ratio per environment", func() {
+		Expect(ruleGroups.Groups[0].Rules).To(ContainElement(Rule{
+			Record: "environment:test_namespace_jobs_success:ratio",
+			Expr:   "sum by (environment) (test_namespace_job_status == bool 5) / sum by (environment) ((test_namespace_job_status == bool 4) + (test_namespace_job_status == bool 5))",
+		}))
+	})
+
+	It("aggregates the archive growth per environment and backend", func() {
+		Expect(ruleGroups.Groups[0].Rules).To(ContainElement(Rule{
+			Record: "environment_backend_name:test_namespace_archives_total:delta1h",
+			Expr:   "sum by (environment, backend_name, archive_status) (delta(test_namespace_archives_total[1h]))",
+		}))
+	})
+
+	Describe("YAML", func() {
+		It("returns the rules in the Prometheus rules file format", func() {
+			content, err := ruleGroups.YAML()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(HavePrefix("groups:\n- name: test_namespace_exporter.rules\n  rules:\n  - record: environment:test_namespace_jobs_failed:sum\n    expr: sum by (environment) (test_namespace_job_status == bool 4)\n"))
+		})
+	})
+})
@@ -0,0 +1,76 @@
+package runtimeconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/common/log"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"gopkg.in/yaml.v2"
+
+	"github.com/bosh-prometheus/shield_exporter/redact"
+)
+
+// secretSuffixes are the flag name suffixes whose values are never exposed.
+var secretSuffixes = []string{".password", ".token", ".secret_id", ".client_secret", "webhook.url", "webhook.jobs.url"}
+
+// Flags returns the effective value of every flag of app, once parsed from
+// the command line and the environment, with secrets redacted.
+func Flags(app *kingpin.Application) map[string]string {
+	flags := map[string]string{}
+	for _, flag := range app.Model().Flags {
+		if flag.Hidden || flag.Name == "help" || flag.Name == "version" {
+			continue
+		}
+
+		value := redact.String(flag.String())
+		if value != "" && isSecret(flag.Name) {
+			value = redact.Secret
+		}
+		flags[flag.Name] = value
+	}
+
+	return flags
+}
+
+func isSecret(flagName string) bool {
+	for _, suffix := range secretSuffixes {
+		if strings.HasSuffix(flagName, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Handler serves the effective configuration as JSON, or as YAML when
+// requested with `?format=yaml`.
+func Handler(app *kingpin.Application) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flags := Flags(app)
+
+		content := &bytes.Buffer{}
+		var err error
+		if r.URL.Query().Get("format") == "yaml" {
+			w.Header().Set("Content-Type", "application/x-yaml")
+			var yamlContent []byte
+			yamlContent, err = yaml.Marshal(flags)
+			content.Write(yamlContent)
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+			encoder := json.NewEncoder(content)
+			encoder.SetEscapeHTML(false)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(flags)
+		}
+		if err != nil {
+			log.Errorf("Error while encoding configuration: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Write(content.Bytes())
+	})
+}
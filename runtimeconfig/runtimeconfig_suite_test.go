@@ -0,0 +1,13 @@
+package runtimeconfig_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestRuntimeconfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Runtimeconfig Suite")
+}
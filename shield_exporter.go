@@ -1,35 +1,230 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"expvar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/pprof"
+	"net/url"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/log"
 	"github.com/prometheus/common/version"
 	"github.com/starkandwayne/shield/api"
 	"gopkg.in/alecthomas/kingpin.v2"
 
+	"github.com/bosh-prometheus/shield_exporter/audit"
+	"github.com/bosh-prometheus/shield_exporter/backend"
+	"github.com/bosh-prometheus/shield_exporter/bosh"
+	"github.com/bosh-prometheus/shield_exporter/cache"
+	"github.com/bosh-prometheus/shield_exporter/client"
 	"github.com/bosh-prometheus/shield_exporter/collectors"
+	"github.com/bosh-prometheus/shield_exporter/config"
+	"github.com/bosh-prometheus/shield_exporter/events"
 	"github.com/bosh-prometheus/shield_exporter/filters"
+	"github.com/bosh-prometheus/shield_exporter/ha"
+	"github.com/bosh-prometheus/shield_exporter/healthcheck"
+	"github.com/bosh-prometheus/shield_exporter/htpasswd"
+	"github.com/bosh-prometheus/shield_exporter/landing"
+	"github.com/bosh-prometheus/shield_exporter/redact"
+	"github.com/bosh-prometheus/shield_exporter/rollup"
+	"github.com/bosh-prometheus/shield_exporter/rules"
+	"github.com/bosh-prometheus/shield_exporter/runtimeconfig"
+	"github.com/bosh-prometheus/shield_exporter/snapshot"
+	"github.com/bosh-prometheus/shield_exporter/systemd"
+	"github.com/bosh-prometheus/shield_exporter/textfile"
+	"github.com/bosh-prometheus/shield_exporter/tlsreload"
+	"github.com/bosh-prometheus/shield_exporter/tracing"
+	"github.com/bosh-prometheus/shield_exporter/uaa"
+	"github.com/bosh-prometheus/shield_exporter/vault"
+	"github.com/bosh-prometheus/shield_exporter/web"
+	"github.com/bosh-prometheus/shield_exporter/webhook"
 )
 
 var (
+	serveCommand = kingpin.Command("serve", "Serve the Shield metrics over HTTP.").Default()
+
+	onceCommand = kingpin.Command("once", "Perform a single collection of the enabled collectors and print the metrics to stdout.")
+
+	generateCommand = kingpin.Command("generate", "Generate Prometheus configuration consistent with the exported metrics.")
+
+	generateRecordingRulesCommand = generateCommand.Command("recording-rules", "Print recording rules aggregating the Shield metrics to stdout.")
+
+	healthcheckCommand = kingpin.Command("healthcheck", "Query the health endpoint of a running exporter, exiting with 0 when healthy or 1 otherwise.")
+
+	healthcheckURL = healthcheckCommand.Flag(
+		"url", "URL, including the route prefix if any, of the exporter to check",
+	).Default("http://localhost:9179").String()
+
+	healthcheckTimeout = healthcheckCommand.Flag(
+		"timeout", "Timeout for the health check",
+	).Default("5s").Duration()
+
+	textfileCommand = kingpin.Command("textfile", "Periodically write the Shield metrics to a file read by the node_exporter textfile collector.")
+
 	shieldBackendUrl = kingpin.Flag(
 		"shield.backend_url", "Shield Backend URL ($SHIELD_EXPORTER_SHIELD_BACKEND_URL)",
-	).Envar("SHIELD_EXPORTER_SHIELD_BACKEND_URL").Required().String()
+	).Envar("SHIELD_EXPORTER_SHIELD_BACKEND_URL").Default("").String()
+
+	shieldDiscoveryDNSSRV = kingpin.Flag(
+		"shield.discovery.dns-srv", "DNS SRV record resolving into the Shield backends to scrape, instead of a single Shield Backend URL ($SHIELD_EXPORTER_SHIELD_DISCOVERY_DNS_SRV)",
+	).Envar("SHIELD_EXPORTER_SHIELD_DISCOVERY_DNS_SRV").Default("").String()
+
+	shieldDiscoveryScheme = kingpin.Flag(
+		"shield.discovery.scheme", "URL scheme of the Shield backends discovered through DNS SRV records ($SHIELD_EXPORTER_SHIELD_DISCOVERY_SCHEME)",
+	).Envar("SHIELD_EXPORTER_SHIELD_DISCOVERY_SCHEME").Default("https").Enum("http", "https")
+
+	shieldDiscoveryRefreshInterval = kingpin.Flag(
+		"shield.discovery.refresh-interval", "Interval at which the DNS SRV records are resolved again ($SHIELD_EXPORTER_SHIELD_DISCOVERY_REFRESH_INTERVAL)",
+	).Envar("SHIELD_EXPORTER_SHIELD_DISCOVERY_REFRESH_INTERVAL").Default("30s").Duration()
 
 	shieldUsername = kingpin.Flag(
 		"shield.username", "Shield Username ($SHIELD_EXPORTER_SHIELD_USERNAME)",
-	).Envar("SHIELD_EXPORTER_SHIELD_USERNAME").Required().String()
+	).Envar("SHIELD_EXPORTER_SHIELD_USERNAME").Default("").String()
 
 	shieldPassword = kingpin.Flag(
 		"shield.password", "Shield Password ($SHIELD_EXPORTER_SHIELD_PASSWORD)",
-	).Envar("SHIELD_EXPORTER_SHIELD_PASSWORD").Required().String()
+	).Envar("SHIELD_EXPORTER_SHIELD_PASSWORD").Default("").String()
+
+	shieldUsernameFile = kingpin.Flag(
+		"shield.username_file", "File containing the Shield Username, reloaded when it changes ($SHIELD_EXPORTER_SHIELD_USERNAME_FILE)",
+	).Envar("SHIELD_EXPORTER_SHIELD_USERNAME_FILE").Default("").String()
+
+	shieldPasswordFile = kingpin.Flag(
+		"shield.password_file", "File containing the Shield Password, reloaded when it changes ($SHIELD_EXPORTER_SHIELD_PASSWORD_FILE)",
+	).Envar("SHIELD_EXPORTER_SHIELD_PASSWORD_FILE").Default("").String()
+
+	shieldCredentialsReloadInterval = kingpin.Flag(
+		"shield.credentials-reload-interval", "Interval at which the Shield credentials files are polled for changes, rotated credentials being picked up up to this interval later, or as soon as Shield rejects the previous ones ($SHIELD_EXPORTER_SHIELD_CREDENTIALS_RELOAD_INTERVAL)",
+	).Envar("SHIELD_EXPORTER_SHIELD_CREDENTIALS_RELOAD_INTERVAL").Default("30s").Duration()
+
+	shieldTenants = kingpin.Flag(
+		"shield.tenant", "Shield v8 tenant, by name or UUID, whose archives, jobs, retention policies, stores, targets and tasks are scraped, repeatable ($SHIELD_EXPORTER_SHIELD_TENANT)",
+	).Envar("SHIELD_EXPORTER_SHIELD_TENANT").Strings()
+
+	shieldUAAURL = kingpin.Flag(
+		"shield.uaa.url", "UAA URL the Shield access token is requested from through the client credentials grant, instead of a Shield username and password ($SHIELD_EXPORTER_SHIELD_UAA_URL)",
+	).Envar("SHIELD_EXPORTER_SHIELD_UAA_URL").Default("").String()
+
+	shieldUAAClientID = kingpin.Flag(
+		"shield.uaa.client_id", "UAA client id ($SHIELD_EXPORTER_SHIELD_UAA_CLIENT_ID)",
+	).Envar("SHIELD_EXPORTER_SHIELD_UAA_CLIENT_ID").Default("").String()
+
+	shieldUAAClientSecret = kingpin.Flag(
+		"shield.uaa.client_secret", "UAA client secret ($SHIELD_EXPORTER_SHIELD_UAA_CLIENT_SECRET)",
+	).Envar("SHIELD_EXPORTER_SHIELD_UAA_CLIENT_SECRET").Default("").String()
+
+	shieldUAATokenRefreshInterval = kingpin.Flag(
+		"shield.uaa.token-refresh-interval", "Interval at which the UAA access token is refreshed when UAA does not report its lifetime ($SHIELD_EXPORTER_SHIELD_UAA_TOKEN_REFRESH_INTERVAL)",
+	).Envar("SHIELD_EXPORTER_SHIELD_UAA_TOKEN_REFRESH_INTERVAL").Default("5m").Duration()
+
+	shieldProxyURL = kingpin.Flag(
+		"shield.proxy_url", "Proxy URL used to connect to the Shield API, instead of the HTTP(S)_PROXY environment variables ($SHIELD_EXPORTER_SHIELD_PROXY_URL)",
+	).Envar("SHIELD_EXPORTER_SHIELD_PROXY_URL").Default("").String()
+
+	shieldSkipStartupCheck = kingpin.Flag(
+		"shield.skip-startup-check", "Do not check the Shield Status at startup, deferring all validation to scrape time ($SHIELD_EXPORTER_SHIELD_SKIP_STARTUP_CHECK)",
+	).Envar("SHIELD_EXPORTER_SHIELD_SKIP_STARTUP_CHECK").Default("false").Bool()
+
+	shieldStartupRetries = kingpin.Flag(
+		"shield.startup-retries", "Number of times to retry getting the Shield Status at startup ($SHIELD_EXPORTER_SHIELD_STARTUP_RETRIES)",
+	).Envar("SHIELD_EXPORTER_SHIELD_STARTUP_RETRIES").Default("0").Int()
+
+	shieldStartupBackoff = kingpin.Flag(
+		"shield.startup-backoff", "Initial delay between startup retries, doubled after every attempt ($SHIELD_EXPORTER_SHIELD_STARTUP_BACKOFF)",
+	).Envar("SHIELD_EXPORTER_SHIELD_STARTUP_BACKOFF").Default("5s").Duration()
+
+	shieldStartupServeOnFailure = kingpin.Flag(
+		"shield.startup-serve-on-failure", "Start serving scrape error metrics instead of exiting when the Shield Status cannot be retrieved at startup ($SHIELD_EXPORTER_SHIELD_STARTUP_SERVE_ON_FAILURE)",
+	).Envar("SHIELD_EXPORTER_SHIELD_STARTUP_SERVE_ON_FAILURE").Default("false").Bool()
+
+	shieldBackendNameRefreshInterval = kingpin.Flag(
+		"shield.backend-name-refresh-interval", "Interval at which the backend name is refreshed from the Shield Status, 0 to only resolve it once ($SHIELD_EXPORTER_SHIELD_BACKEND_NAME_REFRESH_INTERVAL)",
+	).Envar("SHIELD_EXPORTER_SHIELD_BACKEND_NAME_REFRESH_INTERVAL").Default("5m").Duration()
+
+	shieldEvents = kingpin.Flag(
+		"shield.events", "Subscribe to the events stream of a Shield v8 core to count task status updates as they arrive, only supported with `shield.backend_url` ($SHIELD_EXPORTER_SHIELD_EVENTS)",
+	).Envar("SHIELD_EXPORTER_SHIELD_EVENTS").Default("false").Bool()
+
+	shieldEventsReconnectBackoff = kingpin.Flag(
+		"shield.events.reconnect-backoff", "Time waited before reconnecting to the Shield events stream after it was closed or failed ($SHIELD_EXPORTER_SHIELD_EVENTS_RECONNECT_BACKOFF)",
+	).Envar("SHIELD_EXPORTER_SHIELD_EVENTS_RECONNECT_BACKOFF").Default("10s").Duration()
+
+	shieldEventsPingInterval = kingpin.Flag(
+		"shield.events.ping-interval", "Interval at which pings are sent on the Shield events stream, which is reconnected when nothing is received from Shield for twice that long, 0 to disable ($SHIELD_EXPORTER_SHIELD_EVENTS_PING_INTERVAL)",
+	).Envar("SHIELD_EXPORTER_SHIELD_EVENTS_PING_INTERVAL").Default("30s").Duration()
+
+	shieldMaxRequestsPerSecond = kingpin.Flag(
+		"shield.max-requests-per-second", "Maximum number of requests per second sent to the Shield API, 0 for no limit ($SHIELD_EXPORTER_SHIELD_MAX_REQUESTS_PER_SECOND)",
+	).Envar("SHIELD_EXPORTER_SHIELD_MAX_REQUESTS_PER_SECOND").Default("0").Float64()
+
+	shieldMaxConcurrentRequests = kingpin.Flag(
+		"shield.max-concurrent-requests", "Maximum number of simultaneous requests sent to the Shield API, 0 for no limit ($SHIELD_EXPORTER_SHIELD_MAX_CONCURRENT_REQUESTS)",
+	).Envar("SHIELD_EXPORTER_SHIELD_MAX_CONCURRENT_REQUESTS").Default("0").Int()
+
+	shieldMaxIdleConns = kingpin.Flag(
+		"shield.max-idle-conns", "Maximum number of idle keep-alive connections to the Shield API ($SHIELD_EXPORTER_SHIELD_MAX_IDLE_CONNS)",
+	).Envar("SHIELD_EXPORTER_SHIELD_MAX_IDLE_CONNS").Default("10").Int()
+
+	shieldIdleConnTimeout = kingpin.Flag(
+		"shield.idle-conn-timeout", "Time an idle keep-alive connection to the Shield API remains open, 0 for no limit ($SHIELD_EXPORTER_SHIELD_IDLE_CONN_TIMEOUT)",
+	).Envar("SHIELD_EXPORTER_SHIELD_IDLE_CONN_TIMEOUT").Default("90s").Duration()
+
+	shieldTLSHandshakeTimeout = kingpin.Flag(
+		"shield.tls-handshake-timeout", "Maximum time waiting for a TLS handshake with the Shield API, 0 for no timeout ($SHIELD_EXPORTER_SHIELD_TLS_HANDSHAKE_TIMEOUT)",
+	).Envar("SHIELD_EXPORTER_SHIELD_TLS_HANDSHAKE_TIMEOUT").Default("10s").Duration()
+
+	shieldResponseHeaderTimeout = kingpin.Flag(
+		"shield.response-header-timeout", "Maximum time waiting for the Shield API response headers, 0 for no timeout ($SHIELD_EXPORTER_SHIELD_RESPONSE_HEADER_TIMEOUT)",
+	).Envar("SHIELD_EXPORTER_SHIELD_RESPONSE_HEADER_TIMEOUT").Default("0s").Duration()
+
+	scrapeSlowThreshold = kingpin.Flag(
+		"scrape.slow-threshold", "Log a warning whenever a collector takes longer than this duration to scrape a Shield backend, 0 to disable ($SHIELD_EXPORTER_SCRAPE_SLOW_THRESHOLD)",
+	).Envar("SHIELD_EXPORTER_SCRAPE_SLOW_THRESHOLD").Default("0s").Duration()
+
+	textfilePath = kingpin.Flag(
+		"textfile.path", "Path of the file, ending with `.prom`, the `textfile` command writes the metrics to ($SHIELD_EXPORTER_TEXTFILE_PATH)",
+	).Envar("SHIELD_EXPORTER_TEXTFILE_PATH").Default("").String()
+
+	textfileInterval = kingpin.Flag(
+		"textfile.interval", "Interval at which the `textfile` command writes the metrics ($SHIELD_EXPORTER_TEXTFILE_INTERVAL)",
+	).Envar("SHIELD_EXPORTER_TEXTFILE_INTERVAL").Default("1m").Duration()
+
+	scrapeInterval = kingpin.Flag(
+		"scrape.interval", "Interval at which Shield is scraped in the background, serving the cached metrics on every request, 0 to scrape Shield on every request ($SHIELD_EXPORTER_SCRAPE_INTERVAL)",
+	).Envar("SHIELD_EXPORTER_SCRAPE_INTERVAL").Default("0s").Duration()
+
+	scrapeMetricsTTL = kingpin.Flag(
+		"scrape.metrics-ttl", "Maximum age of the cached Shield metrics served when scraping in the background, 0 for no limit ($SHIELD_EXPORTER_SCRAPE_METRICS_TTL)",
+	).Envar("SHIELD_EXPORTER_SCRAPE_METRICS_TTL").Default("0s").Duration()
+
+	tracingOTLPEndpoint = kingpin.Flag(
+		"tracing.otlp-endpoint", "OTLP/HTTP traces endpoint URL, ie `http://localhost:4318/v1/traces`, the traces of the scrapes are exported to, empty to disable tracing ($SHIELD_EXPORTER_TRACING_OTLP_ENDPOINT)",
+	).Envar("SHIELD_EXPORTER_TRACING_OTLP_ENDPOINT").Default("").String()
+
+	tracingSamplingRatio = kingpin.Flag(
+		"tracing.sampling-ratio", "Ratio, between 0 and 1, of the scrapes traced ($SHIELD_EXPORTER_TRACING_SAMPLING_RATIO)",
+	).Envar("SHIELD_EXPORTER_TRACING_SAMPLING_RATIO").Default("1").Float64()
+
+	configFile = kingpin.Flag(
+		"config.file", "Path to the configuration file overriding the collectors, namespace and environment per Shield backend ($SHIELD_EXPORTER_CONFIG_FILE)",
+	).Envar("SHIELD_EXPORTER_CONFIG_FILE").Default("").String()
 
 	filterCollectors = kingpin.Flag(
-		"filter.collectors", "Comma separated collectors to filter (Archives,Jobs,RetentionPolicies,Schedules,Status,Stores,Targets,Tasks) ($SHIELD_EXPORTER_FILTER_COLLECTORS)",
+		"filter.collectors", "Comma separated collectors to filter (Agents,Archives,AuthTokens,Jobs,RetentionPolicies,Schedules,Status,Stores,Targets,Tasks,Tenants,Users) ($SHIELD_EXPORTER_FILTER_COLLECTORS)",
 	).Envar("SHIELD_EXPORTER_FILTER_COLLECTORS").Default("").String()
 
 	metricsNamespace = kingpin.Flag(
@@ -38,16 +233,172 @@ var (
 
 	metricsEnvironment = kingpin.Flag(
 		"metrics.environment", "Environment label to be attached to metrics ($SHIELD_EXPORTER_METRICS_ENVIRONMENT)",
-	).Envar("SHIELD_EXPORTER_METRICS_ENVIRONMENT").Required().String()
+	).Envar("SHIELD_EXPORTER_METRICS_ENVIRONMENT").Default("").String()
+
+	metricsRollup = kingpin.Flag(
+		"metrics.rollup", "Add rollup metrics summed across all Shield backends, without the backend_name label ($SHIELD_EXPORTER_METRICS_ROLLUP)",
+	).Envar("SHIELD_EXPORTER_METRICS_ROLLUP").Default("false").Bool()
+
+	boshInstanceMetadata = kingpin.Flag(
+		"bosh.instance-metadata", "Read the BOSH instance metadata, defaulting the environment label to the BOSH deployment ($SHIELD_EXPORTER_BOSH_INSTANCE_METADATA)",
+	).Envar("SHIELD_EXPORTER_BOSH_INSTANCE_METADATA").Default("false").Bool()
+
+	boshInstanceDir = kingpin.Flag(
+		"bosh.instance-dir", "Directory containing the BOSH instance metadata ($SHIELD_EXPORTER_BOSH_INSTANCE_DIR)",
+	).Envar("SHIELD_EXPORTER_BOSH_INSTANCE_DIR").Default(bosh.DefaultInstanceDir).String()
+
+	boshInstanceLabels = kingpin.Flag(
+		"bosh.instance-labels", "Attach the BOSH deployment, AZ, instance name and id labels to the Shield metrics ($SHIELD_EXPORTER_BOSH_INSTANCE_LABELS)",
+	).Envar("SHIELD_EXPORTER_BOSH_INSTANCE_LABELS").Default("false").Bool()
+
+	metricsBackendName = kingpin.Flag(
+		"metrics.backend_name", "Backend name label to be attached to metrics instead of the name reported by Shield ($SHIELD_EXPORTER_METRICS_BACKEND_NAME)",
+	).Envar("SHIELD_EXPORTER_METRICS_BACKEND_NAME").Default("").String()
+
+	metricsDeprecatedNames = kingpin.Flag(
+		"metrics.deprecated-names", "Also emit deprecated metric names during a migration window ($SHIELD_EXPORTER_METRICS_DEPRECATED_NAMES)",
+	).Envar("SHIELD_EXPORTER_METRICS_DEPRECATED_NAMES").Default("false").Bool()
+
+	metricsUnifiedScrapeError = kingpin.Flag(
+		"metrics.unified-scrape-error", "Also export the last scrape error of every collector as a single exporter_last_scrape_error metric labeled by collector ($SHIELD_EXPORTER_METRICS_UNIFIED_SCRAPE_ERROR)",
+	).Envar("SHIELD_EXPORTER_METRICS_UNIFIED_SCRAPE_ERROR").Default("false").Bool()
+
+	metricsTasksDurationObjectives = kingpin.Flag(
+		"metrics.tasks-duration.objectives", "Comma separated quantile:error objectives of the Tasks duration summary ($SHIELD_EXPORTER_METRICS_TASKS_DURATION_OBJECTIVES)",
+	).Envar("SHIELD_EXPORTER_METRICS_TASKS_DURATION_OBJECTIVES").Default("0.5:0.05,0.9:0.01,0.99:0.001").String()
+
+	metricsTasksDurationMaxAge = kingpin.Flag(
+		"metrics.tasks-duration.max-age", "Duration for which an observation stays relevant for the Tasks duration summary ($SHIELD_EXPORTER_METRICS_TASKS_DURATION_MAX_AGE)",
+	).Envar("SHIELD_EXPORTER_METRICS_TASKS_DURATION_MAX_AGE").Default("10m").Duration()
+
+	metricsTasksDurationAgeBuckets = kingpin.Flag(
+		"metrics.tasks-duration.age-buckets", "Number of buckets used to exclude observations older than max-age from the Tasks duration summary ($SHIELD_EXPORTER_METRICS_TASKS_DURATION_AGE_BUCKETS)",
+	).Envar("SHIELD_EXPORTER_METRICS_TASKS_DURATION_AGE_BUCKETS").Default("5").Uint32()
+
+	metricsNativeHistogramBucketFactor = kingpin.Flag(
+		"metrics.native-histogram-bucket-factor", "Growth factor, greater than 1, between the buckets of the native histograms exposed for the Tasks durations and the exporter HTTP request durations, 0 to disable native histograms ($SHIELD_EXPORTER_METRICS_NATIVE_HISTOGRAM_BUCKET_FACTOR)",
+	).Envar("SHIELD_EXPORTER_METRICS_NATIVE_HISTOGRAM_BUCKET_FACTOR").Default("0").Float64()
+
+	metricsTasksJobName = kingpin.Flag(
+		"metrics.tasks-job-name", "Label the Tasks metrics with the name of their job, adding series per job ($SHIELD_EXPORTER_METRICS_TASKS_JOB_NAME)",
+	).Envar("SHIELD_EXPORTER_METRICS_TASKS_JOB_NAME").Default("false").Bool()
+
+	metricsArchivesExpiringWindows = kingpin.Flag(
+		"metrics.archives-expiring.windows", "Comma separated windows within which expiring valid Archives are counted ($SHIELD_EXPORTER_METRICS_ARCHIVES_EXPIRING_WINDOWS)",
+	).Envar("SHIELD_EXPORTER_METRICS_ARCHIVES_EXPIRING_WINDOWS").Default("24h").String()
+
+	metricsJobSLAEnabled = kingpin.Flag(
+		"metrics.job-sla.enabled", "Export whether every Job has a valid archive more recent than its SLA, listing the archives at every scrape of the Jobs collector ($SHIELD_EXPORTER_METRICS_JOB_SLA_ENABLED)",
+	).Envar("SHIELD_EXPORTER_METRICS_JOB_SLA_ENABLED").Default("false").Bool()
+
+	metricsJobSLAMaxAge = kingpin.Flag(
+		"metrics.job-sla.max-age", "Maximum age of the latest valid archive of every Job, 0 for the interval of its schedule ($SHIELD_EXPORTER_METRICS_JOB_SLA_MAX_AGE)",
+	).Envar("SHIELD_EXPORTER_METRICS_JOB_SLA_MAX_AGE").Default("0s").Duration()
+
+	metricsJobsLegacyLabels = kingpin.Flag(
+		"metrics.jobs.legacy-labels", "Label the per-Job metrics with the job_name only, instead of the job_name, job_uuid, target_name and store_name ($SHIELD_EXPORTER_METRICS_JOBS_LEGACY_LABELS)",
+	).Envar("SHIELD_EXPORTER_METRICS_JOBS_LEGACY_LABELS").Default("false").Bool()
+
+	metricsRestoreSuccessWindow = kingpin.Flag(
+		"metrics.restore-success.window", "Window of the restore Tasks counting towards the restore success ratio and the restores of the targets, 0 for the whole Shield task history ($SHIELD_EXPORTER_METRICS_RESTORE_SUCCESS_WINDOW)",
+	).Envar("SHIELD_EXPORTER_METRICS_RESTORE_SUCCESS_WINDOW").Default("168h").Duration()
+
+	webhookURL = kingpin.Flag(
+		"webhook.url", "URL of a webhook receiving a POST request when a Shield backend fails `webhook.scrape-failures-threshold` consecutive scrapes ($SHIELD_EXPORTER_WEBHOOK_URL)",
+	).Envar("SHIELD_EXPORTER_WEBHOOK_URL").Default("").String()
+
+	webhookTemplateFile = kingpin.Flag(
+		"webhook.template_file", "Go template file rendering the webhook payload, instead of the default JSON payload ($SHIELD_EXPORTER_WEBHOOK_TEMPLATE_FILE)",
+	).Envar("SHIELD_EXPORTER_WEBHOOK_TEMPLATE_FILE").Default("").String()
+
+	webhookScrapeFailuresThreshold = kingpin.Flag(
+		"webhook.scrape-failures-threshold", "Number of consecutive failed scrapes of a Shield backend before sending the webhook ($SHIELD_EXPORTER_WEBHOOK_SCRAPE_FAILURES_THRESHOLD)",
+	).Envar("SHIELD_EXPORTER_WEBHOOK_SCRAPE_FAILURES_THRESHOLD").Default("3").Int()
+
+	webhookTimeout = kingpin.Flag(
+		"webhook.timeout", "Timeout for sending the webhook ($SHIELD_EXPORTER_WEBHOOK_TIMEOUT)",
+	).Envar("SHIELD_EXPORTER_WEBHOOK_TIMEOUT").Default("10s").Duration()
+
+	webhookJobsURL = kingpin.Flag(
+		"webhook.jobs.url", "URL of a webhook receiving a POST request when a Shield job fails or is overdue ($SHIELD_EXPORTER_WEBHOOK_JOBS_URL)",
+	).Envar("SHIELD_EXPORTER_WEBHOOK_JOBS_URL").Default("").String()
+
+	webhookJobsTemplateFile = kingpin.Flag(
+		"webhook.jobs.template_file", "Go template file rendering the job alerts payload, instead of the default Slack compatible payload ($SHIELD_EXPORTER_WEBHOOK_JOBS_TEMPLATE_FILE)",
+	).Envar("SHIELD_EXPORTER_WEBHOOK_JOBS_TEMPLATE_FILE").Default("").String()
+
+	webhookJobsOverdueAfter = kingpin.Flag(
+		"webhook.jobs.overdue-after", "Delay after its next run time past which a Shield job not paused is overdue ($SHIELD_EXPORTER_WEBHOOK_JOBS_OVERDUE_AFTER)",
+	).Envar("SHIELD_EXPORTER_WEBHOOK_JOBS_OVERDUE_AFTER").Default("1h").Duration()
+
+	haLockFile = kingpin.Flag(
+		"ha.lock-file", "Path to a lock file shared by an active/standby pair of exporters, only the instance holding the lock scrapes Shield ($SHIELD_EXPORTER_HA_LOCK_FILE)",
+	).Envar("SHIELD_EXPORTER_HA_LOCK_FILE").Default("").String()
+
+	haLockRetryInterval = kingpin.Flag(
+		"ha.lock-retry-interval", "Interval at which a standby exporter tries to acquire the lock file ($SHIELD_EXPORTER_HA_LOCK_RETRY_INTERVAL)",
+	).Envar("SHIELD_EXPORTER_HA_LOCK_RETRY_INTERVAL").Default("5s").Duration()
+
+	haStandbyRefreshInterval = kingpin.Flag(
+		"ha.standby-refresh-interval", "Interval at which a standby exporter scrapes Shield to refresh the metrics it serves, 0 (default) to only serve the ones scraped while leader ($SHIELD_EXPORTER_HA_STANDBY_REFRESH_INTERVAL)",
+	).Envar("SHIELD_EXPORTER_HA_STANDBY_REFRESH_INTERVAL").Default("0").Duration()
 
 	listenAddress = kingpin.Flag(
-		"web.listen-address", "Address to listen on for web interface and telemetry ($SHIELD_EXPORTER_WEB_LISTEN_ADDRESS)",
-	).Envar("SHIELD_EXPORTER_WEB_LISTEN_ADDRESS").Default(":9179").String()
+		"web.listen-address", "Address to listen on for web interface and telemetry, repeatable to listen on several addresses ($SHIELD_EXPORTER_WEB_LISTEN_ADDRESS)",
+	).Envar("SHIELD_EXPORTER_WEB_LISTEN_ADDRESS").Default(":9179").Strings()
 
 	metricsPath = kingpin.Flag(
 		"web.telemetry-path", "Path under which to expose Prometheus metrics ($SHIELD_EXPORTER_WEB_TELEMETRY_PATH)",
 	).Envar("SHIELD_EXPORTER_WEB_TELEMETRY_PATH").Default("/metrics").String()
 
+	webExternalURL = kingpin.Flag(
+		"web.external-url", "URL under which the exporter is externally reachable (ie behind a reverse proxy), used to generate links ($SHIELD_EXPORTER_WEB_EXTERNAL_URL)",
+	).Envar("SHIELD_EXPORTER_WEB_EXTERNAL_URL").Default("").String()
+
+	webRoutePrefix = kingpin.Flag(
+		"web.route-prefix", "Prefix for the internal routes of web endpoints, defaults to the path of `web.external-url` ($SHIELD_EXPORTER_WEB_ROUTE_PREFIX)",
+	).Envar("SHIELD_EXPORTER_WEB_ROUTE_PREFIX").Default("").String()
+
+	webCollectorEndpoints = kingpin.Flag(
+		"web.collector-endpoints", "Also expose the metrics of each collector under its own path below `web.telemetry-path`, ie `/metrics/tasks` ($SHIELD_EXPORTER_WEB_COLLECTOR_ENDPOINTS)",
+	).Envar("SHIELD_EXPORTER_WEB_COLLECTOR_ENDPOINTS").Default("false").Bool()
+
+	webEnableConfig = kingpin.Flag(
+		"web.enable-config", "Expose the effective configuration, with secrets redacted, at `/config` ($SHIELD_EXPORTER_WEB_ENABLE_CONFIG)",
+	).Envar("SHIELD_EXPORTER_WEB_ENABLE_CONFIG").Default("false").Bool()
+
+	webEnableLifecycle = kingpin.Flag(
+		"web.enable-lifecycle", "Enable the `/-/quit` endpoint, shutting down the exporter gracefully on POST or PUT requests ($SHIELD_EXPORTER_WEB_ENABLE_LIFECYCLE)",
+	).Envar("SHIELD_EXPORTER_WEB_ENABLE_LIFECYCLE").Default("false").Bool()
+
+	webEnableLogLevel = kingpin.Flag(
+		"web.enable-loglevel", "Enable the `/-/loglevel` endpoint, changing the log level on PUT requests ($SHIELD_EXPORTER_WEB_ENABLE_LOGLEVEL)",
+	).Envar("SHIELD_EXPORTER_WEB_ENABLE_LOGLEVEL").Default("false").Bool()
+
+	webEnableAdminAPI = kingpin.Flag(
+		"web.enable-admin-api", "Enable the `/-/collectors` endpoint, enabling or disabling collectors at runtime on PUT requests ($SHIELD_EXPORTER_WEB_ENABLE_ADMIN_API)",
+	).Envar("SHIELD_EXPORTER_WEB_ENABLE_ADMIN_API").Default("false").Bool()
+
+	webEnablePprof = kingpin.Flag(
+		"web.enable-pprof", "Enable the profiling endpoints at `/debug/pprof` ($SHIELD_EXPORTER_WEB_ENABLE_PPROF)",
+	).Envar("SHIELD_EXPORTER_WEB_ENABLE_PPROF").Default("false").Bool()
+
+	webEnableExpvar = kingpin.Flag(
+		"web.enable-expvar", "Expose the internal state of the exporter, ie the requests, errors and cache hits per Shield backend, at `/debug/vars` ($SHIELD_EXPORTER_WEB_ENABLE_EXPVAR)",
+	).Envar("SHIELD_EXPORTER_WEB_ENABLE_EXPVAR").Default("false").Bool()
+
+	webShutdownTimeout = kingpin.Flag(
+		"web.shutdown-timeout", "Maximum time waiting for in-flight requests to complete on a graceful shutdown ($SHIELD_EXPORTER_WEB_SHUTDOWN_TIMEOUT)",
+	).Envar("SHIELD_EXPORTER_WEB_SHUTDOWN_TIMEOUT").Default("30s").Duration()
+
+	maxRequestsInFlight = kingpin.Flag(
+		"web.max-requests-in-flight", "Maximum number of concurrent metrics requests, 0 for no limit ($SHIELD_EXPORTER_WEB_MAX_REQUESTS_IN_FLIGHT)",
+	).Envar("SHIELD_EXPORTER_WEB_MAX_REQUESTS_IN_FLIGHT").Default("0").Int()
+
+	metricsTimeout = kingpin.Flag(
+		"web.timeout", "Timeout for serving a metrics request, 0 for no timeout ($SHIELD_EXPORTER_WEB_TIMEOUT)",
+	).Envar("SHIELD_EXPORTER_WEB_TIMEOUT").Default("0s").Duration()
+
 	authUsername = kingpin.Flag(
 		"web.auth.username", "Username for web interface basic auth ($SHIELD_EXPORTER_WEB_AUTH_USERNAME)",
 	).Envar("SHIELD_EXPORTER_WEB_AUTH_USERNAME").String()
@@ -56,6 +407,58 @@ var (
 		"web.auth.password", "Password for web interface basic auth ($SHIELD_EXPORTER_WEB_AUTH_PASSWORD)",
 	).Envar("SHIELD_EXPORTER_WEB_AUTH_PASSWORD").String()
 
+	authPasswordFile = kingpin.Flag(
+		"web.auth.password_file", "Path to a file that contains the password for web interface basic auth ($SHIELD_EXPORTER_WEB_AUTH_PASSWORD_FILE)",
+	).Envar("SHIELD_EXPORTER_WEB_AUTH_PASSWORD_FILE").ExistingFile()
+
+	vaultAddress = kingpin.Flag(
+		"vault.address", "Vault address to read secrets from ($SHIELD_EXPORTER_VAULT_ADDRESS)",
+	).Envar("SHIELD_EXPORTER_VAULT_ADDRESS").Default("").String()
+
+	vaultToken = kingpin.Flag(
+		"vault.token", "Vault token ($SHIELD_EXPORTER_VAULT_TOKEN)",
+	).Envar("SHIELD_EXPORTER_VAULT_TOKEN").Default("").String()
+
+	vaultRoleID = kingpin.Flag(
+		"vault.approle.role_id", "Vault AppRole role id, used instead of a Vault token ($SHIELD_EXPORTER_VAULT_APPROLE_ROLE_ID)",
+	).Envar("SHIELD_EXPORTER_VAULT_APPROLE_ROLE_ID").Default("").String()
+
+	vaultSecretID = kingpin.Flag(
+		"vault.approle.secret_id", "Vault AppRole secret id ($SHIELD_EXPORTER_VAULT_APPROLE_SECRET_ID)",
+	).Envar("SHIELD_EXPORTER_VAULT_APPROLE_SECRET_ID").Default("").String()
+
+	vaultShieldPath = kingpin.Flag(
+		"vault.shield_path", "Vault KV path of a secret holding the Shield `username` and `password` ($SHIELD_EXPORTER_VAULT_SHIELD_PATH)",
+	).Envar("SHIELD_EXPORTER_VAULT_SHIELD_PATH").Default("").String()
+
+	vaultWebAuthPath = kingpin.Flag(
+		"vault.web_auth_path", "Vault KV path of a secret holding the web interface basic auth `username` and `password` ($SHIELD_EXPORTER_VAULT_WEB_AUTH_PATH)",
+	).Envar("SHIELD_EXPORTER_VAULT_WEB_AUTH_PATH").Default("").String()
+
+	vaultRefreshInterval = kingpin.Flag(
+		"vault.refresh-interval", "Interval at which the Shield and web interface credentials are read again from Vault ($SHIELD_EXPORTER_VAULT_REFRESH_INTERVAL)",
+	).Envar("SHIELD_EXPORTER_VAULT_REFRESH_INTERVAL").Default("5m").Duration()
+
+	vaultRenewInterval = kingpin.Flag(
+		"vault.renew-interval", "Interval at which the Vault token is renewed when its lease duration is unknown ($SHIELD_EXPORTER_VAULT_RENEW_INTERVAL)",
+	).Envar("SHIELD_EXPORTER_VAULT_RENEW_INTERVAL").Default("1h").Duration()
+
+	authHtpasswdFile = kingpin.Flag(
+		"web.auth.htpasswd_file", "Path to an htpasswd file (bcrypt hashes only) with the users allowed for web interface basic auth ($SHIELD_EXPORTER_WEB_AUTH_HTPASSWD_FILE)",
+	).Envar("SHIELD_EXPORTER_WEB_AUTH_HTPASSWD_FILE").ExistingFile()
+
+	authTokenFile = kingpin.Flag(
+		"web.auth.token_file", "Path to a file that contains a bearer token accepted for web interface auth ($SHIELD_EXPORTER_WEB_AUTH_TOKEN_FILE)",
+	).Envar("SHIELD_EXPORTER_WEB_AUTH_TOKEN_FILE").ExistingFile()
+
+	auditLog = kingpin.Flag(
+		"web.audit-log", "Write an audit record for every request to the authenticated web endpoints ($SHIELD_EXPORTER_WEB_AUDIT_LOG)",
+	).Envar("SHIELD_EXPORTER_WEB_AUDIT_LOG").Default("false").Bool()
+
+	auditLogFile = kingpin.Flag(
+		"web.audit-log-file", "Path to a file the audit records are appended to, instead of the standard output ($SHIELD_EXPORTER_WEB_AUDIT_LOG_FILE)",
+	).Envar("SHIELD_EXPORTER_WEB_AUDIT_LOG_FILE").Default("").String()
+
 	tlsCertFile = kingpin.Flag(
 		"web.tls.cert_file", "Path to a file that contains the TLS certificate (PEM format). If the certificate is signed by a certificate authority, the file should be the concatenation of the server's certificate, any intermediates, and the CA's certificate ($SHIELD_EXPORTER_WEB_TLS_CERTFILE)",
 	).Envar("SHIELD_EXPORTER_WEB_TLS_CERTFILE").ExistingFile()
@@ -63,77 +466,628 @@ var (
 	tlsKeyFile = kingpin.Flag(
 		"web.tls.key_file", "Path to a file that contains the TLS private key (PEM format) ($SHIELD_EXPORTER_WEB_TLS_KEYFILE)",
 	).Envar("SHIELD_EXPORTER_WEB_TLS_KEYFILE").ExistingFile()
+
+	tlsReloadInterval = kingpin.Flag(
+		"web.tls.reload-interval", "Interval at which the TLS certificate and key files are checked for changes, 0 to disable reloading ($SHIELD_EXPORTER_WEB_TLS_RELOAD_INTERVAL)",
+	).Envar("SHIELD_EXPORTER_WEB_TLS_RELOAD_INTERVAL").Default("1m").Duration()
+
+	tlsClientCAFile = kingpin.Flag(
+		"web.tls.client_ca_file", "Path to a file that contains the CA certificates (PEM format) used to verify scrapers client certificates ($SHIELD_EXPORTER_WEB_TLS_CLIENT_CA_FILE)",
+	).Envar("SHIELD_EXPORTER_WEB_TLS_CLIENT_CA_FILE").ExistingFile()
+
+	tlsClientAuth = kingpin.Flag(
+		"web.tls.client_auth", "Client certificate policy when `web.tls.client_ca_file` is set (RequestClientCert, RequireAnyClientCert, VerifyClientCertIfGiven, RequireAndVerifyClientCert) ($SHIELD_EXPORTER_WEB_TLS_CLIENT_AUTH)",
+	).Envar("SHIELD_EXPORTER_WEB_TLS_CLIENT_AUTH").Default("RequireAndVerifyClientCert").Enum("RequestClientCert", "RequireAnyClientCert", "VerifyClientCertIfGiven", "RequireAndVerifyClientCert")
 )
 
 func init() {
 	prometheus.MustRegister(version.NewCollector(*metricsNamespace))
 }
 
-type basicAuthHandler struct {
-	handler  http.HandlerFunc
-	username string
-	password string
+// collectorEndpoints are the Shield API endpoints called by every collector.
+var collectorEndpoints = map[string][]string{
+	filters.AgentsCollector:            {"/v2/agents"},
+	filters.ArchivesCollector:          {"/v1/targets", "/v1/stores", "/v1/archives"},
+	filters.AuthTokensCollector:        {"/v2/auth/tokens"},
+	filters.JobsCollector:              {"/v1/jobs", "/v1/archives", "/v1/status/jobs"},
+	filters.RetentionPoliciesCollector: {"/v1/retention", "/v1/jobs"},
+	filters.SchedulesCollector:         {"/v1/schedules", "/v1/jobs"},
+	filters.StatusCollector:            {"/v1/status/internal"},
+	filters.StoresCollector:            {"/v1/stores", "/v1/jobs"},
+	filters.TargetsCollector:           {"/v1/targets", "/v1/jobs", "/v2/agents"},
+	filters.TasksCollector:             {"/v1/jobs", "/v1/tasks", "/v1/archives", "/v1/stores"},
+	filters.TenantsCollector:           {"/v2/tenants", "/v2/tenants/:uuid"},
+	filters.UsersCollector:             {"/v2/auth/local/users", "/v2/tenants", "/v2/tenants/:uuid"},
+}
+
+func shieldRegistry(
+	namespace string,
+	environment string,
+	backendName string,
+	shieldClient *client.Client,
+	tenants []string,
+	collectorsFilter *filters.CollectorsFilter,
+	tasksDurationObjectives map[float64]float64,
+	archivesExpiringWindows map[string]time.Duration,
+	tracer *tracing.Tracer,
+	collectorFamilies *backend.CollectorFamilies,
+) backend.Registries {
+	registries := backend.Registries{}
+	register := func(collectorName string, collector prometheus.Collector, labels map[string]string) {
+		if tracer != nil {
+			collector = tracer.Collector(collector, collectorName, backendName)
+		}
+		if *scrapeSlowThreshold > 0 {
+			collector = collectors.NewSlowScrapeCollector(
+				collector,
+				collectorName,
+				backendName,
+				collectorEndpoints[collectorName],
+				*scrapeSlowThreshold,
+				log.Base(),
+			)
+		}
+		var gatherer prometheus.Gatherer = backend.NewCollectorRegistry(collector)
+		if len(labels) > 0 {
+			gatherer = backend.WrapGatherer(gatherer, func(gatherer prometheus.Gatherer) prometheus.Gatherer {
+				return bosh.NewLabelsGatherer(gatherer, labels)
+			})
+		}
+		if *metricsUnifiedScrapeError {
+			gatherer = backend.NewLastScrapeErrorGatherer(gatherer, namespace, collectorName)
+		}
+		gatherer = collectorFamilies.Gatherer(collectorName, gatherer)
+		if registered, ok := registries[collectorName]; ok {
+			gatherer = backend.Gatherers{registered, gatherer}
+		}
+		registries[collectorName] = gatherer
+	}
+
+	// The metrics about the Shield backend itself do not scrape anything,
+	// and are gathered along with any enabled collector.
+	backendRegistry := prometheus.NewRegistry()
+	backendRegistry.MustRegister(collectors.NewBackendCollector(namespace, environment, backendName, shieldClient))
+	registries[backend.BackendCollector] = collectorFamilies.Gatherer(backend.BackendCollector, backendRegistry)
+
+	// Listings are scraped once per tenant, with a tenant label, when
+	// tenants are selected.
+	type tenantScope struct {
+		shieldClient *client.Client
+		labels       map[string]string
+	}
+	tenantScopes := []tenantScope{{shieldClient: shieldClient}}
+	if len(tenants) > 0 {
+		tenantScopes = []tenantScope{}
+		for _, tenant := range tenants {
+			tenantScopes = append(tenantScopes, tenantScope{
+				shieldClient: shieldClient.WithTenant(tenant),
+				labels:       map[string]string{"tenant": tenant},
+			})
+		}
+	}
+
+	for _, scope := range tenantScopes {
+		if collectorsFilter.Enabled(filters.ArchivesCollector) {
+			archivesCollector := collectors.NewArchivesCollector(namespace, environment, backendName, scope.shieldClient, archivesExpiringWindows)
+			register(filters.ArchivesCollector, archivesCollector, scope.labels)
+		}
+
+		if collectorsFilter.Enabled(filters.JobsCollector) {
+			jobsCollector := collectors.NewJobsCollector(
+				namespace,
+				environment,
+				backendName,
+				scope.shieldClient,
+				*metricsJobSLAEnabled,
+				*metricsJobSLAMaxAge,
+				*metricsJobsLegacyLabels,
+			)
+			register(filters.JobsCollector, jobsCollector, scope.labels)
+		}
+
+		if collectorsFilter.Enabled(filters.RetentionPoliciesCollector) {
+			retentionPoliciesCollector := collectors.NewRetentionPoliciesCollector(namespace, environment, backendName, scope.shieldClient)
+			register(filters.RetentionPoliciesCollector, retentionPoliciesCollector, scope.labels)
+		}
+
+		if collectorsFilter.Enabled(filters.StoresCollector) {
+			storesCollector := collectors.NewStoresCollector(namespace, environment, backendName, scope.shieldClient)
+			register(filters.StoresCollector, storesCollector, scope.labels)
+		}
+
+		if collectorsFilter.Enabled(filters.TargetsCollector) {
+			targetsCollector := collectors.NewTargetsCollector(namespace, environment, backendName, scope.shieldClient, *metricsDeprecatedNames)
+			register(filters.TargetsCollector, targetsCollector, scope.labels)
+		}
+
+		if collectorsFilter.Enabled(filters.TasksCollector) {
+			tasksCollector := collectors.NewTasksCollector(
+				namespace,
+				environment,
+				backendName,
+				scope.shieldClient,
+				tasksDurationObjectives,
+				*metricsTasksDurationMaxAge,
+				*metricsTasksDurationAgeBuckets,
+				*metricsNativeHistogramBucketFactor,
+				*metricsTasksJobName,
+				*metricsRestoreSuccessWindow,
+				*metricsJobsLegacyLabels,
+			)
+			register(filters.TasksCollector, tasksCollector, scope.labels)
+		}
+	}
+
+	if collectorsFilter.Enabled(filters.AgentsCollector) {
+		agentsCollector := collectors.NewAgentsCollector(namespace, environment, backendName, shieldClient)
+		register(filters.AgentsCollector, agentsCollector, nil)
+	}
+
+	if collectorsFilter.Enabled(filters.AuthTokensCollector) {
+		authTokensCollector := collectors.NewAuthTokensCollector(namespace, environment, backendName, shieldClient)
+		register(filters.AuthTokensCollector, authTokensCollector, nil)
+	}
+
+	if collectorsFilter.Enabled(filters.SchedulesCollector) {
+		schedulesCollector := collectors.NewSchedulesCollector(namespace, environment, backendName, shieldClient)
+		register(filters.SchedulesCollector, schedulesCollector, nil)
+	}
+
+	if collectorsFilter.Enabled(filters.StatusCollector) {
+		statusCollector := collectors.NewStatusCollector(namespace, environment, backendName, shieldClient)
+		register(filters.StatusCollector, statusCollector, nil)
+	}
+
+	if collectorsFilter.Enabled(filters.TenantsCollector) {
+		tenantsCollector := collectors.NewTenantsCollector(namespace, environment, backendName, shieldClient)
+		register(filters.TenantsCollector, tenantsCollector, nil)
+	}
+
+	if collectorsFilter.Enabled(filters.UsersCollector) {
+		usersCollector := collectors.NewUsersCollector(namespace, environment, backendName, shieldClient)
+		register(filters.UsersCollector, usersCollector, nil)
+	}
+
+	return registries
+}
+
+// unresolvedRegistry builds the registries gathered instead of the ones of
+// shieldRegistry while the name of the Shield backend is not resolved,
+// reporting the scrapes of the enabled collectors as failed.
+func unresolvedRegistry(
+	namespace string,
+	environment string,
+	collectorsFilter *filters.CollectorsFilter,
+	collectorFamilies *backend.CollectorFamilies,
+) backend.Registries {
+	registries := backend.Registries{}
+
+	backendRegistry := prometheus.NewRegistry()
+	backendRegistry.MustRegister(collectors.NewUnresolvedBackendCollector(namespace, environment, backend.UnresolvedBackendName))
+	registries[backend.BackendCollector] = collectorFamilies.Gatherer(backend.BackendCollector, backendRegistry)
+
+	for _, collectorName := range filters.Collectors {
+		if !collectorsFilter.Enabled(collectorName) {
+			continue
+		}
+
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(collectors.NewUnresolvedCollector(namespace, environment, backend.UnresolvedBackendName, collectorPathName(collectorName)))
+		var gatherer prometheus.Gatherer = registry
+		if *metricsUnifiedScrapeError {
+			gatherer = backend.NewLastScrapeErrorGatherer(gatherer, namespace, collectorName)
+		}
+		registries[collectorName] = collectorFamilies.Gatherer(collectorName, gatherer)
+	}
+
+	return registries
+}
+
+// collectorPathName returns the snake cased collector name used in the path
+// of its own metrics endpoint, ie `retention_policies`.
+func collectorPathName(collectorName string) string {
+	var name []rune
+	for i, r := range collectorName {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				name = append(name, '_')
+			}
+			r = unicode.ToLower(r)
+		}
+		name = append(name, r)
+	}
+	return string(name)
+}
+
+// normalizePathPrefix returns prefix with a leading slash and without a
+// trailing one, so that `/` and an empty prefix both mean the root.
+func normalizePathPrefix(prefix string) string {
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	return prefix
+}
+
+// pingsWithin reports whether a ping of the Shield backends completes, even
+// with an error, within timeout, which is used as the exporter liveness for
+// the systemd watchdog: a ping goes through the same client, concurrency and
+// rate limits as the collectors, so it hangs along with them.
+func pingsWithin(checker backend.Checker, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		checker.Ping()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+var startupRetries = expvar.NewInt("shield_startup_retries")
+
+// backendVars is the internal state of a Shield backend exposed at
+// `/debug/vars`.
+type backendVars struct {
+	Name string `json:"name"`
+	client.Stats
+}
+
+func getShieldStatus(shieldClient *client.Client, retries int, backoff time.Duration) (api.Status, error) {
+	shieldStatus, err := shieldClient.GetStatus()
+	for attempt := 1; err != nil && attempt <= retries; attempt++ {
+		log.Warnf("Error while getting Shield Status, retrying in %s (%d/%d): %v", backoff, attempt, retries, err)
+		startupRetries.Add(1)
+		time.Sleep(backoff)
+		backoff *= 2
+		shieldStatus, err = shieldClient.GetStatus()
+	}
+
+	return shieldStatus, err
 }
 
-func (h *basicAuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	username, password, ok := r.BasicAuth()
-	if !ok || username != h.username || password != h.password {
-		log.Errorf("Invalid HTTP auth from `%s`", r.RemoteAddr)
-		w.Header().Set("WWW-Authenticate", "Basic realm=\"metrics\"")
-		http.Error(w, "Invalid username or password", http.StatusUnauthorized)
-		return
+func parseSummaryObjectives(objectives string) (map[float64]float64, error) {
+	summaryObjectives := make(map[float64]float64)
+
+	for _, objective := range strings.Split(objectives, ",") {
+		objective = strings.TrimSpace(objective)
+		if objective == "" {
+			continue
+		}
+
+		parts := strings.Split(objective, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Summary objective `%s` is not in the `quantile:error` format", objective)
+		}
+
+		quantile, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil || quantile < 0 || quantile > 1 {
+			return nil, fmt.Errorf("Summary objective `%s` has an invalid quantile", objective)
+		}
+
+		epsilon, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || epsilon < 0 || epsilon > 1 {
+			return nil, fmt.Errorf("Summary objective `%s` has an invalid error", objective)
+		}
+
+		summaryObjectives[quantile] = epsilon
 	}
-	h.handler(w, r)
-	return
+
+	return summaryObjectives, nil
 }
 
-func prometheusHandler() http.Handler {
-	handler := prometheus.Handler()
+// parseWindows parses comma separated durations, keyed by their label value.
+func parseWindows(windows string) (map[string]time.Duration, error) {
+	durations := make(map[string]time.Duration)
+
+	for _, window := range strings.Split(windows, ",") {
+		window = strings.TrimSpace(window)
+		if window == "" {
+			continue
+		}
 
-	if *authUsername != "" && *authPassword != "" {
-		handler = &basicAuthHandler{
-			handler:  prometheus.Handler().ServeHTTP,
-			username: *authUsername,
-			password: *authPassword,
+		duration, err := time.ParseDuration(window)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("Window `%s` is not a positive duration", window)
 		}
+
+		durations[window] = duration
 	}
 
-	return handler
+	return durations, nil
+}
+
+type promHTTPLogger struct{}
+
+func (l promHTTPLogger) Println(v ...interface{}) {
+	log.Errorln(v...)
+}
+
+var tlsClientAuthTypes = map[string]tls.ClientAuthType{
+	"RequestClientCert":          tls.RequestClientCert,
+	"RequireAnyClientCert":       tls.RequireAnyClientCert,
+	"VerifyClientCertIfGiven":    tls.VerifyClientCertIfGiven,
+	"RequireAndVerifyClientCert": tls.RequireAndVerifyClientCert,
+}
+
+func clientAuthTLSConfig(clientCAFile string, clientAuth string) (*tls.Config, error) {
+	clientCA, err := ioutil.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(clientCA) {
+		return nil, fmt.Errorf("No PEM certificate found in `%s`", clientCAFile)
+	}
+
+	return &tls.Config{
+		ClientCAs:  clientCAs,
+		ClientAuth: tlsClientAuthTypes[clientAuth],
+	}, nil
+}
+
+func prometheusHandler(gatherer prometheus.Gatherer, scrapesInFlight prometheus.Gauge, auth web.Auth) http.Handler {
+	metricsHandler := promhttp.HandlerFor(
+		gatherer,
+		promhttp.HandlerOpts{
+			ErrorLog:          promHTTPLogger{},
+			EnableOpenMetrics: true,
+		},
+	)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scrapesInFlight.Inc()
+		defer scrapesInFlight.Dec()
+		metricsHandler.ServeHTTP(w, r)
+	})
+
+	return auth.Handler(web.LimitedHandler(handler, *maxRequestsInFlight, *metricsTimeout))
 }
 
 func main() {
 	log.AddFlags(kingpin.CommandLine)
 	kingpin.Version(version.Print("shield_exporter"))
 	kingpin.HelpFlag.Short('h')
-	kingpin.Parse()
+	command := kingpin.Parse()
+
+	if command == healthcheckCommand.FullCommand() {
+		if err := healthcheck.Check(*healthcheckURL, *healthcheckTimeout); err != nil {
+			log.Errorf("Health check failed: %v", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if command == generateRecordingRulesCommand.FullCommand() {
+		recordingRules, err := rules.RecordingRules(*metricsNamespace).YAML()
+		if err != nil {
+			log.Errorf("Error while generating recording rules: %v", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(recordingRules)
+		os.Exit(0)
+	}
+
+	if command == textfileCommand.FullCommand() && !strings.HasSuffix(*textfilePath, ".prom") {
+		log.Errorf("Invalid textfile path `%s`, it must end with `.prom`", *textfilePath)
+		os.Exit(1)
+	}
 
 	log.Infoln("Starting shield_exporter", version.Info())
 	log.Infoln("Build context", version.BuildContext())
 
-	api.Cfg = &api.Config{
-		Backend:  "default",
-		Backends: map[string]string{},
-		Aliases:  map[string]string{},
+	if *authPasswordFile != "" {
+		password, err := ioutil.ReadFile(*authPasswordFile)
+		if err != nil {
+			log.Errorf("Error while reading web interface password: %v", err)
+			os.Exit(1)
+		}
+		*authPassword = strings.TrimSpace(string(password))
+	}
+
+	var webAuth web.Auth
+	if *authHtpasswdFile != "" {
+		var err error
+		webAuth.Htpasswd, err = htpasswd.Load(*authHtpasswdFile)
+		if err != nil {
+			log.Errorf("Error while reading web interface htpasswd file: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	if *auditLog {
+		var auditWriter io.Writer = os.Stdout
+		if *auditLogFile != "" {
+			file, err := os.OpenFile(*auditLogFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+			if err != nil {
+				log.Errorf("Error while opening audit log file: %v", err)
+				os.Exit(1)
+			}
+			defer file.Close()
+			auditWriter = file
+		}
+		webAuth.AuditLogger = audit.NewLogger(auditWriter)
+	}
+
+	if *authTokenFile != "" {
+		token, err := ioutil.ReadFile(*authTokenFile)
+		if err != nil {
+			log.Errorf("Error while reading web interface bearer token: %v", err)
+			os.Exit(1)
+		}
+		webAuth.Token = strings.TrimSpace(string(token))
+		if webAuth.Token == "" {
+			log.Errorln("`web.auth.token_file` must not be empty")
+			os.Exit(1)
+		}
+	}
+
+	var boshInstance bosh.Instance
+	if *boshInstanceMetadata || *boshInstanceLabels {
+		var err error
+		boshInstance, err = bosh.ReadInstance(*boshInstanceDir)
+		if err != nil {
+			log.Errorf("Error while reading BOSH instance metadata: %v", err)
+			os.Exit(1)
+		}
+		if *metricsEnvironment == "" {
+			*metricsEnvironment = boshInstance.Deployment
+		}
 	}
 
-	if err := api.Cfg.AddBackend(*shieldBackendUrl, "default"); err != nil {
-		log.Errorf("Error adding Shield Backend: %s", err.Error())
+	var exporterConfig *config.Config
+	if *configFile != "" {
+		var err error
+		exporterConfig, err = config.Load(*configFile)
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+	}
+
+	// backendEnvironment returns the environment label of a Shield backend,
+	// from its configuration file stanza or the global flag.
+	backendEnvironment := func(backendURL string) string {
+		if environment := exporterConfig.Backend(backendURL).Environment; environment != "" {
+			return environment
+		}
+		return *metricsEnvironment
+	}
+
+	if *shieldDiscoveryDNSSRV == "" && backendEnvironment(*shieldBackendUrl) == "" {
+		log.Errorln("`metrics.environment` must be set unless it is read from the BOSH instance metadata or set for the Shield backend")
+		os.Exit(1)
+	}
+	if *shieldDiscoveryDNSSRV != "" && *metricsEnvironment == "" {
+		log.Errorln("`metrics.environment` must be set unless it is read from the BOSH instance metadata")
 		os.Exit(1)
 	}
 
-	authToken := api.BasicAuthToken(*shieldUsername, *shieldPassword)
-	if err := api.Cfg.UpdateBackend("default", authToken); err != nil {
-		log.Errorf("Error updating Shield Backend: %s", err.Error())
+	if (*shieldBackendUrl == "") == (*shieldDiscoveryDNSSRV == "") {
+		log.Errorln("Exactly one of `shield.backend_url` or `shield.discovery.dns-srv` must be set")
 		os.Exit(1)
 	}
 
-	shieldStatus, err := api.GetStatus()
-	if err != nil {
-		log.Errorf("Error while getting Shield Status: %v", err.Error())
+	var vaultClient *vault.Client
+	if *vaultAddress != "" {
+		var err error
+		vaultClient, err = vault.NewClient(vault.Config{
+			Address:  *vaultAddress,
+			Token:    *vaultToken,
+			RoleID:   *vaultRoleID,
+			SecretID: *vaultSecretID,
+		})
+		if err != nil {
+			log.Errorf("Error creating Vault client: %v", err)
+			os.Exit(1)
+		}
+		vaultClient.StartRenewal(*vaultRenewInterval)
+
+		if *vaultWebAuthPath != "" {
+			vaultWebAuth, err := vaultClient.Read(*vaultWebAuthPath)
+			if err != nil {
+				log.Errorf("Error while reading web interface credentials from Vault: %v", err)
+				os.Exit(1)
+			}
+			webAuth.Credentials = web.NewCredentials(vaultWebAuth["username"], vaultWebAuth["password"])
+			vaultClient.Watch(*vaultWebAuthPath, *vaultRefreshInterval, func(vaultWebAuth map[string]string) {
+				webAuth.Credentials.Set(vaultWebAuth["username"], vaultWebAuth["password"])
+			})
+		}
+	} else if *vaultShieldPath != "" || *vaultWebAuthPath != "" {
+		log.Errorln("`vault.address` must be set to read secrets from Vault")
+		os.Exit(1)
+	}
+
+	var shieldCredentials *client.Credentials
+	if *shieldUAAURL != "" {
+		uaaClient, err := uaa.NewClient(uaa.Config{
+			URL:               *shieldUAAURL,
+			ClientID:          *shieldUAAClientID,
+			ClientSecret:      *shieldUAAClientSecret,
+			SkipSSLValidation: os.Getenv("SHIELD_SKIP_SSL_VERIFY") != "",
+		})
+		if err != nil {
+			log.Errorf("Error creating UAA client: %v", err)
+			os.Exit(1)
+		}
+		token, expiresIn, err := uaaClient.Token()
+		if err != nil {
+			log.Errorf("Error while requesting Shield access token from UAA: %v", err)
+			os.Exit(1)
+		}
+		shieldCredentials = client.NewBearerCredentials(token)
+		uaaClient.StartRefresh(expiresIn, *shieldUAATokenRefreshInterval, shieldCredentials.SetBearer)
+		shieldCredentials.SetReauthenticate(func() error {
+			token, _, err := uaaClient.Token()
+			if err != nil {
+				return err
+			}
+			shieldCredentials.SetBearer(token)
+			return nil
+		})
+	} else if *vaultShieldPath != "" {
+		shieldAuth, err := vaultClient.Read(*vaultShieldPath)
+		if err != nil {
+			log.Errorf("Error while reading Shield credentials from Vault: %v", err)
+			os.Exit(1)
+		}
+		shieldCredentials = client.NewCredentials(shieldAuth["username"], shieldAuth["password"])
+		vaultClient.Watch(*vaultShieldPath, *vaultRefreshInterval, func(shieldAuth map[string]string) {
+			shieldCredentials.Set(shieldAuth["username"], shieldAuth["password"])
+		})
+		shieldCredentials.SetReauthenticate(func() error {
+			shieldAuth, err := vaultClient.Read(*vaultShieldPath)
+			if err != nil {
+				return err
+			}
+			shieldCredentials.Set(shieldAuth["username"], shieldAuth["password"])
+			return nil
+		})
+	} else if *shieldUsernameFile != "" || *shieldPasswordFile != "" {
+		if *shieldUsernameFile == "" || *shieldPasswordFile == "" {
+			log.Errorln("Both `shield.username_file` and `shield.password_file` must be set")
+			os.Exit(1)
+		}
+		credentialsWatcher, err := client.NewCredentialsFilesWatcher(*shieldUsernameFile, *shieldPasswordFile)
+		if err != nil {
+			log.Errorf("Error while reading Shield credentials: %v", err)
+			os.Exit(1)
+		}
+		credentialsWatcher.Start(*shieldCredentialsReloadInterval)
+		shieldCredentials = credentialsWatcher.Credentials()
+		shieldCredentials.SetReauthenticate(func() error {
+			_, err := credentialsWatcher.Reload()
+			return err
+		})
+	} else if *shieldUsername == "" || *shieldPassword == "" {
+		log.Errorln("Either `shield.username` and `shield.password`, `shield.username_file` and `shield.password_file`, `shield.uaa.url`, or `vault.shield_path` must be set")
 		os.Exit(1)
+	} else {
+		shieldCredentials = client.NewCredentials(*shieldUsername, *shieldPassword)
 	}
 
-	log.Infof("Collecting data from Shield `%s' version %s", shieldStatus.Name, shieldStatus.Version)
+	var shieldSemaphore *client.Semaphore
+	if *shieldMaxConcurrentRequests > 0 {
+		shieldSemaphore = client.NewSemaphore(*shieldMaxConcurrentRequests)
+	}
+
+	clientConfig := client.Config{
+		SkipSSLValidation:     os.Getenv("SHIELD_SKIP_SSL_VERIFY") != "",
+		ProxyURL:              *shieldProxyURL,
+		MaxRequestsPerSecond:  *shieldMaxRequestsPerSecond,
+		MaxIdleConns:          *shieldMaxIdleConns,
+		IdleConnTimeout:       *shieldIdleConnTimeout,
+		TLSHandshakeTimeout:   *shieldTLSHandshakeTimeout,
+		ResponseHeaderTimeout: *shieldResponseHeaderTimeout,
+		Semaphore:             shieldSemaphore,
+		Credentials:           shieldCredentials,
+	}
 
 	var collectorsFilters []string
 	if *filterCollectors != "" {
@@ -145,63 +1099,565 @@ func main() {
 		os.Exit(1)
 	}
 
-	if collectorsFilter.Enabled(filters.ArchivesCollector) {
-		archivesCollector := collectors.NewArchivesCollector(*metricsNamespace, *metricsEnvironment, shieldStatus.Name)
-		prometheus.MustRegister(archivesCollector)
+	tasksDurationObjectives, err := parseSummaryObjectives(*metricsTasksDurationObjectives)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
 	}
 
-	if collectorsFilter.Enabled(filters.JobsCollector) {
-		jobsCollector := collectors.NewJobsCollector(*metricsNamespace, *metricsEnvironment, shieldStatus.Name)
-		prometheus.MustRegister(jobsCollector)
+	if *metricsNativeHistogramBucketFactor != 0 && *metricsNativeHistogramBucketFactor <= 1 {
+		log.Errorf("Invalid `metrics.native-histogram-bucket-factor` %v, must be greater than 1 or 0 to disable native histograms", *metricsNativeHistogramBucketFactor)
+		os.Exit(1)
 	}
 
-	if collectorsFilter.Enabled(filters.RetentionPoliciesCollector) {
-		retentionPoliciesCollector := collectors.NewRetentionPoliciesCollector(*metricsNamespace, *metricsEnvironment, shieldStatus.Name)
-		prometheus.MustRegister(retentionPoliciesCollector)
+	archivesExpiringWindows, err := parseWindows(*metricsArchivesExpiringWindows)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
 	}
 
-	if collectorsFilter.Enabled(filters.SchedulesCollector) {
-		schedulesCollector := collectors.NewSchedulesCollector(*metricsNamespace, *metricsEnvironment, shieldStatus.Name)
-		prometheus.MustRegister(schedulesCollector)
+	var tracer *tracing.Tracer
+	if *tracingOTLPEndpoint != "" {
+		tracerProvider, err := tracing.NewTracerProvider(*tracingOTLPEndpoint, *tracingSamplingRatio, version.Version)
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		defer tracerProvider.Shutdown(context.Background())
+		tracer = tracing.NewTracer(tracerProvider)
+		log.Infof("Exporting the traces of the scrapes to `%s`", redact.String(*tracingOTLPEndpoint))
 	}
 
-	if collectorsFilter.Enabled(filters.StatusCollector) {
-		statusCollector := collectors.NewStatusCollector(*metricsNamespace, *metricsEnvironment, shieldStatus.Name)
-		prometheus.MustRegister(statusCollector)
+	collectorsSwitch := filters.NewCollectorsSwitch(collectorsFilter)
+
+	// The collectors of a backend are the ones of its stanza, or the ones of
+	// filter.collectors, unless enabled or disabled through the admin API.
+	collectorEnabled := func(backendURL string, collectorName string) bool {
+		return collectorsSwitch.EnabledFor(collectorName, exporterConfig.CollectorsFilter(backendURL, collectorsFilter))
+	}
+
+	// Collectors disabled at startup can be enabled later on through the
+	// admin API, so they must all be registered.
+	var allCollectorsFilter *filters.CollectorsFilter
+	if *webEnableAdminAPI {
+		allCollectorsFilter, _ = filters.NewCollectorsFilter(filters.Collectors)
+	}
+
+	// The collectors shown on the landing page are the ones of the exporter
+	// and of every backend stanza.
+	configuredCollectorsFilters := append([]*filters.CollectorsFilter{collectorsFilter}, exporterConfig.CollectorsFilters()...)
+	anyConfiguredCollectorsFilter := func(enabled func(collectorsFilter *filters.CollectorsFilter) bool) bool {
+		for _, configuredCollectorsFilter := range configuredCollectorsFilters {
+			if enabled(configuredCollectorsFilter) {
+				return true
+			}
+		}
+		return false
 	}
 
-	if collectorsFilter.Enabled(filters.StoresCollector) {
-		storesCollector := collectors.NewStoresCollector(*metricsNamespace, *metricsEnvironment, shieldStatus.Name)
-		prometheus.MustRegister(storesCollector)
+	// The metrics of the collector endpoints are filtered out of the metrics
+	// of all collectors, by the metric families each collector gathered.
+	var collectorFamilies *backend.CollectorFamilies
+	if *webCollectorEndpoints {
+		collectorFamilies = backend.NewCollectorFamilies()
 	}
 
-	if collectorsFilter.Enabled(filters.TargetsCollector) {
-		targetsCollector := collectors.NewTargetsCollector(*metricsNamespace, *metricsEnvironment, shieldStatus.Name)
-		prometheus.MustRegister(targetsCollector)
+	backendSettings := func(shieldClient *client.Client) (string, *filters.CollectorsFilter) {
+		backendConfig := exporterConfig.Backend(shieldClient.BackendURL())
+
+		namespace := *metricsNamespace
+		if backendConfig.Namespace != "" {
+			namespace = backendConfig.Namespace
+		}
+
+		backendCollectorsFilter := exporterConfig.CollectorsFilter(shieldClient.BackendURL(), collectorsFilter)
+		if allCollectorsFilter != nil {
+			backendCollectorsFilter = allCollectorsFilter
+		}
+
+		return namespace, backendCollectorsFilter
 	}
 
-	if collectorsFilter.Enabled(filters.TasksCollector) {
-		tasksCollector := collectors.NewTasksCollector(*metricsNamespace, *metricsEnvironment, shieldStatus.Name)
-		prometheus.MustRegister(tasksCollector)
+	newShieldRegistry := func(backendName string, shieldClient *client.Client) backend.Registries {
+		namespace, backendCollectorsFilter := backendSettings(shieldClient)
+		return shieldRegistry(namespace, backendEnvironment(shieldClient.BackendURL()), backendName, shieldClient, *shieldTenants, backendCollectorsFilter, tasksDurationObjectives, archivesExpiringWindows, tracer, collectorFamilies)
 	}
 
-	handler := prometheusHandler()
-	http.Handle(*metricsPath, handler)
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte(`<html>
-             <head><title>Shield Exporter</title></head>
-             <body>
-             <h1>Shield Exporter</h1>
-             <p><a href='` + *metricsPath + `'>Metrics</a></p>
-             </body>
-             </html>`))
+	newShieldBackend := func(shieldClient *client.Client, refreshInterval time.Duration, backendName string) *backend.Backend {
+		shieldBackend := backend.NewBackend(shieldClient, newShieldRegistry, refreshInterval, backendName)
+		namespace, backendCollectorsFilter := backendSettings(shieldClient)
+		shieldBackend.SetUnresolvedRegistries(unresolvedRegistry(namespace, backendEnvironment(shieldClient.BackendURL()), backendCollectorsFilter, collectorFamilies))
+		return shieldBackend
+	}
+
+	var shieldCollectors backend.CollectorsGatherer
+	var shieldChecker backend.Checker
+	var landingBackends func() []landing.Backend
+	if *shieldDiscoveryDNSSRV != "" {
+		discovery := backend.NewSRVDiscovery(
+			*shieldDiscoveryDNSSRV,
+			*shieldDiscoveryScheme,
+			net.LookupSRV,
+			func(backendURL string) (*backend.Backend, error) {
+				backendClientConfig := clientConfig
+				backendClientConfig.BackendURL = backendURL
+				shieldClient, err := client.NewClient(backendClientConfig)
+				if err != nil {
+					return nil, err
+				}
+				return newShieldBackend(shieldClient, *shieldBackendNameRefreshInterval, ""), nil
+			},
+		)
+		discovery.Start(*shieldDiscoveryRefreshInterval)
+		if *shieldEvents {
+			log.Warnln("Ignoring `shield.events`, the Shield events stream is only supported with `shield.backend_url`")
+		}
+		shieldCollectors = discovery
+		shieldChecker = discovery
+		landingBackends = func() []landing.Backend {
+			backends := []landing.Backend{}
+			for backendURL, shieldBackend := range discovery.Backends() {
+				backends = append(backends, landing.Backend{URL: backendURL, Name: shieldBackend.Name()})
+			}
+			sort.Slice(backends, func(i, j int) bool { return backends[i].URL < backends[j].URL })
+			return backends
+		}
+		expvar.Publish("shield_backends", expvar.Func(func() interface{} {
+			vars := make(map[string]backendVars)
+			for backendURL, shieldBackend := range discovery.Backends() {
+				vars[backendURL] = backendVars{Name: shieldBackend.Name(), Stats: shieldBackend.Stats()}
+			}
+			return vars
+		}))
+	} else {
+		clientConfig.BackendURL = *shieldBackendUrl
+		shieldClient, err := client.NewClient(clientConfig)
+		if err != nil {
+			log.Errorf("Error creating Shield client: %s", err.Error())
+			os.Exit(1)
+		}
+
+		var shieldStatus api.Status
+		if *shieldSkipStartupCheck {
+			log.Infoln("Skipping Shield startup check, the Shield backend will be validated at scrape time")
+		} else {
+			shieldStatus, err = getShieldStatus(shieldClient, *shieldStartupRetries, *shieldStartupBackoff)
+			if err != nil {
+				log.Errorf("Error while getting Shield Status: %v", err.Error())
+				if !*shieldStartupServeOnFailure {
+					os.Exit(1)
+				}
+				log.Warnln("Serving the Shield metrics once the Shield backend becomes available")
+			} else {
+				log.Infof("Collecting data from Shield `%s' version %s", shieldStatus.Name, shieldStatus.Version)
+			}
+		}
+
+		backendName := shieldStatus.Name
+		backendNameRefreshInterval := *shieldBackendNameRefreshInterval
+		if *metricsBackendName != "" {
+			backendName = *metricsBackendName
+			backendNameRefreshInterval = 0
+		}
+		shieldBackend := newShieldBackend(shieldClient, backendNameRefreshInterval, backendName)
+		shieldCollectors = shieldBackend
+		shieldChecker = shieldBackend
+		landingBackends = func() []landing.Backend {
+			return []landing.Backend{{URL: redact.String(*shieldBackendUrl), Name: shieldBackend.Name()}}
+		}
+		expvar.Publish("shield_backends", expvar.Func(func() interface{} {
+			return map[string]backendVars{
+				redact.String(*shieldBackendUrl): {Name: shieldBackend.Name(), Stats: shieldBackend.Stats()},
+			}
+		}))
+
+		if *shieldEvents {
+			eventsNamespace := *metricsNamespace
+			if namespace := exporterConfig.Backend(shieldClient.BackendURL()).Namespace; namespace != "" {
+				eventsNamespace = namespace
+			}
+			eventsSubscriber := events.NewSubscriber(eventsNamespace, backendEnvironment(shieldClient.BackendURL()), shieldBackend.Name, shieldClient, *shieldEventsReconnectBackoff, *shieldEventsPingInterval)
+			prometheus.MustRegister(eventsSubscriber)
+			eventsSubscriber.Start()
+		}
+	}
+
+	httpRequestsTotal := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: *metricsNamespace,
+			Subsystem: "exporter",
+			Name:      "http_requests_total",
+			Help:      "Total number of HTTP requests served by the Shield Exporter.",
+		},
+		[]string{"code", "handler"},
+	)
+	prometheus.MustRegister(httpRequestsTotal)
+
+	httpRequestDuration := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace:                   *metricsNamespace,
+			Subsystem:                   "exporter",
+			Name:                        "http_request_duration_seconds",
+			Help:                        "Duration of HTTP requests served by the Shield Exporter.",
+			Buckets:                     prometheus.DefBuckets,
+			NativeHistogramBucketFactor: *metricsNativeHistogramBucketFactor,
+		},
+		[]string{"handler"},
+	)
+	prometheus.MustRegister(httpRequestDuration)
+
+	scrapesInFlight := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: *metricsNamespace,
+			Subsystem: "exporter",
+			Name:      "scrapes_in_flight",
+			Help:      "Number of scrapes of the Shield Exporter currently being served.",
+		},
+	)
+	prometheus.MustRegister(scrapesInFlight)
+
+	prometheus.MustRegister(prometheus.NewCounterFunc(
+		prometheus.CounterOpts{
+			Namespace: *metricsNamespace,
+			Subsystem: "exporter",
+			Name:      "reauthentications_total",
+			Help:      "Total number of times the Shield credentials were refreshed after being rejected by Shield.",
+		},
+		func() float64 { return float64(shieldCredentials.Reauthentications()) },
+	))
+
+	newShieldGatherer := func(gatherer prometheus.Gatherer, spanName string) prometheus.Gatherer {
+		if tracer != nil {
+			gatherer = tracer.Gatherer(gatherer, spanName)
+		}
+		if *boshInstanceLabels {
+			gatherer = bosh.NewLabelsGatherer(gatherer, boshInstance.Labels())
+		}
+		if *metricsRollup {
+			gatherer = rollup.NewRollupGatherer(gatherer, exporterConfig.Namespaces(*metricsNamespace))
+		}
+		return gatherer
+	}
+	shieldGatherer := newShieldGatherer(backend.EnabledCollectorsGatherer(shieldCollectors, collectorEnabled), "scrape")
+
+	if *webhookURL != "" {
+		if *webhookScrapeFailuresThreshold < 1 {
+			log.Errorf("Invalid webhook scrape failures threshold `%d`", *webhookScrapeFailuresThreshold)
+			os.Exit(1)
+		}
+
+		templateText := webhook.DefaultScrapeFailuresTemplate
+		if *webhookTemplateFile != "" {
+			templateContent, err := ioutil.ReadFile(*webhookTemplateFile)
+			if err != nil {
+				log.Errorf("Error while reading webhook template: %v", err)
+				os.Exit(1)
+			}
+			templateText = string(templateContent)
+		}
+
+		scrapeFailuresWebhook, err := webhook.NewWebhook(*webhookURL, templateText, *webhookTimeout)
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		shieldGatherer = webhook.NewScrapeFailuresGatherer(shieldGatherer, *metricsNamespace, *webhookScrapeFailuresThreshold, scrapeFailuresWebhook)
+	}
+
+	if *webhookJobsURL != "" {
+		templateText := webhook.DefaultJobAlertsTemplate
+		if *webhookJobsTemplateFile != "" {
+			templateContent, err := ioutil.ReadFile(*webhookJobsTemplateFile)
+			if err != nil {
+				log.Errorf("Error while reading job alerts webhook template: %v", err)
+				os.Exit(1)
+			}
+			templateText = string(templateContent)
+		}
+
+		jobAlertsWebhook, err := webhook.NewWebhook(*webhookJobsURL, templateText, *webhookTimeout)
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		shieldGatherer = webhook.NewJobAlertsGatherer(shieldGatherer, *metricsNamespace, *webhookJobsOverdueAfter, jobAlertsWebhook, time.Now)
+	}
+
+	switch command {
+	case onceCommand.FullCommand():
+		if err := textfile.Encode(os.Stdout, shieldGatherer); err != nil {
+			log.Errorf("Error while collecting metrics: %v", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case textfileCommand.FullCommand():
+		log.Infof("Writing metrics to `%s` every %s", *textfilePath, *textfileInterval)
+		for {
+			if err := textfile.WriteFile(*textfilePath, shieldGatherer); err != nil {
+				log.Errorf("Error while writing metrics to `%s`: %v", *textfilePath, err)
+			}
+			time.Sleep(*textfileInterval)
+		}
+	}
+
+	var elector *ha.FileLockElector
+	if *haLockFile != "" {
+		elector = ha.NewFileLockElector(*haLockFile, *haLockRetryInterval, *metricsNamespace)
+		prometheus.MustRegister(elector)
+		elector.Start()
+
+		standbyGatherer := ha.NewStandbyGatherer(shieldGatherer, elector, *metricsNamespace, *haStandbyRefreshInterval)
+		prometheus.MustRegister(standbyGatherer)
+		standbyGatherer.Start()
+		shieldGatherer = standbyGatherer
+	}
+
+	if *scrapeInterval > 0 {
+		backgroundGatherer := cache.NewBackgroundGatherer(shieldGatherer, *scrapeInterval, *scrapeMetricsTTL, *metricsNamespace)
+		prometheus.MustRegister(backgroundGatherer)
+		backgroundGatherer.Start()
+		shieldGatherer = backgroundGatherer
+	} else if *scrapeMetricsTTL > 0 {
+		log.Warnln("Ignoring `scrape.metrics-ttl`, it only applies when scraping in the background with `scrape.interval`")
+	}
+
+	externalPath := ""
+	if *webExternalURL != "" {
+		externalURL, err := url.Parse(*webExternalURL)
+		if err != nil || externalURL.Scheme == "" || externalURL.Host == "" {
+			log.Errorf("Invalid external URL `%s`", *webExternalURL)
+			os.Exit(1)
+		}
+		externalPath = normalizePathPrefix(externalURL.Path)
+	}
+
+	routePrefix := externalPath
+	if *webRoutePrefix != "" {
+		routePrefix = normalizePathPrefix(*webRoutePrefix)
+	}
+
+	webAuth.Username, webAuth.Password = *authUsername, *authPassword
+
+	var adminFlags []string
+	for _, flag := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"web.enable-config", *webEnableConfig},
+		{"web.enable-lifecycle", *webEnableLifecycle},
+		{"web.enable-loglevel", *webEnableLogLevel},
+		{"web.enable-admin-api", *webEnableAdminAPI},
+		{"web.enable-pprof", *webEnablePprof},
+	} {
+		if flag.enabled {
+			adminFlags = append(adminFlags, flag.name)
+		}
+	}
+	if err := webAuth.Require(adminFlags...); err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+
+	mux := http.NewServeMux()
+	if routePrefix != "" {
+		mux.Handle("/", http.RedirectHandler(externalPath+"/", http.StatusFound))
+	}
+
+	recordingGatherer := landing.NewRecordingGatherer(shieldGatherer)
+	handler := prometheusHandler(prometheus.Gatherers{recordingGatherer, prometheus.DefaultGatherer}, scrapesInFlight, webAuth)
+	mux.Handle(routePrefix+*metricsPath, &web.InstrumentedHandler{
+		Handler:         handler,
+		Name:            "metrics",
+		RequestsTotal:   httpRequestsTotal,
+		RequestDuration: httpRequestDuration,
+	})
+
+	landingCollectors := []landing.Collector{}
+	for _, collectorName := range filters.Collectors {
+		if allCollectorsFilter == nil && !anyConfiguredCollectorsFilter(func(collectorsFilter *filters.CollectorsFilter) bool {
+			return collectorsFilter.Enabled(collectorName)
+		}) {
+			continue
+		}
+		landingCollector := landing.Collector{
+			Name:      collectorName,
+			Subsystem: collectorPathName(collectorName),
+		}
+
+		if *webCollectorEndpoints {
+			collectorGatherer := collectorFamilies.FilterGatherer(shieldGatherer, collectorName)
+
+			collectorPath := *metricsPath + "/" + collectorPathName(collectorName)
+			mux.Handle(routePrefix+collectorPath, &web.InstrumentedHandler{
+				Handler:         prometheusHandler(collectorGatherer, scrapesInFlight, webAuth),
+				Name:            "metrics_" + collectorPathName(collectorName),
+				RequestsTotal:   httpRequestsTotal,
+				RequestDuration: httpRequestDuration,
+			})
+			landingCollector.MetricsPath = externalPath + collectorPath
+		}
+
+		landingCollectors = append(landingCollectors, landingCollector)
+	}
+
+	mux.Handle(routePrefix+"/api/v1/snapshot", &web.InstrumentedHandler{
+		Handler:         webAuth.Handler(snapshot.Handler(*metricsNamespace, recordingGatherer)),
+		Name:            "snapshot",
+		RequestsTotal:   httpRequestsTotal,
+		RequestDuration: httpRequestDuration,
 	})
 
+	mux.Handle(routePrefix+healthcheck.Path, &web.InstrumentedHandler{
+		Handler:         healthcheck.Handler(),
+		Name:            "healthy",
+		RequestsTotal:   httpRequestsTotal,
+		RequestDuration: httpRequestDuration,
+	})
+
+	if *webEnableConfig {
+		mux.Handle(routePrefix+"/config", &web.InstrumentedHandler{
+			Handler:         webAuth.Handler(runtimeconfig.Handler(kingpin.CommandLine)),
+			Name:            "config",
+			RequestsTotal:   httpRequestsTotal,
+			RequestDuration: httpRequestDuration,
+		})
+	}
+
+	quit := make(chan struct{})
+	if *webEnableLifecycle {
+		mux.Handle(routePrefix+"/-/quit", &web.InstrumentedHandler{
+			Handler:         webAuth.Handler(web.QuitHandler(quit)),
+			Name:            "quit",
+			RequestsTotal:   httpRequestsTotal,
+			RequestDuration: httpRequestDuration,
+		})
+	}
+
+	if *webEnableLogLevel {
+		mux.Handle(routePrefix+"/-/loglevel", &web.InstrumentedHandler{
+			Handler:         webAuth.Handler(web.LogLevelHandler(kingpin.CommandLine.GetFlag("log.level").Model().String())),
+			Name:            "loglevel",
+			RequestsTotal:   httpRequestsTotal,
+			RequestDuration: httpRequestDuration,
+		})
+	}
+
+	if *webEnableAdminAPI {
+		adminCollectorsHandler := &web.InstrumentedHandler{
+			Handler:         webAuth.Handler(web.CollectorsHandler(routePrefix+"/-/collectors", collectorsSwitch)),
+			Name:            "collectors",
+			RequestsTotal:   httpRequestsTotal,
+			RequestDuration: httpRequestDuration,
+		}
+		mux.Handle(routePrefix+"/-/collectors", adminCollectorsHandler)
+		mux.Handle(routePrefix+"/-/collectors/", adminCollectorsHandler)
+	}
+
+	mux.Handle(routePrefix+"/", &web.InstrumentedHandler{
+		Handler: landing.NewPage(landing.Config{
+			Namespace:        *metricsNamespace,
+			MetricsPath:      externalPath + *metricsPath,
+			Collectors:       landingCollectors,
+			Backends:         landingBackends,
+			CollectorEnabled: func(collectorName string) bool {
+				return anyConfiguredCollectorsFilter(func(collectorsFilter *filters.CollectorsFilter) bool {
+					return collectorsSwitch.EnabledFor(collectorName, collectorsFilter)
+				})
+			},
+		}, recordingGatherer),
+		Name:            "landing",
+		RequestsTotal:   httpRequestsTotal,
+		RequestDuration: httpRequestDuration,
+	})
+
+	if *webEnablePprof {
+		pprofMux := http.NewServeMux()
+		pprofMux.HandleFunc("/debug/pprof/", pprof.Index)
+		pprofMux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		pprofMux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		pprofMux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		pprofMux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		mux.Handle(routePrefix+"/debug/pprof/", webAuth.Handler(http.StripPrefix(routePrefix, pprofMux)))
+	}
+
+	if *webEnableExpvar {
+		expvar.Publish("shield_collectors", expvar.Func(func() interface{} {
+			return collectorsSwitch.EnabledCollectors()
+		}))
+		mux.Handle(routePrefix+"/debug/vars", webAuth.Handler(expvar.Handler()))
+	}
+
+	server := &http.Server{Handler: mux}
 	if *tlsCertFile != "" && *tlsKeyFile != "" {
-		log.Infoln("Listening TLS on", *listenAddress)
-		log.Fatal(http.ListenAndServeTLS(*listenAddress, *tlsCertFile, *tlsKeyFile, nil))
-	} else {
-		log.Infoln("Listening on", *listenAddress)
-		log.Fatal(http.ListenAndServe(*listenAddress, nil))
+		certificateReloader, err := tlsreload.NewCertificateReloader(*tlsCertFile, *tlsKeyFile)
+		if err != nil {
+			log.Errorf("Error while loading TLS certificate: %v", err)
+			os.Exit(1)
+		}
+		if *tlsReloadInterval > 0 {
+			certificateReloader.Start(*tlsReloadInterval)
+		}
+
+		tlsConfig := &tls.Config{}
+		if *tlsClientCAFile != "" {
+			tlsConfig, err = clientAuthTLSConfig(*tlsClientCAFile, *tlsClientAuth)
+			if err != nil {
+				log.Errorf("Error while configuring TLS client authentication: %v", err)
+				os.Exit(1)
+			}
+		}
+		tlsConfig.GetCertificate = certificateReloader.GetCertificate
+		server.TLSConfig = tlsConfig
+	}
+
+	listeners := []net.Listener{}
+	for _, address := range *listenAddress {
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			log.Errorf("Error while listening on `%s`: %v", address, err)
+			os.Exit(1)
+		}
+		listeners = append(listeners, listener)
+	}
+
+	go func() {
+		// The backend is only validated once its name is resolved, which
+		// is retried with a backoff when Shield is not available yet.
+		for !shieldChecker.Resolve() {
+			time.Sleep(time.Second)
+		}
+		if _, err := systemd.Notify(systemd.Ready); err != nil {
+			log.Errorf("Error while notifying systemd: %v", err)
+		}
+	}()
+	systemd.StartWatchdog(func() bool {
+		return pingsWithin(shieldChecker, systemd.WatchdogInterval()/2)
+	})
+
+	serveTLS := server.TLSConfig != nil
+	serveErrors := make(chan error, len(listeners))
+	for _, listener := range listeners {
+		go func(listener net.Listener) {
+			if serveTLS {
+				log.Infoln("Listening TLS on", listener.Addr())
+				serveErrors <- server.ServeTLS(listener, "", "")
+			} else {
+				log.Infoln("Listening on", listener.Addr())
+				serveErrors <- server.Serve(listener)
+			}
+		}(listener)
+	}
+
+	select {
+	case err := <-serveErrors:
+		log.Fatal(err)
+	case <-quit:
+		log.Infoln("Received termination request, shutting down")
+		ctx, cancel := context.WithTimeout(context.Background(), *webShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Errorf("Error while shutting down: %v", err)
+			os.Exit(1)
+		}
 	}
 }
@@ -0,0 +1,160 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/log"
+
+	"github.com/bosh-prometheus/shield_exporter/collectors"
+)
+
+// LastGatherer returns the metrics of the last gathering and when it happened.
+type LastGatherer interface {
+	LastGathered() ([]*dto.MetricFamily, time.Time)
+}
+
+type Snapshot struct {
+	GatheredAt *time.Time          `json:"gathered_at"`
+	Backends   map[string]*Backend `json:"backends"`
+}
+
+type Backend struct {
+	Environment string            `json:"environment"`
+	Jobs        []Job             `json:"jobs"`
+	Tasks       []TasksSummary    `json:"tasks"`
+	Archives    []ArchivesSummary `json:"archives"`
+}
+
+type Job struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Paused  bool   `json:"paused"`
+	LastRun int64  `json:"last_run"`
+	NextRun int64  `json:"next_run"`
+}
+
+type TasksSummary struct {
+	Operation string `json:"operation"`
+	Status    string `json:"status"`
+	Total     int64  `json:"total"`
+}
+
+type ArchivesSummary struct {
+	Status       string `json:"status"`
+	StorePlugin  string `json:"store_plugin"`
+	TargetPlugin string `json:"target_plugin"`
+	Total        int64  `json:"total"`
+}
+
+// jobStatuses maps the values of the job status metric back to the Shield
+// job statuses.
+var jobStatuses = map[float64]string{
+	1: collectors.PendingStatus,
+	2: collectors.RunningStatus,
+	3: collectors.CanceledStatus,
+	4: collectors.FailedStatus,
+	5: collectors.DoneStatus,
+}
+
+// New builds a snapshot of the Shield data from the last gathered metrics
+// exported under namespace.
+func New(namespace string, gatherer LastGatherer) Snapshot {
+	mfs, gatheredAt := gatherer.LastGathered()
+
+	snapshot := Snapshot{Backends: map[string]*Backend{}}
+	if !gatheredAt.IsZero() {
+		snapshot.GatheredAt = &gatheredAt
+	}
+
+	jobs := map[string]map[string]*Job{}
+	job := func(backendName string, labels map[string]string) *Job {
+		if jobs[backendName] == nil {
+			jobs[backendName] = map[string]*Job{}
+		}
+		name := labels["job_name"]
+		if jobs[backendName][name] == nil {
+			jobs[backendName][name] = &Job{Name: name, Status: "unknown"}
+		}
+		return jobs[backendName][name]
+	}
+
+	for _, mf := range mfs {
+		for _, metric := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			backendName := labels["backend_name"]
+			value := metric.GetGauge().GetValue()
+
+			backend := snapshot.Backends[backendName]
+			newBackend := backend == nil
+			if newBackend {
+				backend = &Backend{
+					Environment: labels["environment"],
+					Jobs:        []Job{},
+					Tasks:       []TasksSummary{},
+					Archives:    []ArchivesSummary{},
+				}
+			}
+
+			switch mf.GetName() {
+			case namespace + "_job_status":
+				if status, ok := jobStatuses[value]; ok {
+					job(backendName, labels).Status = status
+				} else {
+					job(backendName, labels)
+				}
+			case namespace + "_job_paused":
+				job(backendName, labels).Paused = value == 1
+			case namespace + "_job_last_run":
+				job(backendName, labels).LastRun = int64(value)
+			case namespace + "_job_next_run":
+				job(backendName, labels).NextRun = int64(value)
+			case namespace + "_tasks_total":
+				backend.Tasks = append(backend.Tasks, TasksSummary{
+					Operation: labels["task_operation"],
+					Status:    labels["task_status"],
+					Total:     int64(value),
+				})
+			case namespace + "_archives_total":
+				backend.Archives = append(backend.Archives, ArchivesSummary{
+					Status:       labels["archive_status"],
+					StorePlugin:  labels["store_plugin"],
+					TargetPlugin: labels["target_plugin"],
+					Total:        int64(value),
+				})
+			default:
+				continue
+			}
+
+			if newBackend {
+				snapshot.Backends[backendName] = backend
+			}
+		}
+	}
+
+	for backendName, backendJobs := range jobs {
+		backend := snapshot.Backends[backendName]
+		for _, job := range backendJobs {
+			backend.Jobs = append(backend.Jobs, *job)
+		}
+		sort.Slice(backend.Jobs, func(i, j int) bool { return backend.Jobs[i].Name < backend.Jobs[j].Name })
+	}
+
+	return snapshot
+}
+
+// Handler serves the snapshot of the Shield data as JSON.
+func Handler(namespace string, gatherer LastGatherer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(New(namespace, gatherer)); err != nil {
+			log.Errorf("Error while encoding snapshot: %v", err)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package snapshot_test
+
+import (
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	. "github.com/bosh-prometheus/shield_exporter/snapshot"
+)
+
+type fakeLastGatherer struct {
+	mfs        []*dto.MetricFamily
+	gatheredAt time.Time
+}
+
+func (g fakeLastGatherer) LastGathered() ([]*dto.MetricFamily, time.Time) {
+	return g.mfs, g.gatheredAt
+}
+
+var _ = Describe("Snapshot", func() {
+	var (
+		gatherer   fakeLastGatherer
+		gatheredAt = time.Unix(1500000000, 0)
+	)
+
+	BeforeEach(func() {
+		constLabels := prometheus.Labels{"environment": "test_environment", "backend_name": "test_backend"}
+
+		jobStatus := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_namespace_job_status", Help: "help", ConstLabels: constLabels}, []string{"job_name"})
+		jobStatus.WithLabelValues("job_b").Set(4)
+		jobStatus.WithLabelValues("job_a").Set(5)
+		jobPaused := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_namespace_job_paused", Help: "help", ConstLabels: constLabels}, []string{"job_name"})
+		jobPaused.WithLabelValues("job_b").Set(1)
+		jobLastRun := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_namespace_job_last_run", Help: "help", ConstLabels: constLabels}, []string{"job_name"})
+		jobLastRun.WithLabelValues("job_a").Set(1500000000)
+		tasksTotal := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_namespace_tasks_total", Help: "help", ConstLabels: constLabels}, []string{"task_operation", "task_status"})
+		tasksTotal.WithLabelValues("backup", "done").Set(3)
+		archivesTotal := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_namespace_archives_total", Help: "help", ConstLabels: constLabels}, []string{"archive_status", "store_plugin", "target_plugin"})
+		archivesTotal.WithLabelValues("valid", "s3", "postgres").Set(7)
+		storesTotal := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_namespace_stores_total", Help: "help", ConstLabels: constLabels})
+
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(jobStatus, jobPaused, jobLastRun, tasksTotal, archivesTotal, storesTotal)
+		mfs, err := registry.Gather()
+		Expect(err).ToNot(HaveOccurred())
+
+		gatherer = fakeLastGatherer{mfs: mfs, gatheredAt: gatheredAt}
+	})
+
+	Describe("New", func() {
+		It("returns the Shield data per backend", func() {
+			Expect(New("test_namespace", gatherer)).To(Equal(Snapshot{
+				GatheredAt: &gatheredAt,
+				Backends: map[string]*Backend{
+					"test_backend": &Backend{
+						Environment: "test_environment",
+						Jobs: []Job{
+							{Name: "job_a", Status: "done", LastRun: 1500000000},
+							{Name: "job_b", Status: "failed", Paused: true},
+						},
+						Tasks:    []TasksSummary{{Operation: "backup", Status: "done", Total: 3}},
+						Archives: []ArchivesSummary{{Status: "valid", StorePlugin: "s3", TargetPlugin: "postgres", Total: 7}},
+					},
+				},
+			}))
+		})
+
+		Context("when nothing has been gathered yet", func() {
+			BeforeEach(func() {
+				gatherer = fakeLastGatherer{}
+			})
+
+			It("returns an empty snapshot", func() {
+				Expect(New("test_namespace", gatherer)).To(Equal(Snapshot{Backends: map[string]*Backend{}}))
+			})
+		})
+	})
+
+	Describe("Handler", func() {
+		It("serves the snapshot as JSON", func() {
+			recorder := httptest.NewRecorder()
+			Handler("test_namespace", gatherer).ServeHTTP(recorder, httptest.NewRequest("GET", "/api/v1/snapshot", nil))
+			Expect(recorder.Header().Get("Content-Type")).To(Equal("application/json"))
+			Expect(recorder.Body.String()).To(ContainSubstring(`"jobs":[{"name":"job_a","status":"done","paused":false,"last_run":1500000000,"next_run":0}`))
+			Expect(recorder.Body.String()).To(ContainSubstring(`"archives":[{"status":"valid","store_plugin":"s3","target_plugin":"postgres","total":7}]`))
+		})
+	})
+})
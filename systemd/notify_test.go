@@ -0,0 +1,85 @@
+package systemd_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/bosh-prometheus/shield_exporter/systemd"
+)
+
+var _ = Describe("Notify", func() {
+	var (
+		dir  string
+		conn *net.UnixConn
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "shield_exporter_systemd")
+		Expect(err).ToNot(HaveOccurred())
+
+		socketPath := filepath.Join(dir, "notify.sock")
+		conn, err = net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+		Expect(err).ToNot(HaveOccurred())
+		os.Setenv("NOTIFY_SOCKET", socketPath)
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("NOTIFY_SOCKET")
+		conn.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("sends the state to the notification socket", func() {
+		sent, err := Notify(Ready)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sent).To(BeTrue())
+
+		buffer := make([]byte, 64)
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := conn.Read(buffer)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(buffer[:n])).To(Equal("READY=1"))
+	})
+
+	Context("when the notification socket is not set", func() {
+		BeforeEach(func() {
+			os.Unsetenv("NOTIFY_SOCKET")
+		})
+
+		It("does not send anything", func() {
+			sent, err := Notify(Ready)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sent).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("WatchdogInterval", func() {
+	AfterEach(func() {
+		os.Unsetenv("WATCHDOG_USEC")
+		os.Unsetenv("WATCHDOG_PID")
+	})
+
+	It("returns the watchdog interval", func() {
+		os.Setenv("WATCHDOG_USEC", "30000000")
+		Expect(WatchdogInterval()).To(Equal(30 * time.Second))
+	})
+
+	It("returns 0 when the watchdog is not enabled", func() {
+		Expect(WatchdogInterval()).To(BeZero())
+	})
+
+	It("returns 0 when the watchdog is meant for another process", func() {
+		os.Setenv("WATCHDOG_USEC", "30000000")
+		os.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+		Expect(WatchdogInterval()).To(BeZero())
+	})
+})
@@ -0,0 +1,64 @@
+package textfile
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Encode writes the metrics gathered by gatherer in the text exposition
+// format, even when some of them could not be gathered.
+func Encode(w io.Writer, gatherer prometheus.Gatherer) error {
+	metricFamilies, gatherErr := gatherer.Gather()
+	if err := encode(w, metricFamilies); err != nil {
+		return err
+	}
+
+	return gatherErr
+}
+
+// WriteFile writes the metrics gathered by gatherer to path, as expected by
+// the node_exporter textfile collector. The metrics are written to a
+// temporary file renamed afterwards, so a partially written file is never
+// read.
+func WriteFile(path string, gatherer prometheus.Gatherer) error {
+	metricFamilies, gatherErr := gatherer.Gather()
+
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err := encode(file, metricFamilies); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return err
+	}
+
+	return gatherErr
+}
+
+func encode(w io.Writer, metricFamilies []*dto.MetricFamily) error {
+	encoder := expfmt.NewEncoder(w, expfmt.FmtText)
+	for _, metricFamily := range metricFamilies {
+		if err := encoder.Encode(metricFamily); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package textfile_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestTextfile(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Textfile Suite")
+}
@@ -0,0 +1,113 @@
+package textfile_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	. "github.com/bosh-prometheus/shield_exporter/textfile"
+)
+
+var _ = Describe("Textfile", func() {
+	var (
+		registry *prometheus.Registry
+		gatherer prometheus.Gatherer
+	)
+
+	BeforeEach(func() {
+		gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_gauge", Help: "Test gauge."})
+		gauge.Set(42)
+		registry = prometheus.NewRegistry()
+		registry.MustRegister(gauge)
+		gatherer = registry
+	})
+
+	Describe("Encode", func() {
+		var (
+			output *bytes.Buffer
+			err    error
+		)
+
+		JustBeforeEach(func() {
+			output = &bytes.Buffer{}
+			err = Encode(output, gatherer)
+		})
+
+		It("writes the metrics in the text exposition format", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.String()).To(Equal("# HELP test_gauge Test gauge.\n# TYPE test_gauge gauge\ntest_gauge 42\n"))
+		})
+
+		Context("when some metrics can not be gathered", func() {
+			BeforeEach(func() {
+				gatherer = prometheus.Gatherers{
+					registry,
+					prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+						return nil, errors.New("fake error")
+					}),
+				}
+			})
+
+			It("writes the gathered metrics and returns an error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(output.String()).To(ContainSubstring("test_gauge 42\n"))
+			})
+		})
+	})
+
+	Describe("WriteFile", func() {
+		var (
+			dir  string
+			path string
+			err  error
+		)
+
+		BeforeEach(func() {
+			var tempErr error
+			dir, tempErr = ioutil.TempDir("", "textfile")
+			Expect(tempErr).ToNot(HaveOccurred())
+			path = filepath.Join(dir, "shield.prom")
+		})
+
+		JustBeforeEach(func() {
+			err = WriteFile(path, gatherer)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("writes the metrics to the file", func() {
+			Expect(err).ToNot(HaveOccurred())
+			content, readErr := ioutil.ReadFile(path)
+			Expect(readErr).ToNot(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring("test_gauge 42\n"))
+		})
+
+		It("does not leave temporary files behind", func() {
+			files, readErr := ioutil.ReadDir(dir)
+			Expect(readErr).ToNot(HaveOccurred())
+			Expect(files).To(HaveLen(1))
+			Expect(files[0].Name()).To(Equal("shield.prom"))
+			Expect(files[0].Mode().Perm()).To(Equal(os.FileMode(0644)))
+		})
+
+		Context("when the directory does not exist", func() {
+			BeforeEach(func() {
+				path = filepath.Join(dir, "missing", "shield.prom")
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
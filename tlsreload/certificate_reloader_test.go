@@ -0,0 +1,123 @@
+package tlsreload_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/bosh-prometheus/shield_exporter/tlsreload"
+)
+
+func writeCertificate(certFile string, keyFile string, commonName string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).ToNot(HaveOccurred())
+
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	Expect(err).ToNot(HaveOccurred())
+
+	Expect(ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0600)).To(Succeed())
+	Expect(ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}), 0600)).To(Succeed())
+	Expect(os.Chtimes(certFile, modTime, modTime)).To(Succeed())
+	Expect(os.Chtimes(keyFile, modTime, modTime)).To(Succeed())
+}
+
+func commonNameOf(reloader *CertificateReloader) string {
+	certificate, err := reloader.GetCertificate(nil)
+	Expect(err).ToNot(HaveOccurred())
+	cert, err := x509.ParseCertificate(certificate.Certificate[0])
+	Expect(err).ToNot(HaveOccurred())
+	return cert.Subject.CommonName
+}
+
+var _ = Describe("CertificateReloader", func() {
+	var (
+		err      error
+		dir      string
+		certFile string
+		keyFile  string
+		modTime  time.Time
+
+		reloader *CertificateReloader
+	)
+
+	BeforeEach(func() {
+		dir, err = ioutil.TempDir("", "shield_exporter_tlsreload")
+		Expect(err).ToNot(HaveOccurred())
+
+		certFile = filepath.Join(dir, "cert.pem")
+		keyFile = filepath.Join(dir, "key.pem")
+		modTime = time.Now().Add(-time.Minute)
+		writeCertificate(certFile, keyFile, "first", modTime)
+	})
+
+	JustBeforeEach(func() {
+		reloader, err = NewCertificateReloader(certFile, keyFile)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("NewCertificateReloader", func() {
+		It("loads the certificate", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(commonNameOf(reloader)).To(Equal("first"))
+		})
+
+		Context("when the key file does not exist", func() {
+			BeforeEach(func() {
+				keyFile = filepath.Join(dir, "unknown.pem")
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Reload", func() {
+		It("does not reload unmodified files", func() {
+			reloaded, err := reloader.Reload()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reloaded).To(BeFalse())
+		})
+
+		It("reloads modified files", func() {
+			writeCertificate(certFile, keyFile, "second", modTime.Add(time.Second))
+
+			reloaded, err := reloader.Reload()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reloaded).To(BeTrue())
+			Expect(commonNameOf(reloader)).To(Equal("second"))
+		})
+
+		It("keeps the previous certificate when the files are invalid", func() {
+			Expect(ioutil.WriteFile(keyFile, []byte("invalid"), 0600)).To(Succeed())
+			Expect(os.Chtimes(keyFile, modTime.Add(time.Second), modTime.Add(time.Second))).To(Succeed())
+
+			_, err := reloader.Reload()
+			Expect(err).To(HaveOccurred())
+			Expect(commonNameOf(reloader)).To(Equal("first"))
+		})
+	})
+})
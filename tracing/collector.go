@@ -0,0 +1,54 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/bosh-prometheus/shield_exporter/collectors"
+)
+
+// Collector returns a Collector tracing every collection of collector, for
+// the given Shield backend, as a span child of the span of the context it is
+// collected within. collector is collected within the context of that span,
+// so the Shield API calls of a collectors.ContextCollector are traced under
+// it.
+func (t *Tracer) Collector(collector prometheus.Collector, collectorName string, backendName string) prometheus.Collector {
+	return &tracedCollector{
+		tracer:        t.tracer,
+		collector:     collector,
+		collectorName: collectorName,
+		backendName:   backendName,
+	}
+}
+
+type tracedCollector struct {
+	tracer        trace.Tracer
+	collector     prometheus.Collector
+	collectorName string
+	backendName   string
+}
+
+func (c *tracedCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *tracedCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	ctx, span := c.tracer.Start(
+		ctx,
+		"collect "+c.collectorName,
+		trace.WithAttributes(
+			attribute.String("shield_exporter.collector", c.collectorName),
+			attribute.String("shield_exporter.backend_name", c.backendName),
+		),
+	)
+	defer span.End()
+
+	collectors.WithContext(ctx, c.collector).Collect(ch)
+}
+
+func (c *tracedCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.collector.Describe(ch)
+}
@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/bosh-prometheus/shield_exporter/backend"
+)
+
+const instrumentationName = "github.com/bosh-prometheus/shield_exporter"
+
+// NewTracerProvider returns a TracerProvider exporting, in batches, the
+// sampled spans to the OTLP/HTTP traces endpoint URL.
+func NewTracerProvider(endpointURL string, samplingRatio float64, serviceVersion string) (*sdktrace.TracerProvider, error) {
+	if samplingRatio < 0 || samplingRatio > 1 {
+		return nil, fmt.Errorf("Invalid tracing sampling ratio `%v`, must be between 0 and 1", samplingRatio)
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(endpointURL))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid OTLP traces endpoint `%s`: %v", endpointURL, err)
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(samplingRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName("shield_exporter"),
+			semconv.ServiceVersion(serviceVersion),
+		)),
+	), nil
+}
+
+// Tracer traces every gathering of the Shield metrics as a new trace, with
+// a span per collector and per Shield API call. The span of a gathering is
+// passed down to the collectors within the context of the gathering, so
+// concurrent gatherings are traced independently.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+func NewTracer(tracerProvider trace.TracerProvider) *Tracer {
+	return &Tracer{tracer: tracerProvider.Tracer(instrumentationName)}
+}
+
+// Gatherer returns a Gatherer tracing every gathering of gatherer as a new
+// trace, whose root span has the given name. gatherer is gathered within
+// the context of that span when it is a backend.ContextGatherer.
+func (t *Tracer) Gatherer(gatherer prometheus.Gatherer, spanName string) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		ctx, span := t.tracer.Start(context.Background(), spanName, trace.WithNewRoot())
+		defer span.End()
+
+		mfs, err := backend.WithContext(ctx, gatherer).Gather()
+		span.SetAttributes(attribute.Int("shield_exporter.metric_families", len(mfs)))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		return mfs, err
+	})
+}
@@ -0,0 +1,215 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/log"
+	"github.com/starkandwayne/shield/api"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/bosh-prometheus/shield_exporter/backend"
+	"github.com/bosh-prometheus/shield_exporter/client"
+	. "github.com/bosh-prometheus/shield_exporter/tracing"
+)
+
+func init() {
+	log.Base().SetLevel("fatal")
+}
+
+// statusCollector returns a single gauge after getting the Shield status.
+type statusCollector struct {
+	shieldClient *client.Client
+	desc         *prometheus.Desc
+}
+
+func newStatusCollector(shieldClient *client.Client) statusCollector {
+	return statusCollector{
+		shieldClient: shieldClient,
+		desc:         prometheus.NewDesc("fake_metric", "Fake metric.", nil, nil),
+	}
+}
+
+func (c statusCollector) Collect(ch chan<- prometheus.Metric) {
+	c.shieldClient.GetStatus()
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, 1)
+}
+
+func (c statusCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	c.shieldClient = c.shieldClient.WithContext(ctx)
+	c.Collect(ch)
+}
+
+func (c statusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// spansNamed returns the ended spans with the given name.
+func spansNamed(spans []sdktrace.ReadOnlySpan, name string) []sdktrace.ReadOnlySpan {
+	var named []sdktrace.ReadOnlySpan
+	for _, span := range spans {
+		if span.Name() == name {
+			named = append(named, span)
+		}
+	}
+	return named
+}
+
+// spanNamed returns the single ended span with the given name.
+func spanNamed(spans []sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	named := spansNamed(spans, name)
+	Expect(named).To(HaveLen(1), "span "+name+" ended once")
+	return named[0]
+}
+
+var _ = Describe("Tracer", func() {
+	var (
+		server       *ghttp.Server
+		shieldClient *client.Client
+		spanRecorder *tracetest.SpanRecorder
+		tracer       *Tracer
+		gatherer     prometheus.Gatherer
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		server.RouteToHandler("GET", "/v1/status", ghttp.RespondWithJSONEncoded(http.StatusOK, api.Status{}))
+
+		var err error
+		shieldClient, err = client.NewClient(client.Config{BackendURL: server.URL()})
+		Expect(err).ToNot(HaveOccurred())
+
+		spanRecorder = tracetest.NewSpanRecorder()
+		tracer = NewTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+
+		registries := backend.Registries{
+			"Status": backend.NewCollectorRegistry(tracer.Collector(newStatusCollector(shieldClient), "Status", "fake_backend")),
+		}
+		gatherer = backend.ContextGathererFunc(func(ctx context.Context) ([]*dto.MetricFamily, error) {
+			return registries.GatherCollectors(ctx, func(collectorName string) bool { return true })
+		})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Gatherer", func() {
+		It("returns the metrics of the gatherer", func() {
+			mfs, err := tracer.Gatherer(gatherer, "scrape").Gather()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mfs).To(HaveLen(1))
+			Expect(mfs[0].GetName()).To(Equal("fake_metric"))
+		})
+
+		It("traces every gathering as a new trace", func() {
+			tracedGatherer := tracer.Gatherer(gatherer, "scrape")
+			tracedGatherer.Gather()
+			tracedGatherer.Gather()
+
+			scrapeSpans := spansNamed(spanRecorder.Ended(), "scrape")
+			Expect(scrapeSpans).To(HaveLen(2))
+			Expect(scrapeSpans[0].Parent().IsValid()).To(BeFalse())
+			Expect(scrapeSpans[0].SpanContext().TraceID()).ToNot(Equal(scrapeSpans[1].SpanContext().TraceID()))
+		})
+
+		It("traces the collectors and their Shield API calls under the gathering", func() {
+			tracer.Gatherer(gatherer, "scrape").Gather()
+
+			spans := spanRecorder.Ended()
+			scrapeSpan := spanNamed(spans, "scrape")
+			collectorSpan := spanNamed(spans, "collect Status")
+			callSpan := spanNamed(spans, "GET /v1/status")
+
+			Expect(collectorSpan.Parent().SpanID()).To(Equal(scrapeSpan.SpanContext().SpanID()))
+			Expect(collectorSpan.Attributes()).To(ContainElement(attribute.String("shield_exporter.collector", "Status")))
+			Expect(collectorSpan.Attributes()).To(ContainElement(attribute.String("shield_exporter.backend_name", "fake_backend")))
+			Expect(callSpan.Parent().SpanID()).To(Equal(collectorSpan.SpanContext().SpanID()))
+			Expect(callSpan.SpanContext().TraceID()).To(Equal(scrapeSpan.SpanContext().TraceID()))
+		})
+
+		It("traces concurrent gatherings independently", func() {
+			arrived := make(chan struct{}, 2)
+			release := make(chan struct{})
+			server.RouteToHandler("GET", "/v1/status", func(w http.ResponseWriter, r *http.Request) {
+				arrived <- struct{}{}
+				<-release
+				ghttp.RespondWithJSONEncoded(http.StatusOK, api.Status{})(w, r)
+			})
+
+			tracedGatherer := tracer.Gatherer(gatherer, "scrape")
+			done := make(chan error, 2)
+			for i := 0; i < 2; i++ {
+				go func() {
+					_, err := tracedGatherer.Gather()
+					done <- err
+				}()
+			}
+			Eventually(arrived).Should(Receive())
+			Eventually(arrived).Should(Receive())
+			close(release)
+			Expect(<-done).ToNot(HaveOccurred())
+			Expect(<-done).ToNot(HaveOccurred())
+
+			spans := spanRecorder.Ended()
+			scrapeSpans := spansNamed(spans, "scrape")
+			Expect(scrapeSpans).To(HaveLen(2))
+			for _, scrapeSpan := range scrapeSpans {
+				var collectorSpans, callSpans []sdktrace.ReadOnlySpan
+				for _, span := range spans {
+					if span.SpanContext().TraceID() != scrapeSpan.SpanContext().TraceID() {
+						continue
+					}
+					switch span.Name() {
+					case "collect Status":
+						collectorSpans = append(collectorSpans, span)
+					case "GET /v1/status":
+						callSpans = append(callSpans, span)
+					}
+				}
+				Expect(collectorSpans).To(HaveLen(1))
+				Expect(collectorSpans[0].Parent().SpanID()).To(Equal(scrapeSpan.SpanContext().SpanID()))
+				Expect(callSpans).To(HaveLen(1))
+				Expect(callSpans[0].Parent().SpanID()).To(Equal(collectorSpans[0].SpanContext().SpanID()))
+			}
+		})
+
+		Context("when the gathering fails", func() {
+			It("records the error on the gathering span", func() {
+				failingGatherer := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+					return nil, errors.New("fake error")
+				})
+
+				_, err := tracer.Gatherer(failingGatherer, "scrape").Gather()
+				Expect(err).To(MatchError("fake error"))
+
+				scrapeSpan := spanNamed(spanRecorder.Ended(), "scrape")
+				Expect(scrapeSpan.Status().Code).To(Equal(codes.Error))
+				Expect(scrapeSpan.Status().Description).To(Equal("fake error"))
+			})
+		})
+	})
+
+	Describe("Collector", func() {
+		Context("when collected outside of a traced gathering", func() {
+			It("traces the collection as a new trace", func() {
+				gatherer.Gather()
+
+				spans := spanRecorder.Ended()
+				collectorSpan := spanNamed(spans, "collect Status")
+				Expect(collectorSpan.Parent().IsValid()).To(BeFalse())
+				Expect(spanNamed(spans, "GET /v1/status").Parent().SpanID()).To(Equal(collectorSpan.SpanContext().SpanID()))
+			})
+		})
+	})
+})
@@ -0,0 +1,129 @@
+package uaa
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/log"
+)
+
+// refreshRetryInterval is the wait before fetching a token again after a
+// failed refresh.
+const refreshRetryInterval = 30 * time.Second
+
+type Config struct {
+	URL               string
+	ClientID          string
+	ClientSecret      string
+	SkipSSLValidation bool
+	Timeout           time.Duration
+}
+
+// Client fetches access tokens from a UAA (or any OAuth2) token endpoint
+// through the client credentials grant.
+type Client struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func NewClient(config Config) (*Client, error) {
+	if config.URL == "" {
+		return nil, errors.New("UAA URL is required")
+	}
+
+	if config.ClientID == "" {
+		return nil, errors.New("UAA client id is required")
+	}
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &Client{
+		tokenURL:     strings.TrimSuffix(config.URL, "/") + "/oauth/token",
+		clientID:     config.ClientID,
+		clientSecret: config.ClientSecret,
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: config.SkipSSLValidation,
+				},
+			},
+			Timeout: timeout,
+		},
+	}, nil
+}
+
+// Token returns a new access token and its lifetime, zero when unknown.
+func (c *Client) Token() (string, time.Duration, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequest("POST", c.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.SetBasicAuth(url.QueryEscape(c.clientID), url.QueryEscape(c.clientSecret))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, res.Body)
+		return "", 0, fmt.Errorf("UAA token request failed: %s", res.Status)
+	}
+
+	var token tokenResponse
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return "", 0, err
+	}
+	if token.AccessToken == "" {
+		return "", 0, errors.New("UAA token response did not contain an access token")
+	}
+
+	return token.AccessToken, time.Duration(token.ExpiresIn) * time.Second, nil
+}
+
+// StartRefresh fetches a new access token at half the lifetime of the
+// previous one, or at interval when the lifetime is unknown, and hands it to
+// update. Failed refreshes are logged and retried.
+func (c *Client) StartRefresh(expiresIn time.Duration, interval time.Duration, update func(token string)) {
+	go func() {
+		for {
+			wait := interval
+			if expiresIn > 0 {
+				wait = expiresIn / 2
+			}
+			time.Sleep(wait)
+
+			token, tokenExpiresIn, err := c.Token()
+			if err != nil {
+				log.Errorf("Error while refreshing UAA token: %v", err)
+				expiresIn = 2 * refreshRetryInterval
+				continue
+			}
+			expiresIn = tokenExpiresIn
+			update(token)
+		}
+	}()
+}
@@ -0,0 +1,134 @@
+package uaa_test
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/bosh-prometheus/shield_exporter/uaa"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		err       error
+		server    *ghttp.Server
+		config    Config
+		uaaClient *Client
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		config = Config{
+			URL:          server.URL() + "/",
+			ClientID:     "fake_client",
+			ClientSecret: "fake_secret",
+		}
+	})
+
+	JustBeforeEach(func() {
+		uaaClient, err = NewClient(config)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("NewClient", func() {
+		It("does not return an error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("when the URL is not set", func() {
+			BeforeEach(func() {
+				config.URL = ""
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("UAA URL is required"))
+			})
+		})
+
+		Context("when the client id is not set", func() {
+			BeforeEach(func() {
+				config.ClientID = ""
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("UAA client id is required"))
+			})
+		})
+	})
+
+	Describe("Token", func() {
+		Context("when the client credentials are valid", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/oauth/token"),
+						ghttp.VerifyBasicAuth("fake_client", "fake_secret"),
+						ghttp.VerifyContentType("application/x-www-form-urlencoded"),
+						ghttp.VerifyForm(map[string][]string{"grant_type": {"client_credentials"}}),
+						ghttp.RespondWith(http.StatusOK, `{"access_token":"fake_token","token_type":"bearer","expires_in":600}`),
+					),
+				)
+			})
+
+			It("returns the access token and its lifetime", func() {
+				token, expiresIn, err := uaaClient.Token()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(token).To(Equal("fake_token"))
+				Expect(expiresIn).To(Equal(10 * time.Minute))
+			})
+		})
+
+		Context("when the client credentials are invalid", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.RespondWith(http.StatusUnauthorized, `{"error":"unauthorized"}`),
+				)
+			})
+
+			It("returns an error", func() {
+				_, _, err := uaaClient.Token()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("UAA token request failed: 401 Unauthorized"))
+			})
+		})
+
+		Context("when the response does not contain an access token", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.RespondWith(http.StatusOK, `{}`),
+				)
+			})
+
+			It("returns an error", func() {
+				_, _, err := uaaClient.Token()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("UAA token response did not contain an access token"))
+			})
+		})
+	})
+
+	Describe("StartRefresh", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusOK, `{"access_token":"refreshed_token"}`),
+			)
+			server.AllowUnhandledRequests = true
+		})
+
+		It("hands the refreshed token to update", func() {
+			tokens := make(chan string, 10)
+			uaaClient.StartRefresh(100*time.Millisecond, time.Hour, func(token string) {
+				tokens <- token
+			})
+			Eventually(tokens).Should(Receive(Equal("refreshed_token")))
+		})
+	})
+})
@@ -0,0 +1,221 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/log"
+)
+
+type Config struct {
+	Address  string
+	Token    string
+	RoleID   string
+	SecretID string
+	Timeout  time.Duration
+}
+
+// Client is a minimal Vault HTTP API client, reading secrets from KV (version 1
+// or 2) mounts and keeping its token alive.
+type Client struct {
+	address    string
+	roleID     string
+	secretID   string
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	token         string
+	leaseDuration time.Duration
+}
+
+type authResponse struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+	} `json:"auth"`
+}
+
+type secretResponse struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+func NewClient(config Config) (*Client, error) {
+	if config.Address == "" {
+		return nil, errors.New("Vault address is required")
+	}
+
+	if config.Token == "" && config.RoleID == "" {
+		return nil, errors.New("Either a Vault token or an AppRole role id is required")
+	}
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	c := &Client{
+		address:    strings.TrimSuffix(config.Address, "/"),
+		roleID:     config.RoleID,
+		secretID:   config.SecretID,
+		httpClient: &http.Client{Timeout: timeout},
+		token:      config.Token,
+	}
+
+	if c.roleID != "" {
+		if err := c.login(); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// Read returns the string values of the secret at path (ie `secret/shield` or
+// `secret/data/shield` for a KV version 2 mount).
+func (c *Client) Read(path string) (map[string]string, error) {
+	var secret secretResponse
+	if err := c.request("GET", "/v1/"+strings.TrimPrefix(path, "/"), nil, &secret); err != nil {
+		return nil, err
+	}
+
+	data := secret.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+
+	values := map[string]string{}
+	for key, value := range data {
+		if s, ok := value.(string); ok {
+			values[key] = s
+		}
+	}
+
+	return values, nil
+}
+
+// Renew extends the lease of the client token. Tokens obtained through AppRole
+// that can not be renewed anymore are replaced by logging in again.
+func (c *Client) Renew() error {
+	var auth authResponse
+	err := c.request("POST", "/v1/auth/token/renew-self", nil, &auth)
+	if err != nil {
+		if c.roleID != "" {
+			return c.login()
+		}
+		return err
+	}
+
+	c.setAuth(auth)
+	return nil
+}
+
+// StartRenewal renews the client token at half its lease duration, or at
+// interval when the lease duration is unknown.
+func (c *Client) StartRenewal(interval time.Duration) {
+	go func() {
+		for {
+			wait := interval
+			c.mu.Lock()
+			if c.leaseDuration > 0 {
+				wait = c.leaseDuration / 2
+			}
+			c.mu.Unlock()
+
+			time.Sleep(wait)
+			if err := c.Renew(); err != nil {
+				log.Errorf("Error while renewing Vault token: %v", err)
+			}
+		}
+	}()
+}
+
+// Watch reads the secret at path every interval and hands its values to
+// update. Failed reads are logged and the previous values are kept.
+func (c *Client) Watch(path string, interval time.Duration, update func(values map[string]string)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			values, err := c.Read(path)
+			if err != nil {
+				log.Errorf("Error while reading Vault secret `%s`: %v", path, err)
+				continue
+			}
+			update(values)
+		}
+	}()
+}
+
+func (c *Client) login() error {
+	var auth authResponse
+	body := map[string]string{"role_id": c.roleID, "secret_id": c.secretID}
+	if err := c.request("POST", "/v1/auth/approle/login", body, &auth); err != nil {
+		return fmt.Errorf("Error while logging in to Vault with AppRole: %v", err)
+	}
+	if auth.Auth.ClientToken == "" {
+		return errors.New("Vault AppRole login did not return a client token")
+	}
+
+	c.setAuth(auth)
+	return nil
+}
+
+func (c *Client) setAuth(auth authResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if auth.Auth.ClientToken != "" {
+		c.token = auth.Auth.ClientToken
+	}
+	c.leaseDuration = time.Duration(auth.Auth.LeaseDuration) * time.Second
+}
+
+func (c *Client) request(method string, path string, in interface{}, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		content, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequest(method, c.address+path, body)
+	if err != nil {
+		return err
+	}
+
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	c.mu.Lock()
+	token := c.token
+	c.mu.Unlock()
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, res.Body)
+		return fmt.Errorf("Vault request `%s %s` failed: %s", method, path, res.Status)
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
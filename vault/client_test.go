@@ -0,0 +1,161 @@
+package vault_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/bosh-prometheus/shield_exporter/vault"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		err         error
+		server      *ghttp.Server
+		config      Config
+		vaultClient *Client
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		config = Config{
+			Address: server.URL(),
+			Token:   "fake_token",
+		}
+	})
+
+	JustBeforeEach(func() {
+		vaultClient, err = NewClient(config)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("NewClient", func() {
+		It("does not return an error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("when the address is not set", func() {
+			BeforeEach(func() {
+				config.Address = ""
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Vault address is required"))
+			})
+		})
+
+		Context("when neither a token nor a role id are set", func() {
+			BeforeEach(func() {
+				config.Token = ""
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Either a Vault token or an AppRole role id is required"))
+			})
+		})
+
+		Context("when using AppRole", func() {
+			BeforeEach(func() {
+				config.Token = ""
+				config.RoleID = "fake_role_id"
+				config.SecretID = "fake_secret_id"
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/v1/auth/approle/login"),
+						ghttp.VerifyJSON(`{"role_id":"fake_role_id","secret_id":"fake_secret_id"}`),
+						ghttp.RespondWith(http.StatusOK, `{"auth":{"client_token":"approle_token","lease_duration":3600}}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v1/secret/shield"),
+						ghttp.VerifyHeaderKV("X-Vault-Token", "approle_token"),
+						ghttp.RespondWith(http.StatusOK, `{"data":{"username":"fake_username"}}`),
+					),
+				)
+			})
+
+			It("uses the token returned by the login", func() {
+				Expect(err).ToNot(HaveOccurred())
+				values, err := vaultClient.Read("secret/shield")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(values).To(Equal(map[string]string{"username": "fake_username"}))
+			})
+		})
+
+		Context("when the AppRole login fails", func() {
+			BeforeEach(func() {
+				config.Token = ""
+				config.RoleID = "fake_role_id"
+				server.AppendHandlers(ghttp.RespondWith(http.StatusBadRequest, `{}`))
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Read", func() {
+		var (
+			values   map[string]string
+			response string
+		)
+
+		JustBeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/secret/data/shield"),
+					ghttp.VerifyHeaderKV("X-Vault-Token", "fake_token"),
+					ghttp.RespondWith(http.StatusOK, response),
+				),
+			)
+			values, err = vaultClient.Read("secret/data/shield")
+		})
+
+		Context("when the mount is a KV version 1", func() {
+			BeforeEach(func() {
+				response = `{"data":{"username":"fake_username","password":"fake_password"}}`
+			})
+
+			It("returns the secret values", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(values).To(Equal(map[string]string{"username": "fake_username", "password": "fake_password"}))
+			})
+		})
+
+		Context("when the mount is a KV version 2", func() {
+			BeforeEach(func() {
+				response = `{"data":{"data":{"username":"fake_username","password":"fake_password"},"metadata":{"version":1}}}`
+			})
+
+			It("returns the secret values", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(values).To(Equal(map[string]string{"username": "fake_username", "password": "fake_password"}))
+			})
+		})
+	})
+
+	Describe("Renew", func() {
+		JustBeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/auth/token/renew-self"),
+					ghttp.VerifyHeaderKV("X-Vault-Token", "fake_token"),
+					ghttp.RespondWith(http.StatusOK, `{"auth":{"client_token":"fake_token","lease_duration":60}}`),
+				),
+			)
+			err = vaultClient.Renew()
+		})
+
+		It("renews the token", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+})